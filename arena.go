@@ -0,0 +1,167 @@
+package css
+
+// arenaSlabSize is the number of nodes in each slab allocated by an arena.
+const arenaSlabSize = 256
+
+// arenaMaxNames is the number of interned names an arena keeps on release.
+const arenaMaxNames = 16384
+
+// arenaMaxValueLen is the longest token value, other than a name, which
+// an arena interns.
+const arenaMaxValueLen = 32
+
+// Arena allocates the tokens, functions and blocks of style sheets in slabs
+// and interns their names to reduce garbage collection when many style
+// sheets are parsed. Set the same arena on a Scanner and its Parser.
+//
+// Nodes allocated from an arena are only valid until Release is called.
+// An arena is not safe for concurrent use.
+type Arena struct {
+	tokens    tokenSlabs
+	functions functionSlabs
+	blocks    blockSlabs
+	names     map[string]string
+}
+
+// NewArena returns a new, empty arena.
+func NewArena() *Arena {
+	return &Arena{names: make(map[string]string)}
+}
+
+// Release frees every node allocated from the arena so that its memory can
+// be reused. Interned names are kept for later style sheets.
+func (a *Arena) Release() {
+	a.tokens.reset()
+	a.functions.reset()
+	a.blocks.reset()
+	if len(a.names) > arenaMaxNames {
+		a.names = make(map[string]string)
+	}
+}
+
+// token returns a copy of tok allocated from the arena.
+// A nil arena allocates the token from the heap.
+func (a *Arena) token(tok Token) *Token {
+	var p *Token
+	if a == nil {
+		p = new(Token)
+	} else {
+		p = a.tokens.new()
+	}
+	*p = tok
+	return p
+}
+
+// function returns a new function allocated from the arena.
+func (a *Arena) function() *Function {
+	if a == nil {
+		return &Function{}
+	}
+	return a.functions.new()
+}
+
+// block returns a new simple block allocated from the arena.
+func (a *Arena) block() *SimpleBlock {
+	if a == nil {
+		return &SimpleBlock{}
+	}
+	return a.blocks.new()
+}
+
+// intern returns the string for b, allocating it only the first time that
+// it is seen. A nil arena always allocates.
+func (a *Arena) intern(b []byte) string {
+	if a == nil {
+		return string(b)
+	} else if s, ok := a.names[string(b)]; ok {
+		return s
+	}
+	s := string(b)
+	a.names[s] = s
+	return s
+}
+
+// tokenSlabs allocates tokens from slabs which are reused after a reset.
+type tokenSlabs struct {
+	slabs [][]Token
+	n     int // slabs in use
+}
+
+func (s *tokenSlabs) new() *Token {
+	if s.n == 0 || len(s.slabs[s.n-1]) == arenaSlabSize {
+		if s.n == len(s.slabs) {
+			s.slabs = append(s.slabs, make([]Token, 0, arenaSlabSize))
+		}
+		s.n++
+	}
+	slab := &s.slabs[s.n-1]
+	*slab = append(*slab, Token{})
+	return &(*slab)[len(*slab)-1]
+}
+
+// reset clears the slabs so that they don't retain any values.
+func (s *tokenSlabs) reset() {
+	for i, slab := range s.slabs[:s.n] {
+		for j := range slab {
+			slab[j] = Token{}
+		}
+		s.slabs[i] = slab[:0]
+	}
+	s.n = 0
+}
+
+// functionSlabs allocates functions from slabs which are reused after a reset.
+type functionSlabs struct {
+	slabs [][]Function
+	n     int // slabs in use
+}
+
+func (s *functionSlabs) new() *Function {
+	if s.n == 0 || len(s.slabs[s.n-1]) == arenaSlabSize {
+		if s.n == len(s.slabs) {
+			s.slabs = append(s.slabs, make([]Function, 0, arenaSlabSize))
+		}
+		s.n++
+	}
+	slab := &s.slabs[s.n-1]
+	*slab = append(*slab, Function{})
+	return &(*slab)[len(*slab)-1]
+}
+
+func (s *functionSlabs) reset() {
+	for i, slab := range s.slabs[:s.n] {
+		for j := range slab {
+			slab[j] = Function{}
+		}
+		s.slabs[i] = slab[:0]
+	}
+	s.n = 0
+}
+
+// blockSlabs allocates simple blocks from slabs which are reused after a reset.
+type blockSlabs struct {
+	slabs [][]SimpleBlock
+	n     int // slabs in use
+}
+
+func (s *blockSlabs) new() *SimpleBlock {
+	if s.n == 0 || len(s.slabs[s.n-1]) == arenaSlabSize {
+		if s.n == len(s.slabs) {
+			s.slabs = append(s.slabs, make([]SimpleBlock, 0, arenaSlabSize))
+		}
+		s.n++
+	}
+	slab := &s.slabs[s.n-1]
+	*slab = append(*slab, SimpleBlock{})
+	return &(*slab)[len(*slab)-1]
+}
+
+func (s *blockSlabs) reset() {
+	for i, slab := range s.slabs[:s.n] {
+		for j := range slab {
+			slab[j] = SimpleBlock{}
+		}
+		s.slabs[i] = slab[:0]
+	}
+	s.n = 0
+}
@@ -0,0 +1,88 @@
+package css_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// parseAll parses a style sheet and the contents of its blocks.
+func parseAll(p *css.Parser, s *css.Scanner) string {
+	ss := p.ParseStyleSheet(s)
+	var buf bytes.Buffer
+	for _, r := range ss.Rules {
+		if r, ok := r.(*css.QualifiedRule); ok {
+			buf.WriteString(print(p.ConsumeDeclarations(css.NewComponentValueScanner(r.Block.Values))))
+		}
+	}
+	return print(ss) + "\n" + buf.String()
+}
+
+// Ensure that style sheets parsed with an arena are the same as without.
+func TestArena(t *testing.T) {
+	src := "a\\62 c { color: rgb(1, 2, 3); x: [y] } @media print { d { e: f } } g { color: red }"
+
+	var p css.Parser
+	exp := parseAll(&p, css.NewScanner(bytes.NewBufferString(src)))
+
+	// Parse twice to ensure released memory is reused correctly.
+	arena := css.NewArena()
+	for i := 0; i < 2; i++ {
+		p := css.Parser{Arena: arena}
+		s := css.NewScanner(bytes.NewBufferString(src))
+		s.Arena = arena
+		if out := parseAll(&p, s); out != exp {
+			t.Fatalf("%d. unexpected output: %s", i, out)
+		}
+		arena.Release()
+	}
+}
+
+// Ensure that an arena reduces the allocations made while parsing.
+func TestArena_Allocs(t *testing.T) {
+	src := bytes.Repeat([]byte("a.b > c { color: red; margin: 0 auto; width: calc(1px + 2em); }\n"), 20)
+
+	parse := func(arena *css.Arena) {
+		p := css.Parser{Arena: arena}
+		s := css.NewScanner(bytes.NewReader(src))
+		s.Arena = arena
+		for _, r := range p.ParseStyleSheet(s).Rules {
+			p.ConsumeDeclarations(css.NewComponentValueScanner(r.(*css.QualifiedRule).Block.Values))
+		}
+		if arena != nil {
+			arena.Release()
+		}
+	}
+
+	arena := css.NewArena()
+	without := testing.AllocsPerRun(10, func() { parse(nil) })
+	with := testing.AllocsPerRun(10, func() { parse(arena) })
+	if with > without*2/3 {
+		t.Fatalf("expected fewer allocations with an arena: %v with, %v without", with, without)
+	}
+}
+
+func BenchmarkParser(b *testing.B) {
+	src := benchmarkStyleSheet()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var p css.Parser
+		p.ParseStyleSheet(css.NewScanner(bytes.NewReader(src)))
+	}
+}
+
+func BenchmarkParser_Arena(b *testing.B) {
+	src := benchmarkStyleSheet()
+	arena := css.NewArena()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := css.Parser{Arena: arena}
+		s := css.NewScanner(bytes.NewReader(src))
+		s.Arena = arena
+		p.ParseStyleSheet(s)
+		arena.Release()
+	}
+}
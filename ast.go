@@ -1,6 +1,9 @@
 package css
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Node represents a node in the CSS3 abstract syntax tree.
 type Node interface {
@@ -41,6 +44,45 @@ type AtRule struct {
 	Prelude ComponentValues
 	Block   *SimpleBlock
 	Pos     Pos
+
+	endPos Pos // end of a rule without a block, if parsed
+}
+
+// groupingRules are at-rules whose blocks contain a list of rules.
+var groupingRules = []string{"media", "supports", "container", "document", "-moz-document", "scope", "starting-style", "layer"}
+
+// declarationRules are at-rules whose blocks contain a list of declarations,
+// including the margin rules of @page.
+var declarationRules = []string{
+	"font-face", "page", "property", "counter-style", "font-palette-values", "viewport",
+	"top-left-corner", "top-left", "top-center", "top-right", "top-right-corner",
+	"bottom-left-corner", "bottom-left", "bottom-center", "bottom-right", "bottom-right-corner",
+	"left-top", "left-middle", "left-bottom", "right-top", "right-middle", "right-bottom",
+}
+
+// IsGroupingRule returns true if the block of the named at-rule contains a
+// list of rules, such as @media or @supports. The rules of @keyframes
+// aren't included since they are keyframes rather than style rules.
+func IsGroupingRule(name string) bool {
+	return containsString(groupingRules, strings.ToLower(name))
+}
+
+// IsDeclarationRule returns true if the block of the named at-rule contains
+// a list of declarations, such as @font-face or the margin rules of @page.
+func IsDeclarationRule(name string) bool {
+	return containsString(declarationRules, strings.ToLower(name))
+}
+
+// componentValues returns the at-rule as a list of component values.
+func (r *AtRule) componentValues() ComponentValues {
+	a := ComponentValues{&Token{Tok: AtKeywordToken, Value: r.Name, Pos: r.Pos}}
+	a = append(a, r.Prelude...)
+	if r.Block != nil {
+		a = append(a, r.Block)
+	} else {
+		a = append(a, &Token{Tok: SemicolonToken})
+	}
+	return a
 }
 
 // QualifiedRule represents an unnamed rule that includes a prelude and block.
@@ -59,6 +101,18 @@ type Declaration struct {
 	Values    ComponentValues
 	Important bool
 	Pos       Pos
+
+	endPos Pos // if parsed
+}
+
+// componentValues returns the declaration as a list of component values.
+func (d *Declaration) componentValues() ComponentValues {
+	a := ComponentValues{&Token{Tok: IdentToken, Value: d.Name, Pos: d.Pos}, &Token{Tok: ColonToken}}
+	a = append(a, d.Values...)
+	if d.Important {
+		a = append(a, &Token{Tok: DelimToken, Value: "!"}, &Token{Tok: IdentToken, Value: "important"})
+	}
+	return a
 }
 
 // ComponentValues represents a list of component values.
@@ -91,6 +145,9 @@ type SimpleBlock struct {
 	Token  *Token
 	Values ComponentValues
 	Pos    Pos
+
+	// The position of the closing token, or of EOF if the block isn't closed.
+	End Pos
 }
 
 // Function represents a function call with a list of arguments.
@@ -98,6 +155,15 @@ type Function struct {
 	Name   string
 	Values ComponentValues
 	Pos    Pos
+
+	endPos Pos // if parsed
+}
+
+// Comment represents a comment skipped by the scanner. Comments aren't part
+// of the syntax tree. See Scanner.KeepComments.
+type Comment struct {
+	Text string // including the "/*" and "*/" delimiters
+	Pos  Pos
 }
 
 // Token represents a lexical token.
@@ -125,6 +191,8 @@ type Token struct {
 
 	// Position of the token in the source document.
 	Pos Pos
+
+	endPos Pos // position just after the token, if scanned
 }
 
 // Tok represents a lexical token type.
@@ -165,11 +233,59 @@ const (
 	EOFToken
 )
 
-// Pos specifies the line and character position of a token.
-// The Char and Line are both zero-based indexes.
+var tokens = [...]string{
+	IdentToken:          "ident",
+	FunctionToken:       "function",
+	AtKeywordToken:      "at-keyword",
+	HashToken:           "hash",
+	StringToken:         "string",
+	BadStringToken:      "bad-string",
+	URLToken:            "url",
+	BadURLToken:         "bad-url",
+	DelimToken:          "delim",
+	NumberToken:         "number",
+	PercentageToken:     "percentage",
+	DimensionToken:      "dimension",
+	UnicodeRangeToken:   "unicode-range",
+	IncludeMatchToken:   "include-match",
+	DashMatchToken:      "dash-match",
+	PrefixMatchToken:    "prefix-match",
+	SuffixMatchToken:    "suffix-match",
+	SubstringMatchToken: "substring-match",
+	ColumnToken:         "column",
+	WhitespaceToken:     "whitespace",
+	CDOToken:            "CDO",
+	CDCToken:            "CDC",
+	ColonToken:          "colon",
+	SemicolonToken:      "semicolon",
+	CommaToken:          "comma",
+	LBrackToken:         "[",
+	RBrackToken:         "]",
+	LParenToken:         "(",
+	RParenToken:         ")",
+	LBraceToken:         "{",
+	RBraceToken:         "}",
+	EOFToken:            "EOF",
+}
+
+// String returns the name of the token type from the specification, such
+// as "ident" for an <ident-token>, or the character for bracket tokens.
+func (t Tok) String() string {
+	if t > 0 && int(t) < len(tokens) {
+		return tokens[t]
+	}
+	return fmt.Sprintf("Tok(%d)", int(t))
+}
+
+// Pos specifies the file, line and character position of a token.
+// Line is a zero-based index and Char is one-based. A line break has a
+// Char of zero on the line that it begins.
 type Pos struct {
 	Char int
 	Line int
+
+	// The file that was scanned, if the scanner has one.
+	File *File
 }
 
 // Position returns the position for a given Node.
@@ -205,27 +321,89 @@ func Position(n Node) Pos {
 	return Pos{}
 }
 
-// Error represents a syntax error.
-type Error struct {
-	Message string
-	Pos     Pos
-}
-
-// Error returns the formatted string error message.
-func (e *Error) Error() string {
-	return e.Message
+// End returns the position just after the text of a node in the source.
+// Trailing whitespace isn't part of the text. The end is recorded by the
+// scanner and parser. For nodes which weren't parsed, such as ones that were
+// created or decoded, it is calculated from the printed node instead.
+// Returns a zero position if the node has no position.
+func End(n Node) Pos {
+	var end Pos
+	switch n := n.(type) {
+	case *StyleSheet:
+		if n != nil {
+			end = End(n.Rules)
+		}
+	case Rules:
+		if len(n) > 0 {
+			end = End(n[len(n)-1])
+		}
+	case *AtRule:
+		if n != nil && n.Block != nil {
+			end = End(n.Block)
+		} else if n != nil {
+			end = n.endPos
+		}
+	case *QualifiedRule:
+		if n != nil && n.Block != nil {
+			end = End(n.Block)
+		}
+	case Declarations:
+		if len(n) > 0 {
+			end = End(n[len(n)-1])
+		}
+	case *Declaration:
+		if n != nil {
+			end = n.endPos
+		}
+	case ComponentValues:
+		for i := len(n) - 1; i >= 0; i-- {
+			if !isTok(n[i], WhitespaceToken) {
+				end = End(n[i])
+				break
+			}
+		}
+	case *SimpleBlock:
+		// The block ends with a single character closing token.
+		if n != nil && n.End != (Pos{}) {
+			end = n.End
+			end.Char++
+		}
+	case *Function:
+		if n != nil {
+			end = n.endPos
+		}
+	case *Token:
+		if n != nil {
+			end = n.endPos
+		}
+	}
+	if end != (Pos{}) {
+		return end
+	}
+	return printEnd(n)
 }
 
-// ErrorList represents a list of syntax errors.
-type ErrorList []error
+// printEnd returns the position just after the text of a node, calculated
+// from the printed node.
+func printEnd(n Node) Pos {
+	pos := Position(n)
+	if pos.Line == 0 && pos.Char == 0 {
+		return Pos{}
+	} else if tok, ok := n.(*Token); ok && tok.Tok == EOFToken {
+		return pos
+	}
 
-// Error returns the formatted string error message.
-func (a ErrorList) Error() string {
-	switch len(a) {
-	case 0:
-		return "no errors"
-	case 1:
-		return a[0].Error()
+	// The first character is at the node's position. Trailing whitespace,
+	// such as at the end of a declaration, isn't part of the range.
+	for i, ch := range strings.TrimRight(print(n), " \t\r\n\f") {
+		if i == 0 {
+			continue
+		} else if ch == '\n' {
+			pos.Line, pos.Char = pos.Line+1, 0
+		} else {
+			pos.Char++
+		}
 	}
-	return fmt.Sprintf("%s (and %d more errors)", a[0], len(a)-1)
+	pos.Char++
+	return pos
 }
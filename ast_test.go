@@ -32,27 +32,50 @@ func TestComponentValue(t *testing.T) {
 	}
 }
 
+// Ensure that token types have names.
+func TestTok_String(t *testing.T) {
+	var tests = []struct {
+		tok Tok
+		s   string
+	}{
+		{tok: IdentToken, s: "ident"},
+		{tok: AtKeywordToken, s: "at-keyword"},
+		{tok: SubstringMatchToken, s: "substring-match"},
+		{tok: CDOToken, s: "CDO"},
+		{tok: LBraceToken, s: "{"},
+		{tok: EOFToken, s: "EOF"},
+		{tok: 0, s: "Tok(0)"},
+		{tok: EOFToken + 1, s: "Tok(33)"},
+	}
+
+	for i, tt := range tests {
+		if s := tt.tok.String(); s != tt.s {
+			t.Errorf("%d. expected %q, got %q", i, tt.s, s)
+		}
+	}
+}
+
 // Ensure that node positions can be retrieved.
 func TestPosition(t *testing.T) {
 	var tests = []struct {
 		in  Node
 		pos Pos
 	}{
-		{in: &StyleSheet{Rules: Rules{&QualifiedRule{Pos: Pos{1, 2}}}}, pos: Pos{1, 2}},
-		{in: Rules{&AtRule{Pos: Pos{1, 2}}}, pos: Pos{1, 2}},
+		{in: &StyleSheet{Rules: Rules{&QualifiedRule{Pos: Pos{Char: 1, Line: 2}}}}, pos: Pos{Char: 1, Line: 2}},
+		{in: Rules{&AtRule{Pos: Pos{Char: 1, Line: 2}}}, pos: Pos{Char: 1, Line: 2}},
 		{in: Rules{}, pos: Pos{}},
-		{in: &QualifiedRule{Pos: Pos{1, 2}}, pos: Pos{1, 2}},
-		{in: &AtRule{Pos: Pos{1, 2}}, pos: Pos{1, 2}},
-		{in: Declarations{&AtRule{Pos: Pos{1, 2}}}, pos: Pos{1, 2}},
-		{in: Declarations{&Declaration{Pos: Pos{1, 2}}}, pos: Pos{1, 2}},
+		{in: &QualifiedRule{Pos: Pos{Char: 1, Line: 2}}, pos: Pos{Char: 1, Line: 2}},
+		{in: &AtRule{Pos: Pos{Char: 1, Line: 2}}, pos: Pos{Char: 1, Line: 2}},
+		{in: Declarations{&AtRule{Pos: Pos{Char: 1, Line: 2}}}, pos: Pos{Char: 1, Line: 2}},
+		{in: Declarations{&Declaration{Pos: Pos{Char: 1, Line: 2}}}, pos: Pos{Char: 1, Line: 2}},
 		{in: Declarations{}, pos: Pos{}},
-		{in: ComponentValues{&SimpleBlock{Pos: Pos{1, 2}}}, pos: Pos{1, 2}},
-		{in: ComponentValues{&Function{Pos: Pos{1, 2}}}, pos: Pos{1, 2}},
-		{in: ComponentValues{&Token{Pos: Pos{1, 2}}}, pos: Pos{1, 2}},
+		{in: ComponentValues{&SimpleBlock{Pos: Pos{Char: 1, Line: 2}}}, pos: Pos{Char: 1, Line: 2}},
+		{in: ComponentValues{&Function{Pos: Pos{Char: 1, Line: 2}}}, pos: Pos{Char: 1, Line: 2}},
+		{in: ComponentValues{&Token{Pos: Pos{Char: 1, Line: 2}}}, pos: Pos{Char: 1, Line: 2}},
 		{in: ComponentValues{}, pos: Pos{}},
-		{in: &SimpleBlock{Pos: Pos{1, 2}}, pos: Pos{1, 2}},
-		{in: &Function{Pos: Pos{1, 2}}, pos: Pos{1, 2}},
-		{in: &Token{Pos: Pos{1, 2}}, pos: Pos{1, 2}},
+		{in: &SimpleBlock{Pos: Pos{Char: 1, Line: 2}}, pos: Pos{Char: 1, Line: 2}},
+		{in: &Function{Pos: Pos{Char: 1, Line: 2}}, pos: Pos{Char: 1, Line: 2}},
+		{in: &Token{Pos: Pos{Char: 1, Line: 2}}, pos: Pos{Char: 1, Line: 2}},
 	}
 
 	for _, tt := range tests {
@@ -62,6 +85,26 @@ func TestPosition(t *testing.T) {
 	}
 }
 
+// Ensure that the end position of a node can be calculated.
+func TestEnd(t *testing.T) {
+	var tests = []struct {
+		in  Node
+		end Pos
+	}{
+		{in: &Token{Tok: IdentToken, Value: "foo", Pos: Pos{Char: 3, Line: 1}}, end: Pos{Char: 6, Line: 1}},
+		{in: &Token{Tok: EOFToken, Pos: Pos{Char: 3, Line: 1}}, end: Pos{Char: 3, Line: 1}},
+		{in: &SimpleBlock{Token: &Token{Tok: LBraceToken}, Values: ComponentValues{&Token{Tok: WhitespaceToken, Value: "\n  "}}, Pos: Pos{Char: 5, Line: 0}}, end: Pos{Char: 4, Line: 1}},
+		{in: ComponentValues{&Token{Tok: IdentToken, Value: "a", Pos: Pos{Char: 1, Line: 0}}, &Token{Tok: WhitespaceToken, Value: " "}}, end: Pos{Char: 2, Line: 0}},
+		{in: &Token{Tok: IdentToken, Value: "foo"}, end: Pos{}},
+	}
+
+	for _, tt := range tests {
+		if end := End(tt.in); !reflect.DeepEqual(tt.end, end) {
+			t.Errorf("expected: %#v, got: %#v", tt.end, end)
+		}
+	}
+}
+
 // Ensure that an error list can be properly formatted.
 func TestErrorList_Error(t *testing.T) {
 	var tests = []struct {
@@ -82,4 +125,29 @@ func TestErrorList_Error(t *testing.T) {
 
 }
 
+// Ensure that at-rules are classified by the contents of their blocks.
+func TestIsGroupingRule(t *testing.T) {
+	var tests = []struct {
+		name        string
+		grouping    bool
+		declaration bool
+	}{
+		{name: "media", grouping: true},
+		{name: "SUPPORTS", grouping: true},
+		{name: "-moz-document", grouping: true},
+		{name: "keyframes"},
+		{name: "font-face", declaration: true},
+		{name: "top-left", declaration: true},
+		{name: "import"},
+	}
+
+	for _, tt := range tests {
+		if IsGroupingRule(tt.name) != tt.grouping {
+			t.Errorf("%s: expected grouping=%v", tt.name, tt.grouping)
+		} else if IsDeclarationRule(tt.name) != tt.declaration {
+			t.Errorf("%s: expected declaration=%v", tt.name, tt.declaration)
+		}
+	}
+}
+
 // TODO(benbjohnson): TestPosition_*
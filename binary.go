@@ -0,0 +1,665 @@
+package css
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+// binaryMagic begins every binary encoding of a style sheet.
+const binaryMagic = "CSSB"
+
+// binaryVersion is the version of the binary encoding. It changes whenever
+// the format changes so that older encodings are treated as stale.
+const binaryVersion = 2
+
+// ErrStale is returned when decoding a binary encoding of a style sheet
+// that was encoded from different source or by a different version.
+var ErrStale = errors.New("stale style sheet encoding")
+
+// Record kinds of the binary encoding.
+const (
+	binaryNil byte = iota
+	binaryAtRule
+	binaryQualifiedRule
+	binarySimpleBlock
+	binaryFunction
+	binaryToken
+)
+
+// Flags for the optional fields of an encoded token.
+const (
+	tokenValue byte = 1 << iota
+	tokenType
+	tokenEnding
+	tokenNumber
+	tokenUnit
+	tokenRange
+)
+
+// MarshalStyleSheet returns a binary encoding of a style sheet parsed from
+// src, such as for a build cache. The encoding includes a checksum of src
+// so that UnmarshalStyleSheet can detect when the source has changed.
+//
+// The encoding begins with "CSSB", a version and the checksum. It is
+// followed by the number of each kind of node, a table of the strings used
+// by the nodes and a record for each node, in order, and it ends with a
+// CRC-32 of everything after the checksum. Positions are encoded without
+// their file.
+func MarshalStyleSheet(ss *StyleSheet, src []byte) ([]byte, error) {
+	e := &binaryEncoder{strings: make(map[string]int)}
+	e.countRules(ss.Rules)
+
+	// Encode the records first so that the string table is complete.
+	e.rules(ss.Rules)
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(binaryMagic)
+	buf.Write(binary.AppendUvarint(nil, binaryVersion))
+	sum := sha256.Sum256(src)
+	buf.Write(sum[:])
+
+	// Write the counts, the string table and the records.
+	start := buf.Len()
+	var b []byte
+	for _, n := range e.counts {
+		b = binary.AppendUvarint(b, uint64(n))
+	}
+	b = binary.AppendUvarint(b, uint64(len(e.table)))
+	var size int
+	for _, s := range e.table {
+		size += len(s)
+	}
+	b = binary.AppendUvarint(b, uint64(size))
+	for _, s := range e.table {
+		b = binary.AppendUvarint(b, uint64(len(s)))
+	}
+	buf.Write(b)
+	for _, s := range e.table {
+		buf.WriteString(s)
+	}
+	buf.Write(e.buf)
+
+	crc := crc32.ChecksumIEEE(buf.Bytes()[start:])
+	buf.Write(binary.LittleEndian.AppendUint32(nil, crc))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalStyleSheet decodes a style sheet from its binary encoding.
+// Returns ErrStale if the encoding is from a different version or if src
+// isn't the source that the style sheet was parsed from.
+func UnmarshalStyleSheet(data, src []byte) (*StyleSheet, error) {
+	// Check the header and the checksums.
+	if len(data) < len(binaryMagic) || string(data[:len(binaryMagic)]) != binaryMagic {
+		return nil, errors.New("invalid style sheet encoding")
+	}
+	data = data[len(binaryMagic):]
+	version, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errors.New("invalid style sheet encoding")
+	} else if version != binaryVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrStale, version)
+	}
+	data = data[n:]
+	if len(data) < sha256.Size+4 {
+		return nil, errors.New("invalid style sheet encoding")
+	} else if sum := sha256.Sum256(src); !bytes.Equal(data[:sha256.Size], sum[:]) {
+		return nil, fmt.Errorf("%w: source has changed", ErrStale)
+	}
+	data = data[sha256.Size:]
+	payload, crc := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(payload) != crc {
+		return nil, errors.New("invalid style sheet encoding: checksum mismatch")
+	}
+
+	d := &binaryDecoder{data: payload}
+	d.init()
+	rules := d.rules()
+	if d.err != nil {
+		return nil, d.err
+	} else if d.i != len(d.data) {
+		return nil, errors.New("invalid style sheet encoding: trailing data")
+	}
+	return &StyleSheet{Rules: rules}, nil
+}
+
+// Indexes of the node counts of the binary encoding.
+const (
+	countTokens = iota
+	countBlocks
+	countFunctions
+	countAtRules
+	countQualifiedRules
+	countValues // total length of all component value lists
+	countRules  // total length of all rule lists
+	countN
+)
+
+// binaryEncoder encodes the records and string table of a style sheet.
+type binaryEncoder struct {
+	buf     []byte
+	err     error
+	counts  [countN]int
+	table   []string
+	strings map[string]int
+}
+
+// countRules counts the nodes of rules so that the decoder can allocate
+// them all at once.
+func (e *binaryEncoder) countRules(a Rules) {
+	e.counts[countRules] += len(a)
+	for _, r := range a {
+		switch r := r.(type) {
+		case *AtRule:
+			if r != nil {
+				e.counts[countAtRules]++
+				e.countValues(r.Prelude)
+				e.countBlock(r.Block)
+			}
+		case *QualifiedRule:
+			if r != nil {
+				e.counts[countQualifiedRules]++
+				e.countValues(r.Prelude)
+				e.countBlock(r.Block)
+			}
+		}
+	}
+}
+
+func (e *binaryEncoder) countValues(a ComponentValues) {
+	e.counts[countValues] += len(a)
+	for _, v := range a {
+		switch v := v.(type) {
+		case *SimpleBlock:
+			e.countBlock(v)
+		case *Function:
+			if v != nil {
+				e.counts[countFunctions]++
+				e.countValues(v.Values)
+			}
+		case *Token:
+			if v != nil {
+				e.counts[countTokens]++
+			}
+		}
+	}
+}
+
+func (e *binaryEncoder) countBlock(b *SimpleBlock) {
+	if b == nil {
+		return
+	}
+	e.counts[countBlocks]++
+	if b.Token != nil {
+		e.counts[countTokens]++
+	}
+	e.countValues(b.Values)
+}
+
+func (e *binaryEncoder) rules(a Rules) {
+	e.uvarint(uint64(len(a)))
+	for _, r := range a {
+		switch r := r.(type) {
+		case *AtRule:
+			if r == nil {
+				e.buf = append(e.buf, binaryNil)
+				continue
+			}
+			e.buf = append(e.buf, binaryAtRule)
+			e.string(r.Name)
+			e.pos(r.Pos)
+			e.pos(r.endPos)
+			e.values(r.Prelude)
+			e.block(r.Block)
+		case *QualifiedRule:
+			if r == nil {
+				e.buf = append(e.buf, binaryNil)
+				continue
+			}
+			e.buf = append(e.buf, binaryQualifiedRule)
+			e.pos(r.Pos)
+			e.values(r.Prelude)
+			e.block(r.Block)
+		case nil:
+			e.buf = append(e.buf, binaryNil)
+		default:
+			e.err = fmt.Errorf("cannot encode rule: %T", r)
+		}
+	}
+}
+
+func (e *binaryEncoder) values(a ComponentValues) {
+	e.uvarint(uint64(len(a)))
+	for _, v := range a {
+		switch v := v.(type) {
+		case *SimpleBlock:
+			e.block(v)
+		case *Function:
+			if v == nil {
+				e.buf = append(e.buf, binaryNil)
+				continue
+			}
+			e.buf = append(e.buf, binaryFunction)
+			e.string(v.Name)
+			e.pos(v.Pos)
+			e.pos(v.endPos)
+			e.values(v.Values)
+		case *Token:
+			e.token(v)
+		case nil:
+			e.buf = append(e.buf, binaryNil)
+		default:
+			e.err = fmt.Errorf("cannot encode component value: %T", v)
+		}
+	}
+}
+
+// block encodes a block record or a nil record for a nil block.
+func (e *binaryEncoder) block(b *SimpleBlock) {
+	if b == nil {
+		e.buf = append(e.buf, binaryNil)
+		return
+	}
+	e.buf = append(e.buf, binarySimpleBlock)
+	e.token(b.Token)
+	e.pos(b.Pos)
+	e.pos(b.End)
+	e.values(b.Values)
+}
+
+// token encodes a token record with a flag for each optional field.
+func (e *binaryEncoder) token(t *Token) {
+	if t == nil {
+		e.buf = append(e.buf, binaryNil)
+		return
+	}
+
+	var flags byte
+	if t.Value != "" {
+		flags |= tokenValue
+	}
+	if t.Type != "" {
+		flags |= tokenType
+	}
+	if t.Ending != 0 {
+		flags |= tokenEnding
+	}
+	if t.Number != 0 {
+		flags |= tokenNumber
+	}
+	if t.Unit != "" {
+		flags |= tokenUnit
+	}
+	if t.Start != 0 || t.End != 0 {
+		flags |= tokenRange
+	}
+	e.buf = append(e.buf, binaryToken, byte(t.Tok), flags)
+
+	if flags&tokenValue != 0 {
+		e.string(t.Value)
+	}
+	if flags&tokenType != 0 {
+		e.string(t.Type)
+	}
+	if flags&tokenEnding != 0 {
+		e.uvarint(uint64(t.Ending))
+	}
+	if flags&tokenNumber != 0 {
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(t.Number))
+	}
+	if flags&tokenUnit != 0 {
+		e.string(t.Unit)
+	}
+	if flags&tokenRange != 0 {
+		e.buf = binary.AppendVarint(e.buf, int64(t.Start))
+		e.buf = binary.AppendVarint(e.buf, int64(t.End))
+	}
+	e.pos(t.Pos)
+	e.pos(t.endPos)
+}
+
+func (e *binaryEncoder) pos(pos Pos) {
+	e.buf = binary.AppendVarint(e.buf, int64(pos.Line))
+	e.buf = binary.AppendVarint(e.buf, int64(pos.Char))
+}
+
+// string encodes the index of s in the string table, adding it if needed.
+func (e *binaryEncoder) string(s string) {
+	i, ok := e.strings[s]
+	if !ok {
+		i = len(e.table)
+		e.table = append(e.table, s)
+		e.strings[s] = i
+	}
+	e.uvarint(uint64(i))
+}
+
+func (e *binaryEncoder) uvarint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+// binaryDecoder decodes the records of a style sheet. Nodes are allocated
+// from slices sized by the counts in the encoding.
+type binaryDecoder struct {
+	data []byte
+	i    int
+	err  error
+
+	table []string
+
+	tokens    []Token
+	blocks    []SimpleBlock
+	functions []Function
+	atRules   []AtRule
+	qualified []QualifiedRule
+	valueList []ComponentValue // backing array of all value lists
+	ruleList  []Rule           // backing array of all rule lists
+}
+
+// init reads the node counts and the string table.
+func (d *binaryDecoder) init() {
+	var counts [countN]int
+	for i := range counts {
+		counts[i] = d.count()
+	}
+	n, size := d.count(), d.count()
+	lens := make([]int, n)
+	for i := range lens {
+		lens[i] = d.count()
+	}
+	if d.err != nil {
+		return
+	} else if size > len(d.data)-d.i {
+		d.fail()
+		return
+	}
+
+	// Slice every string from a single allocation.
+	s := string(d.data[d.i : d.i+size])
+	d.i += size
+	d.table = make([]string, n)
+	for i, l := range lens {
+		if l > len(s) {
+			d.fail()
+			return
+		}
+		d.table[i], s = s[:l], s[l:]
+	}
+
+	d.tokens = make([]Token, 0, counts[countTokens])
+	d.blocks = make([]SimpleBlock, 0, counts[countBlocks])
+	d.functions = make([]Function, 0, counts[countFunctions])
+	d.atRules = make([]AtRule, 0, counts[countAtRules])
+	d.qualified = make([]QualifiedRule, 0, counts[countQualifiedRules])
+	d.valueList = make([]ComponentValue, 0, counts[countValues])
+	d.ruleList = make([]Rule, 0, counts[countRules])
+}
+
+func (d *binaryDecoder) rules() Rules {
+	n := d.count()
+	if d.err != nil || n == 0 {
+		return nil
+	} else if len(d.ruleList)+n > cap(d.ruleList) {
+		d.fail()
+		return nil
+	}
+
+	// Reserve the list before decoding nested rules.
+	start := len(d.ruleList)
+	d.ruleList = d.ruleList[:start+n]
+	a := Rules(d.ruleList[start : start+n : start+n])
+	for i := range a {
+		switch d.byte() {
+		case binaryNil:
+		case binaryAtRule:
+			r := d.atRule()
+			if r == nil {
+				return nil
+			}
+			r.Name = d.string()
+			r.Pos, r.endPos = d.pos(), d.pos()
+			r.Prelude = d.componentValues()
+			r.Block = d.block()
+			a[i] = r
+		case binaryQualifiedRule:
+			r := d.qualifiedRule()
+			if r == nil {
+				return nil
+			}
+			r.Pos = d.pos()
+			r.Prelude = d.componentValues()
+			r.Block = d.block()
+			a[i] = r
+		default:
+			d.fail()
+		}
+		if d.err != nil {
+			return nil
+		}
+	}
+	return a
+}
+
+func (d *binaryDecoder) componentValues() ComponentValues {
+	n := d.count()
+	if d.err != nil || n == 0 {
+		return nil
+	} else if len(d.valueList)+n > cap(d.valueList) {
+		d.fail()
+		return nil
+	}
+
+	// Reserve the list before decoding nested values.
+	start := len(d.valueList)
+	d.valueList = d.valueList[:start+n]
+	a := ComponentValues(d.valueList[start : start+n : start+n])
+	for i := range a {
+		switch d.byte() {
+		case binaryNil:
+		case binarySimpleBlock:
+			if b := d.simpleBlock(); b != nil {
+				a[i] = b
+			}
+		case binaryFunction:
+			f := d.function()
+			if f == nil {
+				return nil
+			}
+			f.Name = d.string()
+			f.Pos, f.endPos = d.pos(), d.pos()
+			f.Values = d.componentValues()
+			a[i] = f
+		case binaryToken:
+			if t := d.token(); t != nil {
+				a[i] = t
+			}
+		default:
+			d.fail()
+		}
+		if d.err != nil {
+			return nil
+		}
+	}
+	return a
+}
+
+// block decodes a block record or a nil record.
+func (d *binaryDecoder) block() *SimpleBlock {
+	switch d.byte() {
+	case binaryNil:
+		return nil
+	case binarySimpleBlock:
+		return d.simpleBlock()
+	}
+	d.fail()
+	return nil
+}
+
+func (d *binaryDecoder) simpleBlock() *SimpleBlock {
+	if len(d.blocks) == cap(d.blocks) {
+		d.fail()
+		return nil
+	}
+	d.blocks = d.blocks[:len(d.blocks)+1]
+	b := &d.blocks[len(d.blocks)-1]
+
+	switch d.byte() {
+	case binaryNil:
+	case binaryToken:
+		b.Token = d.token()
+	default:
+		d.fail()
+	}
+	b.Pos = d.pos()
+	b.End = d.pos()
+	b.Values = d.componentValues()
+	if d.err != nil {
+		return nil
+	}
+	return b
+}
+
+func (d *binaryDecoder) token() *Token {
+	if len(d.tokens) == cap(d.tokens) {
+		d.fail()
+		return nil
+	}
+	d.tokens = d.tokens[:len(d.tokens)+1]
+	t := &d.tokens[len(d.tokens)-1]
+
+	t.Tok = Tok(d.byte())
+	flags := d.byte()
+	if flags&tokenValue != 0 {
+		t.Value = d.string()
+	}
+	if flags&tokenType != 0 {
+		t.Type = d.string()
+	}
+	if flags&tokenEnding != 0 {
+		t.Ending = rune(d.uvarint())
+	}
+	if flags&tokenNumber != 0 {
+		if len(d.data)-d.i < 8 {
+			d.fail()
+			return nil
+		}
+		t.Number = math.Float64frombits(binary.LittleEndian.Uint64(d.data[d.i:]))
+		d.i += 8
+	}
+	if flags&tokenUnit != 0 {
+		t.Unit = d.string()
+	}
+	if flags&tokenRange != 0 {
+		t.Start, t.End = int(d.varint()), int(d.varint())
+	}
+	t.Pos, t.endPos = d.pos(), d.pos()
+	if d.err != nil {
+		return nil
+	}
+	return t
+}
+
+func (d *binaryDecoder) function() *Function {
+	if len(d.functions) == cap(d.functions) {
+		d.fail()
+		return nil
+	}
+	d.functions = d.functions[:len(d.functions)+1]
+	return &d.functions[len(d.functions)-1]
+}
+
+func (d *binaryDecoder) atRule() *AtRule {
+	if len(d.atRules) == cap(d.atRules) {
+		d.fail()
+		return nil
+	}
+	d.atRules = d.atRules[:len(d.atRules)+1]
+	return &d.atRules[len(d.atRules)-1]
+}
+
+func (d *binaryDecoder) qualifiedRule() *QualifiedRule {
+	if len(d.qualified) == cap(d.qualified) {
+		d.fail()
+		return nil
+	}
+	d.qualified = d.qualified[:len(d.qualified)+1]
+	return &d.qualified[len(d.qualified)-1]
+}
+
+func (d *binaryDecoder) pos() Pos {
+	return Pos{Line: int(d.varint()), Char: int(d.varint())}
+}
+
+// string decodes a string by its index in the string table.
+func (d *binaryDecoder) string() string {
+	i := d.uvarint()
+	if d.err != nil {
+		return ""
+	} else if i >= uint64(len(d.table)) {
+		d.fail()
+		return ""
+	}
+	return d.table[i]
+}
+
+// count decodes a length which must not be longer than the remaining data.
+func (d *binaryDecoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (d *binaryDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	} else if d.i >= len(d.data) {
+		d.fail()
+		return 0
+	}
+	b := d.data[d.i]
+	d.i++
+	return b
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data[d.i:])
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.i += n
+	return v
+}
+
+func (d *binaryDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.data[d.i:])
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.i += n
+	return v
+}
+
+// fail records that the encoding is invalid.
+func (d *binaryDecoder) fail() {
+	if d.err == nil {
+		d.err = errors.New("invalid style sheet encoding")
+	}
+}
@@ -0,0 +1,110 @@
+package css_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that decoding a binary encoding returns the same style sheet.
+func TestMarshalStyleSheet(t *testing.T) {
+	var tests = []string{
+		``,
+		`a { color: red }`,
+		`@charset "utf-8"; @import url(a.css) screen;`,
+		"@media (min-width: 100px) {\n  a > b:not(.c) { width: calc(100% - 2px) !important }\n}",
+		`a { content: 'x'; unicode-range: U+0-7F; margin: -1e3px 50% .5em 0 }`,
+		"a { b: [c] (d) }\n\n@font-face { src: url(\"x\") }\r\n@x",
+		`a{`,
+	}
+
+	for i, tt := range tests {
+		src := []byte(tt)
+		var p css.Parser
+		ss := p.ParseStyleSheet(css.NewScannerBytes(src))
+
+		data, err := css.MarshalStyleSheet(ss, src)
+		if err != nil {
+			t.Fatalf("%d. %s", i, err)
+		}
+		other, err := css.UnmarshalStyleSheet(data, src)
+		if err != nil {
+			t.Fatalf("%d. %s", i, err)
+		} else if got, exp := dump(other), dump(ss); got != exp {
+			t.Errorf("%d. unexpected style sheet:\n\nexp: %s\n\ngot: %s", i, exp, got)
+		} else if got, exp := mustMarshalJSON(t, other), mustMarshalJSON(t, ss); got != exp {
+			t.Errorf("%d. unexpected nodes:\n\nexp: %s\n\ngot: %s", i, exp, got)
+		}
+	}
+}
+
+// Ensure that encodings of other sources or versions are stale.
+func TestUnmarshalStyleSheet_Stale(t *testing.T) {
+	src := []byte(`a { color: red }`)
+	var p css.Parser
+	data, err := css.MarshalStyleSheet(p.ParseStyleSheet(css.NewScannerBytes(src)), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := css.UnmarshalStyleSheet(data, []byte(`a { color: blue }`)); !errors.Is(err, css.ErrStale) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The version follows the magic.
+	other := append([]byte{}, data...)
+	other[4] = 0
+	if _, err := css.UnmarshalStyleSheet(other, src); !errors.Is(err, css.ErrStale) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure that corrupt and truncated encodings return an error.
+func TestUnmarshalStyleSheet_Invalid(t *testing.T) {
+	src := []byte("@media print { a > b { c: 1px 'd' U+0-7F } }")
+	var p css.Parser
+	data, err := css.MarshalStyleSheet(p.ParseStyleSheet(css.NewScannerBytes(src)), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < len(data); i++ {
+		if _, err := css.UnmarshalStyleSheet(data[:i], src); err == nil {
+			t.Fatalf("%d. expected error for truncated encoding", i)
+		}
+
+		other := append([]byte{}, data...)
+		other[i] ^= 0xFF
+		if _, err := css.UnmarshalStyleSheet(other, src); err == nil {
+			t.Fatalf("%d. expected error for corrupt encoding", i)
+		}
+	}
+}
+
+func BenchmarkUnmarshalStyleSheet(b *testing.B) {
+	src := benchmarkStyleSheet()
+	var p css.Parser
+	data, err := css.MarshalStyleSheet(p.ParseStyleSheet(css.NewScannerBytes(src)), src)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := css.UnmarshalStyleSheet(data, src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func mustMarshalJSON(tb testing.TB, v interface{}) string {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.String()
+}
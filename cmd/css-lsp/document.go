@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/benbjohnson/css"
+)
+
+// document represents an open text document and its parsed style sheet.
+type document struct {
+	uri     string
+	version int
+	text    string
+	lines   []int // offset of the start of each line
+
+	ss     *css.StyleSheet
+	errors []*css.Error
+	decls  []*css.Declaration
+}
+
+// newDocument returns a parsed document.
+func newDocument(uri string, version int, text string) *document {
+	d := &document{uri: uri, version: version}
+	d.setText(text)
+	return d
+}
+
+// setText replaces the text of the document and reparses it.
+func (d *document) setText(text string) {
+	d.setLines(text)
+
+	var p css.Parser
+	s := css.NewScannerString(text)
+	d.ss = p.ParseStyleSheet(s)
+	d.decls = nil
+	d.parseRules(&p, d.ss.Rules)
+
+	d.errors = append([]*css.Error{}, s.Errors...)
+	d.errors = appendErrors(d.errors, p.Errors)
+}
+
+// setLines replaces the text of the document and finds the start of each line.
+func (d *document) setLines(text string) {
+	d.text = text
+	d.lines = []int{0}
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\r':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				i++
+			}
+			d.lines = append(d.lines, i+1)
+		case '\n', '\f':
+			d.lines = append(d.lines, i+1)
+		}
+	}
+}
+
+// appendErrors appends the style sheet errors in errs to a.
+func appendErrors(a []*css.Error, errs []error) []*css.Error {
+	for _, err := range errs {
+		if err, ok := err.(*css.Error); ok {
+			a = append(a, err)
+		}
+	}
+	return a
+}
+
+// parseRules parses the blocks of rules into declarations and nested rules.
+func (d *document) parseRules(p *css.Parser, rules css.Rules) {
+	for _, r := range rules {
+		switch r := r.(type) {
+		case *css.QualifiedRule:
+			d.parseDeclarations(p, r.Block)
+		case *css.AtRule:
+			if r.Block == nil {
+				continue
+			}
+			name := strings.ToLower(r.Name)
+			if css.IsGroupingRule(name) || strings.HasSuffix(name, "keyframes") {
+				d.parseRules(p, nestedRules(p, r))
+			} else {
+				d.parseDeclarations(p, r.Block)
+			}
+		}
+	}
+}
+
+func (d *document) parseDeclarations(p *css.Parser, b *css.SimpleBlock) {
+	if b == nil {
+		return
+	}
+	for _, n := range p.ConsumeDeclarations(css.NewComponentValueScanner(b.Values)) {
+		switch n := n.(type) {
+		case *css.Declaration:
+			d.decls = append(d.decls, n)
+		case *css.AtRule:
+			d.parseRules(p, css.Rules{n})
+		}
+	}
+}
+
+// nestedRules returns the rules within the block of a grouping at-rule.
+func nestedRules(p *css.Parser, r *css.AtRule) css.Rules {
+	return p.ConsumeRules(css.NewComponentValueScanner(r.Block.Values), false)
+}
+
+// edit replaces the text of a range. Only the rules affected by the edit
+// are reparsed, along with the declarations of every rule.
+func (d *document) edit(r Range, text string) {
+	start, end := d.offset(r.Start), d.offset(r.End)
+	if end < start {
+		start, end = end, start
+	}
+	e := css.Edit{Start: start, End: end, Text: text}
+
+	// Reparse moves the rules after the edit so their positions are
+	// recorded first.
+	old := d.ss.Rules
+	starts := make([]css.Pos, len(old))
+	for i, r := range old {
+		starts[i] = css.Position(r)
+	}
+
+	var p css.Parser
+	ss := p.Reparse(d.ss, d.text, e)
+	d.setLines(e.Apply(d.text))
+	d.ss = ss
+
+	// Find the rules which were reused before and after the edit. Those
+	// after it have been moved, unless the edit didn't change the length.
+	var head, tail int
+	for head < len(old) && head < len(ss.Rules) && ss.Rules[head] == old[head] && css.Position(old[head]) == starts[head] {
+		head++
+	}
+	for tail < len(old)-head && tail < len(ss.Rules)-head && ss.Rules[len(ss.Rules)-1-tail] == old[len(old)-1-tail] {
+		tail++
+	}
+
+	// Errors within the reused rules are kept, moving those after the
+	// edit the same way as their rules.
+	var errors []*css.Error
+	for _, err := range d.errors {
+		if head == len(old) || (head > 0 && posLess(err.Pos, starts[head])) {
+			errors = append(errors, err)
+		}
+	}
+	var discard css.Parser
+	d.decls = nil
+	for i, r := range ss.Rules {
+		if i < head || i >= len(ss.Rules)-tail {
+			d.parseRules(&discard, css.Rules{r})
+		} else {
+			d.parseRules(&p, css.Rules{r})
+		}
+	}
+	errors = appendErrors(errors, p.Errors)
+	if tail > 0 {
+		from := starts[len(old)-tail]
+		to := css.Position(old[len(old)-tail])
+		for _, err := range d.errors {
+			if !posLess(err.Pos, from) {
+				other := *err
+				other.Pos, other.End = shiftPos(err.Pos, from, to), shiftPos(err.End, from, to)
+				errors = append(errors, &other)
+			}
+		}
+	}
+	d.errors = errors
+}
+
+// shiftPos moves pos the same way as the position from was moved to the
+// position to. Positions on the same line as from are moved by the same
+// number of characters.
+func shiftPos(pos, from, to css.Pos) css.Pos {
+	if pos == (css.Pos{}) {
+		return pos
+	} else if pos.Line == from.Line {
+		pos.Char += to.Char - from.Char
+	}
+	pos.Line += to.Line - from.Line
+	return pos
+}
+
+// posLess returns true if a is before b.
+func posLess(a, b css.Pos) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Char < b.Char)
+}
+
+// offset returns the byte offset of a position.
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	} else if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	i, n := d.lines[pos.Line], 0
+	for i < len(d.text) && n < pos.Character {
+		ch, size := utf8.DecodeRuneInString(d.text[i:])
+		if ch == '\r' || ch == '\n' {
+			break
+		}
+		i += size
+		n += utf16Len(ch)
+	}
+	return i
+}
+
+// line returns the text of a line without its line break.
+func (d *document) line(i int) string {
+	if i >= len(d.lines) {
+		return ""
+	}
+	end := len(d.text)
+	if i+1 < len(d.lines) {
+		end = d.lines[i+1]
+	}
+	return strings.TrimRight(d.text[d.lines[i]:end], "\r\n")
+}
+
+// position converts a scanner position to a protocol position. Character
+// zero of a line is the line break which ends the line before it.
+func (d *document) position(pos css.Pos) Position {
+	if pos.Char == 0 && pos.Line > 0 {
+		line := d.line(pos.Line - 1)
+		return Position{Line: pos.Line - 1, Character: d.units(line, len(line))}
+	} else if pos.Line >= len(d.lines) {
+		return d.end()
+	}
+	return Position{Line: pos.Line, Character: d.units(d.line(pos.Line), pos.Char-1)}
+}
+
+// units returns the number of UTF-16 code units in the first n code points of s.
+func (d *document) units(s string, n int) int {
+	var u int
+	for _, ch := range s {
+		if n <= 0 {
+			break
+		}
+		u += utf16Len(ch)
+		n--
+	}
+	return u
+}
+
+// end returns the position of the end of the document.
+func (d *document) end() Position {
+	line := len(d.lines) - 1
+	return Position{Line: line, Character: d.units(d.line(line), len(d.line(line)))}
+}
+
+// rangeOf returns the range of a node.
+func (d *document) rangeOf(n css.Node) Range {
+	return Range{Start: d.position(css.Position(n)), End: d.position(css.End(n))}
+}
+
+// diagnostics returns the errors of the document.
+func (d *document) diagnostics() []Diagnostic {
+	a := []Diagnostic{}
+	for _, err := range d.errors {
+		r := Range{Start: d.position(err.Pos), End: d.position(err.End)}
+		if err.End == (css.Pos{}) || before(r.End, r.Start) {
+			r.End = r.Start
+			r.End.Character++
+		}
+		severity := SeverityError
+		if err.Severity == css.Warning {
+			severity = SeverityWarning
+		}
+		a = append(a, Diagnostic{Range: r, Severity: severity, Code: err.Code, Source: "css", Message: err.Message})
+	}
+	return a
+}
+
+// symbols returns the outline of a list of rules.
+func (d *document) symbols(rules css.Rules) []DocumentSymbol {
+	var p css.Parser
+	a := []DocumentSymbol{}
+	for _, r := range rules {
+		switch r := r.(type) {
+		case *css.QualifiedRule:
+			a = append(a, DocumentSymbol{
+				Name:           collapse(print(r.Prelude)),
+				Kind:           SymbolClass,
+				Range:          d.rangeOf(r),
+				SelectionRange: d.rangeOf(r.Prelude),
+			})
+		case *css.AtRule:
+			sym := DocumentSymbol{
+				Name:  "@" + r.Name,
+				Kind:  SymbolNamespace,
+				Range: d.rangeOf(r),
+			}
+			sym.Detail = collapse(print(r.Prelude))
+			sym.SelectionRange = Range{Start: d.position(r.Pos), End: d.position(css.Pos{Char: r.Pos.Char + 1 + utf8.RuneCountInString(r.Name), Line: r.Pos.Line})}
+
+			name := strings.ToLower(r.Name)
+			if r.Block != nil && (css.IsGroupingRule(name) || strings.HasSuffix(name, "keyframes")) {
+				sym.Children = d.symbols(nestedRules(&p, r))
+			}
+			a = append(a, sym)
+		}
+	}
+	return a
+}
+
+// foldingRanges returns the ranges of blocks which span several lines.
+// Blocks which span the same lines share a single range.
+func (d *document) foldingRanges() []FoldingRange {
+	a := []FoldingRange{}
+	seen := make(map[FoldingRange]bool)
+	css.Inspect(d.ss, func(n css.Node) bool {
+		if b, ok := n.(*css.SimpleBlock); ok {
+			r := d.rangeOf(b)
+			if fr := (FoldingRange{StartLine: r.Start.Line, EndLine: r.End.Line}); fr.EndLine > fr.StartLine && !seen[fr] {
+				a = append(a, fr)
+				seen[fr] = true
+			}
+		}
+		return true
+	})
+	return a
+}
+
+// hover returns a description of the property at a position, if any.
+func (d *document) hover(pos Position) *Hover {
+	for _, decl := range d.decls {
+		r := Range{Start: d.position(decl.Pos), End: d.position(css.Pos{Char: decl.Pos.Char + utf8.RuneCountInString(decl.Name), Line: decl.Pos.Line})}
+		if !r.contains(pos) {
+			continue
+		}
+
+		prop := css.LookupProperty(decl.Name)
+		if prop == nil {
+			return nil
+		}
+		return &Hover{Contents: MarkupContent{Kind: "markdown", Value: describe(prop)}, Range: r}
+	}
+	return nil
+}
+
+// describe returns a markdown description of a property.
+func describe(prop *css.Property) string {
+	var buf bytes.Buffer
+	buf.WriteString("**" + prop.Name + "**\n\n")
+	buf.WriteString("```\n" + prop.Name + ": " + prop.Syntax + "\n```\n\n")
+	if prop.IsShorthand() {
+		buf.WriteString("Shorthand for: " + strings.Join(prop.Longhands, ", ") + "\n\n")
+	}
+	if prop.Initial != "" {
+		buf.WriteString("Initial: `" + prop.Initial + "`\n\n")
+	}
+	if prop.AppliesTo != "" {
+		buf.WriteString("Applies to: " + prop.AppliesTo + "\n\n")
+	}
+	if prop.Inherited {
+		buf.WriteString("Inherited: yes\n\n")
+	} else {
+		buf.WriteString("Inherited: no\n\n")
+	}
+	buf.WriteString("Animation type: " + prop.Animation.String())
+	return buf.String()
+}
+
+// format returns the printed style sheet. Returns false if the document has
+// errors or comments since they would be dropped from the output.
+func (d *document) format() (string, bool) {
+	for _, err := range d.errors {
+		if err.Severity != css.Warning {
+			return "", false
+		}
+	}
+	if hasComment(d.text) {
+		return "", false
+	}
+	return print(d.ss), true
+}
+
+// hasComment returns true if text contains a comment outside of a string.
+func hasComment(text string) bool {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch ch := text[i]; {
+		case quote != 0 && ch == '\\':
+			i++
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '/' && i+1 < len(text) && text[i+1] == '*':
+			return true
+		}
+	}
+	return false
+}
+
+// print returns the printed text of a node.
+func print(n css.Node) string {
+	var buf bytes.Buffer
+	var p css.Printer
+	_ = p.Print(&buf, n)
+	return buf.String()
+}
+
+// collapse trims whitespace and collapses runs of it to a single space.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// utf16Len returns the number of UTF-16 code units needed to encode ch.
+func utf16Len(ch rune) int {
+	if ch >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Ensure that an edited document matches a document parsed from its new text.
+func TestDocument_Edit(t *testing.T) {
+	var tests = []struct {
+		in   string
+		r    Range
+		text string
+	}{
+		{in: "a { x y }\nb { x: y }\nc { 'z\n}", r: newRange(1, 4, 1, 5), text: "color"},
+		{in: "a { x y }\nb { x: y }\nc { 'z\n}", r: newRange(1, 0, 1, 0), text: "d { }\n"},
+		{in: "a { x y }\nb { x: y }\nc { 'z\n}", r: newRange(0, 5, 0, 5), text: ":"},
+		{in: "a { x y }\nb { x: y }\nc { 'z\n}", r: newRange(1, 10, 2, 0), text: " "},
+		{in: "a { x y } b { x: y } c { 'z\n}", r: newRange(0, 14, 0, 14), text: "\n\n"},
+		{in: "a { }\n@media print { b { x y } }\nc { x z }", r: newRange(0, 4, 0, 4), text: "}"},
+		{in: "/* a */ b { }", r: newRange(0, 0, 0, 0), text: "'x\n"},
+		{in: "a { x y }\nb { }", r: newRange(1, 0, 1, 0), text: "\n"},
+		{in: "a { x y }\nb { }", r: newRange(0, 9, 0, 9), text: " "},
+		{in: "a { }\fb { x y }", r: newRange(0, 0, 0, 0), text: "\n"},
+	}
+
+	for i, tt := range tests {
+		d := newDocument("file:///a.css", 1, tt.in)
+		d.edit(tt.r, tt.text)
+		exp := newDocument("file:///a.css", 1, d.text)
+		if got, want := sortedDiagnostics(d.diagnostics()), sortedDiagnostics(exp.diagnostics()); !reflect.DeepEqual(got, want) {
+			t.Errorf("%d. unexpected diagnostics:\n\nexp: %#v\n\ngot: %#v", i, want, got)
+		} else if got, want := d.symbols(d.ss.Rules), exp.symbols(exp.ss.Rules); !reflect.DeepEqual(got, want) {
+			t.Errorf("%d. unexpected symbols:\n\nexp: %#v\n\ngot: %#v", i, want, got)
+		} else if got, want := d.foldingRanges(), exp.foldingRanges(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%d. unexpected folding ranges: %v", i, got)
+		} else if len(d.decls) != len(exp.decls) {
+			t.Errorf("%d. unexpected declarations: %d", i, len(d.decls))
+		}
+	}
+}
+
+// sortedDiagnostics returns a copy of a sorted by position.
+func sortedDiagnostics(a []Diagnostic) []Diagnostic {
+	a = append([]Diagnostic{}, a...)
+	sort.SliceStable(a, func(i, j int) bool { return before(a[i].Range.Start, a[j].Range.Start) })
+	return a
+}
+
+// Ensure that a form feed begins a new line, as it does in the scanner.
+func TestDocument_FormFeed(t *testing.T) {
+	d := newDocument("file:///a.css", 1, "a { }\fb { x y }")
+	if a := d.diagnostics(); len(a) != 1 {
+		t.Fatalf("unexpected diagnostics: %#v", a)
+	} else if a[0].Range != newRange(1, 6, 1, 7) {
+		t.Fatalf("unexpected range: %#v", a[0].Range)
+	} else if r := d.rangeOf(d.ss.Rules[1]); r != newRange(1, 0, 1, 9) {
+		t.Fatalf("unexpected rule range: %#v", r)
+	}
+}
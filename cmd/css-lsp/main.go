@@ -0,0 +1,20 @@
+// Command css-lsp is a Language Server Protocol server for CSS. It speaks
+// the protocol over stdin and stdout and provides diagnostics, document
+// symbols, folding ranges, hover information for properties and formatting.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	s := NewServer(os.Stdin, os.Stdout)
+	if err := s.Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	} else if !s.Shutdown() {
+		// The client exited without requesting a shutdown first.
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// Server represents a language server which communicates with a single
+// client over a reader and writer, such as stdin and stdout.
+type Server struct {
+	r *bufio.Reader
+	w io.Writer
+
+	docs     map[string]*document
+	shutdown bool // true once the client requests a shutdown
+}
+
+// NewServer returns a new server which reads requests from r and writes
+// responses and notifications to w.
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		r:    bufio.NewReader(r),
+		w:    w,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the client sends an exit notification or the
+// reader is closed. Returns io.EOF if the reader closes before an exit.
+func (s *Server) Serve() error {
+	for {
+		b, err := readMessage(s.r)
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(b, &req); err != nil {
+			if err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			return nil
+		} else if req.ID == nil {
+			if err := s.notify(&req); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, rerr := s.call(&req)
+		if err := s.reply(req.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+// Shutdown returns true if the client requested a shutdown before exiting.
+func (s *Server) Shutdown() bool { return s.shutdown }
+
+// call handles a request and returns its result.
+func (s *Server) call(req *request) (interface{}, *responseError) {
+	if s.shutdown {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shut down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		return &InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:           TextDocumentSyncOptions{OpenClose: true, Change: SyncIncremental},
+				DocumentSymbolProvider:     true,
+				FoldingRangeProvider:       true,
+				HoverProvider:              true,
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "css-lsp"},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/documentSymbol":
+		var params TextDocumentParams
+		d, err := s.document(req, &params, &params.TextDocument)
+		if d == nil {
+			return nil, err
+		}
+		return d.symbols(d.ss.Rules), nil
+
+	case "textDocument/foldingRange":
+		var params TextDocumentParams
+		d, err := s.document(req, &params, &params.TextDocument)
+		if d == nil {
+			return nil, err
+		}
+		return d.foldingRanges(), nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		d, err := s.document(req, &params, &params.TextDocument)
+		if d == nil {
+			return nil, err
+		}
+		if h := d.hover(params.Position); h != nil {
+			return h, nil
+		}
+		return nil, nil
+
+	case "textDocument/formatting":
+		var params TextDocumentParams
+		d, err := s.document(req, &params, &params.TextDocument)
+		if d == nil {
+			return nil, err
+		}
+		text, ok := d.format()
+		if !ok || text == d.text {
+			return []TextEdit{}, nil
+		}
+		return []TextEdit{{Range: Range{End: d.end()}, NewText: text}}, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// document decodes the parameters of a request and returns the document
+// that they refer to.
+func (s *Server) document(req *request, params interface{}, id *TextDocumentIdentifier) (*document, *responseError) {
+	if err := json.Unmarshal(req.Params, params); err != nil {
+		return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	d := s.docs[id.URI]
+	if d == nil {
+		return nil, &responseError{Code: codeInvalidParams, Message: "unknown document: " + id.URI}
+	}
+	return d, nil
+}
+
+// notify handles a notification. Unknown notifications are ignored.
+func (s *Server) notify(req *request) error {
+	switch req.Method {
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		item := params.TextDocument
+		d := newDocument(item.URI, item.Version, item.Text)
+		s.docs[item.URI] = d
+		return s.publish(d)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		d := s.docs[params.TextDocument.URI]
+		if d == nil {
+			return nil
+		}
+		for _, c := range params.ContentChanges {
+			if c.Range == nil {
+				d.setText(c.Text)
+			} else {
+				d.edit(*c.Range, c.Text)
+			}
+		}
+		d.version = params.TextDocument.Version
+		return s.publish(d)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		delete(s.docs, params.TextDocument.URI)
+
+		// Clear the diagnostics of the closed document.
+		return writeMessage(s.w, &notification{
+			JSONRPC: "2.0",
+			Method:  "textDocument/publishDiagnostics",
+			Params:  &PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}},
+		})
+	}
+	return nil
+}
+
+// publish sends the diagnostics of a document to the client.
+func (s *Server) publish(d *document) error {
+	return writeMessage(s.w, &notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  &PublishDiagnosticsParams{URI: d.uri, Version: d.version, Diagnostics: d.diagnostics()},
+	})
+}
+
+// reply writes the response to a request.
+func (s *Server) reply(id *json.RawMessage, result interface{}, err *responseError) error {
+	if err != nil {
+		return writeMessage(s.w, &errorResponse{JSONRPC: "2.0", ID: id, Error: err})
+	}
+	return writeMessage(s.w, &response{JSONRPC: "2.0", ID: id, Result: result})
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Ensure that the server publishes diagnostics for scanner and parser errors.
+func TestServer_Diagnostics(t *testing.T) {
+	c := newTestClient(t)
+	defer c.Close()
+
+	c.Notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: "file:///a.css", Version: 1, Text: "a { color: red }\nb { x y; 'z\n}"}})
+	var params PublishDiagnosticsParams
+	c.Receive("textDocument/publishDiagnostics", &params)
+	if params.URI != "file:///a.css" || params.Version != 1 {
+		t.Fatalf("unexpected params: %#v", params)
+	} else if codes := diagnosticCodes(params.Diagnostics); codes != "newline-in-string,expected-colon,unexpected-token" {
+		t.Fatalf("unexpected diagnostics: %s", codes)
+	} else if r := params.Diagnostics[1].Range; r != (Range{Start: Position{Line: 1, Character: 6}, End: Position{Line: 1, Character: 7}}) {
+		t.Fatalf("unexpected range: %#v", r)
+	}
+
+	// Fix the errors with incremental changes.
+	c.Notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: VersionedTextDocumentIdentifier{URI: "file:///a.css", Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Range: &Range{Start: Position{Line: 1, Character: 9}, End: Position{Line: 1, Character: 11}}, Text: ""},
+			{Range: &Range{Start: Position{Line: 1, Character: 5}, End: Position{Line: 1, Character: 5}}, Text: ":"},
+		},
+	})
+	c.Receive("textDocument/publishDiagnostics", &params)
+	if params.Version != 2 || len(params.Diagnostics) != 0 {
+		t.Fatalf("unexpected params: %#v", params)
+	}
+
+	// Closing the document clears its diagnostics.
+	c.Notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: "file:///a.css"}})
+	c.Receive("textDocument/publishDiagnostics", &params)
+	if len(params.Diagnostics) != 0 {
+		t.Fatalf("unexpected params: %#v", params)
+	}
+}
+
+// Ensure that the server returns symbols for rules and nested rules.
+func TestServer_DocumentSymbol(t *testing.T) {
+	c := newTestClient(t)
+	defer c.Close()
+	c.Open("file:///a.css", "@import 'x.css';\na,\nb { }\n@media print {\n  c { }\n}")
+
+	var symbols []DocumentSymbol
+	c.Call("textDocument/documentSymbol", TextDocumentParams{TextDocument: TextDocumentIdentifier{URI: "file:///a.css"}}, &symbols)
+	exp := []DocumentSymbol{
+		{Name: "@import", Detail: "'x.css'", Kind: SymbolNamespace, Range: newRange(0, 0, 0, 16), SelectionRange: newRange(0, 0, 0, 7)},
+		{Name: "a, b", Kind: SymbolClass, Range: newRange(1, 0, 2, 5), SelectionRange: newRange(1, 0, 2, 1)},
+		{Name: "@media", Detail: "print", Kind: SymbolNamespace, Range: newRange(3, 0, 5, 1), SelectionRange: newRange(3, 0, 3, 6), Children: []DocumentSymbol{
+			{Name: "c", Kind: SymbolClass, Range: newRange(4, 2, 4, 7), SelectionRange: newRange(4, 2, 4, 3)},
+		}},
+	}
+	if !reflect.DeepEqual(symbols, exp) {
+		t.Fatalf("unexpected symbols:\n\nexp: %#v\n\ngot: %#v", exp, symbols)
+	}
+}
+
+// Ensure that the server returns folding ranges for multi-line blocks.
+func TestServer_FoldingRange(t *testing.T) {
+	c := newTestClient(t)
+	defer c.Close()
+	c.Open("file:///a.css", "a { }\n@media print {\n  b {\n    x: y;\n  }\n}\nc { x: [\n] }")
+
+	var ranges []FoldingRange
+	c.Call("textDocument/foldingRange", TextDocumentParams{TextDocument: TextDocumentIdentifier{URI: "file:///a.css"}}, &ranges)
+	if exp := []FoldingRange{{1, 5}, {2, 4}, {6, 7}}; !reflect.DeepEqual(ranges, exp) {
+		t.Fatalf("unexpected ranges: %v", ranges)
+	}
+}
+
+// Ensure that the server describes the property under the cursor.
+func TestServer_Hover(t *testing.T) {
+	c := newTestClient(t)
+	defer c.Close()
+	c.Open("file:///a.css", "a {\n  color: red;\n  --x: 1;\n}\n@media print { b { MARGIN: 0 } }")
+
+	var tests = []struct {
+		pos   Position
+		name  string
+		start Position
+	}{
+		{pos: Position{Line: 1, Character: 2}, name: "color", start: Position{Line: 1, Character: 2}},
+		{pos: Position{Line: 1, Character: 7}, name: "color", start: Position{Line: 1, Character: 2}},
+		{pos: Position{Line: 1, Character: 9}},
+		{pos: Position{Line: 2, Character: 3}},
+		{pos: Position{Line: 4, Character: 20}, name: "margin", start: Position{Line: 4, Character: 19}},
+	}
+
+	for i, tt := range tests {
+		var h *Hover
+		c.Call("textDocument/hover", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: "file:///a.css"}, Position: tt.pos}, &h)
+		if tt.name == "" {
+			if h != nil {
+				t.Errorf("%d. unexpected hover: %#v", i, h)
+			}
+		} else if h == nil {
+			t.Errorf("%d. expected hover", i)
+		} else if !strings.HasPrefix(h.Contents.Value, "**"+tt.name+"**") || h.Range.Start != tt.start {
+			t.Errorf("%d. unexpected hover: %#v", i, h)
+		}
+	}
+}
+
+// Ensure that the server formats documents with the printer.
+func TestServer_Formatting(t *testing.T) {
+	c := newTestClient(t)
+	defer c.Close()
+
+	var tests = []struct {
+		in    string
+		edits []TextEdit
+	}{
+		{in: "a { x: y }\n\nb { }", edits: []TextEdit{{Range: newRange(0, 0, 2, 5), NewText: "a { x: y } b { }"}}},
+		{in: "a { x: y }", edits: []TextEdit{}},
+		{in: "a { x: y }\n/* c */", edits: []TextEdit{}},
+		{in: "a { x: y } 'b\n", edits: []TextEdit{}},
+	}
+
+	for i, tt := range tests {
+		c.Open("file:///a.css", tt.in)
+		var edits []TextEdit
+		c.Call("textDocument/formatting", TextDocumentParams{TextDocument: TextDocumentIdentifier{URI: "file:///a.css"}}, &edits)
+		if !reflect.DeepEqual(edits, tt.edits) {
+			t.Errorf("%d. unexpected edits: %#v", i, edits)
+		}
+	}
+}
+
+// Ensure that the server returns errors for unknown methods and documents.
+func TestServer_Errors(t *testing.T) {
+	c := newTestClient(t)
+	defer c.Close()
+
+	if err := c.call("foo/bar", nil, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := c.call("textDocument/hover", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: "file:///x.css"}}, nil); err == nil || err.Code != codeInvalidParams {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Requests after a shutdown are invalid.
+	c.Call("shutdown", nil, nil)
+	if err := c.call("textDocument/hover", nil, nil); err == nil || err.Code != codeInvalidRequest {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure that the server stops once the client exits.
+func TestServer_Exit(t *testing.T) {
+	c := newTestClient(t)
+	var result InitializeResult
+	c.Call("initialize", map[string]interface{}{}, &result)
+	if !result.Capabilities.HoverProvider || result.Capabilities.TextDocumentSync.Change != SyncIncremental {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+	c.Call("shutdown", nil, nil)
+	c.Notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Fatal(err)
+	} else if !c.server.Shutdown() {
+		t.Fatal("expected shutdown")
+	}
+}
+
+// Ensure that messages with a bad content length are rejected without
+// allocating the length up front.
+func TestReadMessage_ContentLength(t *testing.T) {
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{in: "Content-Length: 2\r\n\r\n{}", err: ""},
+		{in: "Content-Length: 99999999999\r\n\r\n{}", err: "content length too large: 99999999999"},
+		{in: "Content-Length: 67108864\r\n\r\n{}", err: "unexpected EOF"},
+		{in: "\r\n{}", err: "missing content length"},
+	}
+
+	for i, tt := range tests {
+		b, err := readMessage(bufio.NewReader(strings.NewReader(tt.in)))
+		if tt.err == "" && (err != nil || string(b) != "{}") {
+			t.Errorf("%d. unexpected message: %q, %v", i, b, err)
+		} else if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
+
+// testClient is an in-process client connected to a server over pipes.
+type testClient struct {
+	t      *testing.T
+	server *Server
+	w      *io.PipeWriter
+	r      *bufio.Reader
+	id     int
+	done   chan error
+}
+
+func newTestClient(t *testing.T) *testClient {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	c := &testClient{t: t, server: NewServer(sr, sw), w: cw, r: bufio.NewReader(cr), done: make(chan error, 1)}
+	go func() {
+		c.done <- c.server.Serve()
+		sw.Close()
+	}()
+	return c
+}
+
+// Close closes the connection to the server.
+func (c *testClient) Close() {
+	c.w.Close()
+	<-c.done
+}
+
+// Open opens a document and discards its diagnostics.
+func (c *testClient) Open(uri, text string) {
+	c.Notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, Version: 1, Text: text}})
+	c.Receive("textDocument/publishDiagnostics", nil)
+}
+
+// Notify sends a notification to the server.
+func (c *testClient) Notify(method string, params interface{}) {
+	if err := writeMessage(c.w, map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// Receive reads a notification from the server and decodes its parameters into v.
+func (c *testClient) Receive(method string, v interface{}) {
+	var msg struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	c.read(&msg)
+	if msg.Method != method {
+		c.t.Fatalf("unexpected notification: %s", msg.Method)
+	} else if v != nil {
+		if err := json.Unmarshal(msg.Params, v); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+}
+
+// Call sends a request to the server and decodes its result into v.
+func (c *testClient) Call(method string, params, v interface{}) {
+	if err := c.call(method, params, v); err != nil {
+		c.t.Fatalf("%s: %s", method, err)
+	}
+}
+
+func (c *testClient) call(method string, params, v interface{}) *responseError {
+	c.id++
+	if err := writeMessage(c.w, map[string]interface{}{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params}); err != nil {
+		c.t.Fatal(err)
+	}
+
+	var msg struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *responseError  `json:"error"`
+	}
+	c.read(&msg)
+	if msg.ID != c.id {
+		c.t.Fatalf("unexpected id: %d", msg.ID)
+	} else if msg.Error != nil {
+		return msg.Error
+	} else if v != nil {
+		if err := json.Unmarshal(msg.Result, v); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	return nil
+}
+
+func (c *testClient) read(v interface{}) {
+	b, err := readMessage(c.r)
+	if err != nil {
+		c.t.Fatal(err)
+	} else if err := json.Unmarshal(b, v); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func newRange(l0, c0, l1, c1 int) Range {
+	return Range{Start: Position{Line: l0, Character: c0}, End: Position{Line: l1, Character: c1}}
+}
+
+func diagnosticCodes(a []Diagnostic) string {
+	var codes []string
+	for _, d := range a {
+		codes = append(codes, d.Code)
+	}
+	return strings.Join(codes, ",")
+}
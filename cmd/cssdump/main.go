@@ -0,0 +1,215 @@
+// Command cssdump prints the tokens or syntax tree of a CSS style sheet.
+//
+// It is a debugging aid for seeing how the scanner and parser see their
+// input, such as whether a construct is parsed as a qualified rule or an
+// at-rule.
+//
+// Usage:
+//
+//	cssdump [flags] [path]
+//
+// The style sheet is read from path or from the standard input if no path
+// is given. The flags are:
+//
+//	-tokens
+//		Print the token stream instead of the syntax tree. Each token is
+//		printed on its own line with its position, type and value, followed
+//		by its number, unit and type flag if it has them.
+//	-json
+//		Print the syntax tree as JSON.
+//	-decls
+//		Parse the input as a list of declarations, such as the contents of a
+//		style attribute, instead of a style sheet.
+//
+// Scanner and parser errors are printed to standard error after the output
+// and cssdump exits with a non-zero status.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/benbjohnson/css"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command with the given arguments and returns its exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("cssdump", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	tokens := fs.Bool("tokens", false, "print the token stream instead of the syntax tree")
+	asJSON := fs.Bool("json", false, "print the syntax tree as JSON")
+	decls := fs.Bool("decls", false, "parse the input as a list of declarations")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cssdump [flags] [path]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	} else if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	// Read the input from a file or stdin.
+	name, r := "<stdin>", stdin
+	if fs.NArg() == 1 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+		name, r = fs.Arg(0), f
+	}
+	s := css.NewFileSet().NewScanner(name, r)
+
+	var errs css.ErrorList
+	if *tokens {
+		for tok := s.Scan(); ; tok = s.Scan() {
+			fmt.Fprintln(stdout, formatToken(tok))
+			if tok.Tok == css.EOFToken {
+				break
+			}
+		}
+	} else {
+		var p css.Parser
+		var n css.Node
+		if *decls {
+			n = p.ParseDeclarations(s)
+		} else {
+			n = p.ParseStyleSheet(s)
+		}
+		errs = append(errs, p.Errors...)
+
+		if *asJSON {
+			b, err := json.MarshalIndent(n, "", "\t")
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return 1
+			}
+			fmt.Fprintf(stdout, "%s\n", b)
+		} else {
+			(&dumper{w: stdout}).dump(n, 0)
+		}
+	}
+
+	for _, err := range s.Errors {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		errs.Sort()
+		for _, err := range errs {
+			fmt.Fprintln(stderr, err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// dumper prints a syntax tree with each node on its own line, indented
+// below its parent.
+type dumper struct {
+	w io.Writer
+}
+
+func (d *dumper) dump(n css.Node, depth int) {
+	switch n := n.(type) {
+	case *css.StyleSheet:
+		d.line(depth, "StyleSheet")
+		d.rules(n.Rules, depth+1)
+	case css.Rules:
+		d.line(depth, "Rules")
+		d.rules(n, depth+1)
+	case *css.AtRule:
+		d.line(depth, "AtRule @%s %s", n.Name, pos(n.Pos))
+		d.values("Prelude", n.Prelude, depth+1)
+		if n.Block != nil {
+			d.dump(n.Block, depth+1)
+		}
+	case *css.QualifiedRule:
+		d.line(depth, "QualifiedRule %s", pos(n.Pos))
+		d.values("Prelude", n.Prelude, depth+1)
+		if n.Block != nil {
+			d.dump(n.Block, depth+1)
+		}
+	case css.Declarations:
+		d.line(depth, "Declarations")
+		for _, v := range n {
+			d.dump(v, depth+1)
+		}
+	case *css.Declaration:
+		if n.Important {
+			d.line(depth, "Declaration %s !important %s", n.Name, pos(n.Pos))
+		} else {
+			d.line(depth, "Declaration %s %s", n.Name, pos(n.Pos))
+		}
+		for _, v := range n.Values {
+			d.dump(v, depth+1)
+		}
+	case css.ComponentValues:
+		d.values("ComponentValues", n, depth)
+	case *css.SimpleBlock:
+		d.line(depth, "SimpleBlock %s %s-%s", n.Token.Tok, pos(n.Pos), pos(n.End))
+		for _, v := range n.Values {
+			d.dump(v, depth+1)
+		}
+	case *css.Function:
+		d.line(depth, "Function %s %s", n.Name, pos(n.Pos))
+		for _, v := range n.Values {
+			d.dump(v, depth+1)
+		}
+	case *css.Token:
+		d.line(depth, "Token %s", formatToken(n))
+	}
+}
+
+func (d *dumper) rules(a css.Rules, depth int) {
+	for _, r := range a {
+		d.dump(r, depth)
+	}
+}
+
+func (d *dumper) values(name string, a css.ComponentValues, depth int) {
+	d.line(depth, "%s", name)
+	for _, v := range a {
+		d.dump(v, depth+1)
+	}
+}
+
+func (d *dumper) line(depth int, format string, args ...interface{}) {
+	fmt.Fprintf(d.w, "%s%s\n", strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
+
+// formatToken returns the position, type and value of a token followed by
+// the fields which are only set for some types of tokens.
+func formatToken(tok *css.Token) string {
+	s := fmt.Sprintf("%s %s %q", pos(tok.Pos), tok.Tok, tok.Value)
+	switch tok.Tok {
+	case css.NumberToken, css.PercentageToken, css.DimensionToken:
+		s += " number=" + strconv.FormatFloat(tok.Number, 'g', -1, 64)
+	case css.UnicodeRangeToken:
+		s += fmt.Sprintf(" start=%X end=%X", tok.Start, tok.End)
+	}
+	if tok.Unit != "" {
+		s += fmt.Sprintf(" unit=%q", tok.Unit)
+	}
+	if tok.Type != "" {
+		s += " type=" + tok.Type
+	}
+	return s
+}
+
+// pos returns a position as "line:char" with a one-based line. The file is
+// omitted since all positions are in the same file.
+func pos(p css.Pos) string {
+	return strconv.Itoa(p.Line+1) + ":" + strconv.Itoa(p.Char)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Ensure that the token stream and syntax tree are printed.
+func TestRun(t *testing.T) {
+	var tests = []struct {
+		args []string
+		in   string
+		out  string
+	}{
+		{
+			args: []string{"-tokens"},
+			in:   "a{width:1.5em}U+0-7F",
+			out: `1:1 ident "a"
+1:2 { ""
+1:3 ident "width"
+1:8 colon ""
+1:9 dimension "1.5em" number=1.5 unit="em" type=number
+1:14 } ""
+1:15 unicode-range "" start=0 end=7F
+1:20 EOF ""
+`,
+		},
+		{
+			in: "@media print{a{}}\nb:hover{c:d!important}",
+			out: `StyleSheet
+  AtRule @media 1:1
+    Prelude
+      Token 1:7 whitespace " "
+      Token 1:8 ident "print"
+    SimpleBlock { 1:13-1:17
+      Token 1:14 ident "a"
+      SimpleBlock { 1:15-1:16
+  QualifiedRule 2:1
+    Prelude
+      Token 2:1 ident "b"
+      Token 2:2 colon ""
+      Token 2:3 ident "hover"
+    SimpleBlock { 2:8-2:22
+      Token 2:9 ident "c"
+      Token 2:10 colon ""
+      Token 2:11 ident "d"
+      Token 2:12 delim "!"
+      Token 2:13 ident "important"
+`,
+		},
+		{
+			args: []string{"-decls"},
+			in:   "a:calc(1px)!important;b:c",
+			out: `Declarations
+  Declaration a !important 1:1
+    Function calc 1:3
+      Token 1:8 dimension "1px" number=1 unit="px" type=integer
+  Declaration b 1:23
+    Token 1:25 ident "c"
+`,
+		},
+		{
+			args: []string{"-json", "-decls"},
+			in:   "a:1",
+			out: `[
+	{
+		"type": "Declaration",
+		"name": "a",
+		"values": [
+			{
+				"type": "Token",
+				"tok": "number",
+				"value": "1",
+				"flag": "integer",
+				"number": 1,
+				"pos": {
+					"line": 0,
+					"char": 3
+				},
+				"endPos": {
+					"line": 0,
+					"char": 4
+				}
+			}
+		],
+		"pos": {
+			"line": 0,
+			"char": 1
+		},
+		"endPos": {
+			"line": 0,
+			"char": 4
+		}
+	}
+]
+`,
+		},
+	}
+
+	for i, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(tt.args, strings.NewReader(tt.in), &stdout, &stderr); code != 0 {
+			t.Errorf("%d. unexpected exit code: %d (%s)", i, code, stderr.String())
+		} else if stdout.String() != tt.out {
+			t.Errorf("%d. unexpected output:\n\nexp:\n%s\ngot:\n%s", i, tt.out, stdout.String())
+		}
+	}
+}
+
+// Ensure that errors are printed after the output.
+func TestRun_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, strings.NewReader("a{b:'c\n}"), &stdout, &stderr); code != 1 {
+		t.Fatalf("unexpected exit code: %d", code)
+	} else if !strings.HasPrefix(stdout.String(), "StyleSheet\n") {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	} else if s := stderr.String(); s != "<stdin>:1:5: unexpected newline in string\n" {
+		t.Fatalf("unexpected errors: %q", s)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines printed around each change.
+const diffContext = 3
+
+// op represents a line of a diff. Kind is ' ' for an unchanged line, '-' for
+// a deleted line and '+' for an inserted line.
+type op struct {
+	kind byte
+	line string
+}
+
+// diff returns a unified diff of the lines of a and b. Returns an empty
+// string if they are the same.
+func diff(aname, bname, a, b string) string {
+	ops := editScript(splitLines(a), splitLines(b))
+
+	var buf bytes.Buffer
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk until there are enough unchanged lines to end it.
+		end := i + 1
+		for j := end; j < len(ops) && j <= end+2*diffContext; j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			}
+		}
+		start, stop := i-diffContext, end+diffContext
+		if start < 0 {
+			start = 0
+		}
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", aname, bname)
+		}
+		writeHunk(&buf, ops, start, stop)
+		i = stop
+	}
+	return buf.String()
+}
+
+// writeHunk writes the lines of ops[start:stop] with a range header.
+func writeHunk(buf *bytes.Buffer, ops []op, start, stop int) {
+	// Count the lines of each side before and within the hunk.
+	var astart, bstart, alen, blen int
+	for i, o := range ops[:stop] {
+		if o.kind != '+' {
+			if i < start {
+				astart++
+			} else {
+				alen++
+			}
+		}
+		if o.kind != '-' {
+			if i < start {
+				bstart++
+			} else {
+				blen++
+			}
+		}
+	}
+	fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(astart, alen), hunkRange(bstart, blen))
+
+	for _, o := range ops[start:stop] {
+		buf.WriteByte(o.kind)
+		buf.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange returns the range of lines after the first n lines.
+// An empty range refers to the line before it.
+func hunkRange(n, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", n)
+	} else if length == 1 {
+		return fmt.Sprintf("%d", n+1)
+	}
+	return fmt.Sprintf("%d,%d", n+1, length)
+}
+
+// splitLines splits s into lines, including their line breaks.
+func splitLines(s string) []string {
+	var a []string
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '\n') + 1
+		if i == 0 {
+			i = len(s)
+		}
+		a, s = append(a, s[:i]), s[i:]
+	}
+	return a
+}
+
+// editScript returns the shortest list of deletions and insertions which
+// turns a into b, using Myers' algorithm.
+func editScript(a, b []string) []op {
+	n, m := len(a), len(b)
+	max := n + m
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	// Find the furthest point reached on each diagonal for increasing
+	// numbers of edits until the end of both lists is reached.
+loop:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int{}, v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+				x = v[max+k+1]
+			} else {
+				x = v[max+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				break loop
+			}
+		}
+	}
+
+	// Walk back through the trace to build the script in reverse.
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevk int
+		if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+			prevk = k + 1
+		} else {
+			prevk = k - 1
+		}
+		prevx := v[max+prevk]
+		prevy := prevx - prevk
+
+		for x > prevx && y > prevy {
+			ops = append(ops, op{' ', a[x-1]})
+			x, y = x-1, y-1
+		}
+		if d > 0 {
+			if x == prevx {
+				ops = append(ops, op{'+', b[y-1]})
+			} else {
+				ops = append(ops, op{'-', a[x-1]})
+			}
+		}
+		x, y = prevx, prevy
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
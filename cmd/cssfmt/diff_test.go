@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+)
+
+// Ensure that unified diffs are generated with context and separate hunks.
+func TestDiff(t *testing.T) {
+	var tests = []struct {
+		a, b string
+		out  string
+	}{
+		{a: "a\nb\n", b: "a\nb\n", out: ""},
+		{a: "", b: "a\n", out: "--- a\n+++ b\n@@ -0,0 +1 @@\n+a\n"},
+		{a: "a\n", b: "", out: "--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n"},
+		{a: "a\nb\nc\n", b: "a\nx\nc\n", out: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{a: "a", b: "a\n", out: "--- a\n+++ b\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n"},
+		{
+			a:   "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:   "x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n",
+			out: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n",
+		},
+		{
+			a:   "1\n2\n3\n4\n5\n6\n7\n8\n",
+			b:   "x\n2\n3\n4\n5\n6\n7\ny\n",
+			out: "--- a\n+++ b\n@@ -1,8 +1,8 @@\n-1\n+x\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+y\n",
+		},
+	}
+
+	for i, tt := range tests {
+		if out := diff("a", "b", tt.a, tt.b); out != tt.out {
+			t.Errorf("%d. unexpected diff:\n\nexp: %q\n\ngot: %q", i, tt.out, out)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+// Command cssfmt formats CSS style sheets.
+//
+// Without an explicit path, it processes the standard input. Given a file,
+// it operates on that file. Given a directory, it operates on all .css files
+// in that directory, recursively. By default, cssfmt prints the formatted
+// style sheets to standard output.
+//
+// Usage:
+//
+//	cssfmt [flags] [path ...]
+//
+// The flags are:
+//
+//	-d
+//		Do not print formatted style sheets to standard output. If a file's
+//		formatting is different from cssfmt's, print diffs to standard output.
+//	-l
+//		Do not print formatted style sheets to standard output. If a file's
+//		formatting is different from cssfmt's, print its name to standard
+//		output.
+//	-w
+//		Do not print formatted style sheets to standard output. If a file's
+//		formatting is different from cssfmt's, overwrite it with cssfmt's
+//		version.
+//	-indent string
+//		Indent nested rules and declarations with this string.
+//	-tabs
+//		Indent with tabs instead of -indent.
+//	-compact
+//		Print without indentation or separating spaces.
+//
+// Files with scanning or parsing errors are not formatted. The errors are
+// printed to standard error and cssfmt exits with a non-zero status. The
+// formatted output is scanned again and compared with the original, and a
+// file is neither printed nor rewritten if its tokens changed by more than
+// whitespace, comments and empty declarations.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/benbjohnson/css"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// command represents a single invocation of cssfmt.
+type command struct {
+	list, write, diff bool
+	printer           css.Printer
+
+	stdout, stderr io.Writer
+	fset           *css.FileSet
+	failed         bool // true if any file couldn't be formatted
+}
+
+// run executes the command with the given arguments and returns its exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &command{stdout: stdout, stderr: stderr, fset: css.NewFileSet()}
+
+	fs := flag.NewFlagSet("cssfmt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&c.list, "l", false, "list files whose formatting differs from cssfmt's")
+	fs.BoolVar(&c.write, "w", false, "write result to (source) file instead of stdout")
+	fs.BoolVar(&c.diff, "d", false, "display diffs instead of rewriting files")
+	indent := fs.String("indent", "  ", "indent nested rules and declarations with this string")
+	tabs := fs.Bool("tabs", false, "indent with tabs")
+	compact := fs.Bool("compact", false, "print without indentation or separating spaces")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cssfmt [flags] [path ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	switch {
+	case *compact:
+		c.printer.Compact = true
+	case *tabs:
+		c.printer.Indent = "\t"
+	default:
+		c.printer.Indent = *indent
+	}
+
+	if fs.NArg() == 0 {
+		if c.write {
+			fmt.Fprintln(stderr, "error: cannot use -w with standard input")
+			return 2
+		}
+		c.process("<standard input>", stdin, true)
+	}
+	for _, path := range fs.Args() {
+		fi, err := os.Stat(path)
+		if err != nil {
+			c.report(err)
+		} else if fi.IsDir() {
+			c.walk(path)
+		} else {
+			c.processFile(path)
+		}
+	}
+
+	if c.failed {
+		return 2
+	}
+	return 0
+}
+
+// walk processes every .css file in a directory tree.
+func (c *command) walk(root string) {
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			c.report(err)
+		} else if !fi.IsDir() && strings.HasSuffix(fi.Name(), ".css") && !strings.HasPrefix(fi.Name(), ".") {
+			c.processFile(path)
+		}
+		return nil
+	})
+	if err != nil {
+		c.report(err)
+	}
+}
+
+func (c *command) processFile(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		c.report(err)
+		return
+	}
+	defer f.Close()
+	c.process(path, f, false)
+}
+
+// process formats a single style sheet.
+func (c *command) process(name string, r io.Reader, stdin bool) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		c.report(err)
+		return
+	}
+
+	res, err := c.format(name, src)
+	if err != nil {
+		c.report(err)
+		return
+	} else if err := verify(src, res); err != nil {
+		c.report(fmt.Errorf("%s: %s", name, err))
+		return
+	}
+
+	if !bytes.Equal(src, res) {
+		if c.list {
+			fmt.Fprintln(c.stdout, name)
+		}
+		if c.write {
+			fi, err := os.Stat(name)
+			if err != nil {
+				c.report(err)
+				return
+			} else if err := os.WriteFile(name, res, fi.Mode().Perm()); err != nil {
+				c.report(err)
+				return
+			}
+		}
+		if c.diff {
+			orig := name + ".orig"
+			if stdin {
+				orig, name = "<standard input>.orig", "<standard input>"
+			}
+			fmt.Fprintf(c.stdout, "diff %s %s\n", orig, name)
+			_, _ = io.WriteString(c.stdout, diff(orig, name, string(src), string(res)))
+		}
+	}
+	if !c.list && !c.write && !c.diff {
+		_, _ = c.stdout.Write(res)
+	}
+}
+
+// format parses a style sheet and returns the formatted result.
+// Returns the scanning and parsing errors if there are any.
+func (c *command) format(name string, src []byte) ([]byte, error) {
+	var p css.Parser
+	s := css.NewScannerBytes(src)
+	s.File = c.fset.AddFile(name)
+	s.KeepComments = true
+	ss := p.ParseStyleSheet(s)
+
+	var errs css.ErrorList
+	for _, err := range s.Errors {
+		errs = append(errs, err)
+	}
+	errs = append(errs, p.Errors...)
+	if len(errs) > 0 {
+		errs.Sort()
+		return nil, errs
+	}
+
+	var buf bytes.Buffer
+	printer := c.printer
+	printer.Comments = s.Comments
+	if err := printer.Print(&buf, ss); err != nil {
+		return nil, err
+	}
+	if printer.Compact && buf.Len() > 0 {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// verify returns an error if formatted differs from src by more than
+// whitespace, comments and empty declarations, such as a value which was
+// printed without escaping and is scanned as different tokens.
+func verify(src, formatted []byte) error {
+	a, b := tokens(src), tokens(formatted)
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if i >= len(a) || i >= len(b) || a[i] != b[i] {
+			return errors.New("formatted style sheet is not equivalent to the original")
+		}
+	}
+	return nil
+}
+
+// tokens returns the tokens of a style sheet in a form where formatting
+// differences are removed. Whitespace is only kept between tokens where it
+// can be significant and semicolons are only kept between declarations.
+func tokens(src []byte) []string {
+	var a []string
+	var prev *css.Token
+	ws := false
+	s := css.NewScannerBytes(src)
+	for tok := s.Scan(); tok.Tok != css.EOFToken; tok = s.Scan() {
+		switch tok.Tok {
+		case css.WhitespaceToken:
+			ws = true
+			continue
+		case css.SemicolonToken:
+			prev, ws = tok, false
+			continue
+		}
+
+		if prev != nil && prev.Tok == css.SemicolonToken && tok.Tok != css.RBraceToken && len(a) > 0 && a[len(a)-1] != "{" {
+			a = append(a, ";")
+		}
+		if ws && spaced(prev) && spaced(tok) {
+			a = append(a, " ")
+		}
+		a = append(a, formatToken(tok))
+		prev, ws = tok, false
+	}
+	return a
+}
+
+// spaced returns true if whitespace next to a token can be significant.
+func spaced(tok *css.Token) bool {
+	if tok == nil {
+		return false
+	}
+	switch tok.Tok {
+	case css.LBraceToken, css.RBraceToken, css.LParenToken, css.RParenToken,
+		css.LBrackToken, css.RBrackToken, css.SemicolonToken, css.ColonToken,
+		css.FunctionToken, css.AtKeywordToken:
+		return false
+	case css.DelimToken:
+		return tok.Value != "!"
+	}
+	return true
+}
+
+func formatToken(tok *css.Token) string {
+	switch tok.Tok {
+	case css.LBraceToken:
+		return "{"
+	case css.UnicodeRangeToken:
+		return fmt.Sprintf("%s %X-%X", tok.Tok, tok.Start, tok.End)
+	}
+	return fmt.Sprintf("%s %q %q", tok.Tok, tok.Value, tok.Unit)
+}
+
+// report prints an error and marks the command as failed. Each error in an
+// error list is printed on its own line.
+func (c *command) report(err error) {
+	var errs css.ErrorList
+	if errors.As(err, &errs) {
+		for _, err := range errs {
+			fmt.Fprintln(c.stderr, err)
+		}
+	} else {
+		fmt.Fprintln(c.stderr, err)
+	}
+	c.failed = true
+}
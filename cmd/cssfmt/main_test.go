@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Ensure that style sheets from standard input are formatted to standard output.
+func TestRun_Stdin(t *testing.T) {
+	var tests = []struct {
+		args []string
+		in   string
+		out  string
+		code int
+	}{
+		{in: "a{x:y}", out: "a {\n  x: y;\n}\n"},
+		{args: []string{"-tabs"}, in: "a{x:y}", out: "a {\n\tx: y;\n}\n"},
+		{args: []string{"-indent", "    "}, in: "@media print{a{x:y}}", out: "@media print {\n    a {\n        x: y;\n    }\n}\n"},
+		{args: []string{"-compact"}, in: "a{x:y}\n\nb{x:y}", out: "a{x:y}b{x:y}\n"},
+		{in: "/* c */\na{x:y} /* d */", out: "/* c */\na {\n  x: y;\n} /* d */\n"},
+		{args: []string{"-l"}, in: "a{x:y}", out: "<standard input>\n"},
+		{args: []string{"-l"}, in: "a {\n  x: y;\n}\n", out: ""},
+		{args: []string{"-d"}, in: "a {\n  x: y\n}\n", out: "diff <standard input>.orig <standard input>\n--- <standard input>.orig\n+++ <standard input>\n@@ -1,3 +1,3 @@\n a {\n-  x: y\n+  x: y;\n }\n"},
+		{in: `.\31 0{x:"a\"b" 'c\'' "d\\";y:url( "a b.png" ) 1\65 1}`, out: ".\\31 0 {\n  x: \"a\\\"b\" 'c\\'' \"d\\\\\";\n  y: url(\"a b.png\") 1\\65 1;\n}\n"},
+		{args: []string{"-w"}, in: "a{x:y}", code: 2},
+		{in: "a { x: 'y\n}", code: 2},
+	}
+
+	for i, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(tt.args, strings.NewReader(tt.in), &stdout, &stderr); code != tt.code {
+			t.Errorf("%d. unexpected exit code: %d (%s)", i, code, stderr.String())
+		} else if stdout.String() != tt.out {
+			t.Errorf("%d. unexpected output:\n\nexp: %q\n\ngot: %q", i, tt.out, stdout.String())
+		}
+	}
+}
+
+// Ensure that parse errors are reported with their file and position.
+func TestRun_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.css")
+	mustWriteFile(t, path, "a { x: y }\nb { 'c\n}")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-w", path}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("unexpected exit code: %d", code)
+	} else if !strings.HasPrefix(stderr.String(), path+":2:5: ") {
+		t.Fatalf("unexpected errors: %s", stderr.String())
+	} else if s := mustReadFile(t, path); s != "a { x: y }\nb { 'c\n}" {
+		t.Fatalf("unexpected rewrite: %q", s)
+	}
+}
+
+// Ensure that directories are walked and their files rewritten in place.
+func TestRun_Write(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, filepath.Join(dir, "a.css"), "a{x:y}")
+	mustWriteFile(t, filepath.Join(dir, "b.css"), "b {\n  x: y;\n}\n")
+	mustWriteFile(t, filepath.Join(dir, "c.txt"), "c{x:y}")
+	if err := os.Mkdir(filepath.Join(dir, "d"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	mustWriteFile(t, filepath.Join(dir, "d", "e.css"), "e{x:y}")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-l", "-w", dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code: %d (%s)", code, stderr.String())
+	} else if exp := filepath.Join(dir, "a.css") + "\n" + filepath.Join(dir, "d", "e.css") + "\n"; stdout.String() != exp {
+		t.Fatalf("unexpected output: %q", stdout.String())
+	}
+
+	if s := mustReadFile(t, filepath.Join(dir, "a.css")); s != "a {\n  x: y;\n}\n" {
+		t.Fatalf("unexpected a.css: %q", s)
+	} else if s := mustReadFile(t, filepath.Join(dir, "c.txt")); s != "c{x:y}" {
+		t.Fatalf("unexpected c.txt: %q", s)
+	} else if s := mustReadFile(t, filepath.Join(dir, "d", "e.css")); s != "e {\n  x: y;\n}\n" {
+		t.Fatalf("unexpected e.css: %q", s)
+	}
+}
+
+// Ensure that a file is not rewritten if formatting would change its tokens.
+func TestRun_Write_Unverified(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.css")
+	mustWriteFile(t, path, "a/**/b{x:y}")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-w", path}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("unexpected exit code: %d", code)
+	} else if s := stderr.String(); s != path+": formatted style sheet is not equivalent to the original\n" {
+		t.Fatalf("unexpected errors: %s", s)
+	} else if s := mustReadFile(t, path); s != "a/**/b{x:y}" {
+		t.Fatalf("unexpected rewrite: %q", s)
+	}
+}
+
+func mustWriteFile(t *testing.T, path, s string) {
+	if err := os.WriteFile(path, []byte(s), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustReadFile(t *testing.T, path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// Ensure that formatting which changes the tokens of a style sheet is detected.
+func TestVerify(t *testing.T) {
+	var tests = []struct {
+		src, formatted string
+		ok             bool
+	}{
+		{src: "a{x:y}", formatted: "a {\n  x: y;\n}\n", ok: true},
+		{src: "a , b>c{x : y z!important;;}", formatted: "a , b>c {\n  x: y z !important;\n}\n", ok: true},
+		{src: "@media print{a{x:calc( 1px  +  2em )}}", formatted: "@media print {\n  a {\n    x: calc(1px + 2em);\n  }\n}\n", ok: true},
+		{src: "a{x:y}/* c */", formatted: "a {\n  x: y;\n}\n/* c */\n", ok: true},
+		{src: ".a .b{}", formatted: ".a.b {}\n"},
+		{src: "a b{}", formatted: "ab {}\n"},
+		{src: "a{x:y;z:w}", formatted: "a {\n  x: y z: w;\n}\n"},
+		{src: `a{x:"a\"b"}`, formatted: "a {\n  x: \"a\"b\";\n}\n"},
+		{src: `.\31 0{}`, formatted: ".10 {}\n"},
+		{src: `a{x:url( "a b.png" )}`, formatted: "a {\n  x: url(a b.png);\n}\n"},
+	}
+
+	for i, tt := range tests {
+		if err := verify([]byte(tt.src), []byte(tt.formatted)); tt.ok && err != nil {
+			t.Errorf("%d. unexpected error: %s", i, err)
+		} else if !tt.ok && err == nil {
+			t.Errorf("%d. expected error", i)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/benbjohnson/css"
+)
+
+// bundler parses style sheets and inlines the local style sheets that they
+// import. The rules of an imported style sheet replace its @import rule and
+// are wrapped in @layer, @supports and @media rules for the import's
+// conditions.
+//
+// Imports which can't be inlined, such as remote URLs, are kept where they
+// are. Since @import rules must precede all other rules, a style sheet's
+// imports are only inlined if all of them can be. Otherwise none of them are
+// so that the cascade order of the imported rules doesn't change. Style
+// sheets which declare namespaces are never inlined because their prefixes
+// would apply to the importing style sheet and @namespace rules can't be
+// nested in the rules for the import's conditions.
+type bundler struct {
+	fset     *css.FileSet
+	errors   css.ErrorList
+	nobundle bool // if set, no imports are inlined
+
+	charset *css.AtRule // first @charset rule
+	stack   []string    // absolute paths of the files being bundled
+	size    int         // total size of the parsed style sheets
+}
+
+// newBundler returns a new bundler.
+func newBundler() *bundler {
+	return &bundler{fset: css.NewFileSet()}
+}
+
+// bundle returns the rules of a bundle of style sheets in order. The
+// @charset rule is placed first. Since @import rules must precede all other
+// rules, the imports kept by a style sheet are moved, along with any @layer
+// statements before them, ahead of the rules of the style sheets before it.
+// This changes the cascade order so a warning is reported.
+func (b *bundler) bundle(sheets ...css.Rules) css.Rules {
+	var head, body css.Rules
+	if b.charset != nil {
+		head = append(head, b.charset)
+	}
+	for _, rules := range sheets {
+		n, imports := leadingImports(rules)
+		if len(body) == 0 {
+			imports = n
+		} else if imports > 0 {
+			b.warning("moved-import", rules[imports-1], "import moved before the rules of the preceding style sheets")
+		}
+		head, body = append(head, rules[:imports]...), append(body, rules[imports:]...)
+	}
+	return append(head, body...)
+}
+
+// leadingImports returns the number of @import and @layer statement rules
+// at the start of rules and the number of them up to the last @import rule.
+func leadingImports(rules css.Rules) (n, imports int) {
+	for _, r := range rules {
+		at, ok := r.(*css.AtRule)
+		if !ok {
+			break
+		} else if name := strings.ToLower(at.Name); name == "import" {
+			imports = n + 1
+		} else if name != "layer" || at.Block != nil {
+			break
+		}
+		n++
+	}
+	return n, imports
+}
+
+// parseFile parses a style sheet from a file and inlines its imports.
+func (b *bundler) parseFile(path string) (css.Rules, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, _ := b.parse(path, abs, src)
+	return rules, nil
+}
+
+// parse parses a style sheet and inlines its imports. Imports are resolved
+// relative to the directory of abs. Returns false if the imports were kept
+// because one of them couldn't be inlined.
+func (b *bundler) parse(name, abs string, src []byte) (css.Rules, bool) {
+	b.stack, b.size = append(b.stack, abs), b.size+len(src)
+	defer func() { b.stack = b.stack[:len(b.stack)-1] }()
+
+	var p css.Parser
+	s := css.NewScannerBytes(src)
+	s.File = b.fset.AddFile(name)
+	ss := p.ParseStyleSheet(s)
+	for _, err := range s.Errors {
+		b.errors = append(b.errors, err)
+	}
+	b.errors = append(b.errors, p.Errors...)
+
+	// Build the inlined rules and the rules with the imports kept. No more
+	// imports are inlined once one can't be.
+	var inlined, kept css.Rules
+	ok := !b.nobundle
+	for _, r := range ss.Rules {
+		if at, isAt := r.(*css.AtRule); isAt {
+			switch strings.ToLower(at.Name) {
+			case "charset":
+				if b.charset == nil {
+					b.charset = at
+				}
+				continue
+			case "import":
+				kept = append(kept, r)
+				if ok {
+					var rules css.Rules
+					rules, ok = b.inline(at, filepath.Dir(abs))
+					inlined = append(inlined, rules...)
+				}
+				continue
+			}
+		}
+		inlined, kept = append(inlined, r), append(kept, r)
+	}
+	if !ok {
+		return kept, false
+	}
+	return inlined, true
+}
+
+// inline returns the rules of a style sheet imported by r. Returns false if
+// the import can't be inlined, such as an import of a remote URL, of a style
+// sheet with its own imports which can't be inlined or of a style sheet
+// which declares namespaces.
+func (b *bundler) inline(r *css.AtRule, dir string) (css.Rules, bool) {
+	prelude := trimLeft(r.Prelude)
+	if len(prelude) == 0 {
+		b.error("expected-import-url", r, "expected import url")
+		return nil, true
+	}
+	url, ok := importURL(prelude[0])
+	if !ok || !isLocal(url) {
+		return nil, false
+	}
+
+	// Resolve the path and make sure it isn't already being bundled.
+	path := url
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	path = filepath.FromSlash(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	for _, p := range b.stack {
+		if p == path {
+			b.error("import-cycle", r, "import cycle: %s", url)
+			return nil, true
+		}
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		if e, ok := err.(*os.PathError); ok {
+			err = e.Err
+		}
+		b.error("import-not-found", r, "cannot import %s: %s", url, err)
+		return nil, true
+	}
+	rules, ok := b.parse(relativePath(path), path, src)
+	if !ok {
+		return nil, false
+	}
+	for _, r := range rules {
+		if at, ok := r.(*css.AtRule); ok && strings.EqualFold(at.Name, "namespace") {
+			return nil, false
+		}
+	}
+
+	// Wrap the rules in the conditions of the import, innermost first.
+	layer, supports, media := importConditions(prelude[1:])
+	if len(media) > 0 {
+		rules = wrap("media", media, rules, r.Pos)
+	}
+	if supports != nil {
+		rules = wrap("supports", css.ComponentValues{supports}, rules, r.Pos)
+	}
+	if layer != nil {
+		rules = wrap("layer", layer, rules, r.Pos)
+	}
+	return rules, true
+}
+
+// relativePath returns path relative to the working directory if it's
+// within it. Otherwise it returns path.
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	} else if rel, err := filepath.Rel(wd, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return rel
+	}
+	return path
+}
+
+// error records an error for the text of node n.
+func (b *bundler) error(code string, n css.Node, format string, args ...interface{}) {
+	b.errors = append(b.errors, &css.Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Pos:     css.Position(n),
+		End:     css.End(n),
+	})
+}
+
+// warning records a warning for the text of node n.
+func (b *bundler) warning(code string, n css.Node, format string, args ...interface{}) {
+	b.error(code, n, format, args...)
+	b.errors[len(b.errors)-1].(*css.Error).Severity = css.Warning
+}
+
+// importURL returns the URL of an @import rule from a string, url token or
+// url() function.
+func importURL(v css.ComponentValue) (string, bool) {
+	switch v := v.(type) {
+	case *css.Token:
+		if v.Tok == css.StringToken || v.Tok == css.URLToken {
+			return v.Value, true
+		}
+	case *css.Function:
+		if values := nonwhitespace(v.Values); strings.EqualFold(v.Name, "url") && len(values) == 1 {
+			if tok, ok := values[0].(*css.Token); ok && tok.Tok == css.StringToken {
+				return tok.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// isLocal returns true if url refers to a file on the local file system.
+func isLocal(url string) bool {
+	return url != "" && !strings.Contains(url, "://") && !strings.HasPrefix(url, "//") && !strings.HasPrefix(strings.ToLower(url), "data:")
+}
+
+// importConditions splits the values after an import's URL into its layer
+// prelude, supports condition and media query list. The layer prelude is
+// empty but not nil for an anonymous layer.
+func importConditions(values css.ComponentValues) (layer css.ComponentValues, supports *css.SimpleBlock, media css.ComponentValues) {
+	values = trimLeft(values)
+	if len(values) > 0 {
+		switch v := values[0].(type) {
+		case *css.Token:
+			if v.Tok == css.IdentToken && strings.EqualFold(v.Value, "layer") {
+				layer, values = css.ComponentValues{}, values[1:]
+			}
+		case *css.Function:
+			if strings.EqualFold(v.Name, "layer") {
+				layer, values = append(css.ComponentValues{space()}, v.Values...), values[1:]
+			}
+		}
+	}
+	values = trimLeft(values)
+	if len(values) > 0 {
+		if v, ok := values[0].(*css.Function); ok && strings.EqualFold(v.Name, "supports") {
+			supports = &css.SimpleBlock{Token: &css.Token{Tok: css.LParenToken}, Values: v.Values, Pos: v.Pos}
+			values = values[1:]
+		}
+	}
+	values = trimLeft(values)
+	if len(values) > 0 {
+		media = append(css.ComponentValues{space()}, values...)
+	}
+	return layer, supports, media
+}
+
+// wrap returns rules wrapped in a grouping at-rule.
+func wrap(name string, prelude css.ComponentValues, rules css.Rules, pos css.Pos) css.Rules {
+	block := &css.SimpleBlock{Token: &css.Token{Tok: css.LBraceToken, Pos: pos}, Values: ruleValues(rules), Pos: pos}
+	return css.Rules{&css.AtRule{Name: name, Prelude: prelude, Block: block, Pos: pos}}
+}
+
+// ruleValues returns rules as a list of component values so they can be
+// used as the contents of a block.
+func ruleValues(a css.Rules) css.ComponentValues {
+	var values css.ComponentValues
+	for _, r := range a {
+		switch r := r.(type) {
+		case *css.QualifiedRule:
+			values = append(values, r.Prelude...)
+			values = append(values, r.Block)
+		case *css.AtRule:
+			values = append(values, &css.Token{Tok: css.AtKeywordToken, Value: r.Name, Pos: r.Pos})
+			values = append(values, r.Prelude...)
+			if r.Block != nil {
+				values = append(values, r.Block)
+			} else {
+				values = append(values, &css.Token{Tok: css.SemicolonToken})
+			}
+		}
+	}
+	return values
+}
+
+// nonwhitespace returns values without whitespace tokens.
+func nonwhitespace(values css.ComponentValues) css.ComponentValues {
+	var a css.ComponentValues
+	for _, v := range values {
+		if !isWhitespace(v) {
+			a = append(a, v)
+		}
+	}
+	return a
+}
+
+// trimLeft returns values without leading whitespace tokens.
+func trimLeft(values css.ComponentValues) css.ComponentValues {
+	for len(values) > 0 && isWhitespace(values[0]) {
+		values = values[1:]
+	}
+	return values
+}
+
+func isWhitespace(v css.ComponentValue) bool {
+	tok, ok := v.(*css.Token)
+	return ok && tok.Tok == css.WhitespaceToken
+}
+
+func space() css.ComponentValue {
+	return &css.Token{Tok: css.WhitespaceToken, Value: " "}
+}
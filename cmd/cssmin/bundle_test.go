@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that local imports are inlined within their conditions.
+func TestBundler(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, filepath.Join(dir, "b.css"), "b{}")
+	mustWriteFile(t, filepath.Join(dir, "sub", "c.css"), "@charset 'x';@import '../b.css';c{}")
+	mustWriteFile(t, filepath.Join(dir, "d.css"), "@import 'b.css';@import url(https://example.com/d.css);d{}")
+	mustWriteFile(t, filepath.Join(dir, "ns.css"), "@namespace svg url(http://www.w3.org/2000/svg);svg|a{}")
+
+	var tests = []struct {
+		in  string
+		out string
+	}{
+		{in: `@import "b.css";a{}`, out: `b{}a{}`},
+		{in: `@import url(b.css);`, out: `b{}`},
+		{in: `@import url("sub/c.css");`, out: `@charset 'x';b{}c{}`},
+		{in: `@import "b.css" print;`, out: `@media print{b{}}`},
+		{in: `@import "b.css" layer;`, out: `@layer{b{}}`},
+		{in: `@import "b.css" layer(x) supports(display: grid) print;`, out: `@layer x{@supports(display: grid){@media print{b{}}}}`},
+		{in: `a{}@import "//example.com/a.css";`, out: `a{}@import "//example.com/a.css";`},
+		{in: `@import "//example.com/a.css";@import "b.css";a{}`, out: `@import "//example.com/a.css";@import "b.css";a{}`},
+		{in: `@import "b.css";@import "//example.com/a.css";a{}`, out: `@import "b.css";@import "//example.com/a.css";a{}`},
+		{in: `@import "b.css";@import "d.css";a{}`, out: `@import "b.css";@import "d.css";a{}`},
+		{in: `@import "b.css";@import "sub/c.css";a{}`, out: `@charset 'x';b{}b{}c{}a{}`},
+		{in: `@import "data:text/css,a{}";`, out: `@import "data:text/css,a{}";`},
+		{in: `@import "ns.css" print;a{}`, out: `@import "ns.css" print;a{}`},
+		{in: `@import "b.css";@import "ns.css";a{}`, out: `@import "b.css";@import "ns.css";a{}`},
+	}
+
+	for i, tt := range tests {
+		b := newBundler()
+		rules, _ := b.parse("a.css", filepath.Join(dir, "a.css"), []byte(tt.in))
+		if len(b.errors) > 0 {
+			t.Errorf("%d. unexpected errors: %s", i, b.errors)
+		} else if out := print(&css.StyleSheet{Rules: b.bundle(rules)}); out != tt.out {
+			t.Errorf("%d. unexpected output:\n\nexp: %s\n\ngot: %s", i, tt.out, out)
+		}
+	}
+}
+
+// Ensure that the imports kept by each style sheet of a bundle are moved
+// ahead of the rules of the style sheets before it.
+func TestBundler_Bundle(t *testing.T) {
+	var tests = []struct {
+		in   []string
+		out  string
+		warn bool
+	}{
+		{in: []string{`a{}`, `b{}`}, out: `a{}b{}`},
+		{in: []string{`@import "//x/a.css";a{}`, `b{}`}, out: `@import "//x/a.css";a{}b{}`},
+		{in: []string{`a{}`, `@import "//x/b.css";b{}`}, out: `@import "//x/b.css";a{}b{}`, warn: true},
+		{in: []string{`@charset "x";@layer a;a{}`, `@layer b;@import "//x/b.css";@layer c;b{}`}, out: `@charset "x";@layer a;@layer b;@import "//x/b.css";a{}@layer c;b{}`, warn: true},
+		{in: []string{`a{}`, `b{}@import "//x/b.css";`}, out: `a{}b{}@import "//x/b.css";`},
+	}
+
+	for i, tt := range tests {
+		b := newBundler()
+		var sheets []css.Rules
+		for j, s := range tt.in {
+			rules, _ := b.parse(fmt.Sprintf("%d.css", j), fmt.Sprintf("/%d.css", j), []byte(s))
+			sheets = append(sheets, rules)
+		}
+		if out := print(&css.StyleSheet{Rules: b.bundle(sheets...)}); out != tt.out {
+			t.Errorf("%d. unexpected output:\n\nexp: %s\n\ngot: %s", i, tt.out, out)
+		} else if warn := len(b.errors) == 1 && b.errors[0].(*css.Error).Code == "moved-import"; warn != tt.warn || (!warn && len(b.errors) > 0) {
+			t.Errorf("%d. unexpected errors: %s", i, b.errors)
+		}
+	}
+}
+
+// Ensure that import cycles are reported.
+func TestBundler_Cycle(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, filepath.Join(dir, "a.css"), "@import 'b.css';a{}")
+	mustWriteFile(t, filepath.Join(dir, "b.css"), "@import 'a.css';b{}")
+
+	b := newBundler()
+	rules, err := b.parseFile(filepath.Join(dir, "a.css"))
+	if err != nil {
+		t.Fatal(err)
+	} else if out := print(&css.StyleSheet{Rules: b.bundle(rules)}); out != "b{}a{}" {
+		t.Fatalf("unexpected output: %s", out)
+	} else if len(b.errors) != 1 || b.errors[0].(*css.Error).Code != "import-cycle" {
+		t.Fatalf("unexpected errors: %s", b.errors)
+	}
+}
+
+func print(n css.Node) string {
+	var buf bytes.Buffer
+	p := css.Printer{Compact: true}
+	_ = p.Print(&buf, n)
+	return buf.String()
+}
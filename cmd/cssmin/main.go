@@ -0,0 +1,191 @@
+// Command cssmin minifies CSS style sheets.
+//
+// The style sheets named on the command line, or the standard input if
+// none are named, are bundled into a single style sheet which is minified
+// with every pass of the minify package. Local style sheets imported with
+// @import are inlined, resolved relative to the file that imports them.
+// Imports which can't be inlined, such as remote imports, are kept along with
+// the other imports of the same style sheet. Kept imports are moved ahead of
+// the rules of the preceding style sheets on the command line, which is
+// reported as a warning since it changes the cascade order.
+//
+// Usage:
+//
+//	cssmin [flags] [path ...]
+//
+// The flags are:
+//
+//	-o file
+//		Write the output to file instead of standard output.
+//	-map file
+//		Write a source map to file and reference it from the output.
+//	-nobundle
+//		Keep @import rules instead of inlining local style sheets.
+//	-q
+//		Do not report the size of the output.
+//
+// Errors are reported to standard error as "file:line:col: message" and
+// no output is written. The size of the input and output is reported to
+// standard error unless -q is set.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/benbjohnson/css"
+	"github.com/benbjohnson/css/minify"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command with the given arguments and returns its exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("cssmin", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("o", "", "write output to `file` instead of stdout")
+	mapPath := fs.String("map", "", "write a source map to `file`")
+	nobundle := fs.Bool("nobundle", false, "keep @import rules instead of inlining them")
+	quiet := fs.Bool("q", false, "do not report the size of the output")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cssmin [flags] [path ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// Parse and bundle the inputs.
+	b := newBundler()
+	b.nobundle = *nobundle
+	var sheets []css.Rules
+	if fs.NArg() == 0 {
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		rules, _ := b.parse("<stdin>", filepath.Join(wd, "<stdin>"), src)
+		sheets = append(sheets, rules)
+	}
+	for _, path := range fs.Args() {
+		a, err := b.parseFile(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		sheets = append(sheets, a)
+	}
+	rules := b.bundle(sheets...)
+
+	// Report errors and stop if any of them aren't warnings.
+	b.errors.Sort()
+	var failed bool
+	for _, err := range b.errors {
+		fmt.Fprintln(stderr, err)
+		var e *css.Error
+		if !errors.As(err, &e) || e.Severity != css.Warning {
+			failed = true
+		}
+	}
+	if failed {
+		return 1
+	}
+
+	ss := &css.StyleSheet{Rules: rules}
+	minify.Minify(ss, minify.AllPasses)
+
+	// Print the style sheet and its source map.
+	var buf bytes.Buffer
+	printer := css.Printer{Compact: true}
+	if *mapPath != "" {
+		printer.SourceMap = &css.SourceMapGenerator{}
+		if *out != "" {
+			printer.SourceMap.File = filepath.Base(*out)
+		}
+	}
+	if err := printer.Print(&buf, ss); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	size := buf.Len()
+
+	if *mapPath != "" {
+		if err := writeSourceMap(*mapPath, printer.SourceMap.SourceMap()); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(&buf, "\n/*# sourceMappingURL=%s */", relativeURL(*mapPath, *out))
+	}
+	buf.WriteByte('\n')
+
+	if *out == "" {
+		if _, err := stdout.Write(buf.Bytes()); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	} else if err := os.WriteFile(*out, buf.Bytes(), 0666); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if !*quiet {
+		fmt.Fprintf(stderr, "%d -> %d bytes (%s)\n", b.size, size, savings(b.size, size))
+	}
+	return 0
+}
+
+// writeSourceMap writes a source map to a file. The sources are made
+// relative to the directory of the file.
+func writeSourceMap(path string, m *css.SourceMap) error {
+	for i, src := range m.Sources {
+		if src != "<stdin>" {
+			m.Sources[i] = relativeURL(src, path)
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0666)
+}
+
+// relativeURL returns a URL for the file at path relative to the directory
+// of the file at base. Returns path if it can't be made relative.
+func relativeURL(path, base string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	dir, err := filepath.Abs(filepath.Dir(base))
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// savings returns the size reduction as a percentage.
+func savings(before, after int) string {
+	if before == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(before-after)*100/float64(before))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Ensure that style sheets are bundled, minified and written with a source map.
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteFile(t, filepath.Join(dir, "a.css"), "@import 'b.css';\na {\n  color: #ff0000;\n}\n")
+	mustWriteFile(t, filepath.Join(dir, "b.css"), "b { margin: 0px }\n")
+
+	out, mapPath := filepath.Join(dir, "out", "a.min.css"), filepath.Join(dir, "out", "a.min.css.map")
+	if err := os.Mkdir(filepath.Dir(out), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-o", out, "-map", mapPath, filepath.Join(dir, "a.css")}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code: %d (%s)", code, stderr.String())
+	} else if s := stderr.String(); s != "59 -> 23 bytes (61.0%)\n" {
+		t.Fatalf("unexpected report: %q", s)
+	} else if s := mustReadFile(t, out); s != "b{margin:0}a{color:red}\n/*# sourceMappingURL=a.min.css.map */\n" {
+		t.Fatalf("unexpected output: %q", s)
+	} else if s := mustReadFile(t, mapPath); !strings.HasPrefix(s, `{"version":3,"file":"a.min.css","sources":["../b.css","../a.css"],`) {
+		t.Fatalf("unexpected source map: %s", s)
+	}
+}
+
+// Ensure that standard input is minified to standard output.
+func TestRun_Stdin(t *testing.T) {
+	var tests = []struct {
+		args []string
+		in   string
+		out  string
+	}{
+		{in: "a { color: #ff0000 }\na { margin: 0px }", out: "a{color:red;margin:0}\n"},
+		{in: "@import url(https://example.com/a.css);", out: "@import url(https://example.com/a.css);\n"},
+		{args: []string{"-nobundle"}, in: "@import 'a.css';\na { b: c }", out: "@import'a.css';a{b:c}\n"},
+		{in: `#x\:y{content:"a\\"}z{font-family:"a\"b"}.\31 0{background:url( "a b.png" )}`, out: `#x\:y{content:"a\\"}z{font-family:"a\"b"}.\31 0{background:url("a b.png")}` + "\n"},
+	}
+
+	for i, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(append([]string{"-q"}, tt.args...), strings.NewReader(tt.in), &stdout, &stderr); code != 0 {
+			t.Errorf("%d. unexpected exit code: %d (%s)", i, code, stderr.String())
+		} else if stdout.String() != tt.out {
+			t.Errorf("%d. unexpected output: %q", i, stdout.String())
+		} else if stderr.Len() != 0 {
+			t.Errorf("%d. unexpected stderr: %q", i, stderr.String())
+		}
+	}
+}
+
+// Ensure that an import kept by a later input is moved ahead of the rules of
+// the earlier inputs with a warning.
+func TestRun_MovedImport(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.css"), filepath.Join(dir, "b.css")
+	mustWriteFile(t, a, "a { color: #ff0000 }\n")
+	mustWriteFile(t, b, "@import url(https://example.com/b.css);\nb { margin: 0px }\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-q", a, b}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code: %d (%s)", code, stderr.String())
+	} else if s := stdout.String(); s != "@import url(https://example.com/b.css);a{color:red}b{margin:0}\n" {
+		t.Fatalf("unexpected output: %q", s)
+	} else if exp := b + ":1:1: import moved before the rules of the preceding style sheets\n"; stderr.String() != exp {
+		t.Fatalf("unexpected warnings: %q", stderr.String())
+	}
+}
+
+// Ensure that errors are reported with their file and position and that no
+// output is written.
+func TestRun_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path, out := filepath.Join(dir, "a.css"), filepath.Join(dir, "out.css")
+	mustWriteFile(t, path, "a { x: y }\n@import 'missing.css';\nb { 'c\n}")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-o", out, path}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("unexpected exit code: %d", code)
+	} else if exp := path + ":2:1: cannot import missing.css: no such file or directory\n" + path + ":3:5: unexpected newline in string\n"; stderr.String() != exp {
+		t.Fatalf("unexpected errors: %q", stderr.String())
+	} else if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("unexpected output: %v", err)
+	}
+}
+
+func mustWriteFile(t *testing.T, path, s string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	} else if err := os.WriteFile(path, []byte(s), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustReadFile(t *testing.T, path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,282 @@
+package css
+
+import "strings"
+
+// MatchDataType returns true if a single component value is an instance of
+// the named basic data type, such as "length" or "color". The name does not
+// include the surrounding angle brackets. Unknown data types never match.
+//
+// Math functions such as calc() are accepted for numeric types without
+// checking the type of their contents.
+func MatchDataType(name string, v ComponentValue) bool {
+	switch name {
+	case "length":
+		return isLength(v)
+	case "percentage":
+		return isTok(v, PercentageToken) || isMathFunction(v)
+	case "length-percentage":
+		return isLength(v) || isTok(v, PercentageToken)
+	case "number":
+		return isTok(v, NumberToken) || isMathFunction(v)
+	case "integer":
+		if tok, ok := v.(*Token); ok {
+			return tok.Tok == NumberToken && tok.Type == "integer"
+		}
+		return isMathFunction(v)
+	case "number-percentage":
+		return isTok(v, NumberToken) || isTok(v, PercentageToken) || isMathFunction(v)
+	case "angle":
+		return isDimension(v, angleUnits) || isZero(v) || isMathFunction(v)
+	case "angle-percentage":
+		return isDimension(v, angleUnits) || isZero(v) || isTok(v, PercentageToken) || isMathFunction(v)
+	case "time":
+		return isDimension(v, timeUnits) || isMathFunction(v)
+	case "time-percentage":
+		return isDimension(v, timeUnits) || isTok(v, PercentageToken) || isMathFunction(v)
+	case "frequency":
+		return isDimension(v, frequencyUnits) || isMathFunction(v)
+	case "resolution":
+		return isDimension(v, resolutionUnits) || isMathFunction(v)
+	case "flex":
+		return isDimension(v, []string{"fr"})
+	case "string":
+		return isTok(v, StringToken)
+	case "url":
+		return isURL(v)
+	case "ident":
+		return isTok(v, IdentToken)
+	case "custom-ident":
+		tok, ok := v.(*Token)
+		return ok && tok.Tok == IdentToken && !IsWideKeyword(tok.Value) && !strings.EqualFold(tok.Value, "default")
+	case "dashed-ident":
+		tok, ok := v.(*Token)
+		return ok && tok.Tok == IdentToken && strings.HasPrefix(tok.Value, "--")
+	case "hex-color":
+		return isHexColor(v)
+	case "color":
+		return isColor(v)
+	case "image":
+		return isURL(v) || isFunction(v, imageFunctions)
+	case "transform-function":
+		return isFunction(v, transformFunctions)
+	}
+	return false
+}
+
+// IsWideKeyword returns true if the identifier is a CSS-wide keyword which
+// is valid for every property, such as "inherit" or "initial".
+func IsWideKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "initial", "inherit", "unset", "revert", "revert-layer":
+		return true
+	}
+	return false
+}
+
+// isTok returns true if v is a token of the given type.
+func isTok(v ComponentValue, tok Tok) bool {
+	t, ok := v.(*Token)
+	return ok && t.Tok == tok
+}
+
+// isZero returns true if v is the unitless number zero.
+func isZero(v ComponentValue) bool {
+	tok, ok := v.(*Token)
+	return ok && tok.Tok == NumberToken && tok.Number == 0
+}
+
+// isLength returns true if v is a length dimension, unitless zero or math function.
+func isLength(v ComponentValue) bool {
+	return isDimension(v, lengthUnits) || isZero(v) || isMathFunction(v)
+}
+
+// isDimension returns true if v is a dimension with one of the given units.
+// Units are matched case-insensitively.
+func isDimension(v ComponentValue, units []string) bool {
+	tok, ok := v.(*Token)
+	if !ok || tok.Tok != DimensionToken {
+		return false
+	}
+	for _, u := range units {
+		if strings.EqualFold(u, tok.Unit) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFunction returns true if v is a function with one of the given names.
+func isFunction(v ComponentValue, names []string) bool {
+	fn, ok := v.(*Function)
+	if !ok {
+		return false
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, fn.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMathFunction returns true if v is a math function such as calc().
+func isMathFunction(v ComponentValue) bool {
+	return isFunction(v, mathFunctions)
+}
+
+// isURL returns true if v is a url token or a url()/src() function.
+func isURL(v ComponentValue) bool {
+	return isTok(v, URLToken) || isFunction(v, []string{"url", "src"})
+}
+
+// isHexColor returns true if v is a hash token with 3, 4, 6 or 8 hex digits.
+func isHexColor(v ComponentValue) bool {
+	tok, ok := v.(*Token)
+	if !ok || tok.Tok != HashToken {
+		return false
+	}
+	switch len(tok.Value) {
+	case 3, 4, 6, 8:
+	default:
+		return false
+	}
+	for _, ch := range tok.Value {
+		if !isHexDigit(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// isColor returns true if v is a hex color, named color, system color or
+// color function.
+func isColor(v ComponentValue) bool {
+	if isHexColor(v) || isFunction(v, colorFunctions) {
+		return true
+	} else if tok, ok := v.(*Token); ok && tok.Tok == IdentToken {
+		name := strings.ToLower(tok.Value)
+		if _, ok := NamedColors[name]; ok {
+			return true
+		}
+		switch name {
+		case "transparent", "currentcolor":
+			return true
+		}
+		return containsString(systemColors, name)
+	}
+	return false
+}
+
+// isComputationallyIndependent returns true if the values can be computed
+// without reference to an element, such as em units or var() references.
+func isComputationallyIndependent(values ComponentValues) bool {
+	for _, v := range values {
+		switch v := v.(type) {
+		case *Token:
+			if v.Tok == DimensionToken && isDimension(v, relativeLengthUnits) {
+				return false
+			}
+		case *Function:
+			if strings.EqualFold(v.Name, "var") || strings.EqualFold(v.Name, "env") || !isComputationallyIndependent(v.Values) {
+				return false
+			}
+		case *SimpleBlock:
+			if !isComputationallyIndependent(v.Values) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+var absoluteLengthUnits = []string{"px", "cm", "mm", "q", "in", "pc", "pt"}
+
+var relativeLengthUnits = []string{
+	"em", "rem", "ex", "rex", "cap", "rcap", "ch", "rch", "ic", "ric", "lh", "rlh",
+	"vw", "vh", "vi", "vb", "vmin", "vmax",
+	"svw", "svh", "svi", "svb", "svmin", "svmax",
+	"lvw", "lvh", "lvi", "lvb", "lvmin", "lvmax",
+	"dvw", "dvh", "dvi", "dvb", "dvmin", "dvmax",
+	"cqw", "cqh", "cqi", "cqb", "cqmin", "cqmax",
+}
+
+var lengthUnits = append(append([]string{}, absoluteLengthUnits...), relativeLengthUnits...)
+
+var angleUnits = []string{"deg", "grad", "rad", "turn"}
+
+var timeUnits = []string{"s", "ms"}
+
+var frequencyUnits = []string{"hz", "khz"}
+
+var resolutionUnits = []string{"dpi", "dpcm", "dppx", "x"}
+
+// systemColors are the lowercase system color keywords. (css-color-4 §6.2)
+var systemColors = []string{
+	"accentcolor", "accentcolortext", "activetext", "buttonborder", "buttonface",
+	"buttontext", "canvas", "canvastext", "field", "fieldtext", "graytext",
+	"highlight", "highlighttext", "linktext", "mark", "marktext",
+	"selecteditem", "selecteditemtext", "visitedtext",
+}
+
+var mathFunctions = []string{
+	"calc", "min", "max", "clamp", "round", "mod", "rem", "sin", "cos", "tan",
+	"asin", "acos", "atan", "atan2", "pow", "sqrt", "hypot", "log", "exp", "abs", "sign",
+}
+
+var colorFunctions = []string{
+	"rgb", "rgba", "hsl", "hsla", "hwb", "lab", "lch", "oklab", "oklch", "color", "color-mix", "light-dark",
+}
+
+var imageFunctions = []string{
+	"linear-gradient", "radial-gradient", "conic-gradient",
+	"repeating-linear-gradient", "repeating-radial-gradient", "repeating-conic-gradient",
+	"-webkit-linear-gradient", "-webkit-radial-gradient", "-moz-linear-gradient", "-moz-radial-gradient",
+	"image", "image-set", "-webkit-image-set", "cross-fade", "element", "paint",
+}
+
+var transformFunctions = []string{
+	"matrix", "matrix3d", "translate", "translate3d", "translatex", "translatey", "translatez",
+	"scale", "scale3d", "scalex", "scaley", "scalez", "rotate", "rotate3d", "rotatex", "rotatey", "rotatez",
+	"skew", "skewx", "skewy", "perspective",
+}
+
+// NamedColors maps the CSS named colors to their hex values. (css-color-4 §6.1)
+var NamedColors = map[string]string{
+	"aliceblue": "#f0f8ff", "antiquewhite": "#faebd7", "aqua": "#00ffff", "aquamarine": "#7fffd4",
+	"azure": "#f0ffff", "beige": "#f5f5dc", "bisque": "#ffe4c4", "black": "#000000",
+	"blanchedalmond": "#ffebcd", "blue": "#0000ff", "blueviolet": "#8a2be2", "brown": "#a52a2a",
+	"burlywood": "#deb887", "cadetblue": "#5f9ea0", "chartreuse": "#7fff00", "chocolate": "#d2691e",
+	"coral": "#ff7f50", "cornflowerblue": "#6495ed", "cornsilk": "#fff8dc", "crimson": "#dc143c",
+	"cyan": "#00ffff", "darkblue": "#00008b", "darkcyan": "#008b8b", "darkgoldenrod": "#b8860b",
+	"darkgray": "#a9a9a9", "darkgreen": "#006400", "darkgrey": "#a9a9a9", "darkkhaki": "#bdb76b",
+	"darkmagenta": "#8b008b", "darkolivegreen": "#556b2f", "darkorange": "#ff8c00", "darkorchid": "#9932cc",
+	"darkred": "#8b0000", "darksalmon": "#e9967a", "darkseagreen": "#8fbc8f", "darkslateblue": "#483d8b",
+	"darkslategray": "#2f4f4f", "darkslategrey": "#2f4f4f", "darkturquoise": "#00ced1", "darkviolet": "#9400d3",
+	"deeppink": "#ff1493", "deepskyblue": "#00bfff", "dimgray": "#696969", "dimgrey": "#696969",
+	"dodgerblue": "#1e90ff", "firebrick": "#b22222", "floralwhite": "#fffaf0", "forestgreen": "#228b22",
+	"fuchsia": "#ff00ff", "gainsboro": "#dcdcdc", "ghostwhite": "#f8f8ff", "gold": "#ffd700",
+	"goldenrod": "#daa520", "gray": "#808080", "green": "#008000", "greenyellow": "#adff2f",
+	"grey": "#808080", "honeydew": "#f0fff0", "hotpink": "#ff69b4", "indianred": "#cd5c5c",
+	"indigo": "#4b0082", "ivory": "#fffff0", "khaki": "#f0e68c", "lavender": "#e6e6fa",
+	"lavenderblush": "#fff0f5", "lawngreen": "#7cfc00", "lemonchiffon": "#fffacd", "lightblue": "#add8e6",
+	"lightcoral": "#f08080", "lightcyan": "#e0ffff", "lightgoldenrodyellow": "#fafad2", "lightgray": "#d3d3d3",
+	"lightgreen": "#90ee90", "lightgrey": "#d3d3d3", "lightpink": "#ffb6c1", "lightsalmon": "#ffa07a",
+	"lightseagreen": "#20b2aa", "lightskyblue": "#87cefa", "lightslategray": "#778899", "lightslategrey": "#778899",
+	"lightsteelblue": "#b0c4de", "lightyellow": "#ffffe0", "lime": "#00ff00", "limegreen": "#32cd32",
+	"linen": "#faf0e6", "magenta": "#ff00ff", "maroon": "#800000", "mediumaquamarine": "#66cdaa",
+	"mediumblue": "#0000cd", "mediumorchid": "#ba55d3", "mediumpurple": "#9370db", "mediumseagreen": "#3cb371",
+	"mediumslateblue": "#7b68ee", "mediumspringgreen": "#00fa9a", "mediumturquoise": "#48d1cc", "mediumvioletred": "#c71585",
+	"midnightblue": "#191970", "mintcream": "#f5fffa", "mistyrose": "#ffe4e1", "moccasin": "#ffe4b5",
+	"navajowhite": "#ffdead", "navy": "#000080", "oldlace": "#fdf5e6", "olive": "#808000",
+	"olivedrab": "#6b8e23", "orange": "#ffa500", "orangered": "#ff4500", "orchid": "#da70d6",
+	"palegoldenrod": "#eee8aa", "palegreen": "#98fb98", "paleturquoise": "#afeeee", "palevioletred": "#db7093",
+	"papayawhip": "#ffefd5", "peachpuff": "#ffdab9", "peru": "#cd853f", "pink": "#ffc0cb",
+	"plum": "#dda0dd", "powderblue": "#b0e0e6", "purple": "#800080", "rebeccapurple": "#663399",
+	"red": "#ff0000", "rosybrown": "#bc8f8f", "royalblue": "#4169e1", "saddlebrown": "#8b4513",
+	"salmon": "#fa8072", "sandybrown": "#f4a460", "seagreen": "#2e8b57", "seashell": "#fff5ee",
+	"sienna": "#a0522d", "silver": "#c0c0c0", "skyblue": "#87ceeb", "slateblue": "#6a5acd",
+	"slategray": "#708090", "slategrey": "#708090", "snow": "#fffafa", "springgreen": "#00ff7f",
+	"steelblue": "#4682b4", "tan": "#d2b48c", "teal": "#008080", "thistle": "#d8bfd8",
+	"tomato": "#ff6347", "turquoise": "#40e0d0", "violet": "#ee82ee", "wheat": "#f5deb3",
+	"white": "#ffffff", "whitesmoke": "#f5f5f5", "yellow": "#ffff00", "yellowgreen": "#9acd32",
+}
@@ -15,6 +15,43 @@ the CSS syntax tree such as identifiers, whitespace, and strings. The second
 step is to feed these tokens into the parser which creates the abstract syntax
 tree (AST) based on the context of the tokens.
 
+Positions record the line and character of each token and node. Scanners
+created from a FileSet also record the file so that positions and errors from
+many style sheets can be reported as "file:line:char".
+
+Each Error has a stable Code, such as "expected-colon", for tools which
+need to match errors without parsing messages. Errors also record their
+severity, whether the scanner, parser or validator reported them and the
+range of the offending text. Pretty formats an error with its source line.
+
+The parser recovers from errors and keeps going by default. Set MaxErrors or
+FailFast to stop reading input once enough errors have occurred, and
+ErrorHandler to receive each error as it occurs.
+
+A RuleReader parses the top-level rules of a style sheet one at a time so
+that large style sheets can be filtered and printed as they are read.
+
+Editors can keep a style sheet up to date as its source changes by passing
+each Edit to Reparse. Only the top-level rules affected by the edit are
+reparsed and the rest are reused. Walk and Inspect traverse a syntax tree.
+
+The scanner decodes its input into code points using the encoding declared by
+a byte order mark or @charset rule. NewScannerEncoding also accepts encodings
+supplied with the input, such as an HTTP charset, or by the referring
+document. The encoding of a reader is detected when the first token is
+scanned. Decoders for additional encodings can be registered in Decoders.
+
+NewScannerString and NewScannerBytes scan input which is already in memory.
+Token values are sliced from the input rather than copied and the tokens are
+allocated together in chunks, so scanning makes a few allocations per style
+sheet rather than one per token. Scanning still isn't free of allocations
+since the tokens outlive the scanner.
+
+When many style sheets are parsed, an Arena can be set on both the Scanner
+and the Parser. Tokens, functions and blocks are then allocated in slabs and
+names are interned. Call Release once a style sheet is no longer used so its
+memory can be reused for the next one.
+
 Unlike many language parsers, the abstract syntax tree for CSS saves many of the
 original tokens in the stream so they can be reparsed at different levels. For
 example, parsing a @media query will save off the raw tokens found in the
@@ -51,5 +88,63 @@ an identifier immediately followed by a left parenthesis, then zero or more
 component values, and then ending with a right parenthesis.
 
 
+Typed Models
+
+Some preludes and blocks can be reparsed into typed models using the Parser.
+ParsePageRule converts an @page rule into its page selectors, declarations
+and margin rules. ParseNamespaces collects the @namespace rules of a style
+sheet and ParseSelectors parses a qualified rule's prelude into a list of
+selectors, resolving namespace prefixes along the way. ParsePropertyRegistry
+validates the @property rules of a style sheet and collects them into a
+registry of custom property syntaxes, inheritance and initial values.
+
+
+Value Definitions
+
+ParseGrammar parses the value definition syntax used by the specifications,
+such as "[ <length> | auto ]{1,4}", and Grammar.Match checks a declaration's
+component values against it. A Matcher resolves named types and property
+references. A successful match returns a tree of the values matched by each
+component, otherwise an *Error is positioned at the offending value.
+
+LookupProperty returns the definition of a standard property, including its
+grammar, initial value, inheritance and animation type. Validate checks a
+declaration against these definitions. ExpandShorthand expands a shorthand
+declaration, such as margin or background, into its longhands and
+CombineShorthand combines a complete set of longhands into the shortest
+equivalent shorthand.
+
+
+Printing
+
+The Printer writes nodes back out as CSS text. Setting a SourceMapGenerator
+on the printer records the position of each printed token and rule so that
+a Source Map v3 document can be generated for the output. Style sheets
+scanned from a FileSet are mapped back to their original files.
+
+Input generated by a preprocessor can be mapped back to its authored source
+by setting a SourceMap on the Scanner. Token, node and error positions then
+refer to the original file, line and character.
+
+Setting an Indent on the printer formats style sheets with each rule and
+declaration on its own line. Comments are dropped by the scanner unless
+KeepComments is set; passing the collected comments to the printer keeps
+them in the formatted output.
+
+
+Serialization
+
+Style sheets, rules, declarations and component values can be encoded as
+JSON with encoding/json. Each node is an object with a "type" field naming
+its type so that lists of rules and component values can be decoded, and
+UnmarshalNode decodes a single node of any type. Positions are encoded
+without their file.
+
+MarshalStyleSheet encodes a style sheet in a compact binary format which
+UnmarshalStyleSheet decodes much faster than the source can be parsed, such
+as for a build cache. The encoding includes a checksum of the source and
+decoding returns ErrStale if the source or the format has changed since.
+
+
 */
 package css
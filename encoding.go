@@ -0,0 +1,225 @@
+package css
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Decoder returns a reader which converts r from a character encoding to UTF-8.
+type Decoder func(r io.Reader) io.Reader
+
+// Decoders maps encoding names to their decoders. Decoders for additional
+// encodings, such as those from golang.org/x/text, can be registered by
+// adding them to the map using their lowercase name.
+var Decoders = map[string]Decoder{
+	"utf-8":        func(r io.Reader) io.Reader { return r },
+	"utf-16le":     func(r io.Reader) io.Reader { return newUTF16Reader(r, false) },
+	"utf-16be":     func(r io.Reader) io.Reader { return newUTF16Reader(r, true) },
+	"iso-8859-1":   func(r io.Reader) io.Reader { return newLatin1Reader(r, nil) },
+	"windows-1252": func(r io.Reader) io.Reader { return newLatin1Reader(r, &windows1252) },
+}
+
+// encodingLabels maps alternate encoding labels to their encoding names.
+var encodingLabels = map[string]string{
+	"utf8":              "utf-8",
+	"unicode-1-1-utf-8": "utf-8",
+	"utf-16":            "utf-16le",
+	"unicode":           "utf-16le",
+	"ucs-2":             "utf-16le",
+	"unicodefffe":       "utf-16be",
+	"latin1":            "iso-8859-1",
+	"l1":                "iso-8859-1",
+	"iso8859-1":         "iso-8859-1",
+	"iso_8859-1":        "iso-8859-1",
+	"iso-ir-100":        "iso-8859-1",
+	"ascii":             "windows-1252",
+	"us-ascii":          "windows-1252",
+	"cp1252":            "windows-1252",
+	"x-cp1252":          "windows-1252",
+}
+
+// lookupEncoding returns the name of the encoding for a label.
+// Returns a blank string if the encoding has no decoder.
+func lookupEncoding(label string) string {
+	name := strings.ToLower(strings.TrimSpace(label))
+	if other, ok := encodingLabels[name]; ok {
+		name = other
+	}
+	if _, ok := Decoders[name]; !ok {
+		return ""
+	}
+	return name
+}
+
+// charsetPrefix is the exact byte sequence that begins an @charset rule.
+var charsetPrefix = []byte(`@charset "`)
+
+// detectEncoding determines the encoding of the input from its first bytes.
+// Returns the encoding name and the length of the byte order mark, if any.
+//
+// A byte order mark takes precedence, followed by the protocol encoding
+// (such as an HTTP charset), the @charset rule and then the environment
+// encoding (such as the referring document's encoding). (§3.2)
+func detectEncoding(b []byte, protocol, environment string) (name string, bom int) {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8", 3
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return "utf-16be", 2
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return "utf-16le", 2
+	}
+
+	if name := lookupEncoding(protocol); name != "" {
+		return name, 0
+	}
+
+	// Only the first 1024 bytes are checked for the @charset rule.
+	if len(b) > 1024 {
+		b = b[:1024]
+	}
+	if bytes.HasPrefix(b, charsetPrefix) {
+		if i := bytes.Index(b[len(charsetPrefix):], []byte(`";`)); i != -1 {
+			if name := lookupEncoding(string(b[len(charsetPrefix) : len(charsetPrefix)+i])); name != "" {
+				// A UTF-16 @charset can't be correct if it was readable as ASCII.
+				if name == "utf-16le" || name == "utf-16be" {
+					return "utf-8", 0
+				}
+				return name, 0
+			}
+		}
+	}
+
+	if name := lookupEncoding(environment); name != "" {
+		return name, 0
+	}
+	return "utf-8", 0
+}
+
+// utf16Reader decodes UTF-16 input into UTF-8.
+type utf16Reader struct {
+	r         *bufio.Reader
+	bigEndian bool
+	unit      rune // unread code unit, if pending
+	pending   bool
+	buf       [utf8.UTFMax]byte
+	bufn      int
+}
+
+func newUTF16Reader(r io.Reader, bigEndian bool) *utf16Reader {
+	return &utf16Reader{r: bufio.NewReader(r), bigEndian: bigEndian}
+}
+
+// ReadRune reads the next code point. Unpaired surrogates and a trailing
+// odd byte are replaced with the Unicode replacement character.
+func (r *utf16Reader) ReadRune() (ch rune, size int, err error) {
+	u, err := r.readUnit()
+	if err != nil {
+		return 0, 0, err
+	}
+	if !utf16.IsSurrogate(u) {
+		return u, utf8.RuneLen(u), nil
+	}
+
+	// Combine a high surrogate with the following low surrogate.
+	if u < 0xDC00 {
+		if u2, err := r.readUnit(); err == nil {
+			if ch := utf16.DecodeRune(u, u2); ch != utf8.RuneError {
+				return ch, utf8.RuneLen(ch), nil
+			}
+			r.unit, r.pending = u2, true
+		}
+	}
+	return utf8.RuneError, utf8.RuneLen(utf8.RuneError), nil
+}
+
+// readUnit reads the next 16-bit code unit.
+func (r *utf16Reader) readUnit() (rune, error) {
+	if r.pending {
+		r.pending = false
+		return r.unit, nil
+	}
+
+	b0, err := r.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	b1, err := r.r.ReadByte()
+	if err == io.EOF {
+		return utf8.RuneError, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if r.bigEndian {
+		return rune(b0)<<8 | rune(b1), nil
+	}
+	return rune(b1)<<8 | rune(b0), nil
+}
+
+func (r *utf16Reader) Read(p []byte) (int, error) { return readRunes(r, p, &r.buf, &r.bufn) }
+
+// windows1252 maps the bytes 0x80 to 0x9F of windows-1252 to code points.
+// The other bytes are the same as in ISO-8859-1. (WHATWG Encoding §9.1)
+var windows1252 = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+// latin1Reader decodes ISO-8859-1 input, or a single-byte encoding which
+// only differs from it in the bytes 0x80 to 0x9F, into UTF-8.
+type latin1Reader struct {
+	r    *bufio.Reader
+	c1   *[32]rune // code points of the bytes 0x80 to 0x9F, if different
+	buf  [utf8.UTFMax]byte
+	bufn int
+}
+
+func newLatin1Reader(r io.Reader, c1 *[32]rune) *latin1Reader {
+	return &latin1Reader{r: bufio.NewReader(r), c1: c1}
+}
+
+// ReadRune reads the next byte as a code point.
+func (r *latin1Reader) ReadRune() (ch rune, size int, err error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	ch = rune(b)
+	if r.c1 != nil && b >= 0x80 && b <= 0x9F {
+		ch = r.c1[b-0x80]
+	}
+	return ch, utf8.RuneLen(ch), nil
+}
+
+func (r *latin1Reader) Read(p []byte) (int, error) { return readRunes(r, p, &r.buf, &r.bufn) }
+
+// readRunes fills p with the UTF-8 encoding of the runes read from rd.
+// Bytes of a rune which don't fit in p are saved in buf for the next read.
+func readRunes(rd io.RuneReader, p []byte, buf *[utf8.UTFMax]byte, bufn *int) (n int, err error) {
+	for n < len(p) {
+		if *bufn == 0 {
+			ch, _, err := rd.ReadRune()
+			if err != nil {
+				if n > 0 {
+					return n, nil
+				}
+				return 0, err
+			}
+			*bufn = utf8.EncodeRune(buf[:], ch)
+		}
+
+		// Copy as much of the buffered rune as possible.
+		i := copy(p[n:], buf[:*bufn])
+		copy(buf[:], buf[i:*bufn])
+		*bufn -= i
+		n += i
+	}
+	return n, nil
+}
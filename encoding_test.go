@@ -0,0 +1,87 @@
+package css_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that the scanner determines the encoding of its input.
+func TestNewScannerEncoding(t *testing.T) {
+	var tests = []struct {
+		in          string
+		protocol    string
+		environment string
+		encoding    string
+		s           string
+	}{
+		{in: `a{}`, encoding: "utf-8", s: `a{}`},
+		{in: "\xEF\xBB\xBFa{}", encoding: "utf-8", s: `a{}`},
+		{in: "\xFF\xFEa\x00{\x00}\x00", encoding: "utf-16le", s: `a{}`},
+		{in: "\xFE\xFF\x00a\xD8\x3D\xDE\x00", encoding: "utf-16be", s: "a\U0001F600"},
+		{in: "\xFE\xFF\x00a\xDE\x00\x00b", encoding: "utf-16be", s: "a�b"},
+		{in: "\xFE\xFF\x00a\xD8\x3D\x00b", encoding: "utf-16be", s: "a�b"},
+		{in: "\xFF\xFEa\x00b", encoding: "utf-16le", s: "a�"},
+		{in: "@charset \"iso-8859-1\"; a{content:\"\xE9\"}", encoding: "iso-8859-1", s: `@charset "iso-8859-1"; a{content:"é"}`},
+		{in: "@charset \"Latin1\";\xE9", encoding: "iso-8859-1", s: `@charset "Latin1";é`},
+		{in: `@charset "utf-16";`, encoding: "utf-8", s: `@charset "utf-16";`},
+		{in: `@charset "bogus";`, encoding: "utf-8", s: `@charset "bogus";`},
+		{in: `@charset 'latin1';`, encoding: "utf-8", s: `@charset 'latin1';`},
+		{in: `@charset "utf-8";`, protocol: "latin1", encoding: "iso-8859-1", s: `@charset "utf-8";`},
+		{in: "\xEF\xBB\xBFa", protocol: "latin1", encoding: "utf-8", s: `a`},
+		{in: "\xE9", environment: "iso-8859-1", encoding: "iso-8859-1", s: `é`},
+		{in: `@charset "utf-8";`, environment: "iso-8859-1", encoding: "utf-8", s: `@charset "utf-8";`},
+		{in: `a`, protocol: "bogus", environment: "bogus", encoding: "utf-8", s: `a`},
+		{in: "\x93a\x94\x80\x81", protocol: "us-ascii", encoding: "windows-1252", s: "\u201Ca\u201D\u20AC\u0081"},
+		{in: "@charset \"ascii\";\x99", encoding: "windows-1252", s: "@charset \"ascii\";\u2122"},
+	}
+
+	for i, tt := range tests {
+		s := css.NewScannerEncoding(strings.NewReader(tt.in), tt.protocol, tt.environment)
+		var buf bytes.Buffer
+		var p css.Printer
+		for tok := s.Scan(); tok.Tok != css.EOFToken; tok = s.Scan() {
+			_ = p.Print(&buf, tok)
+		}
+		if s.Encoding != tt.encoding {
+			t.Errorf("%d. <%q> encoding: exp=%q, got=%q", i, tt.in, tt.encoding, s.Encoding)
+		}
+		if buf.String() != tt.s {
+			t.Errorf("%d. <%q> exp=%q, got=%q", i, tt.in, tt.s, buf.String())
+		}
+	}
+}
+
+// Ensure that custom decoders can be registered.
+func TestDecoders(t *testing.T) {
+	css.Decoders["x-upper"] = func(r io.Reader) io.Reader {
+		b, _ := io.ReadAll(r)
+		return bytes.NewReader(bytes.ToUpper(b))
+	}
+	defer delete(css.Decoders, "x-upper")
+
+	s := css.NewScannerEncoding(strings.NewReader(`abc`), "X-Upper", "")
+	if tok := s.Scan(); tok.Value != "ABC" {
+		t.Fatalf("unexpected token: %#v", tok)
+	} else if s.Encoding != "x-upper" {
+		t.Fatalf("unexpected encoding: %s", s.Encoding)
+	}
+}
+
+// Ensure that creating a scanner doesn't read from its reader.
+func TestNewScanner_Lazy(t *testing.T) {
+	r, w := io.Pipe()
+	s := css.NewScanner(r)
+	go func() {
+		_, _ = w.Write([]byte("\xFE\xFF\x00a"))
+		_ = w.Close()
+	}()
+	if tok := s.Scan(); tok.Tok != css.IdentToken || tok.Value != "a" {
+		t.Fatalf("unexpected token: %#v", tok)
+	} else if s.Encoding != "utf-16be" {
+		t.Fatalf("unexpected encoding: %s", s.Encoding)
+	}
+}
@@ -0,0 +1,185 @@
+package css
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity represents how serious an error is.
+type Severity int
+
+const (
+	// ParseError is an error defined by the specification. The parser
+	// recovers from it but the input is invalid.
+	ParseError Severity = iota
+
+	// Warning is a problem with valid input, such as an unknown descriptor
+	// which is ignored.
+	Warning
+)
+
+// String returns the string representation of the severity.
+func (s Severity) String() string {
+	switch s {
+	case ParseError:
+		return "error"
+	case Warning:
+		return "warning"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Origin represents the stage which reported an error.
+type Origin int
+
+const (
+	ParserOrigin Origin = iota
+	ScannerOrigin
+	ValidatorOrigin
+)
+
+// String returns the string representation of the origin.
+func (o Origin) String() string {
+	switch o {
+	case ParserOrigin:
+		return "parser"
+	case ScannerOrigin:
+		return "scanner"
+	case ValidatorOrigin:
+		return "validator"
+	}
+	return fmt.Sprintf("Origin(%d)", int(o))
+}
+
+// Error represents a syntax error.
+type Error struct {
+	// A stable, machine-readable identifier such as "expected-colon".
+	Code string
+
+	Message  string
+	Severity Severity
+	Origin   Origin
+
+	// The range of the offending text. End is the position just after the
+	// text and is zero if the end is unknown.
+	Pos Pos
+	End Pos
+}
+
+// newError returns a parse error for the text of node n.
+func newError(code string, n Node, format string, args ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Pos:     Position(n),
+		End:     End(n),
+	}
+}
+
+// newValidatorError returns a validation error for the text of node n.
+func newValidatorError(code string, n Node, format string, args ...interface{}) *Error {
+	err := newError(code, n, format, args...)
+	err.Origin = ValidatorOrigin
+	return err
+}
+
+// Error returns the formatted string error message. The message is prefixed
+// with its position if the position has a file.
+func (e *Error) Error() string {
+	if e.Pos.File != nil {
+		return e.Pos.String() + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Pretty returns the error with the offending line of src and a caret under
+// the offending text, such as:
+//
+//	a.css:2:5: error: expected colon, got y [expected-colon]
+//	  x y
+//	    ^
+//
+// The source line is omitted if it isn't in src.
+func (e *Error) Pretty(src []byte) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s: %s: %s", e.Pos, e.Severity, e.Message)
+	if e.Code != "" {
+		fmt.Fprintf(&buf, " [%s]", e.Code)
+	}
+	buf.WriteByte('\n')
+
+	lines := strings.Split(string(src), "\n")
+	if e.Pos.Line < 0 || e.Pos.Line >= len(lines) {
+		return buf.String()
+	}
+	line := []rune(strings.TrimSuffix(lines[e.Pos.Line], "\r"))
+	buf.WriteString(string(line))
+	buf.WriteByte('\n')
+
+	// Indent the caret using the line's own tabs so that it lines up.
+	col := e.Pos.Char - 1
+	if col < 0 {
+		col = 0
+	} else if col > len(line) {
+		col = len(line)
+	}
+	for _, ch := range line[:col] {
+		if ch == '\t' {
+			buf.WriteByte('\t')
+		} else {
+			buf.WriteByte(' ')
+		}
+	}
+
+	// Underline the text, stopping at the end of the line.
+	n := 1
+	if e.End.Line > e.Pos.Line {
+		n = len(line) - col
+	} else if e.End.Line == e.Pos.Line && e.End.Char > e.Pos.Char {
+		n = e.End.Char - e.Pos.Char
+	}
+	if rest := len(line) - col; n > rest && rest > 0 {
+		n = rest
+	}
+	buf.WriteByte('^')
+	if n > 1 {
+		buf.WriteString(strings.Repeat("~", n-1))
+	}
+	buf.WriteByte('\n')
+	return buf.String()
+}
+
+// ErrorList represents a list of syntax errors.
+type ErrorList []error
+
+// Error returns the formatted string error message.
+func (a ErrorList) Error() string {
+	switch len(a) {
+	case 0:
+		return "no errors"
+	case 1:
+		return a[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", a[0], len(a)-1)
+}
+
+// Sort sorts the list by position. Errors which aren't an *Error are
+// moved to the end of the list.
+func (a ErrorList) Sort() {
+	sort.Stable(errorsByPos(a))
+}
+
+type errorsByPos ErrorList
+
+func (a errorsByPos) Len() int      { return len(a) }
+func (a errorsByPos) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a errorsByPos) Less(i, j int) bool {
+	x, xok := a[i].(*Error)
+	y, yok := a[j].(*Error)
+	if !xok || !yok {
+		return xok && !yok
+	}
+	return x.Pos.less(y.Pos)
+}
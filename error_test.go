@@ -0,0 +1,146 @@
+package css_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that errors are reported with a code, severity, origin and range.
+func TestError_Fields(t *testing.T) {
+	var tests = []struct {
+		in       string
+		code     string
+		severity css.Severity
+		origin   css.Origin
+		pos      css.Pos
+		end      css.Pos
+	}{
+		{in: "a {}\n@page :foo {}", code: "unknown-pseudo-page", pos: css.Pos{Char: 8, Line: 1}, end: css.Pos{Char: 11, Line: 1}},
+		{in: "@property --x { syntax: '*'; inherits: false; foo: 1 }", code: "unknown-descriptor", severity: css.Warning, pos: css.Pos{Char: 47}, end: css.Pos{Char: 53}},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		ss := p.ParseStyleSheet(css.NewScanner(strings.NewReader(tt.in)))
+		for _, r := range ss.Rules {
+			if r, ok := r.(*css.AtRule); ok {
+				if strings.EqualFold(r.Name, "page") {
+					p.ParsePageRule(r)
+				} else if strings.EqualFold(r.Name, "property") {
+					p.ParsePropertyRule(r)
+				}
+			}
+		}
+
+		if len(p.Errors) != 1 {
+			t.Errorf("%d. unexpected errors: %v", i, p.Errors)
+			continue
+		}
+		e := p.Errors[0].(*css.Error)
+		if e.Code != tt.code {
+			t.Errorf("%d. code: expected %q, got %q", i, tt.code, e.Code)
+		} else if e.Severity != tt.severity {
+			t.Errorf("%d. severity: expected %s, got %s", i, tt.severity, e.Severity)
+		} else if e.Origin != tt.origin {
+			t.Errorf("%d. origin: expected %s, got %s", i, tt.origin, e.Origin)
+		} else if e.Pos != tt.pos || e.End != tt.end {
+			t.Errorf("%d. range: expected %s-%s, got %s-%s", i, tt.pos, tt.end, e.Pos, e.End)
+		}
+	}
+}
+
+// Ensure that declaration and scanner errors have a code, origin and range.
+func TestError_Declarations(t *testing.T) {
+	var tests = []struct {
+		in     string
+		code   string
+		origin css.Origin
+		pos    css.Pos
+		end    css.Pos
+	}{
+		{in: "x y", code: "expected-colon", pos: css.Pos{Char: 3}, end: css.Pos{Char: 4}},
+		{in: "b: url(x\"y)", code: "bad-url", origin: css.ScannerOrigin, pos: css.Pos{Char: 4}, end: css.Pos{Char: 10}},
+		{in: "b: \\\n", code: "unescaped-backslash", origin: css.ScannerOrigin, pos: css.Pos{Char: 4}, end: css.Pos{Char: 5}},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		s := css.NewScanner(strings.NewReader(tt.in))
+		p.ParseDeclarations(s)
+
+		errs := p.Errors
+		for _, err := range s.Errors {
+			errs = append(errs, err)
+		}
+		if len(errs) != 1 {
+			t.Errorf("%d. unexpected errors: %v", i, errs)
+			continue
+		}
+		e := errs[0].(*css.Error)
+		if e.Code != tt.code || e.Origin != tt.origin {
+			t.Errorf("%d. unexpected error: %s (%s, %s)", i, e, e.Code, e.Origin)
+		} else if e.Pos != tt.pos || e.End != tt.end {
+			t.Errorf("%d. range: expected %s-%s, got %s-%s", i, tt.pos, tt.end, e.Pos, e.End)
+		}
+	}
+}
+
+// Ensure that validation errors have a code and a range.
+func TestError_Validate(t *testing.T) {
+	var p css.Parser
+	decls := p.ParseDeclarations(css.NewScanner(strings.NewReader("color: red blue")))
+	err := css.Validate(decls[0].(*css.Declaration))
+	if e, ok := err.(*css.Error); !ok {
+		t.Fatalf("unexpected error: %#v", err)
+	} else if e.Code != "invalid-value" || e.Origin != css.ValidatorOrigin {
+		t.Fatalf("unexpected error: %s (%s, %s)", e, e.Code, e.Origin)
+	} else if e.Pos != (css.Pos{Char: 12}) || e.End != (css.Pos{Char: 16}) {
+		t.Fatalf("unexpected range: %s-%s", e.Pos, e.End)
+	}
+}
+
+// Ensure that errors can be printed with the offending source line.
+func TestError_Pretty(t *testing.T) {
+	fset := css.NewFileSet()
+	a := fset.AddFile("a.css")
+
+	var tests = []struct {
+		err *css.Error
+		src string
+		s   string
+	}{
+		{
+			err: &css.Error{Code: "expected-colon", Message: "expected colon, got y", Pos: css.Pos{Char: 5, Line: 1, File: a}, End: css.Pos{Char: 6, Line: 1, File: a}},
+			src: "a {}\n  x y\n",
+			s:   "a.css:2:5: error: expected colon, got y [expected-colon]\n  x y\n    ^\n",
+		},
+		{
+			err: &css.Error{Code: "unknown-descriptor", Message: "unknown", Severity: css.Warning, Pos: css.Pos{Char: 3}, End: css.Pos{Char: 9}},
+			src: "\tb\tfoo: 1;\r\n",
+			s:   "1:3: warning: unknown [unknown-descriptor]\n\tb\tfoo: 1;\n\t ^~~~~~\n",
+		},
+		{
+			err: &css.Error{Message: "multiline", Pos: css.Pos{Char: 3}, End: css.Pos{Char: 2, Line: 2}},
+			src: "a { b: c;\n}",
+			s:   "1:3: error: multiline\na { b: c;\n  ^~~~~~~\n",
+		},
+		{
+			err: &css.Error{Message: "no range", Pos: css.Pos{Char: 2}},
+			src: "abc",
+			s:   "1:2: error: no range\nabc\n ^\n",
+		},
+		{
+			err: &css.Error{Message: "missing line", Pos: css.Pos{Char: 1, Line: 5}},
+			src: "abc",
+			s:   "6:1: error: missing line\n",
+		},
+	}
+
+	for i, tt := range tests {
+		if s := tt.err.Pretty([]byte(tt.src)); s != tt.s {
+			t.Errorf("%d. expected:\n%s\ngot:\n%s", i, tt.s, s)
+		}
+	}
+}
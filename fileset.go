@@ -0,0 +1,94 @@
+package css
+
+import (
+	"io"
+	"strconv"
+)
+
+// FileSet represents a set of named source files, such as the style sheets
+// of a project. Each file is registered once by name and positions scanned
+// from it refer to it by handle.
+//
+// Unlike go/token, a position isn't a single offset which is resolved
+// through the set. Each Pos keeps its line and character along with a
+// pointer to its File so it can be printed and compared on its own, at the
+// cost of being three words rather than one.
+type FileSet struct {
+	files  []*File
+	byName map[string]*File
+}
+
+// NewFileSet returns a new, empty file set.
+func NewFileSet() *FileSet {
+	return &FileSet{byName: make(map[string]*File)}
+}
+
+// AddFile returns the file with the given name, adding it to the set if it
+// hasn't already been added.
+func (s *FileSet) AddFile(name string) *File {
+	if f := s.byName[name]; f != nil {
+		return f
+	}
+	f := &File{name: name, index: len(s.files), set: s}
+	s.files = append(s.files, f)
+	s.byName[name] = f
+	return f
+}
+
+// File returns the file with the given name or nil if it isn't in the set.
+func (s *FileSet) File(name string) *File { return s.byName[name] }
+
+// Files returns the files in the order they were added.
+func (s *FileSet) Files() []*File { return append([]*File{}, s.files...) }
+
+// NewScanner returns a scanner for a named file in the set. The positions
+// of the tokens it scans refer to the file.
+func (s *FileSet) NewScanner(name string, r io.Reader) *Scanner {
+	scanner := NewScanner(r)
+	scanner.File = s.AddFile(name)
+	return scanner
+}
+
+// File represents a single file in a FileSet.
+type File struct {
+	name  string
+	index int // order added to the set
+	set   *FileSet
+}
+
+// Name returns the name of the file.
+func (f *File) Name() string {
+	if f == nil {
+		return ""
+	}
+	return f.name
+}
+
+// Set returns the file set that the file belongs to.
+func (f *File) Set() *FileSet { return f.set }
+
+// String returns the position as "file:line:char" with a one-based line.
+// The file is omitted if the position has none.
+func (p Pos) String() string {
+	s := strconv.Itoa(p.Line+1) + ":" + strconv.Itoa(p.Char)
+	if p.File != nil {
+		s = p.File.name + ":" + s
+	}
+	return s
+}
+
+// less returns true if p is before other. Positions in files are ordered
+// by when the file was added to its set and come after positions without
+// a file.
+func (p Pos) less(other Pos) bool {
+	if p.File != other.File {
+		if p.File == nil || other.File == nil {
+			return p.File == nil
+		}
+		return p.File.index < other.File.index
+	}
+	if p.Line != other.Line {
+		return p.Line < other.Line
+	}
+	return p.Char < other.Char
+}
@@ -0,0 +1,61 @@
+package css_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that files are registered once by name.
+func TestFileSet_AddFile(t *testing.T) {
+	fset := css.NewFileSet()
+	a, b := fset.AddFile("a.css"), fset.AddFile("b.css")
+	if other := fset.AddFile("a.css"); other != a {
+		t.Fatalf("unexpected file: %#v", other)
+	} else if fset.File("b.css") != b || fset.File("c.css") != nil {
+		t.Fatal("unexpected file lookup")
+	} else if files := fset.Files(); len(files) != 2 || files[0] != a || files[1] != b {
+		t.Fatalf("unexpected files: %#v", files)
+	} else if a.Name() != "a.css" || a.Set() != fset {
+		t.Fatalf("unexpected file: %#v", a)
+	}
+}
+
+// Ensure that nodes and errors from a file set's scanners refer to their files.
+func TestFileSet_NewScanner(t *testing.T) {
+	fset := css.NewFileSet()
+
+	var p css.Parser
+	ss := p.ParseStyleSheet(fset.NewScanner("a.css", strings.NewReader("a {}\n  b {")))
+	if pos := ss.Rules[1].(*css.QualifiedRule).Pos; pos.File != fset.File("a.css") || pos.String() != "a.css:2:3" {
+		t.Fatalf("unexpected pos: %s", pos)
+	}
+	p.ParseDeclarations(fset.NewScanner("b.css", strings.NewReader("x y")))
+	p.ParseDeclarations(fset.NewScanner("a.css", strings.NewReader("\n  x y")))
+	p.ParseDeclarations(css.NewScanner(strings.NewReader("x y")))
+
+	errs := append(css.ErrorList{errors.New("other")}, p.Errors...)
+	errs.Sort()
+	if len(errs) != 4 {
+		t.Fatalf("unexpected errors: %#v", errs)
+	} else if errs[0].Error() != "expected colon, got y" {
+		t.Fatalf("unexpected error(0): %s", errs[0])
+	} else if errs[1].Error() != "a.css:2:5: expected colon, got y" {
+		t.Fatalf("unexpected error(1): %s", errs[1])
+	} else if errs[2].Error() != "b.css:1:3: expected colon, got y" {
+		t.Fatalf("unexpected error(2): %s", errs[2])
+	} else if errs[3].Error() != "other" {
+		t.Fatalf("unexpected error(3): %s", errs[3])
+	}
+}
+
+// Ensure that positions are formatted with a one-based line.
+func TestPos_String(t *testing.T) {
+	if s := (css.Pos{Char: 3, Line: 0}).String(); s != "1:3" {
+		t.Fatalf("unexpected string: %s", s)
+	} else if s := (css.Pos{Char: 3, Line: 4, File: css.NewFileSet().AddFile("x.css")}).String(); s != "x.css:5:3" {
+		t.Fatalf("unexpected string: %s", s)
+	}
+}
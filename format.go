@@ -0,0 +1,280 @@
+package css
+
+import (
+	"io"
+	"strings"
+)
+
+// formatter prints rules with each rule and declaration on its own line.
+// It is used by a Printer with an indent.
+type formatter struct {
+	p        *Printer
+	w        io.Writer
+	err      error
+	comments []*Comment // comments which haven't been printed yet
+	line     int        // source line at the end of the last item printed
+	first    bool       // true until an item is printed in the current block
+}
+
+// blockKind represents the contents of a block.
+type blockKind int
+
+const (
+	unknownBlock blockKind = iota
+	rulesBlock
+	declarationsBlock
+)
+
+// format prints a style sheet, list of rules or rule.
+func (f *formatter) format(n Node) error {
+	var items []Node
+	switch n := n.(type) {
+	case *StyleSheet:
+		if n != nil {
+			items = ruleNodes(n.Rules)
+		}
+	case Rules:
+		items = ruleNodes(n)
+	case Rule:
+		items = []Node{n}
+	}
+	f.items(items, 0, Pos{}, true)
+	return f.err
+}
+
+// items prints a list of rules or declarations followed by the comments
+// before end. All remaining comments are printed if last is true.
+func (f *formatter) items(a []Node, depth int, end Pos, last bool) {
+	f.first = true
+	for i, n := range a {
+		pos := Position(n)
+		f.leading(pos, depth, false)
+		f.gap(pos.Line)
+		f.indent(depth)
+		f.item(n, depth)
+
+		next := end
+		if i+1 < len(a) {
+			next = Position(a[i+1])
+		}
+		f.trailing(next)
+		f.write("\n")
+		f.first = false
+	}
+	if last || end != (Pos{}) {
+		f.leading(end, depth, last)
+	}
+}
+
+// item prints a single rule or declaration.
+func (f *formatter) item(n Node, depth int) {
+	switch n := n.(type) {
+	case *QualifiedRule:
+		f.print(collapseWhitespace(n.Prelude))
+		f.write(" ")
+		f.block(n.Block, depth, declarationsBlock)
+
+	case *AtRule:
+		f.write("@" + serializeIdent(n.Name))
+		if prelude := collapseWhitespace(n.Prelude); len(prelude) > 0 {
+			f.write(" ")
+			f.print(prelude)
+		}
+		if n.Block == nil {
+			f.write(";")
+			f.line = End(n).Line
+			return
+		}
+		f.write(" ")
+		f.block(n.Block, depth, atRuleBlockKind(n.Name))
+
+	case *Declaration:
+		f.write(serializeIdent(n.Name) + ":")
+		values := trimWhitespace(n.Values)
+		if !strings.HasPrefix(n.Name, "--") {
+			values = collapseWhitespace(values)
+		}
+		if len(values) > 0 {
+			f.write(" ")
+			f.print(values)
+		}
+		if n.Important {
+			f.write(" !important")
+		}
+		f.write(";")
+		f.line = End(n).Line
+
+	default:
+		f.print(n)
+		f.line = End(n).Line
+	}
+}
+
+// block prints a {-block. Blocks are printed as they are if their contents
+// are unknown or can't be parsed without errors.
+func (f *formatter) block(b *SimpleBlock, depth int, kind blockKind) {
+	if b == nil {
+		f.write("{}")
+		return
+	}
+	end := b.End
+	if end == (Pos{}) {
+		end = End(b)
+	}
+
+	items, ok := parseBlock(b, kind)
+	if !ok {
+		f.print(b)
+		f.line = end.Line
+		return
+	} else if len(items) == 0 && (len(f.comments) == 0 || !f.comments[0].Pos.less(b.End)) {
+		f.write("{}")
+		f.line = end.Line
+		return
+	}
+
+	f.write("{\n")
+	f.items(items, depth+1, b.End, false)
+	f.indent(depth)
+	f.write("}")
+	f.line = end.Line
+}
+
+// leading prints the comments before pos on their own lines.
+func (f *formatter) leading(pos Pos, depth int, all bool) {
+	for len(f.comments) > 0 && (all || f.comments[0].Pos.less(pos)) {
+		c := f.comments[0]
+		f.comments = f.comments[1:]
+		f.gap(c.Pos.Line)
+		f.indent(depth)
+		f.write(c.Text + "\n")
+		f.line = c.Pos.Line + strings.Count(c.Text, "\n")
+		f.first = false
+	}
+}
+
+// trailing prints the single-line comments which follow the last item on
+// the same line and come before next.
+func (f *formatter) trailing(next Pos) {
+	for len(f.comments) > 0 {
+		c := f.comments[0]
+		if c.Pos.Line != f.line || strings.Contains(c.Text, "\n") {
+			return
+		} else if next != (Pos{}) && !c.Pos.less(next) {
+			return
+		}
+		f.comments = f.comments[1:]
+		f.write(" " + c.Text)
+	}
+}
+
+// gap prints a blank line if there was at least one before line in the source.
+func (f *formatter) gap(line int) {
+	if !f.first && line > f.line+1 {
+		f.write("\n")
+	}
+}
+
+func (f *formatter) indent(depth int) {
+	f.write(strings.Repeat(f.p.Indent, depth))
+}
+
+func (f *formatter) print(n Node) {
+	if err := f.p.print(f.w, n); err != nil && f.err == nil {
+		f.err = err
+	}
+}
+
+func (f *formatter) write(s string) {
+	if f.err == nil {
+		_, f.err = io.WriteString(f.w, s)
+	}
+}
+
+// atRuleBlockKind returns the contents of an at-rule's block.
+func atRuleBlockKind(name string) blockKind {
+	switch {
+	case IsGroupingRule(name), strings.HasSuffix(strings.ToLower(name), "keyframes"):
+		return rulesBlock
+	case IsDeclarationRule(name):
+		return declarationsBlock
+	}
+	return unknownBlock
+}
+
+// parseBlock parses the contents of a {-block. Returns false if the block
+// can't be parsed without errors.
+func parseBlock(b *SimpleBlock, kind blockKind) ([]Node, bool) {
+	if b.Token == nil || b.Token.Tok != LBraceToken {
+		return nil, false
+	}
+
+	var p Parser
+	var items []Node
+	switch kind {
+	case rulesBlock:
+		items = ruleNodes(p.ConsumeRules(NewComponentValueScanner(b.Values), false))
+	case declarationsBlock:
+		for _, n := range p.ConsumeDeclarations(NewComponentValueScanner(b.Values)) {
+			// A {-block in a value may be a nested rule so it's left as is.
+			if d, ok := n.(*Declaration); ok && hasBraceBlock(d.Values) {
+				return nil, false
+			}
+			items = append(items, n)
+		}
+	default:
+		return nil, false
+	}
+	return items, len(p.Errors) == 0
+}
+
+// hasBraceBlock returns true if values contain a {-block.
+func hasBraceBlock(values ComponentValues) bool {
+	for _, v := range values {
+		if b, ok := v.(*SimpleBlock); ok && b.Token != nil && b.Token.Tok == LBraceToken {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleNodes(a Rules) []Node {
+	items := make([]Node, len(a))
+	for i, r := range a {
+		items[i] = r
+	}
+	return items
+}
+
+// trimWhitespace returns values without leading or trailing whitespace.
+func trimWhitespace(values ComponentValues) ComponentValues {
+	for len(values) > 0 && isTok(values[0], WhitespaceToken) {
+		values = values[1:]
+	}
+	for len(values) > 0 && isTok(values[len(values)-1], WhitespaceToken) {
+		values = values[:len(values)-1]
+	}
+	return values
+}
+
+// collapseWhitespace returns a copy of values without leading or trailing
+// whitespace and with other whitespace replaced by a single space,
+// including within functions and blocks.
+func collapseWhitespace(values ComponentValues) ComponentValues {
+	var a ComponentValues
+	for _, v := range trimWhitespace(values) {
+		switch v := v.(type) {
+		case *Token:
+			if v.Tok != WhitespaceToken {
+				a = append(a, v)
+			} else if !isTok(a[len(a)-1], WhitespaceToken) {
+				a = append(a, &Token{Tok: WhitespaceToken, Value: " ", Pos: v.Pos})
+			}
+		case *Function:
+			a = append(a, &Function{Name: v.Name, Values: collapseWhitespace(v.Values), Pos: v.Pos})
+		case *SimpleBlock:
+			a = append(a, &SimpleBlock{Token: v.Token, Values: collapseWhitespace(v.Values), Pos: v.Pos, End: v.End})
+		}
+	}
+	return a
+}
@@ -0,0 +1,1086 @@
+package css
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Grammar represents a parsed value definition, such as the grammar of a
+// property's value: "[ <length> | auto ]{1,4}". (css-values-4 §2)
+type Grammar struct {
+	Root GrammarNode
+}
+
+// ParseGrammar parses a value definition string into a grammar.
+func ParseGrammar(def string) (*Grammar, error) {
+	gp := &grammarParser{lex: &grammarLexer{s: def}}
+	n, err := gp.parseOneOf()
+	if err != nil {
+		return nil, err
+	}
+	if tok := gp.next(); tok.kind != gEOF {
+		return nil, fmt.Errorf("unexpected %q in grammar at offset %d", tok.text, tok.pos)
+	}
+	return &Grammar{Root: n}, nil
+}
+
+// MustParseGrammar parses a value definition and panics if it is invalid.
+func MustParseGrammar(def string) *Grammar {
+	g, err := ParseGrammar(def)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// String returns the value definition of the grammar.
+func (g *Grammar) String() string { return g.Root.String() }
+
+// Match matches values against the grammar without resolving any named
+// types other than the basic data types. See Matcher.Match for details.
+func (g *Grammar) Match(values ComponentValues) (*Match, error) {
+	var m Matcher
+	return m.Match(g, values)
+}
+
+// GrammarNode represents a node in a value definition grammar.
+type GrammarNode interface {
+	String() string
+	grammarNode()
+}
+
+func (_ *KeywordNode) grammarNode()    {}
+func (_ *LiteralNode) grammarNode()    {}
+func (_ *TypeNode) grammarNode()       {}
+func (_ *FunctionNode) grammarNode()   {}
+func (_ *BlockNode) grammarNode()      {}
+func (_ *GroupNode) grammarNode()      {}
+func (_ *MultiplierNode) grammarNode() {}
+
+// KeywordNode represents a keyword which is matched case-insensitively.
+type KeywordNode struct {
+	Name string
+}
+
+// String returns the keyword.
+func (n *KeywordNode) String() string { return n.Name }
+
+// LiteralNode represents a literal delimiter such as "," or "/".
+type LiteralNode struct {
+	Value string
+}
+
+// String returns the literal.
+func (n *LiteralNode) String() string {
+	if n.Value == "," || n.Value == "/" {
+		return n.Value
+	}
+	return "'" + n.Value + "'"
+}
+
+// TypeNode represents a data type, such as <length>, or a property
+// reference, such as <'margin-top'>. Numeric types can have a range.
+type TypeNode struct {
+	Name     string
+	Property bool
+
+	// Inclusive range of allowed values for numeric types.
+	HasRange bool
+	Min, Max float64
+}
+
+// String returns the type in angle brackets.
+func (n *TypeNode) String() string {
+	if n.Property {
+		return "<'" + n.Name + "'>"
+	} else if n.HasRange {
+		return fmt.Sprintf("<%s [%s,%s]>", n.Name, formatRange(n.Min), formatRange(n.Max))
+	}
+	return "<" + n.Name + ">"
+}
+
+// formatRange formats a range bound, using ∞ for infinite values.
+func formatRange(f float64) string {
+	if math.IsInf(f, 1) {
+		return "∞"
+	} else if math.IsInf(f, -1) {
+		return "-∞"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// FunctionNode represents a function with a grammar for its arguments.
+type FunctionNode struct {
+	Name string
+	Body GrammarNode
+}
+
+// String returns the function with its arguments.
+func (n *FunctionNode) String() string {
+	if n.Body == nil {
+		return n.Name + "()"
+	}
+	return n.Name + "( " + n.Body.String() + " )"
+}
+
+// BlockNode represents a literal [-block or (-block, such as "'[' <custom-ident>* ']'".
+type BlockNode struct {
+	Tok  Tok // LBrackToken or LParenToken
+	Body GrammarNode
+}
+
+// String returns the block with its contents.
+func (n *BlockNode) String() string {
+	open, close := "'['", "']'"
+	if n.Tok == LParenToken {
+		open, close = "'('", "')'"
+	}
+	if n.Body == nil {
+		return open + " " + close
+	}
+	return open + " " + n.Body.String() + " " + close
+}
+
+// GroupCombinator represents the way the children of a group are combined.
+type GroupCombinator int
+
+const (
+	// Juxtaposed components must all occur in the given order.
+	SequenceCombinator GroupCombinator = iota
+
+	// "&&" components must all occur in any order.
+	AllCombinator
+
+	// "||" components must have one or more occur in any order.
+	AnyCombinator
+
+	// "|" components must have exactly one occur.
+	OneCombinator
+)
+
+// GroupNode represents a group of components, such as "[ a | b ]".
+// If Required is set then the group must not match an empty list of values.
+type GroupNode struct {
+	Combinator GroupCombinator
+	Children   []GrammarNode
+	Required   bool
+}
+
+// String returns the group in brackets.
+func (n *GroupNode) String() string {
+	sep := " "
+	switch n.Combinator {
+	case AllCombinator:
+		sep = " && "
+	case AnyCombinator:
+		sep = " || "
+	case OneCombinator:
+		sep = " | "
+	}
+	var a []string
+	for _, c := range n.Children {
+		a = append(a, c.String())
+	}
+	s := "[ " + strings.Join(a, sep) + " ]"
+	if n.Required {
+		s += "!"
+	}
+	return s
+}
+
+// MultiplierNode represents a repeated component, such as "<length>{1,4}"
+// or "<color>#". A Max of -1 indicates no upper bound. Comma-separated
+// repetitions are created by the "#" multiplier.
+type MultiplierNode struct {
+	Node  GrammarNode
+	Min   int
+	Max   int
+	Comma bool
+}
+
+// String returns the component with its multiplier.
+func (n *MultiplierNode) String() string {
+	s := n.Node.String()
+	if n.Comma {
+		s += "#"
+		if n.Min == 1 && n.Max == -1 {
+			return s
+		}
+	} else {
+		switch {
+		case n.Min == 0 && n.Max == 1:
+			return s + "?"
+		case n.Min == 0 && n.Max == -1:
+			return s + "*"
+		case n.Min == 1 && n.Max == -1:
+			return s + "+"
+		}
+	}
+	if n.Min == n.Max {
+		return s + "{" + strconv.Itoa(n.Min) + "}"
+	} else if n.Max == -1 {
+		return s + "{" + strconv.Itoa(n.Min) + ",}"
+	}
+	return s + "{" + strconv.Itoa(n.Min) + "," + strconv.Itoa(n.Max) + "}"
+}
+
+// Match represents the part of a value list matched by a grammar node.
+// Children contains the matches of the node's sub-components.
+type Match struct {
+	Node     GrammarNode
+	Values   ComponentValues
+	Children []*Match
+}
+
+// Walk calls fn for the match and each of its descendants in depth-first
+// order. Children are skipped if fn returns false.
+func (m *Match) Walk(fn func(*Match) bool) {
+	if !fn(m) {
+		return
+	}
+	for _, c := range m.Children {
+		c.Walk(fn)
+	}
+}
+
+// Property returns the values matched by the first reference to the given
+// property, such as <'margin-top'>, and whether it was found.
+func (m *Match) Property(name string) (ComponentValues, bool) {
+	var values ComponentValues
+	var found bool
+	m.Walk(func(m *Match) bool {
+		if found {
+			return false
+		} else if n, ok := m.Node.(*TypeNode); ok && n.Property && n.Name == name {
+			values, found = m.Values, true
+			return false
+		}
+		return true
+	})
+	return values, found
+}
+
+// Matcher matches component values against grammars and resolves the named
+// types and property references that are not basic data types.
+type Matcher struct {
+	// Grammars for named types, such as "line-style" for <line-style>.
+	// Function types are named with their parentheses, such as "rgb()".
+	Types map[string]*Grammar
+
+	// Grammars for property references, such as "margin-top" for <'margin-top'>.
+	Properties map[string]*Grammar
+}
+
+// Match matches the values against a grammar. Whitespace is ignored.
+// Returns the match tree for the root of the grammar. If the values do not
+// match then an *Error is returned positioned at the furthest value that
+// could be matched.
+func (m *Matcher) Match(g *Grammar, values ComponentValues) (*Match, error) {
+	st := &matchState{m: m, values: values.nonwhitespace(), furthest: -1}
+
+	var result *Match
+	st.match(g.Root, 0, func(i int, mt *Match) bool {
+		if i != len(st.values) {
+			st.fail(i, "end of value")
+			return false
+		}
+		result = mt
+		return true
+	})
+	if st.err != nil {
+		return nil, st.err
+	} else if result != nil {
+		return result, nil
+	}
+	return nil, st.error(values)
+}
+
+// matchState holds the state for a single match.
+type matchState struct {
+	m      *Matcher
+	values ComponentValues
+
+	// Furthest index that failed and the components expected at that point.
+	furthest int
+	expected []string
+
+	// Furthest failure inside a function or block at the furthest index.
+	inner *matchState
+
+	// Fatal error, such as an unresolved type.
+	err error
+}
+
+// fail records that the expected component did not match at index i.
+func (st *matchState) fail(i int, expected string) {
+	if i > st.furthest {
+		st.furthest, st.expected, st.inner = i, nil, nil
+	}
+	if i == st.furthest && !containsString(st.expected, expected) {
+		st.expected = append(st.expected, expected)
+	}
+}
+
+// error returns an error describing the furthest failure.
+func (st *matchState) error(orig ComponentValues) error {
+	// Failures inside a function or block are more precise.
+	if st.inner != nil {
+		return st.inner.error(nil)
+	}
+
+	// Only report the end of the value if nothing else was expected.
+	var a []string
+	for _, s := range st.expected {
+		if s != "end of value" {
+			a = append(a, s)
+		}
+	}
+	expected := strings.Join(a, " or ")
+	if st.furthest < 0 || st.furthest >= len(st.values) {
+		return &Error{Code: "unexpected-end-of-value", Message: fmt.Sprintf("unexpected end of value, expected %s", expected), Origin: ValidatorOrigin, Pos: endPosition(orig)}
+	}
+	v := st.values[st.furthest]
+	if expected == "" {
+		return &Error{Code: "unexpected-value", Message: fmt.Sprintf("unexpected: %s", print(v)), Origin: ValidatorOrigin, Pos: Position(v), End: End(v)}
+	}
+	return &Error{Code: "unexpected-value", Message: fmt.Sprintf("unexpected %s, expected %s", print(v), expected), Origin: ValidatorOrigin, Pos: Position(v), End: End(v)}
+}
+
+// endPosition returns the position of the last value in a list.
+func endPosition(values ComponentValues) Pos {
+	if len(values) == 0 {
+		return Pos{}
+	}
+	return Position(values[len(values)-1])
+}
+
+// match matches node n starting at index i. For each way that the node can
+// match, k is called with the index after the match and the match tree.
+// Returns true as soon as k returns true.
+func (st *matchState) match(n GrammarNode, i int, k func(int, *Match) bool) bool {
+	if st.err != nil {
+		return false
+	}
+
+	switch n := n.(type) {
+	case *KeywordNode:
+		if i < len(st.values) {
+			if tok, ok := st.values[i].(*Token); ok && tok.Tok == IdentToken && strings.EqualFold(tok.Value, n.Name) {
+				return k(i+1, &Match{Node: n, Values: st.values[i : i+1]})
+			}
+		}
+		st.fail(i, n.Name)
+		return false
+
+	case *LiteralNode:
+		if i < len(st.values) && st.matchLiteral(n.Value, st.values[i]) {
+			return k(i+1, &Match{Node: n, Values: st.values[i : i+1]})
+		}
+		st.fail(i, n.String())
+		return false
+
+	case *TypeNode:
+		return st.matchType(n, i, k)
+
+	case *FunctionNode:
+		if i < len(st.values) {
+			if fn, ok := st.values[i].(*Function); ok && strings.EqualFold(fn.Name, n.Name) {
+				if child, ok := st.matchInner(n, n.Body, i, fn.Values); ok {
+					return k(i+1, &Match{Node: n, Values: st.values[i : i+1], Children: child})
+				}
+				return false
+			}
+		}
+		st.fail(i, n.Name+"()")
+		return false
+
+	case *BlockNode:
+		if i < len(st.values) {
+			if b, ok := st.values[i].(*SimpleBlock); ok && b.Token.Tok == n.Tok {
+				if child, ok := st.matchInner(n, n.Body, i, b.Values); ok {
+					return k(i+1, &Match{Node: n, Values: st.values[i : i+1], Children: child})
+				}
+				return false
+			}
+		}
+		st.fail(i, n.String())
+		return false
+
+	case *GroupNode:
+		wrap := func(j int, children []*Match) bool {
+			if n.Required && j == i {
+				return false
+			}
+			return k(j, &Match{Node: n, Values: st.values[i:j], Children: children})
+		}
+		switch n.Combinator {
+		case SequenceCombinator:
+			return st.matchSequence(n.Children, i, nil, wrap)
+		case OneCombinator:
+			for _, c := range n.Children {
+				if st.match(c, i, func(j int, m *Match) bool { return wrap(j, []*Match{m}) }) {
+					return true
+				}
+			}
+			return false
+		case AllCombinator:
+			return st.matchUnordered(n.Children, make([]bool, len(n.Children)), len(n.Children), i, nil, wrap)
+		case AnyCombinator:
+			return st.matchUnordered(n.Children, make([]bool, len(n.Children)), 1, i, nil, wrap)
+		}
+
+	case *MultiplierNode:
+		return st.matchRepeat(n, 0, i, i, nil, k)
+	}
+
+	panic(fmt.Sprintf("unexpected grammar node: %T", n))
+}
+
+// matchInner matches the entire contents of the function or block at index i
+// against the body of node n. A nil body only matches empty contents.
+func (st *matchState) matchInner(n, body GrammarNode, i int, values ComponentValues) ([]*Match, bool) {
+	inner := &matchState{m: st.m, values: values.nonwhitespace(), furthest: -1}
+
+	var children []*Match
+	var ok bool
+	if body == nil {
+		ok = len(inner.values) == 0
+		inner.fail(0, "end of value")
+	} else {
+		ok = inner.match(body, 0, func(j int, m *Match) bool {
+			if j != len(inner.values) {
+				inner.fail(j, "end of value")
+				return false
+			}
+			children = []*Match{m}
+			return true
+		})
+	}
+
+	if !ok {
+		if inner.err != nil {
+			st.err = inner.err
+			return nil, false
+		}
+
+		// Record the failure at this index but keep the inner failure
+		// location when it is inside the contents, since it is more precise.
+		// Failures of other alternatives at the same location are merged.
+		st.fail(i, n.String())
+		if st.furthest == i && inner.furthest >= 0 && inner.furthest < len(inner.values) {
+			if prev := st.inner; prev != nil && prev.furthest == inner.furthest && prev.inner == nil && inner.inner == nil {
+				for _, s := range inner.expected {
+					prev.fail(inner.furthest, s)
+				}
+			} else if prev == nil || inner.furthest > prev.furthest {
+				st.inner = inner
+			}
+		}
+	}
+	return children, ok
+}
+
+// matchSequence matches each node in order.
+func (st *matchState) matchSequence(nodes []GrammarNode, i int, acc []*Match, k func(int, []*Match) bool) bool {
+	if len(nodes) == 0 {
+		return k(i, acc)
+	}
+	return st.match(nodes[0], i, func(j int, m *Match) bool {
+		return st.matchSequence(nodes[1:], j, appendMatch(acc, m), k)
+	})
+}
+
+// matchUnordered matches the nodes in any order. At least min nodes must
+// match and each node can only match once.
+func (st *matchState) matchUnordered(nodes []GrammarNode, used []bool, min, i int, acc []*Match, k func(int, []*Match) bool) bool {
+	for idx, c := range nodes {
+		if used[idx] {
+			continue
+		}
+		used[idx] = true
+		ok := st.match(c, i, func(j int, m *Match) bool {
+			return st.matchUnordered(nodes, used, min, j, appendMatch(acc, m), k)
+		})
+		used[idx] = false
+		if ok {
+			return true
+		}
+	}
+
+	// Finish if enough nodes have matched.
+	if len(acc) >= min {
+		return k(i, acc)
+	}
+	return false
+}
+
+// matchRepeat greedily matches a multiplied node as many times as allowed.
+func (st *matchState) matchRepeat(n *MultiplierNode, count, start, i int, acc []*Match, k func(int, *Match) bool) bool {
+	if n.Max == -1 || count < n.Max {
+		next := i
+
+		// Comma-separated repetitions need a comma between each one.
+		if n.Comma && count > 0 {
+			if i < len(st.values) && isTok(st.values[i], CommaToken) {
+				next = i + 1
+			} else {
+				st.fail(i, ",")
+				next = -1
+			}
+		}
+
+		if next >= 0 && st.match(n.Node, next, func(j int, m *Match) bool {
+			if j == i {
+				return false // prevent infinite empty repetition
+			}
+			return st.matchRepeat(n, count+1, start, j, appendMatch(acc, m), k)
+		}) {
+			return true
+		}
+	}
+
+	if count >= n.Min {
+		return k(i, &Match{Node: n, Values: st.values[start:i], Children: acc})
+	}
+	return false
+}
+
+// matchLiteral returns true if v is the literal delimiter.
+func (st *matchState) matchLiteral(lit string, v ComponentValue) bool {
+	tok, ok := v.(*Token)
+	if !ok {
+		return false
+	}
+	switch lit {
+	case ",":
+		return tok.Tok == CommaToken
+	case ":":
+		return tok.Tok == ColonToken
+	case ";":
+		return tok.Tok == SemicolonToken
+	}
+	return tok.Tok == DelimToken && tok.Value == lit
+}
+
+// matchType matches a data type or property reference.
+func (st *matchState) matchType(n *TypeNode, i int, k func(int, *Match) bool) bool {
+	// Property references and named types are resolved to their grammars.
+	var g *Grammar
+	if n.Property {
+		if g = st.m.Properties[n.Name]; g == nil {
+			st.err = fmt.Errorf("unknown property reference: %s", n)
+			return false
+		}
+	} else if g = st.m.Types[n.Name]; g == nil && !isBasicDataType(n.Name) {
+		st.err = fmt.Errorf("unknown type: %s", n)
+		return false
+	}
+
+	if g != nil {
+		furthest, expected := st.furthest, len(st.expected)
+		ok := st.match(g.Root, i, func(j int, m *Match) bool {
+			return k(j, &Match{Node: n, Values: st.values[i:j], Children: []*Match{m}})
+		})
+
+		// Report the type itself rather than its components when it fails
+		// at its first value.
+		if !ok && st.furthest == i && st.inner == nil {
+			if furthest == i {
+				st.expected = st.expected[:expected]
+			} else {
+				st.expected = nil
+			}
+			st.fail(i, n.String())
+		}
+		return ok
+	}
+
+	// Otherwise match a single value against a basic data type.
+	if i < len(st.values) && MatchDataType(n.Name, st.values[i]) && n.inRange(st.values[i]) {
+		return k(i+1, &Match{Node: n, Values: st.values[i : i+1]})
+	}
+	st.fail(i, n.String())
+	return false
+}
+
+// inRange returns true if a numeric value is within the type's range.
+// Non-numeric values, such as math functions, are not range checked.
+func (n *TypeNode) inRange(v ComponentValue) bool {
+	if !n.HasRange {
+		return true
+	}
+	tok, ok := v.(*Token)
+	if !ok {
+		return true
+	}
+	switch tok.Tok {
+	case NumberToken, PercentageToken, DimensionToken:
+		return tok.Number >= n.Min && tok.Number <= n.Max
+	}
+	return true
+}
+
+// isBasicDataType returns true if the name is matched by MatchDataType.
+func isBasicDataType(name string) bool {
+	switch name {
+	case "length", "percentage", "length-percentage", "number", "integer", "number-percentage",
+		"angle", "angle-percentage", "time", "time-percentage", "frequency", "resolution", "flex",
+		"string", "url", "ident", "custom-ident", "dashed-ident", "hex-color", "color", "image",
+		"transform-function":
+		return true
+	}
+	return false
+}
+
+// appendMatch returns a copy of acc with m appended so that backtracking
+// branches never share the same backing array.
+func appendMatch(acc []*Match, m *Match) []*Match {
+	a := make([]*Match, len(acc), len(acc)+1)
+	copy(a, acc)
+	return append(a, m)
+}
+
+// grammarParser is a recursive descent parser for value definitions.
+// Components are combined with the following precedence, from tightest to
+// loosest: juxtaposition, "&&", "||" and "|".
+type grammarParser struct {
+	lex  *grammarLexer
+	tok  grammarToken
+	peek bool
+
+	// Closing literals of the enclosing literal blocks, such as "']'".
+	closers []string
+}
+
+// next returns the next token from the lexer.
+func (gp *grammarParser) next() grammarToken {
+	if gp.peek {
+		gp.peek = false
+		return gp.tok
+	}
+	gp.tok = gp.lex.lex()
+	return gp.tok
+}
+
+// backup pushes the last token back so it is returned by the next call.
+func (gp *grammarParser) backup() { gp.peek = true }
+
+// parseOneOf parses components separated by "|".
+func (gp *grammarParser) parseOneOf() (GrammarNode, error) {
+	return gp.parseCombined(gBar, OneCombinator, gp.parseAnyOf)
+}
+
+// parseAnyOf parses components separated by "||".
+func (gp *grammarParser) parseAnyOf() (GrammarNode, error) {
+	return gp.parseCombined(gDoubleBar, AnyCombinator, gp.parseAllOf)
+}
+
+// parseAllOf parses components separated by "&&".
+func (gp *grammarParser) parseAllOf() (GrammarNode, error) {
+	return gp.parseCombined(gDoubleAmp, AllCombinator, gp.parseSequence)
+}
+
+// parseCombined parses operands separated by a combinator token.
+func (gp *grammarParser) parseCombined(sep grammarTokenKind, comb GroupCombinator, operand func() (GrammarNode, error)) (GrammarNode, error) {
+	var children []GrammarNode
+	for {
+		n, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, n)
+
+		if tok := gp.next(); tok.kind != sep {
+			gp.backup()
+			break
+		}
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &GroupNode{Combinator: comb, Children: children}, nil
+}
+
+// parseSequence parses juxtaposed components.
+func (gp *grammarParser) parseSequence() (GrammarNode, error) {
+	var children []GrammarNode
+	for {
+		tok := gp.next()
+		gp.backup()
+		switch tok.kind {
+		case gEOF, gBar, gDoubleBar, gDoubleAmp, gRBrack, gRParen, gLiteral:
+			if tok.kind == gLiteral && (len(gp.closers) == 0 || gp.closers[len(gp.closers)-1] != tok.text) {
+				break
+			} else if len(children) == 0 {
+				return nil, fmt.Errorf("unexpected %s in grammar at offset %d", tok.describe(), tok.pos)
+			} else if len(children) == 1 {
+				return children[0], nil
+			}
+			return &GroupNode{Combinator: SequenceCombinator, Children: children}, nil
+		}
+
+		n, err := gp.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, n)
+	}
+}
+
+// parseTerm parses a single component and its multipliers.
+func (gp *grammarParser) parseTerm() (GrammarNode, error) {
+	n, err := gp.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := gp.next()
+		switch tok.kind {
+		case gMultiplier:
+			n = &MultiplierNode{Node: n, Min: tok.min, Max: tok.max, Comma: tok.comma}
+		case gRequired:
+			g, ok := n.(*GroupNode)
+			if !ok {
+				g = &GroupNode{Combinator: SequenceCombinator, Children: []GrammarNode{n}}
+			}
+			g.Required, n = true, g
+		default:
+			gp.backup()
+			return n, nil
+		}
+	}
+}
+
+// parsePrimary parses a keyword, type, literal, function or bracketed group.
+func (gp *grammarParser) parsePrimary() (GrammarNode, error) {
+	tok := gp.next()
+	switch tok.kind {
+	case gKeyword:
+		return &KeywordNode{Name: tok.text}, nil
+
+	case gType:
+		return tok.node, nil
+
+	case gLiteral:
+		// Literal brackets and parentheses match simple blocks.
+		switch tok.text {
+		case "[":
+			return gp.parseBlock(LBrackToken, "]")
+		case "(":
+			return gp.parseBlock(LParenToken, ")")
+		}
+		return &LiteralNode{Value: tok.text}, nil
+
+	case gFunction:
+		fn := &FunctionNode{Name: tok.text}
+		if next := gp.next(); next.kind == gRParen {
+			return fn, nil
+		}
+		gp.backup()
+
+		body, err := gp.parseOneOf()
+		if err != nil {
+			return nil, err
+		} else if next := gp.next(); next.kind != gRParen {
+			return nil, fmt.Errorf("expected ) in grammar at offset %d, got %s", next.pos, next.describe())
+		}
+		fn.Body = body
+		return fn, nil
+
+	case gLBrack:
+		n, err := gp.parseOneOf()
+		if err != nil {
+			return nil, err
+		} else if next := gp.next(); next.kind != gRBrack {
+			return nil, fmt.Errorf("expected ] in grammar at offset %d, got %s", next.pos, next.describe())
+		}
+
+		// Wrap sequences and single components so the brackets are preserved
+		// for multipliers and "!" to apply to the whole group.
+		if g, ok := n.(*GroupNode); ok {
+			return g, nil
+		}
+		return &GroupNode{Combinator: SequenceCombinator, Children: []GrammarNode{n}}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %s in grammar at offset %d", tok.describe(), tok.pos)
+}
+
+// parseBlock parses the grammar of a literal block up to its closing literal.
+func (gp *grammarParser) parseBlock(tok Tok, close string) (GrammarNode, error) {
+	b := &BlockNode{Tok: tok}
+	if next := gp.next(); next.kind == gLiteral && next.text == close {
+		return b, nil
+	}
+	gp.backup()
+
+	gp.closers = append(gp.closers, close)
+	body, err := gp.parseOneOf()
+	gp.closers = gp.closers[:len(gp.closers)-1]
+	if err != nil {
+		return nil, err
+	} else if next := gp.next(); next.kind != gLiteral || next.text != close {
+		return nil, fmt.Errorf("expected '%s' in grammar at offset %d, got %s", close, next.pos, next.describe())
+	}
+	b.Body = body
+	return b, nil
+}
+
+// grammarTokenKind represents the type of a value definition token.
+type grammarTokenKind int
+
+const (
+	gEOF grammarTokenKind = iota
+	gInvalid
+	gKeyword
+	gType
+	gLiteral
+	gFunction
+	gLBrack
+	gRBrack
+	gRParen
+	gBar
+	gDoubleBar
+	gDoubleAmp
+	gMultiplier
+	gRequired
+)
+
+// grammarToken represents a lexical token in a value definition.
+type grammarToken struct {
+	kind grammarTokenKind
+	text string
+	pos  int
+
+	node GrammarNode // parsed type for gType tokens
+
+	// Multiplier bounds for gMultiplier tokens.
+	min, max int
+	comma    bool
+}
+
+// describe returns a description of the token for error messages.
+func (tok grammarToken) describe() string {
+	if tok.kind == gEOF {
+		return "EOF"
+	}
+	return strconv.Quote(tok.text)
+}
+
+// grammarLexer splits a value definition into tokens.
+type grammarLexer struct {
+	s   string
+	pos int
+}
+
+// lex returns the next token.
+func (l *grammarLexer) lex() grammarToken {
+	// Skip whitespace.
+	for l.pos < len(l.s) && isWhitespace(rune(l.s[l.pos])) {
+		l.pos++
+	}
+	if l.pos >= len(l.s) {
+		return grammarToken{kind: gEOF, pos: l.pos}
+	}
+
+	start := l.pos
+	ch, size := utf8.DecodeRuneInString(l.s[l.pos:])
+	tok := grammarToken{pos: start}
+
+	switch {
+	case ch == '<':
+		end := strings.IndexByte(l.s[l.pos:], '>')
+		if end == -1 {
+			return l.invalid(start)
+		}
+		l.pos += end + 1
+		tok.kind, tok.text = gType, l.s[start:l.pos]
+		n, ok := parseGrammarType(tok.text[1 : len(tok.text)-1])
+		if !ok {
+			return l.invalid(start)
+		}
+		tok.node = n
+		return tok
+
+	case ch == '\'':
+		end := strings.IndexByte(l.s[l.pos+1:], '\'')
+		if end == -1 {
+			return l.invalid(start)
+		}
+		l.pos += end + 2
+		tok.kind, tok.text = gLiteral, l.s[start+1:l.pos-1]
+		return tok
+
+	case ch == ',' || ch == '/' || ch == ':' || ch == ';':
+		l.pos++
+		tok.kind, tok.text = gLiteral, string(ch)
+		return tok
+
+	case ch == '[':
+		l.pos++
+		tok.kind, tok.text = gLBrack, "["
+		return tok
+	case ch == ']':
+		l.pos++
+		tok.kind, tok.text = gRBrack, "]"
+		return tok
+	case ch == ')':
+		l.pos++
+		tok.kind, tok.text = gRParen, ")"
+		return tok
+
+	case ch == '|':
+		if strings.HasPrefix(l.s[l.pos:], "||") {
+			l.pos += 2
+			tok.kind, tok.text = gDoubleBar, "||"
+			return tok
+		}
+		l.pos++
+		tok.kind, tok.text = gBar, "|"
+		return tok
+
+	case ch == '&':
+		if !strings.HasPrefix(l.s[l.pos:], "&&") {
+			return l.invalid(start)
+		}
+		l.pos += 2
+		tok.kind, tok.text = gDoubleAmp, "&&"
+		return tok
+
+	case ch == '!':
+		l.pos++
+		tok.kind, tok.text = gRequired, "!"
+		return tok
+
+	case ch == '?' || ch == '*' || ch == '+':
+		l.pos++
+		tok.kind, tok.text, tok.max = gMultiplier, string(ch), -1
+		switch ch {
+		case '?':
+			tok.min, tok.max = 0, 1
+		case '+':
+			tok.min = 1
+		}
+		return tok
+
+	case ch == '#':
+		l.pos++
+		tok.kind, tok.text, tok.min, tok.max, tok.comma = gMultiplier, "#", 1, -1, true
+		if l.pos < len(l.s) && l.s[l.pos] == '{' {
+			if !l.lexRange(&tok) {
+				return l.invalid(start)
+			}
+			tok.text = l.s[start:l.pos]
+		}
+		return tok
+
+	case ch == '{':
+		tok.kind = gMultiplier
+		if !l.lexRange(&tok) {
+			return l.invalid(start)
+		}
+		tok.text = l.s[start:l.pos]
+		return tok
+
+	case isNameStart(ch) || ch == '-':
+		// Read a keyword, which may be followed by "(" for a function.
+		l.pos += size
+		for l.pos < len(l.s) {
+			ch, size := utf8.DecodeRuneInString(l.s[l.pos:])
+			if !isName(ch) {
+				break
+			}
+			l.pos += size
+		}
+		tok.kind, tok.text = gKeyword, l.s[start:l.pos]
+		if l.pos < len(l.s) && l.s[l.pos] == '(' {
+			l.pos++
+			tok.kind = gFunction
+		}
+		return tok
+	}
+
+	return l.invalid(start)
+}
+
+// invalid returns a token that the parser will report as unexpected.
+func (l *grammarLexer) invalid(start int) grammarToken {
+	_, size := utf8.DecodeRuneInString(l.s[start:])
+	l.pos = start + size
+	return grammarToken{kind: gInvalid, text: l.s[start:l.pos], pos: start}
+}
+
+// lexRange reads a "{A}", "{A,}" or "{A,B}" multiplier range.
+func (l *grammarLexer) lexRange(tok *grammarToken) bool {
+	end := strings.IndexByte(l.s[l.pos:], '}')
+	if end == -1 {
+		return false
+	}
+	body := l.s[l.pos+1 : l.pos+end]
+	l.pos += end + 1
+
+	parts := strings.Split(body, ",")
+	min, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || len(parts) > 2 {
+		return false
+	}
+	tok.min, tok.max = min, min
+	if len(parts) == 2 {
+		if s := strings.TrimSpace(parts[1]); s == "" {
+			tok.max = -1
+		} else if tok.max, err = strconv.Atoi(s); err != nil || tok.max < min {
+			return false
+		}
+	}
+	return true
+}
+
+// parseGrammarType parses the inside of a type reference's angle brackets,
+// such as "length", "'margin-top'" or "length [0,∞]".
+func parseGrammarType(s string) (*TypeNode, bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") && len(s) > 2 {
+		return &TypeNode{Name: s[1 : len(s)-1], Property: true}, true
+	}
+
+	n := &TypeNode{Name: s}
+
+	// Parse an optional range, such as "[0,∞]".
+	if i := strings.IndexByte(s, '['); i != -1 {
+		if !strings.HasSuffix(s, "]") {
+			return nil, false
+		}
+		bounds := strings.Split(s[i+1:len(s)-1], ",")
+		if len(bounds) != 2 {
+			return nil, false
+		}
+		var ok bool
+		if n.Min, ok = parseRangeBound(bounds[0]); !ok {
+			return nil, false
+		} else if n.Max, ok = parseRangeBound(bounds[1]); !ok {
+			return nil, false
+		}
+		n.Name, n.HasRange = strings.TrimSpace(s[:i]), true
+	}
+
+	if n.Name == "" || strings.ContainsAny(n.Name, " \t\n") {
+		return nil, false
+	}
+	return n, true
+}
+
+// parseRangeBound parses a number or an infinite bound.
+func parseRangeBound(s string) (float64, bool) {
+	switch s = strings.TrimSpace(s); s {
+	case "∞", "+∞", "inf", "+inf":
+		return math.Inf(1), true
+	case "-∞", "-inf":
+		return math.Inf(-1), true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
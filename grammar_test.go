@@ -0,0 +1,162 @@
+package css_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that value definitions can be parsed and formatted.
+func TestParseGrammar(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out string
+		err string
+	}{
+		{in: `auto`, out: `auto`},
+		{in: `<length>`, out: `<length>`},
+		{in: `<'margin-top'>`, out: `<'margin-top'>`},
+		{in: `<length [0,∞]>`, out: `<length [0,∞]>`},
+		{in: `<number [-1,1]>`, out: `<number [-1,1]>`},
+		{in: `[ <length> | auto ]{1,4}`, out: `[ <length> | auto ]{1,4}`},
+		{in: `a b | c d`, out: `[ [ a b ] | [ c d ] ]`},
+		{in: `a | b || c && d e`, out: `[ a | [ b || [ c && [ d e ] ] ] ]`},
+		{in: `<color>#`, out: `<color>#`},
+		{in: `<color>#{2,3}`, out: `<color>#{2,3}`},
+		{in: `a? b* c+ d{2} e{2,}`, out: `[ a? b* c+ d{2} e{2,} ]`},
+		{in: `[ a? b? ]!`, out: `[ a? b? ]!`},
+		{in: `rgb( <number>{3} [ / <number> ]? )`, out: `rgb( [ <number>{3} [ / <number> ]? ] )`},
+		{in: `foo()`, out: `foo()`},
+		{in: `'[' <custom-ident>* ']'`, out: `'[' <custom-ident>* ']'`},
+		{in: `<length> , <length>`, out: `[ <length> , <length> ]`},
+
+		{in: ``, err: `unexpected EOF in grammar at offset 0`},
+		{in: `[ a`, err: `expected ] in grammar at offset 3, got EOF`},
+		{in: `a | | b`, err: `unexpected "|" in grammar at offset 4`},
+		{in: `a ]`, err: `unexpected "]" in grammar at offset 2`},
+		{in: `<length`, err: `unexpected "<" in grammar at offset 0`},
+		{in: `a{2,1}`, err: `unexpected "{" in grammar at offset 1`},
+		{in: `a & b`, err: `unexpected "&" in grammar at offset 2`},
+		{in: `f( a`, err: `expected ) in grammar at offset 4, got EOF`},
+		{in: `'[' a`, err: `expected ']' in grammar at offset 5, got EOF`},
+	}
+
+	for i, tt := range tests {
+		g, err := css.ParseGrammar(tt.in)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%d. <%q> unexpected error: %s", i, tt.in, err)
+			continue
+		}
+
+		if s := g.String(); s != tt.out {
+			t.Errorf("%d. <%q> exp=%q, got=%q", i, tt.in, tt.out, s)
+		}
+	}
+}
+
+// Ensure that values can be matched against a grammar.
+func TestGrammar_Match(t *testing.T) {
+	var tests = []struct {
+		grammar string
+		in      string
+		err     string
+	}{
+		{grammar: `[ <length> | auto ]{1,4}`, in: `auto`},
+		{grammar: `[ <length> | auto ]{1,4}`, in: `1px AUTO 0 2em`},
+		{grammar: `[ <length> | auto ]{1,4}`, in: `1px 2px 3px 4px 5px`, err: `unexpected: 5px`},
+		{grammar: `[ <length> | auto ]{1,4}`, in: `1px red`, err: `unexpected red, expected <length> or auto`},
+		{grammar: `[ <length> | auto ]{1,4}`, in: ``, err: `unexpected end of value, expected <length> or auto`},
+		{grammar: `<length [0,∞]>`, in: `10px`},
+		{grammar: `<length [0,∞]>`, in: `-10px`, err: `unexpected -10px, expected <length [0,∞]>`},
+		{grammar: `<color>#`, in: `red, #fff ,blue`},
+		{grammar: `<color>#`, in: `red blue`, err: `unexpected blue, expected ,`},
+		{grammar: `<color>#`, in: `red,`, err: `unexpected end of value, expected <color>`},
+		{grammar: `<color>#{1,2}`, in: `red, blue, green`, err: `unexpected: ,`},
+		{grammar: `a && b && c`, in: `c a b`},
+		{grammar: `a && b && c`, in: `c a`, err: `unexpected end of value, expected b`},
+		{grammar: `a || b || c`, in: `c a`},
+		{grammar: `a || b || c`, in: `c a c`, err: `unexpected c, expected b`},
+		{grammar: `[ a? b? ]!`, in: `b`},
+		{grammar: `[ a? b? ]!`, in: ``, err: `unexpected end of value, expected a or b`},
+		{grammar: `<length>{1,2} <length>`, in: `1px 2px`},
+		{grammar: `<length>+ / <length>`, in: `1px 2px / 3px`},
+		{grammar: `rgb( <number>{3} [ / <number> ]? )`, in: `rgb(1 2 3 / 0.5)`},
+		{grammar: `rgb( <number>{3} [ / <number> ]? )`, in: `rgb(1 2 x)`, err: `unexpected x, expected <number>`},
+		{grammar: `'[' <custom-ident>* ']' <length>`, in: `[a b] 1px`},
+		{grammar: `'[' <custom-ident>* ']'`, in: `(a b)`, err: `unexpected (a b), expected '[' <custom-ident>* ']'`},
+		{grammar: `<ident>`, in: `1px`, err: `unexpected 1px, expected <ident>`},
+
+		// Alternatives are still tried after a function or block doesn't match.
+		{grammar: `foo( <length> ) | foo( auto )`, in: `foo(auto)`},
+		{grammar: `foo( <length> ) | foo( auto )`, in: `foo(1px)`},
+		{grammar: `foo( <length> ) | foo( auto )`, in: `foo(red)`, err: `unexpected red, expected <length> or auto`},
+		{grammar: `'[' <length> ']' | '[' auto ']'`, in: `[auto]`},
+		{grammar: `[ foo( <length> ) | foo( auto ) ]#`, in: `foo(auto), foo(1px)`},
+		{grammar: `foo( <length> ) <length> | foo( auto ) auto`, in: `foo(auto) auto`},
+	}
+
+	for i, tt := range tests {
+		g := css.MustParseGrammar(tt.grammar)
+		var p css.Parser
+		_, err := g.Match(p.ParseComponentValues(css.NewScanner(strings.NewReader(tt.in))))
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%d. %s <%q> error: exp=%q, got=%v", i, tt.grammar, tt.in, tt.err, err)
+			}
+		} else if err != nil {
+			t.Errorf("%d. %s <%q> unexpected error: %s", i, tt.grammar, tt.in, err)
+		}
+	}
+}
+
+// Ensure that match errors are positioned at the offending value.
+func TestGrammar_Match_ErrorPos(t *testing.T) {
+	var p css.Parser
+	values := p.ParseComponentValues(css.NewScanner(strings.NewReader("1px\n  solid red")))
+	_, err := css.MustParseGrammar(`<length>{1,2}`).Match(values)
+	if e, ok := err.(*css.Error); !ok {
+		t.Fatalf("unexpected error: %#v", err)
+	} else if e.Pos != (css.Pos{Char: 3, Line: 1}) {
+		t.Fatalf("unexpected pos: %#v", e.Pos)
+	}
+}
+
+// Ensure that a matcher resolves named types and property references and
+// returns a match tree.
+func TestMatcher_Match(t *testing.T) {
+	m := &css.Matcher{
+		Types: map[string]*css.Grammar{
+			"line-style": css.MustParseGrammar(`none | solid | dashed`),
+		},
+		Properties: map[string]*css.Grammar{
+			"border-width": css.MustParseGrammar(`<length>`),
+			"border-style": css.MustParseGrammar(`<line-style>`),
+			"border-color": css.MustParseGrammar(`<color>`),
+		},
+	}
+	g := css.MustParseGrammar(`<'border-width'> || <'border-style'> || <'border-color'>`)
+
+	var p css.Parser
+	match, err := m.Match(g, p.ParseComponentValues(css.NewScanner(strings.NewReader(`red 2px`))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := match.Property("border-color"); !ok || print(v) != "red" {
+		t.Fatalf("unexpected border-color: %q, %v", print(v), ok)
+	} else if v, ok := match.Property("border-width"); !ok || print(v) != "2px" {
+		t.Fatalf("unexpected border-width: %q, %v", print(v), ok)
+	} else if _, ok := match.Property("border-style"); ok {
+		t.Fatal("unexpected border-style")
+	}
+
+	// Unresolved types are reported.
+	if _, err := g.Match(nil); err == nil || err.Error() != `unknown property reference: <'border-width'>` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,396 @@
+package css
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Nodes are encoded as JSON objects with a "type" field naming their Go
+// type, such as "AtRule" or "Token", so that rules, declarations and
+// component values can be decoded back into the right type. Lists of nodes
+// are encoded as arrays. Token types are encoded by name, as returned by
+// Tok.String.
+//
+// Positions are encoded as {"line":0,"char":1} and omitted if they are
+// zero. The file of a position isn't encoded. The position just after an
+// at-rule, declaration, function or token, as recorded when it was parsed,
+// is encoded as "endPos".
+
+type jsonStyleSheet struct {
+	Type  string `json:"type"`
+	Rules Rules  `json:"rules"`
+}
+
+// MarshalJSON encodes the style sheet as a JSON object.
+func (ss *StyleSheet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonStyleSheet{Type: "StyleSheet", Rules: nonNilRules(ss.Rules)})
+}
+
+// UnmarshalJSON decodes the style sheet from a JSON object.
+func (ss *StyleSheet) UnmarshalJSON(data []byte) error {
+	var v jsonStyleSheet
+	if err := unmarshalType(data, "StyleSheet", &v); err != nil {
+		return err
+	}
+	*ss = StyleSheet{Rules: v.Rules}
+	return nil
+}
+
+type jsonAtRule struct {
+	Type    string          `json:"type"`
+	Name    string          `json:"name"`
+	Prelude ComponentValues `json:"prelude"`
+	Block   *SimpleBlock    `json:"block,omitempty"`
+	Pos     *Pos            `json:"pos,omitempty"`
+	EndPos  *Pos            `json:"endPos,omitempty"`
+}
+
+// MarshalJSON encodes the at-rule as a JSON object.
+func (r *AtRule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonAtRule{Type: "AtRule", Name: r.Name, Prelude: nonNilValues(r.Prelude), Block: r.Block, Pos: jsonPos(r.Pos), EndPos: jsonPos(r.endPos)})
+}
+
+// UnmarshalJSON decodes the at-rule from a JSON object.
+func (r *AtRule) UnmarshalJSON(data []byte) error {
+	var v jsonAtRule
+	if err := unmarshalType(data, "AtRule", &v); err != nil {
+		return err
+	}
+	*r = AtRule{Name: v.Name, Prelude: v.Prelude, Block: v.Block, Pos: posValue(v.Pos), endPos: posValue(v.EndPos)}
+	return nil
+}
+
+type jsonQualifiedRule struct {
+	Type    string          `json:"type"`
+	Prelude ComponentValues `json:"prelude"`
+	Block   *SimpleBlock    `json:"block,omitempty"`
+	Pos     *Pos            `json:"pos,omitempty"`
+}
+
+// MarshalJSON encodes the qualified rule as a JSON object.
+func (r *QualifiedRule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonQualifiedRule{Type: "QualifiedRule", Prelude: nonNilValues(r.Prelude), Block: r.Block, Pos: jsonPos(r.Pos)})
+}
+
+// UnmarshalJSON decodes the qualified rule from a JSON object.
+func (r *QualifiedRule) UnmarshalJSON(data []byte) error {
+	var v jsonQualifiedRule
+	if err := unmarshalType(data, "QualifiedRule", &v); err != nil {
+		return err
+	}
+	*r = QualifiedRule{Prelude: v.Prelude, Block: v.Block, Pos: posValue(v.Pos)}
+	return nil
+}
+
+type jsonDeclaration struct {
+	Type      string          `json:"type"`
+	Name      string          `json:"name"`
+	Values    ComponentValues `json:"values"`
+	Important bool            `json:"important,omitempty"`
+	Pos       *Pos            `json:"pos,omitempty"`
+	EndPos    *Pos            `json:"endPos,omitempty"`
+}
+
+// MarshalJSON encodes the declaration as a JSON object.
+func (d *Declaration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDeclaration{Type: "Declaration", Name: d.Name, Values: nonNilValues(d.Values), Important: d.Important, Pos: jsonPos(d.Pos), EndPos: jsonPos(d.endPos)})
+}
+
+// UnmarshalJSON decodes the declaration from a JSON object.
+func (d *Declaration) UnmarshalJSON(data []byte) error {
+	var v jsonDeclaration
+	if err := unmarshalType(data, "Declaration", &v); err != nil {
+		return err
+	}
+	*d = Declaration{Name: v.Name, Values: v.Values, Important: v.Important, Pos: posValue(v.Pos), endPos: posValue(v.EndPos)}
+	return nil
+}
+
+type jsonSimpleBlock struct {
+	Type   string          `json:"type"`
+	Token  *Token          `json:"token"`
+	Values ComponentValues `json:"values"`
+	Pos    *Pos            `json:"pos,omitempty"`
+	End    *Pos            `json:"end,omitempty"`
+}
+
+// MarshalJSON encodes the block as a JSON object.
+func (b *SimpleBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonSimpleBlock{Type: "SimpleBlock", Token: b.Token, Values: nonNilValues(b.Values), Pos: jsonPos(b.Pos), End: jsonPos(b.End)})
+}
+
+// UnmarshalJSON decodes the block from a JSON object.
+func (b *SimpleBlock) UnmarshalJSON(data []byte) error {
+	var v jsonSimpleBlock
+	if err := unmarshalType(data, "SimpleBlock", &v); err != nil {
+		return err
+	}
+	*b = SimpleBlock{Token: v.Token, Values: v.Values, Pos: posValue(v.Pos), End: posValue(v.End)}
+	return nil
+}
+
+type jsonFunction struct {
+	Type   string          `json:"type"`
+	Name   string          `json:"name"`
+	Values ComponentValues `json:"values"`
+	Pos    *Pos            `json:"pos,omitempty"`
+	EndPos *Pos            `json:"endPos,omitempty"`
+}
+
+// MarshalJSON encodes the function as a JSON object.
+func (f *Function) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonFunction{Type: "Function", Name: f.Name, Values: nonNilValues(f.Values), Pos: jsonPos(f.Pos), EndPos: jsonPos(f.endPos)})
+}
+
+// UnmarshalJSON decodes the function from a JSON object.
+func (f *Function) UnmarshalJSON(data []byte) error {
+	var v jsonFunction
+	if err := unmarshalType(data, "Function", &v); err != nil {
+		return err
+	}
+	*f = Function{Name: v.Name, Values: v.Values, Pos: posValue(v.Pos), endPos: posValue(v.EndPos)}
+	return nil
+}
+
+type jsonToken struct {
+	Type   string  `json:"type"`
+	Tok    string  `json:"tok"`
+	Value  string  `json:"value,omitempty"`
+	Flag   string  `json:"flag,omitempty"`
+	Ending string  `json:"ending,omitempty"`
+	Number float64 `json:"number,omitempty"`
+	Unit   string  `json:"unit,omitempty"`
+	Start  int     `json:"start,omitempty"`
+	End    int     `json:"end,omitempty"`
+	Pos    *Pos    `json:"pos,omitempty"`
+	EndPos *Pos    `json:"endPos,omitempty"`
+}
+
+// MarshalJSON encodes the token as a JSON object. The token's Type is
+// encoded as "flag" since "type" names the node.
+func (t *Token) MarshalJSON() ([]byte, error) {
+	v := jsonToken{Type: "Token", Tok: t.Tok.String(), Value: t.Value, Flag: t.Type, Number: t.Number, Unit: t.Unit, Start: t.Start, End: t.End, Pos: jsonPos(t.Pos), EndPos: jsonPos(t.endPos)}
+	if t.Ending != 0 {
+		v.Ending = string(t.Ending)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the token from a JSON object.
+func (t *Token) UnmarshalJSON(data []byte) error {
+	var v jsonToken
+	if err := unmarshalType(data, "Token", &v); err != nil {
+		return err
+	}
+	tok, ok := lookupTok(v.Tok)
+	if !ok {
+		return fmt.Errorf("unknown token type: %q", v.Tok)
+	}
+	*t = Token{Tok: tok, Type: v.Flag, Value: v.Value, Number: v.Number, Unit: v.Unit, Start: v.Start, End: v.End, Pos: posValue(v.Pos), endPos: posValue(v.EndPos)}
+	for _, ch := range v.Ending {
+		t.Ending = ch
+		break
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a list of rules from a JSON array.
+func (a *Rules) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	rules := make(Rules, 0, len(items))
+	for _, item := range items {
+		n, err := UnmarshalNode(item)
+		if err != nil {
+			return err
+		} else if r, ok := n.(Rule); !ok {
+			return fmt.Errorf("expected rule, got %s", nodeType(n))
+		} else {
+			rules = append(rules, r)
+		}
+	}
+	*a = rules
+	return nil
+}
+
+// UnmarshalJSON decodes a list of declarations and at-rules from a JSON array.
+func (a *Declarations) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	decls := make(Declarations, 0, len(items))
+	for _, item := range items {
+		n, err := UnmarshalNode(item)
+		if err != nil {
+			return err
+		}
+		switch n.(type) {
+		case *Declaration, *AtRule:
+			decls = append(decls, n)
+		default:
+			return fmt.Errorf("expected declaration or at-rule, got %s", nodeType(n))
+		}
+	}
+	*a = decls
+	return nil
+}
+
+// UnmarshalJSON decodes a list of component values from a JSON array.
+func (a *ComponentValues) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	values := make(ComponentValues, 0, len(items))
+	for _, item := range items {
+		n, err := UnmarshalNode(item)
+		if err != nil {
+			return err
+		} else if v, ok := n.(ComponentValue); !ok {
+			return fmt.Errorf("expected component value, got %s", nodeType(n))
+		} else {
+			values = append(values, v)
+		}
+	}
+	*a = values
+	return nil
+}
+
+// UnmarshalNode decodes a node from a JSON object by the name in its
+// "type" field.
+func UnmarshalNode(data []byte) (Node, error) {
+	var v struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	var n interface {
+		Node
+		json.Unmarshaler
+	}
+	switch v.Type {
+	case "StyleSheet":
+		n = &StyleSheet{}
+	case "AtRule":
+		n = &AtRule{}
+	case "QualifiedRule":
+		n = &QualifiedRule{}
+	case "Declaration":
+		n = &Declaration{}
+	case "SimpleBlock":
+		n = &SimpleBlock{}
+	case "Function":
+		n = &Function{}
+	case "Token":
+		n = &Token{}
+	case "":
+		return nil, fmt.Errorf("missing node type")
+	default:
+		return nil, fmt.Errorf("unknown node type: %q", v.Type)
+	}
+	if err := n.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// unmarshalType decodes a JSON object into v after checking its type.
+func unmarshalType(data []byte, typ string, v interface{}) error {
+	var t struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	} else if t.Type != typ {
+		return fmt.Errorf("expected %s, got %q", typ, t.Type)
+	}
+	return json.Unmarshal(data, v)
+}
+
+type jsonPosition struct {
+	Line int `json:"line"`
+	Char int `json:"char"`
+}
+
+// MarshalJSON encodes the line and character of the position.
+func (p Pos) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonPosition{Line: p.Line, Char: p.Char})
+}
+
+// UnmarshalJSON decodes the line and character of the position. The file
+// isn't changed.
+func (p *Pos) UnmarshalJSON(data []byte) error {
+	var v jsonPosition
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	p.Line, p.Char = v.Line, v.Char
+	return nil
+}
+
+// jsonPos returns a pointer to pos or nil if it is zero.
+func jsonPos(pos Pos) *Pos {
+	if pos.Line == 0 && pos.Char == 0 {
+		return nil
+	}
+	return &pos
+}
+
+func posValue(pos *Pos) Pos {
+	if pos == nil {
+		return Pos{}
+	}
+	return Pos{Line: pos.Line, Char: pos.Char}
+}
+
+// lookupTok returns the token type with the given name.
+func lookupTok(name string) (Tok, bool) {
+	for tok, s := range tokens {
+		if s == name && s != "" {
+			return Tok(tok), true
+		}
+	}
+	return 0, false
+}
+
+// nodeType returns the name of a node's type as it's encoded.
+func nodeType(n Node) string {
+	switch n.(type) {
+	case *StyleSheet:
+		return "StyleSheet"
+	case *AtRule:
+		return "AtRule"
+	case *QualifiedRule:
+		return "QualifiedRule"
+	case *Declaration:
+		return "Declaration"
+	case *SimpleBlock:
+		return "SimpleBlock"
+	case *Function:
+		return "Function"
+	case *Token:
+		return "Token"
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+// nonNilRules and nonNilValues return an empty list for nil so that lists
+// are always encoded as arrays.
+func nonNilRules(a Rules) Rules {
+	if a == nil {
+		return Rules{}
+	}
+	return a
+}
+
+func nonNilValues(a ComponentValues) ComponentValues {
+	if a == nil {
+		return ComponentValues{}
+	}
+	return a
+}
@@ -0,0 +1,118 @@
+package css_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that nodes are encoded with their type and position.
+func TestStyleSheet_MarshalJSON(t *testing.T) {
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScannerString(`@x;a{b:1.5em!important;c:"d"}`))
+
+	b, err := json.Marshal(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"type":"StyleSheet","rules":[` +
+		`{"type":"AtRule","name":"x","prelude":[],"pos":{"line":0,"char":1},"endPos":{"line":0,"char":4}},` +
+		`{"type":"QualifiedRule","prelude":[{"type":"Token","tok":"ident","value":"a","pos":{"line":0,"char":4},"endPos":{"line":0,"char":5}}],` +
+		`"block":{"type":"SimpleBlock","token":{"type":"Token","tok":"{","pos":{"line":0,"char":5},"endPos":{"line":0,"char":6}},"values":[` +
+		`{"type":"Token","tok":"ident","value":"b","pos":{"line":0,"char":6},"endPos":{"line":0,"char":7}},` +
+		`{"type":"Token","tok":"colon","pos":{"line":0,"char":7},"endPos":{"line":0,"char":8}},` +
+		`{"type":"Token","tok":"dimension","value":"1.5em","flag":"number","number":1.5,"unit":"em","pos":{"line":0,"char":8},"endPos":{"line":0,"char":13}},` +
+		`{"type":"Token","tok":"delim","value":"!","pos":{"line":0,"char":13},"endPos":{"line":0,"char":14}},` +
+		`{"type":"Token","tok":"ident","value":"important","pos":{"line":0,"char":14},"endPos":{"line":0,"char":23}},` +
+		`{"type":"Token","tok":"semicolon","pos":{"line":0,"char":23},"endPos":{"line":0,"char":24}},` +
+		`{"type":"Token","tok":"ident","value":"c","pos":{"line":0,"char":24},"endPos":{"line":0,"char":25}},` +
+		`{"type":"Token","tok":"colon","pos":{"line":0,"char":25},"endPos":{"line":0,"char":26}},` +
+		`{"type":"Token","tok":"string","value":"d","ending":"\"","pos":{"line":0,"char":26},"endPos":{"line":0,"char":29}}],` +
+		`"pos":{"line":0,"char":5},"end":{"line":0,"char":29}},"pos":{"line":0,"char":4}}]}`
+	if string(b) != exp {
+		t.Fatalf("unexpected json:\n\nexp: %s\n\ngot: %s", exp, b)
+	}
+}
+
+// Ensure that decoding an encoded style sheet returns the same style sheet.
+func TestStyleSheet_UnmarshalJSON(t *testing.T) {
+	var tests = []string{
+		``,
+		`a { color: red }`,
+		`@import url(a.css) screen;`,
+		"@media (min-width: 100px) {\n  a > b:not(.c) { width: calc(100% - 2px) !important }\n}",
+		`a { content: 'x'; unicode-range: U+0-7F; margin: -1e3px 50% 0 }`,
+		"a { b: [c] (d) }\n\n@font-face { src: url(\"x\") }",
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		ss := p.ParseStyleSheet(css.NewScannerString(tt))
+
+		b, err := json.Marshal(ss)
+		if err != nil {
+			t.Fatalf("%d. %s", i, err)
+		}
+		var other css.StyleSheet
+		if err := json.Unmarshal(b, &other); err != nil {
+			t.Fatalf("%d. %s", i, err)
+		} else if got, exp := dump(&other), dump(ss); got != exp {
+			t.Errorf("%d. unexpected style sheet:\n\nexp: %s\n\ngot: %s", i, exp, got)
+		} else if b2, _ := json.Marshal(&other); string(b2) != string(b) {
+			t.Errorf("%d. unexpected re-encoding:\n\nexp: %s\n\ngot: %s", i, b, b2)
+		}
+	}
+}
+
+// Ensure that nodes are decoded by their type.
+func TestUnmarshalNode(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out css.Node
+		err string
+	}{
+		{in: `{"type":"Token","tok":"number","value":"1","flag":"integer","number":1}`, out: &css.Token{Tok: css.NumberToken, Value: "1", Type: "integer", Number: 1}},
+		{in: `{"type":"Token","tok":"unicode-range","start":1,"end":255,"pos":{"line":2,"char":3}}`, out: &css.Token{Tok: css.UnicodeRangeToken, Start: 1, End: 255, Pos: css.Pos{Line: 2, Char: 3}}},
+		{in: `{"type":"Declaration","name":"a","values":[{"type":"Token","tok":"ident","value":"b"}],"important":true}`, out: &css.Declaration{Name: "a", Values: css.ComponentValues{&css.Token{Tok: css.IdentToken, Value: "b"}}, Important: true}},
+		{in: `{"type":"Function","name":"f","values":[]}`, out: &css.Function{Name: "f", Values: css.ComponentValues{}}},
+		{in: `{"type":"Token","tok":"foo"}`, err: `unknown token type: "foo"`},
+		{in: `{"tok":"ident"}`, err: `missing node type`},
+		{in: `{"type":"Rule"}`, err: `unknown node type: "Rule"`},
+		{in: `{"type":"Function","name":"f","values":[{"type":"Declaration"}]}`, err: `expected component value, got Declaration`},
+		{in: `{"type":"StyleSheet","rules":[{"type":"Token","tok":"ident"}]}`, err: `expected rule, got Token`},
+		{in: `[]`, err: `json: cannot unmarshal array into Go value of type struct { Type string "json:\"type\"" }`},
+	}
+
+	for i, tt := range tests {
+		n, err := css.UnmarshalNode([]byte(tt.in))
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%d. unexpected error: %v", i, err)
+			}
+		} else if err != nil {
+			t.Errorf("%d. unexpected error: %s", i, err)
+		} else if !reflect.DeepEqual(n, tt.out) {
+			t.Errorf("%d. unexpected node: %#v", i, n)
+		}
+	}
+}
+
+// Ensure that declarations and at-rules in a declaration list are decoded.
+func TestDeclarations_UnmarshalJSON(t *testing.T) {
+	var p css.Parser
+	decls := p.ParseDeclarations(css.NewScannerString(`a: b; @c d; e: f`))
+	b, err := json.Marshal(decls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var other css.Declarations
+	if err := json.Unmarshal(b, &other); err != nil {
+		t.Fatal(err)
+	} else if s := print(other); s != print(decls) || !strings.Contains(s, "@c") {
+		t.Fatalf("unexpected declarations: %s", s)
+	}
+}
@@ -0,0 +1,836 @@
+// Package minify implements transformations which reduce the printed size
+// of a CSS style sheet without changing its meaning.
+//
+// Each transformation is a pass which can be enabled individually. Passes
+// never reorder rules or declarations and only merge or remove them when
+// the result is the same under the cascade. The minified style sheet is
+// best printed with a compact css.Printer.
+package minify
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/benbjohnson/css"
+)
+
+// Pass represents a set of minification passes.
+type Pass int
+
+const (
+	// StripWhitespace removes insignificant whitespace and collapses the
+	// remaining whitespace to a single space.
+	StripWhitespace Pass = 1 << iota
+
+	// ShortenNumbers removes redundant zeros from numbers and units from
+	// zero lengths, such as 0.50 to .5 and 0px to 0.
+	ShortenNumbers
+
+	// ShortenColors replaces colors with their shortest equivalent, such
+	// as #ff0000 to red and rgb(255, 255, 255) to #fff.
+	ShortenColors
+
+	// RemoveEmptyRules removes rules with empty blocks.
+	RemoveEmptyRules
+
+	// RemoveDuplicates removes declarations that are repeated later in the
+	// same block with the same value and importance.
+	RemoveDuplicates
+
+	// RemoveOverridden removes declarations that are overridden by a later
+	// declaration of the same property in the same block. Declarations that
+	// may be fallbacks for older browsers are kept.
+	RemoveOverridden
+
+	// MergeRules merges adjacent rules with identical selectors or with
+	// identical declaration blocks.
+	MergeRules
+
+	// AllPasses enables every pass.
+	AllPasses = StripWhitespace | ShortenNumbers | ShortenColors | RemoveEmptyRules | RemoveDuplicates | RemoveOverridden | MergeRules
+)
+
+// Minify applies the passes to the style sheet in place.
+func Minify(ss *css.StyleSheet, passes Pass) {
+	m := &minifier{passes: passes}
+	ss.Rules = m.rules(ss.Rules, true)
+}
+
+// minifier holds the state for minifying a single style sheet.
+type minifier struct {
+	passes Pass
+}
+
+// enabled returns true if the pass is enabled.
+func (m *minifier) enabled(pass Pass) bool { return m.passes&pass != 0 }
+
+// rules minifies a list of rules. Rules can only be merged if mergeable is
+// set, since rules in some contexts, such as keyframes, don't cascade.
+func (m *minifier) rules(a css.Rules, mergeable bool) css.Rules {
+	var other css.Rules
+	for _, r := range a {
+		switch r := r.(type) {
+		case *css.QualifiedRule:
+			if !m.qualifiedRule(r) {
+				continue
+			}
+		case *css.AtRule:
+			if !m.atRule(r) {
+				continue
+			}
+		}
+
+		// Merge with the previous rule, if possible.
+		if mergeable && m.enabled(MergeRules) && len(other) > 0 && m.merge(other[len(other)-1], r) {
+			continue
+		}
+		other = append(other, r)
+	}
+	return other
+}
+
+// qualifiedRule minifies a qualified rule. Returns false if it should be removed.
+func (m *minifier) qualifiedRule(r *css.QualifiedRule) bool {
+	if m.enabled(StripWhitespace) {
+		r.Prelude = m.whitespace(r.Prelude, selectorContext)
+	}
+	if r.Block == nil {
+		return true
+	}
+
+	decls, ok := parseDeclarations(r.Block)
+	if !ok {
+		return true
+	}
+	decls = m.declarations(decls)
+	if m.enabled(RemoveEmptyRules) && len(decls) == 0 {
+		return false
+	}
+	r.Block.Values = m.declarationValues(decls)
+	return true
+}
+
+// atRule minifies an at-rule. Returns false if it should be removed.
+func (m *minifier) atRule(r *css.AtRule) bool {
+	if m.enabled(StripWhitespace) {
+		r.Prelude = m.whitespace(r.Prelude, atRuleContext)
+
+		// A space is required between the at-keyword and an ident-like prelude.
+		if len(r.Prelude) > 0 && isWord(r.Prelude[0]) {
+			r.Prelude = append(css.ComponentValues{space()}, r.Prelude...)
+		}
+	}
+	if r.Block == nil {
+		return true
+	}
+
+	name := strings.ToLower(r.Name)
+	switch {
+	case css.IsGroupingRule(name):
+		var p css.Parser
+		rules := p.ConsumeRules(css.NewComponentValueScanner(r.Block.Values), false)
+		if len(p.Errors) > 0 {
+			return true
+		}
+		rules = m.rules(rules, true)
+
+		// Empty layers are kept since they establish the layer order.
+		if m.enabled(RemoveEmptyRules) && len(rules) == 0 && name != "layer" {
+			return false
+		}
+		r.Block.Values = ruleValues(rules)
+
+	case strings.HasSuffix(name, "keyframes"):
+		var p css.Parser
+		rules := p.ConsumeRules(css.NewComponentValueScanner(r.Block.Values), false)
+		if len(p.Errors) > 0 {
+			return true
+		}
+		r.Block.Values = ruleValues(m.rules(rules, false))
+
+	case css.IsDeclarationRule(name):
+		decls, ok := parseDeclarations(r.Block)
+		if !ok {
+			return true
+		}
+		r.Block.Values = m.declarationValues(m.declarations(decls))
+	}
+	return true
+}
+
+// merge merges r into prev if they are both qualified rules with the same
+// selectors or the same declarations. Returns true if the rules were merged.
+func (m *minifier) merge(prev, r css.Rule) bool {
+	a, ok := prev.(*css.QualifiedRule)
+	if !ok || a.Block == nil {
+		return false
+	}
+	b, ok := r.(*css.QualifiedRule)
+	if !ok || b.Block == nil {
+		return false
+	}
+
+	// Rules with the same selectors can be combined into a single block.
+	if print(a.Prelude) == print(b.Prelude) {
+		adecls, ok := parseDeclarations(a.Block)
+		if !ok {
+			return false
+		}
+		bdecls, ok := parseDeclarations(b.Block)
+		if !ok {
+			return false
+		}
+		a.Block.Values = m.declarationValues(m.declarations(append(adecls, bdecls...)))
+		return true
+	}
+
+	// Rules with the same declarations can be combined into a selector list.
+	// Browsers drop the entire rule if they don't support one selector so
+	// only selectors which every browser supports are combined.
+	if print(a.Block) == print(b.Block) && isSupported(a.Prelude) && isSupported(b.Prelude) {
+		a.Prelude = append(append(a.Prelude, &css.Token{Tok: css.CommaToken}), b.Prelude...)
+		return true
+	}
+	return false
+}
+
+// declarations minifies a list of declarations.
+func (m *minifier) declarations(a css.Declarations) css.Declarations {
+	for _, n := range a {
+		d, ok := n.(*css.Declaration)
+		if !ok {
+			continue
+		}
+
+		// Custom property values are only trimmed since they may be used anywhere.
+		custom := strings.HasPrefix(d.Name, "--")
+		if m.enabled(StripWhitespace) {
+			if custom {
+				d.Values = trimWhitespace(d.Values)
+			} else {
+				d.Values = m.whitespace(d.Values, valueContext)
+			}
+		}
+		if m.enabled(ShortenNumbers) && !custom {
+			d.Values = shortenNumbers(d.Values, d.Name, false)
+		}
+		if m.enabled(ShortenColors) && !custom {
+			d.Values = shortenColors(d.Values, d.Name)
+		}
+	}
+
+	if m.enabled(RemoveDuplicates) {
+		a = removeDuplicates(a)
+	}
+	if m.enabled(RemoveOverridden) {
+		a = removeOverridden(a)
+	}
+	return a
+}
+
+// declarationValues converts declarations into the component values of a
+// block. The final semicolon is omitted when stripping whitespace.
+func (m *minifier) declarationValues(a css.Declarations) css.ComponentValues {
+	var values css.ComponentValues
+	for i, n := range a {
+		if i > 0 && !m.enabled(StripWhitespace) {
+			values = append(values, space())
+		}
+
+		switch n := n.(type) {
+		case *css.Declaration:
+			values = append(values, &css.Token{Tok: css.IdentToken, Value: n.Name}, &css.Token{Tok: css.ColonToken})
+			values = append(values, n.Values...)
+			if n.Important {
+				values = append(values, &css.Token{Tok: css.DelimToken, Value: "!"}, &css.Token{Tok: css.IdentToken, Value: "important"})
+			}
+			if i < len(a)-1 || !m.enabled(StripWhitespace) {
+				values = append(values, &css.Token{Tok: css.SemicolonToken})
+			}
+		case *css.AtRule:
+			m.atRule(n)
+			values = append(values, ruleValues(css.Rules{n})...)
+		}
+	}
+	return values
+}
+
+// removeDuplicates removes declarations which are repeated later in the list
+// with the same name, value and importance.
+func removeDuplicates(a css.Declarations) css.Declarations {
+	var other css.Declarations
+	for i, n := range a {
+		if d, ok := n.(*css.Declaration); ok && indexDeclaration(a[i+1:], func(x *css.Declaration) bool {
+			return strings.EqualFold(x.Name, d.Name) && x.Important == d.Important && print(x.Values) == print(d.Values)
+		}) != -1 {
+			continue
+		}
+		other = append(other, n)
+	}
+	return other
+}
+
+// removeOverridden removes declarations which are overridden by a later
+// declaration of the same property. The later declaration must be valid and
+// neither can use vendor prefixes or var() since those are often used with
+// fallbacks for browsers that don't support them. Both must also use the
+// same keywords, functions and units, otherwise the earlier one may be a
+// fallback for browsers that don't support the later one.
+func removeOverridden(a css.Declarations) css.Declarations {
+	var other css.Declarations
+	for i, n := range a {
+		if d, ok := n.(*css.Declaration); ok && !isFallback(d) && indexDeclaration(a[i+1:], func(x *css.Declaration) bool {
+			return strings.EqualFold(x.Name, d.Name) && (x.Important || !d.Important) && !isFallback(x) &&
+				css.Validate(x) == nil && features(x.Values) == features(d.Values)
+		}) != -1 {
+			continue
+		}
+		other = append(other, n)
+	}
+	return other
+}
+
+// isFallback returns true if a declaration may be part of a fallback pattern.
+func isFallback(d *css.Declaration) bool {
+	return strings.HasPrefix(d.Name, "--") || hasVendorPrefix(d.Values) || containsFunction(d.Values, "var", "env")
+}
+
+// features returns the sorted keywords, functions and units used in values.
+// Named colors are supported everywhere so they aren't included.
+func features(values css.ComponentValues) string {
+	m := make(map[string]bool)
+	var fn func(css.ComponentValues)
+	fn = func(values css.ComponentValues) {
+		for _, v := range values {
+			switch v := v.(type) {
+			case *css.Token:
+				switch v.Tok {
+				case css.IdentToken:
+					if name := strings.ToLower(v.Value); css.NamedColors[name] == "" {
+						m[name] = true
+					}
+				case css.DimensionToken:
+					m["<"+strings.ToLower(v.Unit)+">"] = true
+				}
+			case *css.Function:
+				m[strings.ToLower(v.Name)+"()"] = true
+				fn(v.Values)
+			case *css.SimpleBlock:
+				fn(v.Values)
+			}
+		}
+	}
+	fn(values)
+
+	a := make([]string, 0, len(m))
+	for k := range m {
+		a = append(a, k)
+	}
+	sort.Strings(a)
+	return strings.Join(a, " ")
+}
+
+// indexDeclaration returns the index of the first declaration matching fn.
+func indexDeclaration(a css.Declarations, fn func(*css.Declaration) bool) int {
+	for i, n := range a {
+		if d, ok := n.(*css.Declaration); ok && fn(d) {
+			return i
+		}
+	}
+	return -1
+}
+
+// whitespaceContext represents the kind of component values being minified
+// since whitespace is significant in different places in each.
+type whitespaceContext int
+
+const (
+	selectorContext whitespaceContext = iota
+	atRuleContext
+	valueContext
+)
+
+// whitespace removes insignificant whitespace from values and collapses the
+// remaining whitespace into a single space.
+func (m *minifier) whitespace(values css.ComponentValues, ctx whitespaceContext) css.ComponentValues {
+	var other css.ComponentValues
+	for i, v := range values {
+		switch v := v.(type) {
+		case *css.Token:
+			if v.Tok == css.WhitespaceToken {
+				var prev css.ComponentValue
+				if len(other) > 0 {
+					prev = other[len(other)-1]
+				}
+				next := nextNonWhitespace(values[i+1:])
+				if prev == nil || next == nil || isSpace(prev) || isSeparator(prev, ctx) || isSeparator(next, ctx) {
+					continue
+				}
+				other = append(other, space())
+				continue
+			}
+		case *css.Function:
+			v.Values = m.whitespace(v.Values, valueContext)
+		case *css.SimpleBlock:
+			v.Values = m.whitespace(v.Values, ctx)
+		}
+		other = append(other, v)
+	}
+
+	// Remove a trailing space left by skipped values.
+	if n := len(other); n > 0 && isSpace(other[n-1]) {
+		other = other[:n-1]
+	}
+	return other
+}
+
+// trimWhitespace removes leading and trailing whitespace from values.
+func trimWhitespace(values css.ComponentValues) css.ComponentValues {
+	for len(values) > 0 && isWhitespace(values[0]) {
+		values = values[1:]
+	}
+	for len(values) > 0 && isWhitespace(values[len(values)-1]) {
+		values = values[:len(values)-1]
+	}
+	return values
+}
+
+// isSeparator returns true if whitespace next to v is insignificant.
+func isSeparator(v css.ComponentValue, ctx whitespaceContext) bool {
+	tok, ok := v.(*css.Token)
+	if !ok {
+		return false
+	}
+	switch tok.Tok {
+	case css.CommaToken, css.SemicolonToken:
+		return true
+	case css.ColonToken:
+		return ctx != selectorContext
+	case css.ColumnToken:
+		return ctx == selectorContext
+	case css.DelimToken:
+		switch ctx {
+		case selectorContext:
+			return tok.Value == ">" || tok.Value == "+" || tok.Value == "~"
+		case valueContext:
+			return tok.Value == "/" || tok.Value == "!"
+		}
+	}
+	return false
+}
+
+// isWord returns true if v would join with a preceding identifier.
+func isWord(v css.ComponentValue) bool {
+	switch v := v.(type) {
+	case *css.Function:
+		return true
+	case *css.Token:
+		switch v.Tok {
+		case css.IdentToken, css.NumberToken, css.PercentageToken, css.DimensionToken, css.HashToken,
+			css.URLToken, css.BadURLToken, css.UnicodeRangeToken, css.AtKeywordToken, css.DelimToken:
+			return true
+		}
+	}
+	return false
+}
+
+// nextNonWhitespace returns the first value which is not whitespace.
+func nextNonWhitespace(values css.ComponentValues) css.ComponentValue {
+	for _, v := range values {
+		if !isWhitespace(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// spaceToken is the single space used for collapsed whitespace.
+var spaceToken = &css.Token{Tok: css.WhitespaceToken, Value: " "}
+
+// space returns a single space token.
+func space() css.ComponentValue { return spaceToken }
+
+// isSpace returns true if v is the collapsed whitespace token.
+func isSpace(v css.ComponentValue) bool { return v == css.ComponentValue(spaceToken) }
+
+// isWhitespace returns true if v is a whitespace token.
+func isWhitespace(v css.ComponentValue) bool {
+	tok, ok := v.(*css.Token)
+	return ok && tok.Tok == css.WhitespaceToken
+}
+
+// shortenNumbers removes redundant zeros from numeric tokens. Units are
+// removed from zero lengths except inside math functions, where they are
+// required, and in the flex shorthand, where a unitless zero is a factor.
+func shortenNumbers(values css.ComponentValues, name string, math bool) css.ComponentValues {
+	for _, v := range values {
+		switch v := v.(type) {
+		case *css.Token:
+			switch v.Tok {
+			case css.NumberToken, css.PercentageToken, css.DimensionToken:
+				num, unit := splitNumber(v.Value)
+				num = shortenNumber(num)
+				if v.Tok == css.DimensionToken && v.Number == 0 && !math && isLengthUnit(unit) && !isFlex(name) {
+					v.Tok, v.Value, v.Unit, v.Type = css.NumberToken, "0", "", "integer"
+					continue
+				}
+				v.Value = num + unit
+			}
+		case *css.Function:
+			v.Values = shortenNumbers(v.Values, name, math || isMathFunction(v.Name))
+		case *css.SimpleBlock:
+			v.Values = shortenNumbers(v.Values, name, math)
+		}
+	}
+	return values
+}
+
+// splitNumber splits the text of a numeric token into its number and unit.
+func splitNumber(s string) (num, unit string) {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+
+	// Include an exponent if it is followed by digits.
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+	return s[:i], s[i:]
+}
+
+// shortenNumber removes leading and trailing zeros from a number.
+// Numbers with exponents are returned as is.
+func shortenNumber(s string) string {
+	if strings.ContainsAny(s, "eE") {
+		return s
+	}
+
+	var sign string
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	intpart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i != -1 {
+		intpart, frac = s[:i], strings.TrimRight(s[i+1:], "0")
+	}
+	intpart = strings.TrimLeft(intpart, "0")
+
+	if frac != "" {
+		return sign + intpart + "." + frac
+	} else if intpart == "" {
+		return "0"
+	}
+	return sign + intpart
+}
+
+// lengthUnits are the units which can be omitted from a zero length.
+var lengthUnits = []string{
+	"px", "cm", "mm", "q", "in", "pc", "pt",
+	"em", "rem", "ex", "rex", "cap", "rcap", "ch", "rch", "ic", "ric", "lh", "rlh",
+	"vw", "vh", "vi", "vb", "vmin", "vmax",
+}
+
+// isLengthUnit returns true if the unit is a length unit.
+func isLengthUnit(unit string) bool { return containsString(lengthUnits, strings.ToLower(unit)) }
+
+// isFlex returns true if the property is the flex shorthand or one of its aliases.
+func isFlex(name string) bool {
+	p := css.LookupProperty(name)
+	return p != nil && p.Name == "flex"
+}
+
+// isMathFunction returns true if the function name is a math function.
+func isMathFunction(name string) bool {
+	switch strings.ToLower(name) {
+	case "calc", "min", "max", "clamp", "round", "mod", "rem", "sin", "cos", "tan",
+		"asin", "acos", "atan", "atan2", "pow", "sqrt", "hypot", "log", "exp", "abs", "sign":
+		return true
+	}
+	return false
+}
+
+// shortenColors replaces hex colors and rgb() functions with their shortest
+// form. Named colors are only replaced in properties whose value is a single
+// color since identifiers such as "red" can be names in other properties.
+func shortenColors(values css.ComponentValues, name string) css.ComponentValues {
+	colorProperty := false
+	if p := css.LookupProperty(name); p != nil && p.Syntax == "<color>" {
+		colorProperty = true
+	}
+
+	for i, v := range values {
+		switch v := v.(type) {
+		case *css.Token:
+			switch v.Tok {
+			case css.HashToken:
+				if hex, ok := normalizeHex(v.Value); ok {
+					values[i] = colorToken(hex, v.Pos)
+				}
+			case css.IdentToken:
+				if hex, ok := css.NamedColors[strings.ToLower(v.Value)]; ok && colorProperty {
+					if tok := colorToken(hex[1:], v.Pos); len(print(tok)) < len(v.Value) {
+						values[i] = tok
+					}
+				}
+			}
+		case *css.Function:
+			if hex, ok := rgbHex(v); ok {
+				values[i] = colorToken(hex, v.Pos)
+			} else {
+				v.Values = shortenColors(v.Values, "")
+			}
+		case *css.SimpleBlock:
+			v.Values = shortenColors(v.Values, "")
+		}
+	}
+	return values
+}
+
+// colorToken returns the shortest token for a 6 or 8 digit lowercase hex
+// color, which is either a short hex color or a named color.
+func colorToken(hex string, pos css.Pos) *css.Token {
+	short := hex
+	if len(hex) == 6 && hex[0] == hex[1] && hex[2] == hex[3] && hex[4] == hex[5] {
+		short = string([]byte{hex[0], hex[2], hex[4]})
+	} else if len(hex) == 8 && hex[0] == hex[1] && hex[2] == hex[3] && hex[4] == hex[5] && hex[6] == hex[7] {
+		short = string([]byte{hex[0], hex[2], hex[4], hex[6]})
+	}
+
+	if name := colorNames["#"+hex]; name != "" && len(name) < len(short)+1 {
+		return &css.Token{Tok: css.IdentToken, Value: name, Pos: pos}
+	}
+	return &css.Token{Tok: css.HashToken, Value: short, Type: "unrestricted", Pos: pos}
+}
+
+// normalizeHex returns a hex color expanded to 6 or 8 lowercase digits.
+func normalizeHex(s string) (string, bool) {
+	s = strings.ToLower(s)
+	for _, ch := range s {
+		if !(ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'f') {
+			return "", false
+		}
+	}
+	switch len(s) {
+	case 3, 4:
+		var buf bytes.Buffer
+		for i := 0; i < len(s); i++ {
+			buf.WriteByte(s[i])
+			buf.WriteByte(s[i])
+		}
+		s = buf.String()
+	case 6, 8:
+	default:
+		return "", false
+	}
+
+	// Drop a fully opaque alpha channel.
+	if len(s) == 8 && s[6:] == "ff" {
+		s = s[:6]
+	}
+	return s, true
+}
+
+// rgbHex returns the hex digits for an rgb() or rgba() function with
+// integer channels and an optional opaque alpha.
+func rgbHex(fn *css.Function) (string, bool) {
+	switch strings.ToLower(fn.Name) {
+	case "rgb", "rgba":
+	default:
+		return "", false
+	}
+
+	// Collect the arguments, which may be separated by commas or spaces.
+	var args []*css.Token
+	for _, v := range fn.Values {
+		tok, ok := v.(*css.Token)
+		if !ok {
+			return "", false
+		}
+		switch tok.Tok {
+		case css.WhitespaceToken, css.CommaToken:
+		case css.DelimToken:
+			if tok.Value != "/" {
+				return "", false
+			}
+		case css.NumberToken, css.PercentageToken:
+			args = append(args, tok)
+		default:
+			return "", false
+		}
+	}
+
+	if len(args) == 4 {
+		if alpha := args[3]; !(alpha.Tok == css.NumberToken && alpha.Number == 1) && !(alpha.Tok == css.PercentageToken && alpha.Number == 100) {
+			return "", false
+		}
+		args = args[:3]
+	} else if len(args) != 3 {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	for _, arg := range args {
+		if arg.Tok != css.NumberToken || arg.Type != "integer" || arg.Number < 0 || arg.Number > 255 {
+			return "", false
+		}
+		s := strconv.FormatInt(int64(arg.Number), 16)
+		if len(s) == 1 {
+			s = "0" + s
+		}
+		buf.WriteString(s)
+	}
+	return buf.String(), true
+}
+
+// colorNames maps hex colors to their shortest color name.
+var colorNames = make(map[string]string)
+
+func init() {
+	for name, hex := range css.NamedColors {
+		if other, ok := colorNames[hex]; !ok || len(name) < len(other) || (len(name) == len(other) && name < other) {
+			colorNames[hex] = name
+		}
+	}
+}
+
+// parseDeclarations parses the declarations in a block. Returns false if
+// the block contains anything other than declarations and at-rules, such as
+// nested rules, since those would be lost by rewriting the block.
+func parseDeclarations(b *css.SimpleBlock) (css.Declarations, bool) {
+	var p css.Parser
+	a := p.ConsumeDeclarations(css.NewComponentValueScanner(b.Values))
+	return a, len(p.Errors) == 0
+}
+
+// ruleValues converts a list of rules into component values.
+func ruleValues(a css.Rules) css.ComponentValues {
+	var values css.ComponentValues
+	for _, r := range a {
+		switch r := r.(type) {
+		case *css.QualifiedRule:
+			values = append(values, r.Prelude...)
+			values = append(values, r.Block)
+		case *css.AtRule:
+			values = append(values, &css.Token{Tok: css.AtKeywordToken, Value: r.Name})
+			values = append(values, r.Prelude...)
+			if r.Block != nil {
+				values = append(values, r.Block)
+			} else {
+				values = append(values, &css.Token{Tok: css.SemicolonToken})
+			}
+		}
+	}
+	return values
+}
+
+// css2Pseudos are the pseudo-classes and pseudo-elements from CSS 2, which
+// every browser supports when written with a single colon.
+var css2Pseudos = []string{"link", "visited", "hover", "active", "focus", "first-child", "first-letter", "first-line", "before", "after"}
+
+// isSupported returns true if every browser supports a selector. Selectors
+// with functions or with pseudo-classes and pseudo-elements added after
+// CSS 2 may not be.
+func isSupported(prelude css.ComponentValues) bool {
+	for i, v := range prelude {
+		switch v := v.(type) {
+		case *css.Function:
+			return false
+		case *css.Token:
+			if v.Tok != css.ColonToken {
+				continue
+			} else if i+1 == len(prelude) {
+				return false
+			}
+			next, ok := prelude[i+1].(*css.Token)
+			if !ok || next.Tok != css.IdentToken || !containsString(css2Pseudos, strings.ToLower(next.Value)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hasVendorPrefix returns true if any identifier or function in values has
+// a vendor prefix, such as -webkit-.
+func hasVendorPrefix(values css.ComponentValues) bool {
+	for _, v := range values {
+		switch v := v.(type) {
+		case *css.Token:
+			if v.Tok == css.IdentToken && isVendorPrefixed(v.Value) {
+				return true
+			}
+		case *css.Function:
+			if isVendorPrefixed(v.Name) || hasVendorPrefix(v.Values) {
+				return true
+			}
+		case *css.SimpleBlock:
+			if hasVendorPrefix(v.Values) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isVendorPrefixed returns true if the name starts with a vendor prefix.
+func isVendorPrefixed(s string) bool {
+	s = strings.ToLower(s)
+	for _, prefix := range []string{"-webkit-", "-moz-", "-ms-", "-o-"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFunction returns true if any value or nested value is a function
+// with one of the given names.
+func containsFunction(values css.ComponentValues, names ...string) bool {
+	for _, v := range values {
+		switch v := v.(type) {
+		case *css.Function:
+			if containsString(names, strings.ToLower(v.Name)) || containsFunction(v.Values, names...) {
+				return true
+			}
+		case *css.SimpleBlock:
+			if containsFunction(v.Values, names...) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsString returns true if a contains s.
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// print returns the printed form of a node.
+func print(n css.Node) string {
+	var buf bytes.Buffer
+	var p css.Printer
+	_ = p.Print(&buf, n)
+	return buf.String()
+}
@@ -0,0 +1,101 @@
+package minify_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+	"github.com/benbjohnson/css/minify"
+)
+
+// Ensure that each pass minifies style sheets correctly.
+func TestMinify(t *testing.T) {
+	var tests = []struct {
+		passes minify.Pass
+		in     string
+		out    string
+	}{
+		// Whitespace.
+		{passes: minify.StripWhitespace, in: `a  >  b ,  c   d { color : red ;  margin : 1px  2px ; }`, out: `a>b,c d{color:red;margin:1px 2px}`},
+		{passes: minify.StripWhitespace, in: `a { font : 12px / 1.5 a , b !important }`, out: `a{font:12px/1.5 a,b!important}`},
+		{passes: minify.StripWhitespace, in: `a:hover , a :first-child { x : y }`, out: `a:hover,a :first-child{x:y}`},
+		{passes: minify.StripWhitespace, in: `@media  screen  and ( min-width : 100px ) , print { a { x : y } }`, out: `@media screen and (min-width:100px),print{a{x:y}}`},
+		{passes: minify.StripWhitespace, in: `@media (color) { a { x : y } }`, out: `@media(color){a{x:y}}`},
+		{passes: minify.StripWhitespace, in: `@import  url(a.css)  screen ;`, out: `@import url(a.css) screen;`},
+		{passes: minify.StripWhitespace, in: `a { width : calc( 100%  -  10px ) }`, out: `a{width:calc(100% - 10px)}`},
+		{passes: minify.StripWhitespace, in: `a { --x :  a  b  }`, out: `a{--x:a  b}`},
+
+		// Numbers.
+		{passes: minify.ShortenNumbers, in: `a{opacity:0.50}`, out: `a{opacity:.5;}`},
+		{passes: minify.ShortenNumbers, in: `a{margin:0px -0.10em 010px 1.0%}`, out: `a{margin:0 -.1em 10px 1%;}`},
+		{passes: minify.ShortenNumbers, in: `a{transition:opacity 0s}`, out: `a{transition:opacity 0s;}`},
+		{passes: minify.ShortenNumbers, in: `a{width:calc(0px + 1.50em)}`, out: `a{width:calc(0px + 1.5em);}`},
+		{passes: minify.ShortenNumbers, in: `a{flex:1 1 0px}`, out: `a{flex:1 1 0px;}`},
+		{passes: minify.ShortenNumbers, in: `a{line-height:1e3}`, out: `a{line-height:1e3;}`},
+		{passes: minify.ShortenNumbers, in: `a{--x:0px}`, out: `a{--x:0px;}`},
+
+		// Colors.
+		{passes: minify.ShortenColors, in: `a{color:#FFFFFF}`, out: `a{color:#fff;}`},
+		{passes: minify.ShortenColors, in: `a{color:#ff0000}`, out: `a{color:red;}`},
+		{passes: minify.ShortenColors, in: `a{color:#11223344}`, out: `a{color:#1234;}`},
+		{passes: minify.ShortenColors, in: `a{color:#123456ff}`, out: `a{color:#123456;}`},
+		{passes: minify.ShortenColors, in: `a{color:rgb(255, 255, 255)}`, out: `a{color:#fff;}`},
+		{passes: minify.ShortenColors, in: `a{color:rgba(0,128,0,1)}`, out: `a{color:green;}`},
+		{passes: minify.ShortenColors, in: `a{color:rgba(0,0,0,.5)}`, out: `a{color:rgba(0,0,0,.5);}`},
+		{passes: minify.ShortenColors, in: `a{color:rgb(10%,0,0)}`, out: `a{color:rgb(10%,0,0);}`},
+		{passes: minify.ShortenColors, in: `a{color:lightgoldenrodyellow}`, out: `a{color:#fafad2;}`},
+		{passes: minify.ShortenColors, in: `a{animation-name:lightgoldenrodyellow}`, out: `a{animation-name:lightgoldenrodyellow;}`},
+		{passes: minify.ShortenColors, in: `a{border:1px solid #FF0000}`, out: `a{border:1px solid red;}`},
+
+		// Empty rules.
+		{passes: minify.RemoveEmptyRules, in: `a{} b{x:y} @media print{c{}} @layer base{} @font-face{}`, out: `b{x:y;} @layer base{} @font-face{}`},
+
+		// Duplicate and overridden declarations.
+		{passes: minify.RemoveDuplicates, in: `a{color:red;margin:0;color:red}`, out: `a{margin:0; color:red;}`},
+		{passes: minify.RemoveDuplicates, in: `a{color:red;color:blue}`, out: `a{color:red; color:blue;}`},
+		{passes: minify.RemoveOverridden, in: `a{color:red;margin:0;color:blue}`, out: `a{margin:0; color:blue;}`},
+		{passes: minify.RemoveOverridden, in: `a{color:red!important;color:blue}`, out: `a{color:red!important; color:blue;}`},
+		{passes: minify.RemoveOverridden, in: `a{color:red;color:blue!important}`, out: `a{color:blue!important;}`},
+		{passes: minify.RemoveOverridden, in: `a{display:flex;display:-webkit-box}`, out: `a{display:flex; display:-webkit-box;}`},
+		{passes: minify.RemoveOverridden, in: `a{color:red;color:var(--c)}`, out: `a{color:red; color:var(--c);}`},
+		{passes: minify.RemoveOverridden, in: `a{color:red;color:nope}`, out: `a{color:red; color:nope;}`},
+		{passes: minify.RemoveOverridden, in: `a{display:flex;display:grid}`, out: `a{display:flex; display:grid;}`},
+		{passes: minify.RemoveOverridden, in: `a{background:red;background:linear-gradient(red,blue)}`, out: `a{background:red; background:linear-gradient(red,blue);}`},
+		{passes: minify.RemoveOverridden, in: `a{height:100vh;height:100dvh}`, out: `a{height:100vh; height:100dvh;}`},
+		{passes: minify.RemoveOverridden, in: `a{margin:1px auto;margin:2px AUTO}`, out: `a{margin:2px AUTO;}`},
+		{passes: minify.RemoveOverridden, in: `a{background:linear-gradient(red,blue);background:linear-gradient(red,green)}`, out: `a{background:linear-gradient(red,green);}`},
+
+		// Merging rules.
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `a{color:red} a{margin:0}`, out: `a{color:red;margin:0}`},
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `a{color:red} b{color:red}`, out: `a,b{color:red}`},
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `a{color:red} c{x:y} a{color:red}`, out: `a{color:red}c{x:y}a{color:red}`},
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `a::-moz-selection{color:red} a::selection{color:red}`, out: `a::-moz-selection{color:red}a::selection{color:red}`},
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `a:focus-visible{x:y} b{x:y}`, out: `a:focus-visible{x:y}b{x:y}`},
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `a:not(.c){x:y} b{x:y}`, out: `a:not(.c){x:y}b{x:y}`},
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `a::before{x:y} b{x:y}`, out: `a::before{x:y}b{x:y}`},
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `a:hover{x:y} b[c]{x:y}`, out: `a:hover,b[c]{x:y}`},
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `@keyframes x{from{top:0} from{left:0}}`, out: `@keyframes x{from{top:0}from{left:0}}`},
+		{passes: minify.MergeRules | minify.StripWhitespace, in: `@media print{a{x:y} b{x:y}}`, out: `@media print{a,b{x:y}}`},
+
+		// All passes.
+		{passes: minify.AllPasses, in: "a {\n  color: #FF0000;\n  margin: 0px 0.50em;\n}\nb {}\na { color: blue }\n", out: `a{margin:0 .5em;color:blue}`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		ss := p.ParseStyleSheet(css.NewScanner(strings.NewReader(tt.in)))
+		if len(p.Errors) > 0 {
+			t.Fatalf("%d. %s", i, p.Errors[0])
+		}
+		minify.Minify(ss, tt.passes)
+
+		var buf bytes.Buffer
+		printer := css.Printer{Compact: tt.passes&minify.StripWhitespace != 0}
+		if err := printer.Print(&buf, ss); err != nil {
+			t.Fatal(err)
+		} else if s := buf.String(); s != tt.out {
+			t.Errorf("%d. <%q>\n\nexp: %s\n\ngot: %s", i, tt.in, tt.out, s)
+		}
+	}
+}
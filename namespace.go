@@ -0,0 +1,116 @@
+package css
+
+import "strings"
+
+// NamespaceRule represents a parsed @namespace rule. (css-namespaces-3 §2)
+// The prefix is blank when the rule declares the default namespace.
+type NamespaceRule struct {
+	Prefix string
+	URI    string
+	Pos    Pos
+}
+
+// Namespaces represents the namespace prefixes declared in a style sheet.
+type Namespaces struct {
+	// The default namespace URI, if one is declared.
+	Default    string
+	HasDefault bool
+
+	// Mapping of declared prefixes to their namespace URIs.
+	// Prefixes are case-sensitive.
+	Prefixes map[string]string
+}
+
+// NewNamespaces returns an empty set of namespaces.
+func NewNamespaces() *Namespaces {
+	return &Namespaces{Prefixes: make(map[string]string)}
+}
+
+// Add declares the prefix or default namespace from a namespace rule.
+// If a prefix is declared more than once then the last declaration is used.
+func (ns *Namespaces) Add(r *NamespaceRule) {
+	if r.Prefix == "" {
+		ns.Default, ns.HasDefault = r.URI, true
+		return
+	}
+	ns.Prefixes[r.Prefix] = r.URI
+}
+
+// Lookup returns the namespace URI for a prefix and whether it was declared.
+func (ns *Namespaces) Lookup(prefix string) (string, bool) {
+	if ns == nil {
+		return "", false
+	}
+	uri, ok := ns.Prefixes[prefix]
+	return uri, ok
+}
+
+// ParseNamespaceRule parses an @namespace at-rule.
+// Returns nil if the at-rule is not an @namespace rule or if it is invalid.
+func (p *Parser) ParseNamespaceRule(r *AtRule) *NamespaceRule {
+	if !strings.EqualFold(r.Name, "namespace") {
+		p.error(newError("expected-at-rule", r, "expected @namespace, got @%s", r.Name))
+		return nil
+	} else if r.Block != nil {
+		p.error(newError("unexpected-block", r.Block, "unexpected block in @namespace"))
+		return nil
+	}
+
+	nr := &NamespaceRule{Pos: r.Pos}
+	a := r.Prelude.nonwhitespace()
+
+	// Read the optional prefix.
+	if len(a) > 0 {
+		if tok, ok := a[0].(*Token); ok && tok.Tok == IdentToken {
+			nr.Prefix, a = tok.Value, a[1:]
+		}
+	}
+
+	// The prefix must be followed by exactly one string or url.
+	if len(a) == 0 {
+		p.error(newError("expected-namespace-uri", r, "expected namespace uri"))
+		return nil
+	} else if tok, ok := a[0].(*Token); !ok || (tok.Tok != StringToken && tok.Tok != URLToken) {
+		p.error(newError("expected-namespace-uri", a[0], "expected namespace uri, got %s", print(a[0])))
+		return nil
+	} else {
+		nr.URI = tok.Value
+	}
+	if len(a) > 1 {
+		p.error(newError("unexpected-token", a[1], "unexpected: %s", print(a[1])))
+		return nil
+	}
+
+	return nr
+}
+
+// ParseNamespaces builds the namespace map from the @namespace rules of a
+// style sheet. Rules must follow any @charset and @import rules and precede
+// all other rules. Misplaced or invalid @namespace rules are ignored.
+func (p *Parser) ParseNamespaces(ss *StyleSheet) *Namespaces {
+	ns := NewNamespaces()
+	var closed bool
+	for _, r := range ss.Rules {
+		r, ok := r.(*AtRule)
+		if !ok {
+			closed = true
+			continue
+		}
+
+		switch strings.ToLower(r.Name) {
+		case "charset", "import":
+			continue
+		case "namespace":
+			if closed {
+				p.error(newError("misplaced-namespace", r, "@namespace must precede all other rules"))
+				continue
+			}
+			if nr := p.ParseNamespaceRule(r); nr != nil {
+				ns.Add(nr)
+			}
+		default:
+			closed = true
+		}
+	}
+	return ns
+}
@@ -0,0 +1,113 @@
+package css_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that @namespace rules are collected into a namespace map.
+func TestParser_ParseNamespaces(t *testing.T) {
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScanner(strings.NewReader(`
+		@charset "utf-8";
+		@import "foo.css";
+		@namespace url(http://www.w3.org/1999/xhtml);
+		@namespace svg "http://www.w3.org/2000/svg";
+		@namespace svg url("http://www.w3.org/2000/svg#2");
+		circle { fill: red }
+		@namespace late "urn:late";
+	`)))
+
+	ns := p.ParseNamespaces(ss)
+	if len(p.Errors) != 1 || p.Errors[0].Error() != `@namespace must precede all other rules` {
+		t.Fatalf("unexpected errors: %v", p.Errors)
+	} else if !ns.HasDefault || ns.Default != "http://www.w3.org/1999/xhtml" {
+		t.Errorf("unexpected default namespace: %q", ns.Default)
+	} else if uri, ok := ns.Lookup("svg"); !ok || uri != "http://www.w3.org/2000/svg#2" {
+		t.Errorf("unexpected svg namespace: %q", uri)
+	} else if _, ok := ns.Lookup("late"); ok {
+		t.Errorf("expected misplaced namespace to be ignored")
+	} else if _, ok := ns.Lookup("SVG"); ok {
+		t.Errorf("expected prefixes to be case-sensitive")
+	}
+}
+
+// Ensure that invalid @namespace rules are reported.
+func TestParser_ParseNamespaceRule(t *testing.T) {
+	var tests = []struct {
+		in     string
+		prefix string
+		uri    string
+		err    string
+	}{
+		{in: `@namespace "urn:x";`, uri: "urn:x"},
+		{in: `@namespace x url(urn:x);`, prefix: "x", uri: "urn:x"},
+		{in: `@media x;`, err: `expected @namespace, got @media`},
+		{in: `@namespace x { }`, err: `unexpected block in @namespace`},
+		{in: `@namespace;`, err: `expected namespace uri`},
+		{in: `@namespace x;`, err: `expected namespace uri`},
+		{in: `@namespace x y;`, err: `expected namespace uri, got y`},
+		{in: `@namespace x "a" "b";`, err: `unexpected: "b"`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		r := p.ParseNamespaceRule(p.ParseRule(css.NewScanner(strings.NewReader(tt.in))).(*css.AtRule))
+		if tt.err != "" {
+			if len(p.Errors) == 0 || p.Errors[0].Error() != tt.err {
+				t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, p.Errors)
+			}
+		} else if len(p.Errors) > 0 {
+			t.Errorf("%d. <%q> unexpected error: %s", i, tt.in, p.Errors)
+		} else if r.Prefix != tt.prefix || r.URI != tt.uri {
+			t.Errorf("%d. <%q> unexpected rule: %#v", i, tt.in, r)
+		}
+	}
+}
+
+// Ensure that selector namespace prefixes are resolved against the declared namespaces.
+func TestParser_ParseSelectors_Namespaces(t *testing.T) {
+	var p css.Parser
+	ns := p.ParseNamespaces(p.ParseStyleSheet(css.NewScanner(strings.NewReader(
+		`@namespace "urn:html"; @namespace svg "urn:svg"; @namespace xlink "urn:xlink";`,
+	))))
+
+	a := p.ParseSelectors(p.ParseComponentValues(css.NewScanner(strings.NewReader(
+		`svg|circle, *|*, |p, div, a[xlink|href], [*|title], [class]`,
+	))), ns)
+	if len(p.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", p.Errors)
+	}
+
+	var tests = []struct {
+		ns  css.NamespacePrefix
+		exp css.NamespacePrefix
+	}{
+		{ns: a[0].Compounds[0].Type.Namespace, exp: css.NamespacePrefix{Prefix: "svg", HasPrefix: true, URI: "urn:svg"}},
+		{ns: a[1].Compounds[0].Type.Namespace, exp: css.NamespacePrefix{Prefix: "*", HasPrefix: true, AnyNamespace: true}},
+		{ns: a[2].Compounds[0].Type.Namespace, exp: css.NamespacePrefix{HasPrefix: true}},
+		{ns: a[3].Compounds[0].Type.Namespace, exp: css.NamespacePrefix{URI: "urn:html"}},
+		{ns: a[4].Compounds[0].Selectors[0].(*css.AttributeSelector).Namespace, exp: css.NamespacePrefix{Prefix: "xlink", HasPrefix: true, URI: "urn:xlink"}},
+		{ns: a[5].Compounds[0].Selectors[0].(*css.AttributeSelector).Namespace, exp: css.NamespacePrefix{Prefix: "*", HasPrefix: true, AnyNamespace: true}},
+		{ns: a[6].Compounds[0].Selectors[0].(*css.AttributeSelector).Namespace, exp: css.NamespacePrefix{}},
+	}
+	for i, tt := range tests {
+		if tt.ns != tt.exp {
+			t.Errorf("%d. namespace: exp=%#v, got=%#v", i, tt.exp, tt.ns)
+		}
+	}
+
+	// Unprefixed type selectors match any namespace without a default.
+	a = p.ParseSelectors(p.ParseComponentValues(css.NewScanner(strings.NewReader(`div`))), css.NewNamespaces())
+	if ns := a[0].Compounds[0].Type.Namespace; !ns.AnyNamespace {
+		t.Errorf("expected any namespace: %#v", ns)
+	}
+
+	// Undeclared prefixes inside of nested selectors are errors.
+	p.ParseSelectors(p.ParseComponentValues(css.NewScanner(strings.NewReader(`a:not(math|mi)`))), ns)
+	if len(p.Errors) != 1 || p.Errors[0].Error() != `undeclared namespace prefix: math` {
+		t.Errorf("unexpected errors: %v", p.Errors)
+	}
+}
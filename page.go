@@ -0,0 +1,312 @@
+package css
+
+import "strings"
+
+// PageRule represents a parsed @page rule. (css-page-3 §3)
+//
+// The prelude is parsed into a list of page selectors and the block is split
+// into the page's own declarations and its nested margin rules.
+type PageRule struct {
+	Selectors    []*PageSelector
+	Declarations []*Declaration
+	MarginRules  []*MarginRule
+	Pos          Pos
+}
+
+// MarginRule returns the margin rule with the given name, if one exists.
+// The name is matched case-insensitively and does not include the "@".
+func (r *PageRule) MarginRule(name string) *MarginRule {
+	for _, m := range r.MarginRules {
+		if strings.EqualFold(m.Name, name) {
+			return m
+		}
+	}
+	return nil
+}
+
+// SetMarginRule replaces the margin rule with the same name or appends it
+// if the page rule does not have one yet.
+func (r *PageRule) SetMarginRule(m *MarginRule) {
+	for i, other := range r.MarginRules {
+		if strings.EqualFold(other.Name, m.Name) {
+			r.MarginRules[i] = m
+			return
+		}
+	}
+	r.MarginRules = append(r.MarginRules, m)
+}
+
+// RemoveMarginRule removes the margin rule with the given name.
+func (r *PageRule) RemoveMarginRule(name string) {
+	for i, m := range r.MarginRules {
+		if strings.EqualFold(m.Name, name) {
+			r.MarginRules = append(r.MarginRules[:i], r.MarginRules[i+1:]...)
+			return
+		}
+	}
+}
+
+// AtRule converts the page rule back into a generic at-rule so that it can
+// be printed or inserted back into a style sheet.
+func (r *PageRule) AtRule() *AtRule {
+	a := &AtRule{Name: "page", Pos: r.Pos}
+
+	// Rebuild the selector list in the prelude.
+	for i, sel := range r.Selectors {
+		if i == 0 {
+			a.Prelude = append(a.Prelude, &Token{Tok: WhitespaceToken, Value: " "})
+		} else {
+			a.Prelude = append(a.Prelude, &Token{Tok: CommaToken}, &Token{Tok: WhitespaceToken, Value: " "})
+		}
+		if sel.Name != "" {
+			a.Prelude = append(a.Prelude, &Token{Tok: IdentToken, Value: sel.Name})
+		}
+		for _, name := range sel.PseudoPages {
+			a.Prelude = append(a.Prelude, &Token{Tok: ColonToken}, &Token{Tok: IdentToken, Value: name})
+		}
+	}
+	if len(a.Prelude) > 0 {
+		a.Prelude = append(a.Prelude, &Token{Tok: WhitespaceToken, Value: " "})
+	}
+
+	// Rebuild the block from the declarations and then the margin rules.
+	a.Block = &SimpleBlock{Token: &Token{Tok: LBraceToken}}
+	a.Block.Values = declarationBlockValues(r.Declarations)
+	for _, m := range r.MarginRules {
+		if len(a.Block.Values) > 0 {
+			a.Block.Values = append(a.Block.Values, &Token{Tok: WhitespaceToken, Value: " "})
+		}
+		a.Block.Values = append(a.Block.Values, m.AtRule().componentValues()...)
+	}
+
+	return a
+}
+
+// PageSelector represents a single selector in an @page prelude.
+// For example, "toc:first" has a name of "toc" and a pseudo-page of "first".
+type PageSelector struct {
+	Name        string
+	PseudoPages []string
+	Pos         Pos
+}
+
+// Specificity returns the specificity of the page selector. (css-page-3 §3.2)
+func (s *PageSelector) Specificity() PageSpecificity {
+	var spec PageSpecificity
+	if s.Name != "" {
+		spec[0] = 1
+	}
+	for _, name := range s.PseudoPages {
+		switch strings.ToLower(name) {
+		case "first", "blank":
+			spec[1]++
+		case "left", "right":
+			spec[2]++
+		}
+	}
+	return spec
+}
+
+// PageSpecificity represents the (f, g, h) specificity of a page selector.
+// The first element counts page names, the second counts :first and :blank
+// pseudo-pages, and the third counts :left and :right pseudo-pages.
+type PageSpecificity [3]int
+
+// Less returns true if the specificity is lower than other.
+func (a PageSpecificity) Less(other PageSpecificity) bool {
+	for i := range a {
+		if a[i] != other[i] {
+			return a[i] < other[i]
+		}
+	}
+	return false
+}
+
+// MarginRule represents a margin at-rule nested inside of an @page rule,
+// such as @top-left or @bottom-center.
+type MarginRule struct {
+	Name         string
+	Declarations []*Declaration
+	Pos          Pos
+}
+
+// AtRule converts the margin rule back into a generic at-rule.
+func (m *MarginRule) AtRule() *AtRule {
+	return &AtRule{
+		Name:    m.Name,
+		Prelude: ComponentValues{&Token{Tok: WhitespaceToken, Value: " "}},
+		Block:   &SimpleBlock{Token: &Token{Tok: LBraceToken}, Values: declarationBlockValues(m.Declarations)},
+		Pos:     m.Pos,
+	}
+}
+
+// MarginRuleNames is the list of valid margin at-rule names. (css-page-3 §4.2)
+var MarginRuleNames = []string{
+	"top-left-corner", "top-left", "top-center", "top-right", "top-right-corner",
+	"bottom-left-corner", "bottom-left", "bottom-center", "bottom-right", "bottom-right-corner",
+	"left-top", "left-middle", "left-bottom",
+	"right-top", "right-middle", "right-bottom",
+}
+
+// IsMarginRuleName returns true if name is a valid margin at-rule name.
+func IsMarginRuleName(name string) bool {
+	for _, v := range MarginRuleNames {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePageRule parses an @page at-rule into a page rule.
+// Returns nil if the at-rule is not an @page rule or its prelude is invalid.
+func (p *Parser) ParsePageRule(r *AtRule) *PageRule {
+	if !strings.EqualFold(r.Name, "page") {
+		p.error(newError("expected-at-rule", r, "expected @page, got @%s", r.Name))
+		return nil
+	}
+
+	pr := &PageRule{Pos: r.Pos}
+
+	// Parse the comma-separated page selectors from the prelude.
+	sels, ok := p.parsePageSelectors(r.Prelude)
+	if !ok {
+		return nil
+	}
+	pr.Selectors = sels
+
+	// A page rule without a block is treated as empty.
+	if r.Block == nil {
+		return pr
+	}
+
+	// Split the block into declarations and margin rules.
+	for _, n := range p.ConsumeDeclarations(NewComponentValueScanner(r.Block.Values)) {
+		switch n := n.(type) {
+		case *Declaration:
+			pr.Declarations = append(pr.Declarations, n)
+		case *AtRule:
+			if m := p.parseMarginRule(n); m != nil {
+				pr.MarginRules = append(pr.MarginRules, m)
+			}
+		}
+	}
+
+	return pr
+}
+
+// parsePageSelectors parses a comma-separated list of page selectors.
+func (p *Parser) parsePageSelectors(values ComponentValues) ([]*PageSelector, bool) {
+	var a []*PageSelector
+	s := NewComponentValueScanner(values)
+	for {
+		p.skipWhitespace(s)
+
+		// An empty prelude is a valid, selector-less page rule.
+		if tok, ok := s.Scan().(*Token); ok && tok.Tok == EOFToken {
+			if len(a) > 0 {
+				p.error(newError("expected-page-selector", tok, "expected page selector, got EOF"))
+				return nil, false
+			}
+			return a, true
+		}
+		s.Unscan()
+
+		sel := p.parsePageSelector(s)
+		if sel == nil {
+			return nil, false
+		}
+		a = append(a, sel)
+
+		// Selectors must be followed by a comma or the end of the prelude.
+		p.skipWhitespace(s)
+		switch v := s.Scan().(type) {
+		case *Token:
+			if v.Tok == EOFToken {
+				return a, true
+			} else if v.Tok == CommaToken {
+				continue
+			}
+		}
+		p.error(newError("expected-comma", s.Current(), "expected comma, got %s", print(s.Current())))
+		return nil, false
+	}
+}
+
+// parsePageSelector parses an optional page name and zero or more pseudo-pages.
+// The components of a selector cannot be separated by whitespace.
+func (p *Parser) parsePageSelector(s ComponentValueScanner) *PageSelector {
+	sel := &PageSelector{Pos: Position(s.Scan())}
+	s.Unscan()
+
+	if tok, ok := s.Scan().(*Token); ok && tok.Tok == IdentToken {
+		sel.Name = tok.Value
+	} else {
+		s.Unscan()
+	}
+
+	for {
+		if tok, ok := s.Scan().(*Token); !ok || tok.Tok != ColonToken {
+			s.Unscan()
+			break
+		}
+
+		tok, ok := s.Scan().(*Token)
+		if !ok || tok.Tok != IdentToken {
+			p.error(newError("expected-pseudo-page", s.Current(), "expected pseudo-page, got %s", print(s.Current())))
+			return nil
+		}
+		switch strings.ToLower(tok.Value) {
+		case "first", "left", "right", "blank":
+			sel.PseudoPages = append(sel.PseudoPages, tok.Value)
+		default:
+			p.error(newError("unknown-pseudo-page", tok, "unknown pseudo-page: %s", tok.Value))
+			return nil
+		}
+	}
+
+	// A selector must have at least a name or a pseudo-page.
+	if sel.Name == "" && len(sel.PseudoPages) == 0 {
+		p.error(newError("expected-page-selector", s.Current(), "expected page selector, got %s", print(s.Current())))
+		return nil
+	}
+	return sel
+}
+
+// parseMarginRule parses a margin at-rule found inside an @page block.
+func (p *Parser) parseMarginRule(r *AtRule) *MarginRule {
+	if !IsMarginRuleName(r.Name) {
+		p.error(newError("unexpected-at-rule", r, "unexpected at-rule in @page: @%s", r.Name))
+		return nil
+	} else if len(r.Prelude.nonwhitespace()) > 0 {
+		p.error(newError("unexpected-prelude", r.Prelude.nonwhitespace(), "unexpected prelude in @%s", r.Name))
+		return nil
+	}
+
+	m := &MarginRule{Name: r.Name, Pos: r.Pos}
+	if r.Block != nil {
+		for _, n := range p.ConsumeDeclarations(NewComponentValueScanner(r.Block.Values)) {
+			switch n := n.(type) {
+			case *Declaration:
+				m.Declarations = append(m.Declarations, n)
+			case *AtRule:
+				p.error(newError("unexpected-at-rule", n, "unexpected at-rule in @%s: @%s", r.Name, n.Name))
+			}
+		}
+	}
+	return m
+}
+
+// declarationBlockValues converts a list of declarations into the component
+// values of a {-block. Each declaration is terminated by a semicolon.
+func declarationBlockValues(a []*Declaration) ComponentValues {
+	var values ComponentValues
+	for i, d := range a {
+		if i > 0 {
+			values = append(values, &Token{Tok: WhitespaceToken, Value: " "})
+		}
+		values = append(values, d.componentValues()...)
+		values = append(values, &Token{Tok: SemicolonToken})
+	}
+	return values
+}
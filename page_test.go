@@ -0,0 +1,126 @@
+package css_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that @page rules can be parsed into a page model.
+func TestParser_ParsePageRule(t *testing.T) {
+	var tests = []struct {
+		in    string
+		sels  []string
+		decls []string
+		boxes []string
+		err   string
+	}{
+		{in: `@page { margin: 1in; }`, decls: []string{`margin: 1in`}},
+		{in: `@page :first { margin-top: 2in }`, sels: []string{`:first`}, decls: []string{`margin-top: 2in `}},
+		{in: `@page toc, index:blank, :left:first {}`, sels: []string{`toc`, `index:blank`, `:left:first`}},
+		{in: `@page { size: A4; @top-left { content: "Invoice"; } @bottom-center { content: counter(page) } }`,
+			decls: []string{`size: A4`},
+			boxes: []string{`top-left{content: "Invoice"}`, `bottom-center{content: counter(page) }`}},
+		{in: `@page;`},
+
+		{in: `@media print {}`, err: `expected @page, got @media`},
+		{in: `@page :middle {}`, err: `unknown pseudo-page: middle`},
+		{in: `@page : first {}`, err: `expected pseudo-page, got  `},
+		{in: `@page foo bar {}`, err: `expected comma, got bar`},
+		{in: `@page foo, {}`, err: `expected page selector, got EOF`},
+		{in: `@page { @top-middle { color: red } }`, err: `unexpected at-rule in @page: @top-middle`},
+		{in: `@page { @top-left foo { color: red } }`, err: `unexpected prelude in @top-left`},
+		{in: `@page { @top-left { @foo; } }`, err: `unexpected at-rule in @top-left: @foo`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		r := p.ParsePageRule(p.ParseRule(css.NewScanner(strings.NewReader(tt.in))).(*css.AtRule))
+		if tt.err != "" {
+			if len(p.Errors) == 0 || p.Errors[0].Error() != tt.err {
+				t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, p.Errors)
+			}
+			continue
+		} else if len(p.Errors) > 0 {
+			t.Errorf("%d. <%q> unexpected error: %s", i, tt.in, p.Errors)
+			continue
+		}
+
+		var sels, decls, boxes []string
+		for _, sel := range r.Selectors {
+			s := sel.Name
+			for _, name := range sel.PseudoPages {
+				s += ":" + name
+			}
+			sels = append(sels, s)
+		}
+		for _, d := range r.Declarations {
+			decls = append(decls, print(d))
+		}
+		for _, m := range r.MarginRules {
+			var a []string
+			for _, d := range m.Declarations {
+				a = append(a, print(d))
+			}
+			boxes = append(boxes, m.Name+"{"+strings.Join(a, ";")+"}")
+		}
+
+		if !reflect.DeepEqual(tt.sels, sels) {
+			t.Errorf("%d. <%q> selectors: exp=%q, got=%q", i, tt.in, tt.sels, sels)
+		}
+		if !reflect.DeepEqual(tt.decls, decls) {
+			t.Errorf("%d. <%q> declarations: exp=%q, got=%q", i, tt.in, tt.decls, decls)
+		}
+		if !reflect.DeepEqual(tt.boxes, boxes) {
+			t.Errorf("%d. <%q> margin rules: exp=%q, got=%q", i, tt.in, tt.boxes, boxes)
+		}
+	}
+}
+
+// Ensure that page selector specificity is calculated as (f, g, h).
+func TestPageSelector_Specificity(t *testing.T) {
+	var tests = []struct {
+		sel  css.PageSelector
+		spec css.PageSpecificity
+	}{
+		{sel: css.PageSelector{}, spec: css.PageSpecificity{0, 0, 0}},
+		{sel: css.PageSelector{Name: "toc"}, spec: css.PageSpecificity{1, 0, 0}},
+		{sel: css.PageSelector{PseudoPages: []string{"first"}}, spec: css.PageSpecificity{0, 1, 0}},
+		{sel: css.PageSelector{PseudoPages: []string{"BLANK", "left"}}, spec: css.PageSpecificity{0, 1, 1}},
+		{sel: css.PageSelector{Name: "toc", PseudoPages: []string{"right", "first"}}, spec: css.PageSpecificity{1, 1, 1}},
+	}
+
+	for i, tt := range tests {
+		if spec := tt.sel.Specificity(); spec != tt.spec {
+			t.Errorf("%d. specificity: exp=%v, got=%v", i, tt.spec, spec)
+		}
+	}
+
+	if !(css.PageSpecificity{0, 1, 0}).Less(css.PageSpecificity{1, 0, 0}) {
+		t.Errorf("expected (0,1,0) < (1,0,0)")
+	} else if (css.PageSpecificity{0, 0, 1}).Less(css.PageSpecificity{0, 0, 1}) {
+		t.Errorf("expected (0,0,1) to not be less than itself")
+	}
+}
+
+// Ensure that a page rule can be modified and converted back into an at-rule.
+func TestPageRule_AtRule(t *testing.T) {
+	var p css.Parser
+	r := p.ParsePageRule(p.ParseRule(css.NewScanner(strings.NewReader(`@page toc:first { margin: 1in; @top-left { content: "a" } }`))).(*css.AtRule))
+
+	// Replace the top-left box, add a bottom-right box and remove it again.
+	r.SetMarginRule(&css.MarginRule{Name: "top-left", Declarations: r.MarginRule("top-left").Declarations})
+	r.SetMarginRule(&css.MarginRule{Name: "bottom-right"})
+	r.RemoveMarginRule("BOTTOM-RIGHT")
+	r.SetMarginRule(&css.MarginRule{Name: "bottom-center", Declarations: []*css.Declaration{
+		{Name: "content", Values: css.ComponentValues{&css.Token{Tok: css.StringToken, Value: "b", Ending: '"'}}, Important: true},
+	}})
+
+	if s := print(r.AtRule()); s != `@page toc:first {margin: 1in; @top-left {content: "a" ;} @bottom-center {content:"b"!important;}}` {
+		t.Errorf("unexpected output: %s", s)
+	} else if r.MarginRule("top-right") != nil {
+		t.Errorf("unexpected margin rule")
+	}
+}
@@ -1,25 +1,39 @@
 package css
 
-import (
-	"fmt"
-	"strings"
-)
+import "strings"
 
 // Parser represents a CSS3 parser.
 type Parser struct {
 	Errors ErrorList
+
+	// MaxErrors stops parsing once this many errors have occurred.
+	// Zero means there is no limit. Scanner errors count towards the limit
+	// but are only recorded in the scanner's Errors.
+	MaxErrors int
+
+	// FailFast stops parsing after the first error.
+	FailFast bool
+
+	// ErrorHandler, if set, is called with each error as it occurs,
+	// including scanner errors.
+	ErrorHandler func(*Error)
+
+	// Arena, if set, allocates functions and blocks. See Arena.
+	Arena *Arena
+
+	nerr int // number of errors reported, excluding warnings
 }
 
 // ParseStyleSheet parses an input stream into a stylesheet.
 func (p *Parser) ParseStyleSheet(s *Scanner) *StyleSheet {
 	ss := &StyleSheet{}
-	ss.Rules = p.ConsumeRules(&scanner{s}, true)
+	ss.Rules = p.ConsumeRules(&scanner{s, p}, true)
 	return ss
 }
 
 // ParseRule parses a list of rules.
 func (p *Parser) ParseRules(s *Scanner) Rules {
-	return p.ConsumeRules(&scanner{s}, false)
+	return p.ConsumeRules(&scanner{s, p}, false)
 }
 
 // ParseRule parses a qualified rule or at-rule.
@@ -27,27 +41,27 @@ func (p *Parser) ParseRule(s *Scanner) Rule {
 	var r Rule
 
 	// Skip over initial whitespace.
-	p.skipWhitespace(&scanner{s})
+	p.skipWhitespace(&scanner{s, p})
 
 	// If the next token is EOF, return syntax error.
 	// If the next token is at-keyword, consume an at-rule.
 	// Otherwise consume a qualified rule. If nothing is returned, return error.
 	tok := s.Scan()
 	if tok.Tok == EOFToken {
-		p.Errors = append(p.Errors, &Error{Message: "unexpected EOF", Pos: Position(s.current())})
+		p.error(newError("unexpected-eof", s.current(), "unexpected EOF"))
 		return nil
 	} else if tok.Tok == AtKeywordToken {
-		r = p.ConsumeAtRule(&scanner{s})
+		r = p.ConsumeAtRule(&scanner{s, p})
 	} else {
 		s.unscan()
-		r = p.ConsumeQualifiedRule(&scanner{s})
+		r = p.ConsumeQualifiedRule(&scanner{s, p})
 	}
 
 	// Skip over trailing whitespace.
-	p.skipWhitespace(&scanner{s})
+	p.skipWhitespace(&scanner{s, p})
 
 	if tok := s.Scan(); tok.Tok != EOFToken {
-		p.Errors = append(p.Errors, &Error{Message: fmt.Sprintf("expected EOF, got %s", print(s.current())), Pos: Position(s.current())})
+		p.error(newError("expected-eof", s.current(), "expected EOF, got %s", print(s.current())))
 		return nil
 	}
 
@@ -57,46 +71,47 @@ func (p *Parser) ParseRule(s *Scanner) Rule {
 // ParseDeclaration parses a name/value declaration.
 func (p *Parser) ParseDeclaration(s *Scanner) *Declaration {
 	// Skip over initial whitespace.
-	p.skipWhitespace(&scanner{s})
+	p.skipWhitespace(&scanner{s, p})
 
 	// If the next token is not an ident then return an error.
 	if tok := s.Scan(); tok.Tok != IdentToken {
-		p.Errors = append(p.Errors, &Error{Message: fmt.Sprintf("expected ident, got %s", print(s.current())), Pos: Position(s.current())})
+		p.error(newError("expected-ident", s.current(), "expected ident, got %s", print(s.current())))
 		return nil
 	}
 	s.unscan()
 
-	// Consume a declaration.
-	return p.ConsumeDeclaration(&scanner{s})
+	// Consume a declaration from its component values.
+	values := p.consumeDeclarationValues(&scanner{s, p})
+	return p.ConsumeDeclaration(NewComponentValueScanner(values))
 }
 
 // ParseDeclarations parses a list of declarations and at-rules.
 func (p *Parser) ParseDeclarations(s *Scanner) Declarations {
-	return p.ConsumeDeclarations(&scanner{s})
+	return p.ConsumeDeclarations(&scanner{s, p})
 }
 
 // ParseComponentValue parses a component value.
 func (p *Parser) ParseComponentValue(s *Scanner) ComponentValue {
 	// Skip over initial whitespace.
-	p.skipWhitespace(&scanner{s})
+	p.skipWhitespace(&scanner{s, p})
 
 	// If the next token is EOF then return an error.
 	if tok := s.Scan(); tok.Tok == EOFToken {
-		p.Errors = append(p.Errors, &Error{Message: "unexpected EOF", Pos: Position(s.current())})
+		p.error(newError("unexpected-eof", s.current(), "unexpected EOF"))
 		return nil
 	}
 	s.unscan()
 
 	// Consume component value.
-	v := p.ConsumeComponentValue(&scanner{s})
+	v := p.ConsumeComponentValue(&scanner{s, p})
 
 	// Skip over any trailing whitespace.
-	p.skipWhitespace(&scanner{s})
+	p.skipWhitespace(&scanner{s, p})
 
 	// If we're not at EOF then return a syntax error.
 	if tok := s.Scan(); tok.Tok != EOFToken {
 		s.unscan()
-		p.Errors = append(p.Errors, &Error{Message: fmt.Sprintf("expected EOF, got %s", print(s.current())), Pos: Position(s.current())})
+		p.error(newError("expected-eof", s.current(), "expected EOF, got %s", print(s.current())))
 		return nil
 	}
 
@@ -109,7 +124,7 @@ func (p *Parser) ParseComponentValues(s *Scanner) ComponentValues {
 
 	// Repeatedly consume a component value until EOF.
 	for {
-		v := p.ConsumeComponentValue(&scanner{s})
+		v := p.ConsumeComponentValue(&scanner{s, p})
 
 		// If the value is an EOF, then exit.
 		if tok, ok := v.(*Token); ok && tok.Tok == EOFToken {
@@ -126,6 +141,18 @@ func (p *Parser) ParseComponentValues(s *Scanner) ComponentValues {
 // ConsumeRules consumes a list of rules from a token stream.
 func (p *Parser) ConsumeRules(s ComponentValueScanner, topLevel bool) Rules {
 	var a Rules
+	for {
+		r := p.consumeRule(s, topLevel)
+		if r == nil {
+			return a
+		}
+		a = append(a, r)
+	}
+}
+
+// consumeRule consumes the next rule in a list of rules.
+// Returns nil once the token stream reaches EOF.
+func (p *Parser) consumeRule(s ComponentValueScanner, topLevel bool) Rule {
 	for {
 		tok := s.Scan()
 		switch tok := tok.(type) {
@@ -134,18 +161,15 @@ func (p *Parser) ConsumeRules(s ComponentValueScanner, topLevel bool) Rules {
 			case WhitespaceToken:
 				continue // nop
 			case EOFToken:
-				return a
+				return nil
 			case CDOToken, CDCToken:
-				if !topLevel {
-					s.Unscan()
-					if r := p.ConsumeQualifiedRule(s); r != nil {
-						a = append(a, r)
-					}
+				// These are ignored at the top level of a style sheet.
+				if topLevel {
 					continue
 				}
 			case AtKeywordToken:
 				if r := p.ConsumeAtRule(s); r != nil {
-					a = append(a, r)
+					return r
 				}
 				continue
 			}
@@ -154,7 +178,7 @@ func (p *Parser) ConsumeRules(s ComponentValueScanner, topLevel bool) Rules {
 		// Otherwise consume a qualified rule.
 		s.Unscan()
 		if r := p.ConsumeQualifiedRule(s); r != nil {
-			a = append(a, r)
+			return r
 		}
 	}
 }
@@ -163,9 +187,10 @@ func (p *Parser) ConsumeRules(s ComponentValueScanner, topLevel bool) Rules {
 func (p *Parser) ConsumeAtRule(s ComponentValueScanner) *AtRule {
 	var r AtRule
 
-	// Set the name to the value of the current token.
+	// Set the name and position to the value of the current token.
 	// TODO(benbjohnson): Validate first token.
-	r.Name = s.Current().(*Token).Value
+	name := s.Current().(*Token)
+	r.Name, r.Pos = name.Value, name.Pos
 
 	// Repeatedly consume the next token.
 	for {
@@ -173,7 +198,13 @@ func (p *Parser) ConsumeAtRule(s ComponentValueScanner) *AtRule {
 		switch tok := tok.(type) {
 		case *Token:
 			switch tok.Tok {
-			case SemicolonToken, EOFToken:
+			case SemicolonToken:
+				r.endPos = End(tok)
+				return &r
+			case EOFToken:
+				if r.endPos = End(r.Prelude); r.endPos == (Pos{}) {
+					r.endPos = End(name)
+				}
 				return &r
 			case LBraceToken:
 				r.Block = p.ConsumeSimpleBlock(s)
@@ -197,6 +228,10 @@ func (p *Parser) ConsumeAtRule(s ComponentValueScanner) *AtRule {
 func (p *Parser) ConsumeQualifiedRule(s ComponentValueScanner) *QualifiedRule {
 	var r QualifiedRule
 
+	// The rule is positioned at its first component value.
+	r.Pos = Position(s.Scan())
+	s.Unscan()
+
 	// Repeatedly consume the next token.
 	for {
 		tok := s.Scan()
@@ -204,7 +239,7 @@ func (p *Parser) ConsumeQualifiedRule(s ComponentValueScanner) *QualifiedRule {
 		case *Token:
 			switch tok.Tok {
 			case EOFToken:
-				p.Errors = append(p.Errors, &Error{Message: "unexpected EOF", Pos: tok.Pos})
+				p.error(newError("unexpected-eof", tok, "unexpected EOF"))
 				return nil
 			case LBraceToken:
 				r.Block = p.ConsumeSimpleBlock(s)
@@ -252,7 +287,7 @@ func (p *Parser) ConsumeDeclarations(s ComponentValueScanner) Declarations {
 		}
 
 		// Any other token is a syntax error.
-		p.Errors = append(p.Errors, &Error{Message: fmt.Sprintf("unexpected: %s", print(tok)), Pos: Position(tok)})
+		p.error(newError("unexpected-token", tok, "unexpected: %s", print(tok)))
 
 		// Repeatedly consume a component values until semicolon or EOF.
 		p.skipComponentValues(s)
@@ -265,24 +300,29 @@ func (p *Parser) ConsumeDeclaration(s ComponentValueScanner) *Declaration {
 
 	// The first token must be an ident.
 	// TODO(benbjohnson): Validate initial token.
-	d.Name = s.Scan().(*Token).Value
+	tok := s.Scan().(*Token)
+	d.Name, d.Pos = tok.Value, tok.Pos
 
 	// Skip over whitespace.
 	p.skipWhitespace(s)
 
 	// The next token must be a colon.
-	if tok := s.Scan().(*Token); tok.Tok != ColonToken {
-		p.Errors = append(p.Errors, &Error{Message: fmt.Sprintf("expected colon, got %s", print(s.Current())), Pos: Position(s.Current())})
+	if tok, ok := s.Scan().(*Token); !ok || tok.Tok != ColonToken {
+		p.error(newError("expected-colon", s.Current(), "expected colon, got %s", print(s.Current())))
 		return nil
 	}
 
-	// Consume the declaration value until EOF.
+	// Consume the declaration value until EOF. The declaration ends at the
+	// colon if it has no value.
+	d.endPos = End(s.Current())
 	for {
-		tok := s.Scan()
-		if tok, ok := tok.(*Token); ok && tok.Tok == EOFToken {
+		v := s.Scan()
+		if isTok(v, EOFToken) {
 			break
+		} else if !isTok(v, WhitespaceToken) {
+			d.endPos = End(v)
 		}
-		d.Values = append(d.Values, tok)
+		d.Values = append(d.Values, v)
 	}
 
 	// Check last two non-whitespace tokens for "!important".
@@ -334,11 +374,12 @@ func (p *Parser) ConsumeComponentValue(s ComponentValueScanner) ComponentValue {
 
 // ConsumeSimpleBlock consumes a simple block. (§5.4.7)
 func (p *Parser) ConsumeSimpleBlock(s ComponentValueScanner) *SimpleBlock {
-	b := &SimpleBlock{}
+	b := p.Arena.block()
 
 	// Set the block's associated token to the current token.
 	// TODO(benbjohnson): Validate first token.
 	b.Token = s.Current().(*Token)
+	b.Pos = b.Token.Pos
 
 	for {
 		tok := s.Scan()
@@ -347,17 +388,21 @@ func (p *Parser) ConsumeSimpleBlock(s ComponentValueScanner) *SimpleBlock {
 		if tok, ok := tok.(*Token); ok {
 			switch tok.Tok {
 			case EOFToken:
+				b.End = tok.Pos
 				return b
 			case RBrackToken:
 				if b.Token.Tok == LBrackToken {
+					b.End = tok.Pos
 					return b
 				}
 			case RBraceToken:
 				if b.Token.Tok == LBraceToken {
+					b.End = tok.Pos
 					return b
 				}
 			case RParenToken:
 				if b.Token.Tok == LParenToken {
+					b.End = tok.Pos
 					return b
 				}
 			}
@@ -371,17 +416,24 @@ func (p *Parser) ConsumeSimpleBlock(s ComponentValueScanner) *SimpleBlock {
 
 // ConsumeFunction consumes a function.
 func (p *Parser) ConsumeFunction(s ComponentValueScanner) *Function {
-	f := &Function{}
+	f := p.Arena.function()
 
 	// Set the name to the first token.
 	// TODO(benbjohnson): Validate first token.
-	f.Name = s.Current().(*Token).Value
+	name := s.Current().(*Token)
+	f.Name, f.Pos = name.Value, name.Pos
 
 	for {
 		tok := s.Scan()
 
 		// If this token is EOF or the mirror of the starting token then return.
-		if tok, ok := tok.(*Token); ok && (tok.Tok == EOFToken || tok.Tok == RParenToken) {
+		if tok, ok := tok.(*Token); ok && tok.Tok == RParenToken {
+			f.endPos = End(tok)
+			return f
+		} else if ok && tok.Tok == EOFToken {
+			if f.endPos = End(f.Values); f.endPos == (Pos{}) {
+				f.endPos = End(name)
+			}
 			return f
 		}
 
@@ -395,12 +447,12 @@ func (p *Parser) ConsumeFunction(s ComponentValueScanner) *Function {
 func (p *Parser) consumeDeclarationValues(s ComponentValueScanner) ComponentValues {
 	var a ComponentValues
 	for {
-		tok := s.Scan()
-		if tok, ok := tok.(*Token); ok && (tok.Tok == SemicolonToken || tok.Tok == EOFToken) {
+		v := p.ConsumeComponentValue(s)
+		if tok, ok := v.(*Token); ok && (tok.Tok == SemicolonToken || tok.Tok == EOFToken) {
 			s.Unscan()
 			return a
 		}
-		a = append(a, tok)
+		a = append(a, v)
 	}
 }
 
@@ -417,10 +469,46 @@ func (p *Parser) skipComponentValues(s ComponentValueScanner) {
 	}
 }
 
+// error records an error unless the parser has already stopped.
+func (p *Parser) error(err *Error) {
+	if p.stopped() {
+		return
+	}
+	p.Errors = append(p.Errors, err)
+	p.report(err)
+}
+
+// scanned reports the errors that s has recorded since it was last checked.
+// They are left in the scanner's Errors rather than added to the parser's.
+func (p *Parser) scanned(s *Scanner) {
+	for ; s.reported < len(s.Errors); s.reported++ {
+		if !p.stopped() {
+			p.report(s.Errors[s.reported])
+		}
+	}
+}
+
+// report counts an error towards the error limit and passes it to the
+// error handler. Warnings don't count towards the limit.
+func (p *Parser) report(err *Error) {
+	if err.Severity != Warning {
+		p.nerr++
+	}
+	if p.ErrorHandler != nil {
+		p.ErrorHandler(err)
+	}
+}
+
+// stopped returns true if the parser has reached its error limit. Once
+// stopped, the parser reads no more input and drops any further errors.
+func (p *Parser) stopped() bool {
+	return (p.FailFast && p.nerr > 0) || (p.MaxErrors > 0 && p.nerr >= p.MaxErrors)
+}
+
 // skipWhitespace skips over all contiguous whitespace tokes.
 func (p *Parser) skipWhitespace(s ComponentValueScanner) {
 	for {
-		if tok, ok := s.Scan().(*Token); ok && tok.Tok != WhitespaceToken {
+		if tok, ok := s.Scan().(*Token); !ok || tok.Tok != WhitespaceToken {
 			s.Unscan()
 			return
 		}
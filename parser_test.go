@@ -12,6 +12,7 @@ import (
 func TestParser_ParseStyleSheet(t *testing.T) {
 	var tests = []ParserTest{
 		{in: `foo { padding: 10px; } @bar;`, out: `foo { padding: 10px; } @bar;`},
+		{in: `<!-- foo { } -->`, out: `foo { }`},
 	}
 
 	for _, tt := range tests {
@@ -78,6 +79,21 @@ func TestParser_ParseDeclaration(t *testing.T) {
 	}
 }
 
+// Ensure that declaration values are parsed into component values.
+func TestParser_ParseDeclaration_ComponentValues(t *testing.T) {
+	var p css.Parser
+	d := p.ParseDeclaration(css.NewScanner(strings.NewReader(`foo: rgb(1, 2) [a]`)))
+	if len(p.Errors) > 0 {
+		t.Fatal(p.Errors)
+	} else if len(d.Values) != 4 {
+		t.Fatalf("unexpected values: %#v", d.Values)
+	} else if _, ok := d.Values[1].(*css.Function); !ok {
+		t.Fatalf("expected function: %#v", d.Values[1])
+	} else if _, ok := d.Values[3].(*css.SimpleBlock); !ok {
+		t.Fatalf("expected simple block: %#v", d.Values[3])
+	}
+}
+
 // Ensure that a list of declarations can be parsed into an AST.
 func TestParser_ParseDeclarations(t *testing.T) {
 	var tests = []ParserTest{
@@ -159,6 +175,161 @@ func TestParser_ConsumeQualifiedRule_ErrUnexpectedEOF(t *testing.T) {
 	}
 }
 
+// Ensure that the parser sets the position of rules, declarations and blocks.
+func TestParser_Positions(t *testing.T) {
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScanner(strings.NewReader("@media print {}\nfoo {\n  width: calc(1px);\n}")))
+	at, qr := ss.Rules[0].(*css.AtRule), ss.Rules[1].(*css.QualifiedRule)
+	decl := p.ConsumeDeclarations(css.NewComponentValueScanner(qr.Block.Values))[0].(*css.Declaration)
+	fn := decl.Values[1].(*css.Function)
+
+	if at.Pos != (css.Pos{Char: 1, Line: 0}) {
+		t.Errorf("unexpected at-rule position: %#v", at.Pos)
+	} else if at.Block.Pos != (css.Pos{Char: 14, Line: 0}) {
+		t.Errorf("unexpected block position: %#v", at.Block.Pos)
+	} else if at.Block.End != (css.Pos{Char: 15, Line: 0}) {
+		t.Errorf("unexpected block end: %#v", at.Block.End)
+	} else if qr.Block.End != (css.Pos{Char: 1, Line: 3}) {
+		t.Errorf("unexpected block end: %#v", qr.Block.End)
+	} else if qr.Pos != (css.Pos{Char: 1, Line: 1}) {
+		t.Errorf("unexpected qualified rule position: %#v", qr.Pos)
+	} else if decl.Pos != (css.Pos{Char: 3, Line: 2}) {
+		t.Errorf("unexpected declaration position: %#v", decl.Pos)
+	} else if fn.Pos != (css.Pos{Char: 10, Line: 2}) {
+		t.Errorf("unexpected function position: %#v", fn.Pos)
+	}
+}
+
+// Ensure that the end of a parsed node is its end in the source, including
+// comments and escapes.
+func TestParser_End(t *testing.T) {
+	var tests = []struct {
+		in  string
+		end css.Pos
+	}{
+		{in: "a {\n /* one\n two\n three */\n color: red;\n}", end: css.Pos{Char: 2, Line: 5}},
+		{in: "a { b: c }  ", end: css.Pos{Char: 11}},
+		{in: "a { b: c", end: css.Pos{Char: 9}},
+		{in: "@import 'a\\'b' ;", end: css.Pos{Char: 17}},
+		{in: "@import url(a.css) /* b */", end: css.Pos{Char: 19}},
+		{in: "@media print { }\n", end: css.Pos{Char: 17}},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		ss := p.ParseStyleSheet(css.NewScannerString(tt.in))
+		if end := css.End(ss.Rules[0]); end != tt.end {
+			t.Errorf("%d. %q: expected %s, got %s", i, tt.in, tt.end, end)
+		}
+	}
+}
+
+// Ensure that the end of parsed declarations and values is their end in the
+// source.
+func TestParser_End_Declarations(t *testing.T) {
+	var tests = []struct {
+		in  string
+		end []css.Pos
+	}{
+		{in: `a: \31 0px; b: "x\"y" !important`, end: []css.Pos{{Char: 11}, {Char: 33}}},
+		{in: "a: calc( 1px /* x */ ) ;b:", end: []css.Pos{{Char: 23}, {Char: 27}}},
+		{in: "a: f(x", end: []css.Pos{{Char: 7}}},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		decls := p.ParseDeclarations(css.NewScannerString(tt.in))
+		if len(decls) != len(tt.end) {
+			t.Errorf("%d. %q: unexpected declarations: %s", i, tt.in, print(decls))
+			continue
+		}
+		for j, d := range decls {
+			if end := css.End(d); end != tt.end[j] {
+				t.Errorf("%d.%d %q: expected %s, got %s", i, j, tt.in, tt.end[j], end)
+			}
+		}
+	}
+}
+
+// Ensure that the parser stops reading input once it reaches its error limit.
+func TestParser_MaxErrors(t *testing.T) {
+	var tests = []struct {
+		in        string
+		maxErrors int
+		failFast  bool
+		out       string
+		errs      int
+	}{
+		{in: `a b; c d; x: 1; e f`, out: `x: 1;`, errs: 3},
+		{in: `a b; c d; x: 1; e f`, maxErrors: 2, out: ``, errs: 2},
+		{in: `a b; c d; x: 1; e f`, maxErrors: 3, out: `x: 1;`, errs: 3},
+		{in: `a: 1; b c; d: 2`, failFast: true, out: `a: 1;`, errs: 1},
+		{in: `a: 1; d: 2`, failFast: true, out: `a: 1; d: 2;`, errs: 0},
+	}
+
+	for i, tt := range tests {
+		p := css.Parser{MaxErrors: tt.maxErrors, FailFast: tt.failFast}
+		v := p.ParseDeclarations(css.NewScanner(strings.NewReader(tt.in)))
+		if out := print(v); out != tt.out {
+			t.Errorf("%d. out: expected %q, got %q", i, tt.out, out)
+		} else if len(p.Errors) != tt.errs {
+			t.Errorf("%d. expected %d errors, got %d: %v", i, tt.errs, len(p.Errors), p.Errors)
+		}
+	}
+}
+
+// Ensure that warnings don't stop a fail-fast parser.
+func TestParser_FailFast_Warning(t *testing.T) {
+	p := css.Parser{FailFast: true}
+	r := p.ParseRule(css.NewScanner(strings.NewReader(`@property --x { foo: 1; syntax: '*'; inherits: false; bar: 2 }`)))
+	p.ParsePropertyRule(r.(*css.AtRule))
+	if len(p.Errors) != 2 {
+		t.Fatalf("unexpected errors: %v", p.Errors)
+	}
+}
+
+// Ensure that the error handler is called with each error as it occurs.
+func TestParser_ErrorHandler(t *testing.T) {
+	var a []string
+	p := css.Parser{MaxErrors: 2}
+	p.ErrorHandler = func(err *css.Error) {
+		a = append(a, err.Code)
+		if len(a) != len(p.Errors) {
+			t.Fatalf("unexpected error count: %d", len(p.Errors))
+		}
+	}
+	p.ParseDeclarations(css.NewScanner(strings.NewReader(`a b; c; e f`)))
+	if strings.Join(a, ",") != "expected-colon,expected-colon" {
+		t.Fatalf("unexpected errors: %v", a)
+	}
+}
+
+// Ensure that scanner errors count towards the error limit and are passed
+// to the error handler.
+func TestParser_ErrorHandler_Scanner(t *testing.T) {
+	var a []string
+	p := css.Parser{FailFast: true}
+	p.ErrorHandler = func(err *css.Error) { a = append(a, err.Code) }
+	s := css.NewScanner(strings.NewReader("a { b: 'x\n; c: url(a b); d: 1 } e { f: 2 }"))
+	ss := p.ParseStyleSheet(s)
+	if len(ss.Rules) != 1 {
+		t.Fatalf("unexpected rules: %s", print(ss))
+	} else if len(p.Errors) != 0 {
+		t.Fatalf("unexpected parser errors: %v", p.Errors)
+	} else if len(s.Errors) != 1 {
+		t.Fatalf("unexpected scanner errors: %v", s.Errors)
+	} else if strings.Join(a, ",") != s.Errors[0].Code {
+		t.Fatalf("unexpected handled errors: %v", a)
+	}
+
+	// Scanner errors count towards the maximum.
+	p = css.Parser{MaxErrors: 2}
+	s = css.NewScanner(strings.NewReader("a { b: 'x\n; c: url(a b); d: 1 } e { f: 2 }"))
+	if ss := p.ParseStyleSheet(s); len(ss.Rules) != 1 || len(s.Errors) != 2 {
+		t.Fatalf("unexpected result: %s, %v", print(ss), s.Errors)
+	}
+}
+
 // ParserTest represents a generic framework for table tests against the parser.
 type ParserTest struct {
 	in  string // input CSS
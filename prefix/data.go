@@ -0,0 +1,284 @@
+package prefix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The compatibility tables map each standard feature to its prefixed forms.
+// Each entry is the prefixed form followed by the browsers that require it:
+//
+//	chrome        all versions
+//	chrome<43     versions before 43
+//	ie>=10        versions 10 and later
+//	ie>=9<10      versions from 9 up to, but not including, 10
+//
+// Browsers that aren't listed never require the prefixed form.
+
+// propertyTable maps properties to their prefixed property names.
+var propertyTable = map[string][]string{
+	"animation":                 animationPrefixes("animation"),
+	"animation-name":            animationPrefixes("animation-name"),
+	"animation-duration":        animationPrefixes("animation-duration"),
+	"animation-timing-function": animationPrefixes("animation-timing-function"),
+	"animation-delay":           animationPrefixes("animation-delay"),
+	"animation-iteration-count": animationPrefixes("animation-iteration-count"),
+	"animation-direction":       animationPrefixes("animation-direction"),
+	"animation-fill-mode":       animationPrefixes("animation-fill-mode"),
+	"animation-play-state":      animationPrefixes("animation-play-state"),
+
+	"transition":                 transitionPrefixes("transition"),
+	"transition-property":        transitionPrefixes("transition-property"),
+	"transition-duration":        transitionPrefixes("transition-duration"),
+	"transition-timing-function": transitionPrefixes("transition-timing-function"),
+	"transition-delay":           transitionPrefixes("transition-delay"),
+
+	"transform":           transformPrefixes("transform"),
+	"transform-origin":    transformPrefixes("transform-origin"),
+	"transform-style":     transform3dPrefixes("transform-style"),
+	"perspective":         transform3dPrefixes("perspective"),
+	"perspective-origin":  transform3dPrefixes("perspective-origin"),
+	"backface-visibility": {"-webkit-backface-visibility chrome<36 safari<15.4 ios_saf<15.4", "-moz-backface-visibility firefox<16"},
+
+	"flex":            flexPrefixes("flex", "-ms-flex ie>=10<11"),
+	"flex-grow":       flexPrefixes("flex-grow"),
+	"flex-shrink":     flexPrefixes("flex-shrink"),
+	"flex-basis":      flexPrefixes("flex-basis"),
+	"flex-direction":  flexPrefixes("flex-direction", "-ms-flex-direction ie>=10<11"),
+	"flex-wrap":       flexPrefixes("flex-wrap", "-ms-flex-wrap ie>=10<11"),
+	"flex-flow":       flexPrefixes("flex-flow", "-ms-flex-flow ie>=10<11"),
+	"order":           flexPrefixes("order"),
+	"justify-content": flexPrefixes("justify-content"),
+	"align-items":     flexPrefixes("align-items"),
+	"align-self":      flexPrefixes("align-self"),
+	"align-content":   flexPrefixes("align-content"),
+
+	"columns":      columnPrefixes("columns"),
+	"column-count": columnPrefixes("column-count"),
+	"column-gap":   columnPrefixes("column-gap"),
+	"column-rule":  columnPrefixes("column-rule"),
+	"column-width": columnPrefixes("column-width"),
+	"column-span":  {"-webkit-column-span chrome<50 safari<9 ios_saf<9"},
+
+	"mask":          maskPrefixes("mask"),
+	"mask-image":    maskPrefixes("mask-image"),
+	"mask-size":     maskPrefixes("mask-size"),
+	"mask-position": maskPrefixes("mask-position"),
+	"mask-repeat":   maskPrefixes("mask-repeat"),
+	"mask-clip":     maskPrefixes("mask-clip"),
+	"mask-origin":   maskPrefixes("mask-origin"),
+
+	"border-radius":         {"-webkit-border-radius chrome<5 safari<5 ios_saf<4 android<2.2", "-moz-border-radius firefox<4"},
+	"box-shadow":            {"-webkit-box-shadow chrome<10 safari<5.1 ios_saf<5 android<4", "-moz-box-shadow firefox<4"},
+	"box-sizing":            {"-webkit-box-sizing chrome<10 safari<5.1 ios_saf<5 android<4", "-moz-box-sizing firefox<29"},
+	"user-select":           {"-webkit-user-select chrome<54 safari ios_saf android<54", "-moz-user-select firefox<69", "-ms-user-select ie>=10 edge<79"},
+	"appearance":            {"-webkit-appearance chrome<84 safari<15.4 ios_saf<15.4 edge<84 android<84 samsung<14", "-moz-appearance firefox<80"},
+	"backdrop-filter":       {"-webkit-backdrop-filter safari<18 ios_saf<18"},
+	"filter":                {"-webkit-filter chrome<53 safari<9.1 ios_saf<9.3 android<53"},
+	"clip-path":             {"-webkit-clip-path chrome<55 safari<13.1 ios_saf<13 android<55"},
+	"hyphens":               {"-webkit-hyphens safari<17 ios_saf<17", "-moz-hyphens firefox<43", "-ms-hyphens ie>=10 edge<79"},
+	"text-size-adjust":      {"-webkit-text-size-adjust ios_saf", "-moz-text-size-adjust firefox", "-ms-text-size-adjust edge<79"},
+	"tab-size":              {"-moz-tab-size firefox<91"},
+	"box-decoration-break":  {"-webkit-box-decoration-break chrome<130 safari ios_saf edge<130"},
+	"print-color-adjust":    {"-webkit-print-color-adjust chrome edge safari<15.4 ios_saf<15.4"},
+	"font-feature-settings": {"-webkit-font-feature-settings chrome<48", "-moz-font-feature-settings firefox<34"},
+}
+
+// valueTable maps property values to their prefixed values.
+var valueTable = map[string]map[string][]string{
+	"display": {
+		"flex":        {"-webkit-flex chrome<29 safari<9 ios_saf<9 android<4.4", "-ms-flexbox ie>=10<11"},
+		"inline-flex": {"-webkit-inline-flex chrome<29 safari<9 ios_saf<9 android<4.4", "-ms-inline-flexbox ie>=10<11"},
+	},
+	"position": {
+		"sticky": {"-webkit-sticky safari<13 ios_saf<13"},
+	},
+}
+
+// functionTable maps functions to their prefixed function names.
+var functionTable = map[string][]string{
+	"linear-gradient":           gradientPrefixes("linear-gradient"),
+	"radial-gradient":           gradientPrefixes("radial-gradient"),
+	"repeating-linear-gradient": gradientPrefixes("repeating-linear-gradient"),
+	"repeating-radial-gradient": gradientPrefixes("repeating-radial-gradient"),
+	"image-set":                 {"-webkit-image-set chrome<113 safari<17 ios_saf<17 edge<113 android<113"},
+}
+
+// selectorTable maps pseudo-classes and pseudo-elements to their prefixed forms.
+var selectorTable = map[string][]string{
+	"::placeholder": {
+		"::-webkit-input-placeholder chrome<57 safari<10.1 ios_saf<10.3 android<57 samsung<7",
+		"::-moz-placeholder firefox<51",
+		":-ms-input-placeholder ie>=10",
+		"::-ms-input-placeholder edge<79",
+	},
+	"::selection":            {"::-moz-selection firefox<62"},
+	"::file-selector-button": {"::-webkit-file-upload-button chrome<89 safari<14.1 ios_saf<14.5 edge<89 android<89"},
+	":fullscreen":            {":-webkit-full-screen chrome<71 safari<16.4 edge<79", ":-moz-full-screen firefox<64", ":-ms-fullscreen ie>=11"},
+	":any-link":              {":-webkit-any-link chrome<65 safari<9 ios_saf<9 android<65", ":-moz-any-link firefox<50"},
+	":read-only":             {":-moz-read-only firefox<78"},
+	":read-write":            {":-moz-read-write firefox<78"},
+}
+
+// atRuleTable maps at-rules to their prefixed at-rule names.
+var atRuleTable = map[string][]string{
+	"keyframes": {"-webkit-keyframes chrome<43 safari<9 ios_saf<9 android<4.4", "-moz-keyframes firefox<16"},
+}
+
+func animationPrefixes(name string) []string {
+	return []string{"-webkit-" + name + " chrome<43 safari<9 ios_saf<9 android<4.4", "-moz-" + name + " firefox<16"}
+}
+
+func transitionPrefixes(name string) []string {
+	return []string{"-webkit-" + name + " chrome<26 safari<6.1 ios_saf<7 android<4.4", "-moz-" + name + " firefox<16"}
+}
+
+func transformPrefixes(name string) []string {
+	return []string{"-webkit-" + name + " chrome<36 safari<9 ios_saf<9 android<4.4", "-moz-" + name + " firefox<16", "-ms-" + name + " ie>=9<10"}
+}
+
+func transform3dPrefixes(name string) []string {
+	return []string{"-webkit-" + name + " chrome<36 safari<9 ios_saf<9 android<4.4", "-moz-" + name + " firefox<16"}
+}
+
+func flexPrefixes(name string, other ...string) []string {
+	return append([]string{"-webkit-" + name + " chrome<29 safari<9 ios_saf<9 android<4.4"}, other...)
+}
+
+func columnPrefixes(name string) []string {
+	return []string{"-webkit-" + name + " chrome<50 safari<9 ios_saf<9 android<50", "-moz-" + name + " firefox<52"}
+}
+
+func maskPrefixes(name string) []string {
+	return []string{"-webkit-" + name + " chrome<120 safari<15.4 ios_saf<15.4 edge<120 android<120 samsung<25"}
+}
+
+func gradientPrefixes(name string) []string {
+	return []string{"-webkit-" + name + " chrome<26 safari<7 ios_saf<7 android<4.4", "-moz-" + name + " firefox<16"}
+}
+
+// stat represents a range of versions of a browser requiring a prefixed form.
+type stat struct {
+	browser string
+	since   Version
+	until   Version
+}
+
+// variant represents the prefixed form of a feature.
+type variant struct {
+	name   string // prefixed name, such as "-webkit-transform"
+	prefix string // vendor prefix, such as "-webkit-"
+	stats  []stat
+}
+
+// needed returns true if any target requires the variant.
+func (v *variant) needed(targets Targets) bool {
+	for _, t := range targets {
+		for _, s := range v.stats {
+			if t.includes(s.browser, s.since, s.until) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// table represents a compiled compatibility table.
+type table struct {
+	variants map[string][]*variant // by standard name
+	standard map[string]string     // prefixed name to standard name
+}
+
+// lookup returns the variant with the given prefixed name.
+func (t *table) lookup(name string) *variant {
+	for _, v := range t.variants[t.standard[name]] {
+		if v.name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// compile converts a compatibility table into a lookup table.
+func compile(m map[string][]string) *table {
+	t := &table{variants: make(map[string][]*variant), standard: make(map[string]string)}
+	for name, entries := range m {
+		for _, entry := range entries {
+			v := parseVariant(entry)
+			t.variants[name] = append(t.variants[name], v)
+			t.standard[v.name] = name
+		}
+	}
+	return t
+}
+
+// parseVariant parses a single entry from a compatibility table.
+func parseVariant(entry string) *variant {
+	fields := strings.Fields(entry)
+	v := &variant{name: fields[0], prefix: vendorPrefix(fields[0])}
+	if v.prefix == "" {
+		panic(fmt.Sprintf("prefix: missing vendor prefix: %s", entry))
+	}
+
+	for _, field := range fields[1:] {
+		var s stat
+		if i := strings.IndexAny(field, "<>"); i == -1 {
+			s.browser = field
+		} else {
+			s.browser, field = field[:i], field[i:]
+			if strings.HasPrefix(field, ">=") {
+				field = field[2:]
+				i := strings.IndexByte(field, '<')
+				if i == -1 {
+					i = len(field)
+				}
+				s.since = mustParseVersion(field[:i])
+				field = field[i:]
+			}
+			if strings.HasPrefix(field, "<") {
+				s.until = mustParseVersion(field[1:])
+			}
+		}
+		if _, ok := browsers[s.browser]; !ok {
+			panic(fmt.Sprintf("prefix: unknown browser: %s", entry))
+		}
+		v.stats = append(v.stats, s)
+	}
+	return v
+}
+
+// mustParseVersion parses a version from a compatibility table.
+func mustParseVersion(s string) Version {
+	v, _, err := parseVersion(s)
+	if err != nil {
+		panic("prefix: " + err.Error())
+	}
+	return v
+}
+
+// vendorPrefix returns the vendor prefix of a name, such as "-webkit-".
+// Leading colons from pseudo-classes and pseudo-elements are ignored.
+func vendorPrefix(name string) string {
+	name = strings.TrimLeft(name, ":")
+	if !strings.HasPrefix(name, "-") {
+		return ""
+	}
+	if i := strings.IndexByte(name[1:], '-'); i > 0 {
+		return name[:i+2]
+	}
+	return ""
+}
+
+var (
+	properties = compile(propertyTable)
+	functions  = compile(functionTable)
+	selectors  = compile(selectorTable)
+	atRules    = compile(atRuleTable)
+	values     = make(map[string]*table)
+)
+
+func init() {
+	for name, m := range valueTable {
+		values[name] = compile(m)
+	}
+}
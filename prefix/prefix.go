@@ -0,0 +1,705 @@
+// Package prefix adds and removes vendor prefixes in a CSS style sheet based
+// on the browsers that it targets.
+//
+// Targets are described using a subset of the browserslist query syntax and
+// are checked against an embedded compatibility table. Prefixed forms of
+// properties, values, gradient functions, selectors and @keyframes are
+// inserted before their standard form when a target requires them. Prefixed
+// forms which no target requires are removed when the standard form is
+// also present.
+package prefix
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/benbjohnson/css"
+)
+
+// Prefix adds the vendor prefixes required by targets to the style sheet and
+// removes the prefixes that none of the targets require.
+func Prefix(ss *css.StyleSheet, targets Targets) {
+	p := &prefixer{targets: targets}
+	ss.Rules, _ = p.rules(ss.Rules, "")
+}
+
+// prefixer holds the state for prefixing a single style sheet.
+type prefixer struct {
+	targets Targets
+}
+
+// needed returns true if the variant is required by the targets and can be
+// used in a context restricted to the only prefix, if set.
+func (p *prefixer) needed(v *variant, only string) bool {
+	return (only == "" || v.prefix == only) && v.needed(p.targets)
+}
+
+// rules prefixes a list of rules. Returns true if the list was changed.
+func (p *prefixer) rules(a css.Rules, only string) (css.Rules, bool) {
+	var other css.Rules
+	var changed bool
+	for _, r := range a {
+		switch r := r.(type) {
+		case *css.QualifiedRule:
+			if p.outdatedRule(r, a) {
+				changed = true
+				continue
+			}
+			if p.block(r.Block, only) {
+				changed = true
+			}
+			for _, c := range p.prefixRule(r, a, only) {
+				other, changed = append(other, c), true
+			}
+
+		case *css.AtRule:
+			if p.outdatedAtRule(r, a) {
+				changed = true
+				continue
+			}
+			for _, c := range p.prefixAtRule(r, a, only) {
+				other, changed = append(other, c), true
+			}
+			if p.atRule(r, only) {
+				changed = true
+			}
+		}
+		other = append(other, r)
+	}
+	return other, changed
+}
+
+// outdatedRule returns true if the rule uses a prefixed selector which no
+// target requires and the same rule exists with the standard selector.
+func (p *prefixer) outdatedRule(r *css.QualifiedRule, a css.Rules) bool {
+	for _, sel := range pseudoNames(r.Prelude) {
+		v := selectors.lookup(sel)
+		if v == nil || v.needed(p.targets) {
+			continue
+		}
+		prelude := print(replacePseudo(r.Prelude, sel, selectors.standard[sel]))
+		for _, other := range a {
+			if other, ok := other.(*css.QualifiedRule); ok && print(other.Prelude) == prelude {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prefixRule returns copies of the rule for each prefixed selector required
+// by the targets. Browsers drop rules with unknown selectors so each prefix
+// requires its own rule.
+func (p *prefixer) prefixRule(r *css.QualifiedRule, a css.Rules, only string) []css.Rule {
+	var rules []css.Rule
+	for _, sel := range pseudoNames(r.Prelude) {
+		for _, v := range selectors.variants[sel] {
+			if !p.needed(v, only) {
+				continue
+			}
+			prelude := replacePseudo(r.Prelude, sel, v.name)
+			if indexQualifiedRule(a, print(prelude)) != -1 {
+				continue
+			}
+			rules = append(rules, &css.QualifiedRule{Prelude: prelude, Block: cloneBlock(r.Block), Pos: r.Pos})
+		}
+	}
+	return rules
+}
+
+// outdatedAtRule returns true if the at-rule has a prefixed name which no
+// target requires and the same at-rule exists with the standard name.
+func (p *prefixer) outdatedAtRule(r *css.AtRule, a css.Rules) bool {
+	name := strings.ToLower(r.Name)
+	v := atRules.lookup(name)
+	if v == nil || v.needed(p.targets) {
+		return false
+	}
+	return indexAtRule(a, atRules.standard[name], print(r.Prelude)) != -1
+}
+
+// prefixAtRule returns copies of the at-rule for each prefixed name required
+// by the targets. The contents of each copy only use the copy's prefix.
+func (p *prefixer) prefixAtRule(r *css.AtRule, a css.Rules, only string) []css.Rule {
+	var rules []css.Rule
+	for _, v := range atRules.variants[strings.ToLower(r.Name)] {
+		if !p.needed(v, only) || indexAtRule(a, v.name, print(r.Prelude)) != -1 {
+			continue
+		}
+		other := &css.AtRule{Name: v.name, Prelude: cloneValues(r.Prelude), Block: cloneBlock(r.Block), Pos: r.Pos}
+		p.atRule(other, v.prefix)
+		rules = append(rules, other)
+	}
+	return rules
+}
+
+// atRule prefixes the contents of an at-rule. Returns true if it was changed.
+func (p *prefixer) atRule(r *css.AtRule, only string) bool {
+	if r.Block == nil {
+		return false
+	}
+
+	// Prefixed at-rules only use their own prefix.
+	name := strings.ToLower(r.Name)
+	if v := atRules.lookup(name); v != nil {
+		only, name = v.prefix, atRules.standard[name]
+	}
+
+	switch {
+	case css.IsGroupingRule(name), name == "keyframes":
+		var parser css.Parser
+		rules := parser.ConsumeRules(css.NewComponentValueScanner(r.Block.Values), false)
+		if len(parser.Errors) > 0 {
+			return false
+		}
+		rules, changed := p.rules(rules, only)
+		if changed {
+			r.Block.Values = ruleValues(rules, r.Block.Values)
+		}
+		return changed
+
+	case name == "page", name == "font-face":
+		return p.block(r.Block, only)
+	}
+	return false
+}
+
+// block prefixes the declarations in a block. Returns true if it was changed.
+// Blocks which contain anything other than declarations are left as is.
+func (p *prefixer) block(b *css.SimpleBlock, only string) bool {
+	if b == nil {
+		return false
+	}
+
+	var parser css.Parser
+	decls := parser.ConsumeDeclarations(css.NewComponentValueScanner(b.Values))
+	if len(parser.Errors) > 0 {
+		return false
+	}
+
+	decls, changed := p.declarations(decls, only)
+	if changed {
+		b.Values = declarationValues(decls, b.Values)
+	}
+	return changed
+}
+
+// declarations prefixes a list of declarations. Returns true if the list was changed.
+func (p *prefixer) declarations(a css.Declarations, only string) (css.Declarations, bool) {
+	var other css.Declarations
+	var changed bool
+	for _, n := range a {
+		d, ok := n.(*css.Declaration)
+		if !ok {
+			other = append(other, n)
+			continue
+		}
+
+		if p.outdatedDeclaration(d, a) {
+			changed = true
+			continue
+		}
+		for _, c := range p.prefixDeclaration(d, a, only) {
+			other, changed = append(other, c), true
+		}
+		other = append(other, d)
+	}
+	return other, changed
+}
+
+// outdatedDeclaration returns true if the declaration uses a prefixed
+// property, value or function which no target requires and the declaration
+// is also specified in its standard form.
+func (p *prefixer) outdatedDeclaration(d *css.Declaration, a css.Declarations) bool {
+	name := strings.ToLower(d.Name)
+
+	// Prefixed property, such as "-webkit-transform".
+	if v := properties.lookup(name); v != nil {
+		return !v.needed(p.targets) && indexDeclaration(a, properties.standard[name], "") != -1
+	}
+
+	// Prefixed value, such as "display: -webkit-flex".
+	if t := values[name]; t != nil {
+		if ident := strings.ToLower(identValue(d.Values)); ident != "" {
+			if v := t.lookup(ident); v != nil {
+				return !v.needed(p.targets) && indexDeclaration(a, name, t.standard[ident]) != -1
+			}
+		}
+	}
+
+	// Prefixed functions, such as "-webkit-linear-gradient()".
+	var found bool
+	for _, fn := range functionNames(d.Values) {
+		if v := functions.lookup(fn); v != nil {
+			if v.needed(p.targets) {
+				return false
+			}
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+	for _, n := range a {
+		if other, ok := n.(*css.Declaration); ok && strings.EqualFold(other.Name, d.Name) && containsStandardFunction(other.Values) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixDeclaration returns the prefixed declarations required by the
+// targets which don't already exist in the list.
+func (p *prefixer) prefixDeclaration(d *css.Declaration, a css.Declarations, only string) []*css.Declaration {
+	var decls []*css.Declaration
+	name := strings.ToLower(d.Name)
+
+	// Prefixed properties.
+	for _, v := range properties.variants[name] {
+		if p.needed(v, only) && indexDeclaration(a, v.name, "") == -1 {
+			decls = append(decls, &css.Declaration{Name: v.name, Values: cloneValues(d.Values), Important: d.Important, Pos: d.Pos})
+		}
+	}
+
+	// Prefixed values.
+	if t := values[name]; t != nil {
+		if ident := strings.ToLower(identValue(d.Values)); ident != "" {
+			for _, v := range t.variants[ident] {
+				if p.needed(v, only) && indexDeclaration(a, name, v.name) == -1 {
+					decls = append(decls, &css.Declaration{Name: d.Name, Values: replaceIdent(d.Values, v.name), Important: d.Important, Pos: d.Pos})
+				}
+			}
+		}
+	}
+
+	// Prefixed functions. Each prefix is added once for all functions in the value.
+	var prefixes []string
+	for _, fn := range functionNames(d.Values) {
+		for _, v := range functions.variants[fn] {
+			if p.needed(v, only) && !containsString(prefixes, v.prefix) {
+				prefixes = append(prefixes, v.prefix)
+			}
+		}
+	}
+	for _, prefix := range prefixes {
+		values, ok := prefixFunctions(d.Values, prefix)
+		if !ok || indexDeclaration(a, name, print(values)) != -1 {
+			continue
+		}
+		decls = append(decls, &css.Declaration{Name: d.Name, Values: values, Important: d.Important, Pos: d.Pos})
+	}
+
+	return decls
+}
+
+// prefixFunctions returns a copy of values with each standard function
+// converted to its prefixed form. Returns false if a function cannot be
+// converted to the legacy syntax used by the prefixed form.
+func prefixFunctions(values css.ComponentValues, prefix string) (css.ComponentValues, bool) {
+	values = cloneValues(values)
+	for _, v := range values {
+		fn, ok := v.(*css.Function)
+		if !ok {
+			continue
+		}
+		name := strings.ToLower(fn.Name)
+		if _, ok := functionTable[name]; !ok {
+			continue
+		}
+
+		switch name {
+		case "linear-gradient", "repeating-linear-gradient":
+			if !legacyLinearGradient(fn) {
+				return nil, false
+			}
+		case "radial-gradient", "repeating-radial-gradient":
+			// The legacy syntax doesn't support "at <position>".
+			if containsIdent(fn.Values, "at") {
+				return nil, false
+			}
+		}
+		fn.Name = prefix + fn.Name
+	}
+	return values, true
+}
+
+// legacyLinearGradient converts the direction of a linear gradient to the
+// legacy syntax, which specifies the starting side instead of the ending
+// side and measures angles counter-clockwise from the east.
+func legacyLinearGradient(fn *css.Function) bool {
+	var i int
+	for i < len(fn.Values) && isWhitespace(fn.Values[i]) {
+		i++
+	}
+	if i == len(fn.Values) {
+		return true
+	}
+
+	switch tok, _ := fn.Values[i].(*css.Token); {
+	case tok == nil:
+		return true
+
+	case tok.Tok == css.DimensionToken:
+		if strings.ToLower(tok.Unit) != "deg" {
+			return false
+		}
+		deg := strconv.FormatFloat(90-tok.Number, 'f', -1, 64)
+		fn.Values[i] = &css.Token{Tok: css.DimensionToken, Value: deg + "deg", Number: 90 - tok.Number, Unit: "deg", Type: "number", Pos: tok.Pos}
+		return true
+
+	case tok.Tok == css.IdentToken && strings.EqualFold(tok.Value, "to"):
+		// Replace "to <side>" with the opposite side and drop the "to" keyword.
+		end := len(fn.Values)
+		for j := i + 1; j < len(fn.Values); j++ {
+			if tok, ok := fn.Values[j].(*css.Token); ok && tok.Tok == css.CommaToken {
+				end = j
+				break
+			}
+		}
+
+		var sides css.ComponentValues
+		for _, v := range fn.Values[i+1 : end] {
+			if isWhitespace(v) {
+				continue
+			}
+			tok, ok := v.(*css.Token)
+			if !ok || tok.Tok != css.IdentToken || oppositeSides[strings.ToLower(tok.Value)] == "" {
+				return false
+			}
+			if len(sides) > 0 {
+				sides = append(sides, &css.Token{Tok: css.WhitespaceToken, Value: " "})
+			}
+			sides = append(sides, &css.Token{Tok: css.IdentToken, Value: oppositeSides[strings.ToLower(tok.Value)], Pos: tok.Pos})
+		}
+		if len(sides) == 0 {
+			return false
+		}
+		fn.Values = append(append(append(css.ComponentValues{}, fn.Values[:i]...), sides...), fn.Values[end:]...)
+		return true
+	}
+	return true
+}
+
+// oppositeSides maps gradient sides to their opposite side.
+var oppositeSides = map[string]string{"top": "bottom", "bottom": "top", "left": "right", "right": "left"}
+
+// pseudoNames returns the names of the pseudo-classes and pseudo-elements
+// in a selector which appear in the selector table, including their colons.
+func pseudoNames(values css.ComponentValues) []string {
+	var a []string
+	for i := range values {
+		if name, _ := pseudoAt(values, i); name != "" {
+			if _, ok := selectors.standard[name]; ok {
+				a = append(a, name)
+			} else if _, ok := selectors.variants[name]; ok {
+				a = append(a, name)
+			}
+		}
+	}
+	return a
+}
+
+// pseudoAt returns the lowercase name of the pseudo-class or pseudo-element
+// starting at index i and the number of values it spans.
+func pseudoAt(values css.ComponentValues, i int) (string, int) {
+	var colons int
+	for j := i; j < len(values); j++ {
+		tok, ok := values[j].(*css.Token)
+		if !ok {
+			return "", 0
+		}
+		switch {
+		case tok.Tok == css.ColonToken && colons < 2:
+			colons++
+		case tok.Tok == css.IdentToken && colons > 0:
+			// Don't match the second half of a pseudo-element.
+			if i > 0 {
+				if prev, ok := values[i-1].(*css.Token); ok && prev.Tok == css.ColonToken {
+					return "", 0
+				}
+			}
+			return strings.Repeat(":", colons) + strings.ToLower(tok.Value), j - i + 1
+		default:
+			return "", 0
+		}
+	}
+	return "", 0
+}
+
+// replacePseudo returns a copy of values with the pseudo-class or pseudo-element
+// named from replaced with to.
+func replacePseudo(values css.ComponentValues, from, to string) css.ComponentValues {
+	var other css.ComponentValues
+	for i := 0; i < len(values); i++ {
+		if name, n := pseudoAt(values, i); name == from {
+			pos := css.Position(values[i])
+			for j := 0; j < len(to)-len(strings.TrimLeft(to, ":")); j++ {
+				other = append(other, &css.Token{Tok: css.ColonToken, Pos: pos})
+			}
+			other = append(other, &css.Token{Tok: css.IdentToken, Value: strings.TrimLeft(to, ":"), Pos: pos})
+			i += n - 1
+			continue
+		}
+		other = append(other, cloneValue(values[i]))
+	}
+	return other
+}
+
+// identValue returns the identifier if values contains a single identifier.
+func identValue(values css.ComponentValues) string {
+	var ident string
+	for _, v := range values {
+		if isWhitespace(v) {
+			continue
+		}
+		tok, ok := v.(*css.Token)
+		if !ok || tok.Tok != css.IdentToken || ident != "" {
+			return ""
+		}
+		ident = tok.Value
+	}
+	return ident
+}
+
+// replaceIdent returns a copy of values with its identifier replaced.
+func replaceIdent(values css.ComponentValues, ident string) css.ComponentValues {
+	values = cloneValues(values)
+	for _, v := range values {
+		if tok, ok := v.(*css.Token); ok && tok.Tok == css.IdentToken {
+			tok.Value = ident
+		}
+	}
+	return values
+}
+
+// containsIdent returns true if values contains the identifier.
+func containsIdent(values css.ComponentValues, ident string) bool {
+	for _, v := range values {
+		if tok, ok := v.(*css.Token); ok && tok.Tok == css.IdentToken && strings.EqualFold(tok.Value, ident) {
+			return true
+		}
+	}
+	return false
+}
+
+// functionNames returns the lowercase names of the top-level functions in values.
+func functionNames(values css.ComponentValues) []string {
+	var a []string
+	for _, v := range values {
+		if fn, ok := v.(*css.Function); ok {
+			a = append(a, strings.ToLower(fn.Name))
+		}
+	}
+	return a
+}
+
+// containsStandardFunction returns true if values contains an unprefixed
+// function from the function table.
+func containsStandardFunction(values css.ComponentValues) bool {
+	for _, name := range functionNames(values) {
+		if _, ok := functionTable[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// indexDeclaration returns the index of the declaration with the given name.
+// If value is not blank then the declaration's value must also match.
+func indexDeclaration(a css.Declarations, name, value string) int {
+	for i, n := range a {
+		d, ok := n.(*css.Declaration)
+		if !ok || !strings.EqualFold(d.Name, name) {
+			continue
+		}
+		if value == "" || strings.EqualFold(strings.TrimSpace(print(d.Values)), strings.TrimSpace(value)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexQualifiedRule returns the index of the qualified rule with the given prelude.
+func indexQualifiedRule(a css.Rules, prelude string) int {
+	for i, r := range a {
+		if r, ok := r.(*css.QualifiedRule); ok && print(r.Prelude) == prelude {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexAtRule returns the index of the at-rule with the given name and prelude.
+func indexAtRule(a css.Rules, name, prelude string) int {
+	for i, r := range a {
+		if r, ok := r.(*css.AtRule); ok && strings.EqualFold(r.Name, name) && print(r.Prelude) == prelude {
+			return i
+		}
+	}
+	return -1
+}
+
+// declarationValues converts declarations into the component values of a
+// block. The whitespace before the first declaration in the original values
+// is used to separate declarations so that indentation is preserved.
+func declarationValues(a css.Declarations, orig css.ComponentValues) css.ComponentValues {
+	leading, trailing := edgeWhitespace(orig)
+	sep := leading
+	if sep == nil {
+		sep = &css.Token{Tok: css.WhitespaceToken, Value: " "}
+	}
+
+	var values css.ComponentValues
+	if leading != nil {
+		values = append(values, leading)
+	}
+	for i, n := range a {
+		if i > 0 {
+			values = append(values, sep)
+		}
+
+		switch n := n.(type) {
+		case *css.Declaration:
+			values = append(values, &css.Token{Tok: css.IdentToken, Value: n.Name, Pos: n.Pos}, &css.Token{Tok: css.ColonToken})
+			values = append(values, trimRight(n.Values)...)
+			if n.Important {
+				values = append(values,
+					&css.Token{Tok: css.WhitespaceToken, Value: " "},
+					&css.Token{Tok: css.DelimToken, Value: "!"},
+					&css.Token{Tok: css.IdentToken, Value: "important"},
+				)
+			}
+			values = append(values, &css.Token{Tok: css.SemicolonToken})
+		case *css.AtRule:
+			values = append(values, atRuleValues(n)...)
+		}
+	}
+	if trailing != nil {
+		values = append(values, trailing)
+	}
+	return values
+}
+
+// ruleValues converts rules into the component values of a block. Like
+// declarationValues, the original indentation is preserved.
+func ruleValues(a css.Rules, orig css.ComponentValues) css.ComponentValues {
+	leading, trailing := edgeWhitespace(orig)
+	sep := leading
+	if sep == nil {
+		sep = &css.Token{Tok: css.WhitespaceToken, Value: " "}
+	}
+
+	var values css.ComponentValues
+	if leading != nil {
+		values = append(values, leading)
+	}
+	for i, r := range a {
+		if i > 0 {
+			values = append(values, sep)
+		}
+		switch r := r.(type) {
+		case *css.QualifiedRule:
+			values = append(values, r.Prelude...)
+			values = append(values, r.Block)
+		case *css.AtRule:
+			values = append(values, atRuleValues(r)...)
+		}
+	}
+	if trailing != nil {
+		values = append(values, trailing)
+	}
+	return values
+}
+
+// atRuleValues converts an at-rule into component values.
+func atRuleValues(r *css.AtRule) css.ComponentValues {
+	values := css.ComponentValues{&css.Token{Tok: css.AtKeywordToken, Value: r.Name, Pos: r.Pos}}
+	values = append(values, r.Prelude...)
+	if r.Block != nil {
+		return append(values, r.Block)
+	}
+	return append(values, &css.Token{Tok: css.SemicolonToken})
+}
+
+// edgeWhitespace returns the leading and trailing whitespace tokens of values.
+func edgeWhitespace(values css.ComponentValues) (leading, trailing css.ComponentValue) {
+	if len(values) > 0 && isWhitespace(values[0]) {
+		leading = values[0]
+	}
+	if len(values) > 1 && isWhitespace(values[len(values)-1]) {
+		trailing = values[len(values)-1]
+	}
+	return
+}
+
+// trimRight removes trailing whitespace from values.
+func trimRight(values css.ComponentValues) css.ComponentValues {
+	for len(values) > 0 && isWhitespace(values[len(values)-1]) {
+		values = values[:len(values)-1]
+	}
+	return values
+}
+
+// isWhitespace returns true if v is a whitespace token.
+func isWhitespace(v css.ComponentValue) bool {
+	tok, ok := v.(*css.Token)
+	return ok && tok.Tok == css.WhitespaceToken
+}
+
+// cloneValues returns a deep copy of values.
+func cloneValues(values css.ComponentValues) css.ComponentValues {
+	if values == nil {
+		return nil
+	}
+	other := make(css.ComponentValues, len(values))
+	for i, v := range values {
+		other[i] = cloneValue(v)
+	}
+	return other
+}
+
+// cloneValue returns a deep copy of a component value.
+func cloneValue(v css.ComponentValue) css.ComponentValue {
+	switch v := v.(type) {
+	case *css.Token:
+		other := *v
+		return &other
+	case *css.Function:
+		return &css.Function{Name: v.Name, Values: cloneValues(v.Values), Pos: v.Pos}
+	case *css.SimpleBlock:
+		return cloneBlock(v)
+	}
+	return v
+}
+
+// cloneBlock returns a deep copy of a block.
+func cloneBlock(b *css.SimpleBlock) *css.SimpleBlock {
+	if b == nil {
+		return nil
+	}
+	other := &css.SimpleBlock{Values: cloneValues(b.Values), Pos: b.Pos}
+	if b.Token != nil {
+		tok := *b.Token
+		other.Token = &tok
+	}
+	return other
+}
+
+// containsString returns true if a contains s.
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// print returns the printed form of a node.
+func print(n css.Node) string {
+	var buf bytes.Buffer
+	var p css.Printer
+	_ = p.Print(&buf, n)
+	return buf.String()
+}
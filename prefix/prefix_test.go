@@ -0,0 +1,70 @@
+package prefix_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+	"github.com/benbjohnson/css/prefix"
+)
+
+// Ensure that vendor prefixes are added and removed for the targets.
+func TestPrefix(t *testing.T) {
+	var tests = []struct {
+		targets string
+		in      string
+		out     string
+	}{
+		// Properties.
+		{targets: `chrome 30`, in: `a { transform: none }`, out: `a { -webkit-transform: none; transform: none; }`},
+		{targets: `chrome >= 40`, in: `a { transform: none }`, out: `a { transform: none }`},
+		{targets: `chrome >= 40`, in: `a { -webkit-transform: none; transform: none }`, out: `a { transform: none; }`},
+		{targets: `chrome >= 40`, in: `a { -webkit-transform: none }`, out: `a { -webkit-transform: none }`},
+		{targets: `chrome >= 40`, in: `a { -webkit-tap-highlight-color: red; color: red }`, out: `a { -webkit-tap-highlight-color: red; color: red }`},
+		{targets: `safari >= 15, firefox 60`, in: "a {\n  user-select: none !important;\n}", out: "a {\n  -webkit-user-select: none !important;\n  -moz-user-select: none !important;\n  user-select: none !important;\n}"},
+		{targets: `ie 9-11`, in: `a { transform: none }`, out: `a { -ms-transform: none; transform: none; }`},
+		{targets: `ie 11`, in: `a { transform: none }`, out: `a { transform: none }`},
+		{targets: `chrome 30`, in: `a { -webkit-transform: none; transform: none }`, out: `a { -webkit-transform: none; transform: none }`},
+
+		// Values.
+		{targets: `safari 8, ie 10`, in: `a { display: flex }`, out: `a { display: -webkit-flex; display: -ms-flexbox; display: flex; }`},
+		{targets: `safari >= 13`, in: `a { position: -webkit-sticky; position: sticky }`, out: `a { position: sticky; }`},
+		{targets: `safari 12`, in: `a { position: sticky }`, out: `a { position: -webkit-sticky; position: sticky; }`},
+
+		// Functions.
+		{targets: `chrome 25`, in: `a { background: linear-gradient(to right, red, blue) }`, out: `a { background: -webkit-linear-gradient(left, red, blue); background: linear-gradient(to right, red, blue); }`},
+		{targets: `firefox 15`, in: `a { background: linear-gradient(45deg, red, blue) }`, out: `a { background: -moz-linear-gradient(45deg, red, blue); background: linear-gradient(45deg, red, blue); }`},
+		{targets: `chrome 25`, in: `a { background: linear-gradient(0deg, red, blue) }`, out: `a { background: -webkit-linear-gradient(90deg, red, blue); background: linear-gradient(0deg, red, blue); }`},
+		{targets: `chrome 25`, in: `a { background: radial-gradient(circle at top, red, blue) }`, out: `a { background: radial-gradient(circle at top, red, blue) }`},
+		{targets: `chrome >= 80`, in: `a { background: -webkit-linear-gradient(left, red, blue); background: linear-gradient(to right, red, blue) }`, out: `a { background: linear-gradient(to right, red, blue); }`},
+
+		// Selectors.
+		{targets: `chrome 50, firefox 50`, in: `input::placeholder { color: gray }`, out: `input::-webkit-input-placeholder { color: gray } input::-moz-placeholder { color: gray } input::placeholder { color: gray }`},
+		{targets: `ie 11`, in: `input::placeholder { color: gray }`, out: `input:-ms-input-placeholder { color: gray } input::placeholder { color: gray }`},
+		{targets: `chrome >= 80`, in: `input::-webkit-input-placeholder { color: gray } input::placeholder { color: gray }`, out: `input::placeholder { color: gray }`},
+		{targets: `firefox 61`, in: `::selection { color: red }`, out: `::-moz-selection { color: red } ::selection { color: red }`},
+
+		// At-rules.
+		{targets: `safari 8, firefox 15`, in: `@keyframes x { from { transform: none } }`, out: `@-webkit-keyframes x { from { -webkit-transform: none; transform: none; } } @-moz-keyframes x { from { -moz-transform: none; transform: none; } } @keyframes x { from { -webkit-transform: none; -moz-transform: none; transform: none; } }`},
+		{targets: `chrome >= 80`, in: `@-webkit-keyframes x { from { top: 0 } } @keyframes x { from { top: 0 } }`, out: `@keyframes x { from { top: 0 } }`},
+		{targets: `chrome 30`, in: `@media print { a { transform: none } }`, out: `@media print { a { -webkit-transform: none; transform: none; } }`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		ss := p.ParseStyleSheet(css.NewScanner(strings.NewReader(tt.in)))
+		if len(p.Errors) > 0 {
+			t.Fatalf("%d. %s", i, p.Errors[0])
+		}
+		prefix.Prefix(ss, prefix.MustParseTargets(tt.targets))
+
+		var buf bytes.Buffer
+		var printer css.Printer
+		if err := printer.Print(&buf, ss); err != nil {
+			t.Fatal(err)
+		} else if s := buf.String(); s != tt.out {
+			t.Errorf("%d. <%q>\n\nexp: %s\n\ngot: %s", i, tt.in, tt.out, s)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package prefix
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version represents a browser version.
+type Version struct {
+	Major int
+	Minor int
+}
+
+// Less returns true if v is an earlier version than other.
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	return v.Minor < other.Minor
+}
+
+// IsZero returns true if the version is unset.
+func (v Version) IsZero() bool { return v == Version{} }
+
+// String returns the version as "major.minor", or "major" if there is no minor version.
+func (v Version) String() string {
+	if v.Minor == 0 {
+		return strconv.Itoa(v.Major)
+	}
+	return strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor)
+}
+
+// Target represents a range of versions of a single browser.
+type Target struct {
+	Browser string
+
+	// The first version in the range and the first version after it.
+	// A zero Max means the range has no upper bound.
+	Min Version
+	Max Version
+}
+
+// includes returns true if any version from since up to, but not including,
+// until is in the target range. A zero until includes all later versions.
+func (t Target) includes(browser string, since, until Version) bool {
+	if t.Browser != browser {
+		return false
+	} else if !until.IsZero() && !t.Min.Less(until) {
+		return false
+	} else if !t.Max.IsZero() && !since.Less(t.Max) {
+		return false
+	}
+	return true
+}
+
+// Targets represents the set of browsers that a style sheet supports.
+type Targets []Target
+
+// browsers maps browser names and their aliases to canonical names.
+var browsers = map[string]string{
+	"chrome":   "chrome",
+	"and_chr":  "chrome",
+	"firefox":  "firefox",
+	"ff":       "firefox",
+	"and_ff":   "firefox",
+	"safari":   "safari",
+	"ios_saf":  "ios_saf",
+	"ios":      "ios_saf",
+	"edge":     "edge",
+	"ie":       "ie",
+	"explorer": "ie",
+	"opera":    "opera",
+	"android":  "android",
+	"samsung":  "samsung",
+}
+
+// ParseTargets parses a browserslist-like query into a list of targets.
+//
+// Queries are separated by commas, newlines or "or". Each query is a browser
+// name followed by a version ("safari 9"), a comparison ("chrome >= 80") or
+// an inclusive range ("ie 10-11"). Lines starting with "#" are comments.
+func ParseTargets(s string) (Targets, error) {
+	var a Targets
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.IndexByte(line, '#'); i != -1 {
+			line = line[:i]
+		}
+		for _, q := range strings.Split(line, ",") {
+			for _, q := range strings.Split(q, " or ") {
+				if q = strings.TrimSpace(q); q == "" {
+					continue
+				}
+				t, err := parseTarget(q)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, t)
+			}
+		}
+	}
+	return a, nil
+}
+
+// MustParseTargets parses a query and panics if it is invalid.
+func MustParseTargets(s string) Targets {
+	a, err := ParseTargets(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// parseTarget parses a single query.
+func parseTarget(q string) (Target, error) {
+	fields := strings.Fields(strings.ToLower(q))
+	if len(fields) == 0 {
+		return Target{}, fmt.Errorf("empty query")
+	}
+
+	// Allow the operator to be attached to the version, such as "chrome >=80".
+	if len(fields) == 2 {
+		if i := strings.IndexAny(fields[1], "0123456789"); i > 0 {
+			fields = []string{fields[0], fields[1][:i], fields[1][i:]}
+		}
+	}
+
+	browser, ok := browsers[fields[0]]
+	if !ok {
+		return Target{}, fmt.Errorf("unknown browser: %s", fields[0])
+	}
+	t := Target{Browser: browser}
+
+	switch len(fields) {
+	case 1:
+		return t, nil
+
+	case 2:
+		// An exact version or an inclusive range of versions.
+		lo, hi := fields[1], fields[1]
+		if i := strings.IndexByte(fields[1], '-'); i != -1 {
+			lo, hi = fields[1][:i], fields[1][i+1:]
+		}
+		min, _, err := parseVersion(lo)
+		if err != nil {
+			return Target{}, err
+		}
+		max, minor, err := parseVersion(hi)
+		if err != nil {
+			return Target{}, err
+		}
+		t.Min, t.Max = min, next(max, minor)
+		if !t.Min.Less(t.Max) {
+			return Target{}, fmt.Errorf("invalid version range: %s", fields[1])
+		}
+		return t, nil
+
+	case 3:
+		v, minor, err := parseVersion(fields[2])
+		if err != nil {
+			return Target{}, err
+		}
+		switch fields[1] {
+		case ">=":
+			t.Min = v
+		case ">":
+			t.Min = next(v, minor)
+		case "<=":
+			t.Max = next(v, minor)
+		case "<":
+			t.Max = v
+		default:
+			return Target{}, fmt.Errorf("unknown operator: %s", fields[1])
+		}
+		return t, nil
+	}
+	return Target{}, fmt.Errorf("unsupported query: %s", q)
+}
+
+// parseVersion parses a "major" or "major.minor" version. Returns true if
+// the minor version was specified.
+func parseVersion(s string) (Version, bool, error) {
+	major, minor := s, ""
+	if i := strings.IndexByte(s, '.'); i != -1 {
+		major, minor = s[:i], s[i+1:]
+	}
+
+	var v Version
+	var err error
+	if v.Major, err = strconv.Atoi(major); err != nil || v.Major < 0 {
+		return Version{}, false, fmt.Errorf("invalid version: %s", s)
+	}
+	if minor == "" {
+		return v, false, nil
+	}
+	if v.Minor, err = strconv.Atoi(minor); err != nil || v.Minor < 0 {
+		return Version{}, false, fmt.Errorf("invalid version: %s", s)
+	}
+	return v, true, nil
+}
+
+// next returns the first version after v. If minor is false then the next
+// major version is returned.
+func next(v Version, minor bool) Version {
+	if minor {
+		return Version{v.Major, v.Minor + 1}
+	}
+	return Version{v.Major + 1, 0}
+}
@@ -0,0 +1,54 @@
+package prefix_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/benbjohnson/css/prefix"
+)
+
+// Ensure that browserslist-like queries can be parsed into targets.
+func TestParseTargets(t *testing.T) {
+	var tests = []struct {
+		in      string
+		targets prefix.Targets
+		err     string
+	}{
+		{in: `chrome >= 80`, targets: prefix.Targets{{Browser: "chrome", Min: prefix.Version{80, 0}}}},
+		{in: `Safari 9`, targets: prefix.Targets{{Browser: "safari", Min: prefix.Version{9, 0}, Max: prefix.Version{10, 0}}}},
+		{in: `ios 15.4`, targets: prefix.Targets{{Browser: "ios_saf", Min: prefix.Version{15, 4}, Max: prefix.Version{15, 5}}}},
+		{in: `ie 10-11`, targets: prefix.Targets{{Browser: "ie", Min: prefix.Version{10, 0}, Max: prefix.Version{12, 0}}}},
+		{in: `ff > 60`, targets: prefix.Targets{{Browser: "firefox", Min: prefix.Version{61, 0}}}},
+		{in: `safari <= 15.4`, targets: prefix.Targets{{Browser: "safari", Max: prefix.Version{15, 5}}}},
+		{in: `edge <90`, targets: prefix.Targets{{Browser: "edge", Max: prefix.Version{90, 0}}}},
+		{in: `ie`, targets: prefix.Targets{{Browser: "ie"}}},
+		{
+			in: "# comment\nchrome >= 80, firefox >= 70 or safari >= 13\n\n",
+			targets: prefix.Targets{
+				{Browser: "chrome", Min: prefix.Version{80, 0}},
+				{Browser: "firefox", Min: prefix.Version{70, 0}},
+				{Browser: "safari", Min: prefix.Version{13, 0}},
+			},
+		},
+
+		{in: `netscape 4`, err: `unknown browser: netscape`},
+		{in: `chrome ~ 80`, err: `unknown operator: ~`},
+		{in: `chrome >= x`, err: `invalid version: x`},
+		{in: `ie 11-10`, err: `invalid version range: 11-10`},
+		{in: `last 2 versions`, err: `unknown browser: last`},
+		{in: `chrome >= 80 90`, err: `unsupported query: chrome >= 80 90`},
+	}
+
+	for i, tt := range tests {
+		targets, err := prefix.ParseTargets(tt.in)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, err)
+			}
+		} else if err != nil {
+			t.Errorf("%d. <%q> unexpected error: %s", i, tt.in, err)
+		} else if !reflect.DeepEqual(targets, tt.targets) {
+			t.Errorf("%d. <%q>\n\nexp: %#v\n\ngot: %#v", i, tt.in, tt.targets, targets)
+		}
+	}
+}
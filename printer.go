@@ -4,24 +4,58 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
-// TODO(benbjohnson): Allow collapsing whitespace.
-
 // Printer represents a configurable CSS printer.
-type Printer struct{}
+type Printer struct {
+	// Compact omits the spaces printed between rules and declarations.
+	Compact bool
+
+	// Indent, if set, formats style sheets and rules with each rule and
+	// declaration on its own line, indented by Indent for each level of
+	// nesting. Whitespace within preludes and values is collapsed. Blocks
+	// which can't be parsed into rules or declarations without errors are
+	// printed as they are. Compact is ignored.
+	Indent string
+
+	// Comments are printed between the rules and declarations that they
+	// appear between in the source when formatting with an indent.
+	// See Scanner.KeepComments.
+	Comments []*Comment
 
-func (p *Printer) Print(w io.Writer, n Node) (err error) {
+	// SourceMap, if set, records the source position of each printed
+	// token and rule. It is shared across calls to Print.
+	SourceMap *SourceMapGenerator
+}
+
+// Print writes the CSS representation of a node to w.
+func (p *Printer) Print(w io.Writer, n Node) error {
+	if p.SourceMap != nil {
+		w = &sourceMapWriter{w: w, g: p.SourceMap}
+	}
+	if p.Indent != "" {
+		switch n.(type) {
+		case *StyleSheet, Rules, Rule:
+			f := &formatter{p: p, w: w, comments: p.Comments}
+			return f.format(n)
+		}
+	}
+	return p.print(w, n)
+}
+
+func (p *Printer) print(w io.Writer, n Node) (err error) {
 	switch n := n.(type) {
 	case *StyleSheet:
 		if n == nil {
 			return nil
 		}
 		for i, r := range n.Rules {
-			if i > 0 {
+			if i > 0 && !p.Compact {
 				_, err = w.Write([]byte{' '})
 			}
-			_ = p.Print(w, r)
+			_ = p.print(w, r)
 		}
 
 	case Rules:
@@ -29,23 +63,24 @@ func (p *Printer) Print(w io.Writer, n Node) (err error) {
 			return nil
 		}
 		for i, r := range n {
-			if i > 0 {
+			if i > 0 && !p.Compact {
 				_, _ = w.Write([]byte{' '})
 			}
-			err = p.Print(w, r)
+			err = p.print(w, r)
 		}
 
 	case *AtRule:
 		if n == nil {
 			return nil
 		}
+		p.mark(n.Pos)
 		_, _ = w.Write([]byte{'@'})
-		_, _ = w.Write([]byte(n.Name))
+		_, _ = w.Write([]byte(serializeIdent(n.Name)))
 		if len(n.Prelude) > 0 {
-			_ = p.Print(w, n.Prelude)
+			_ = p.print(w, n.Prelude)
 		}
 		if n.Block != nil {
-			err = p.Print(w, n.Block)
+			err = p.print(w, n.Block)
 		} else {
 			_, err = w.Write([]byte{';'})
 		}
@@ -54,16 +89,18 @@ func (p *Printer) Print(w io.Writer, n Node) (err error) {
 		if n == nil {
 			return nil
 		}
-		_ = p.Print(w, n.Prelude)
-		err = p.Print(w, n.Block)
+		p.mark(n.Pos)
+		_ = p.print(w, n.Prelude)
+		err = p.print(w, n.Block)
 
 	case *Declaration:
 		if n == nil {
 			return nil
 		}
-		_, _ = w.Write([]byte(n.Name))
+		p.mark(n.Pos)
+		_, _ = w.Write([]byte(serializeIdent(n.Name)))
 		_, _ = w.Write([]byte{':'})
-		err = p.Print(w, n.Values)
+		err = p.print(w, n.Values)
 		if n.Important {
 			_, err = w.Write([]byte("!important"))
 		}
@@ -73,10 +110,10 @@ func (p *Printer) Print(w io.Writer, n Node) (err error) {
 			return nil
 		}
 		for i, v := range n {
-			if i > 0 {
+			if i > 0 && !p.Compact {
 				_, _ = w.Write([]byte{' '})
 			}
-			_ = p.Print(w, v)
+			_ = p.print(w, v)
 			_, err = w.Write([]byte{';'})
 		}
 
@@ -85,13 +122,14 @@ func (p *Printer) Print(w io.Writer, n Node) (err error) {
 			return nil
 		}
 		for _, v := range n {
-			err = p.Print(w, v)
+			err = p.print(w, v)
 		}
 
 	case *SimpleBlock:
 		if n == nil {
 			return nil
 		}
+		p.mark(n.Pos)
 		switch n.Token.Tok {
 		case LBraceToken:
 			_, _ = w.Write([]byte{'{'})
@@ -101,7 +139,7 @@ func (p *Printer) Print(w io.Writer, n Node) (err error) {
 			_, _ = w.Write([]byte{'('})
 		}
 
-		_ = p.Print(w, n.Values)
+		_ = p.print(w, n.Values)
 
 		switch n.Token.Tok {
 		case LBraceToken:
@@ -116,33 +154,37 @@ func (p *Printer) Print(w io.Writer, n Node) (err error) {
 		if n == nil {
 			return nil
 		}
-		_, _ = w.Write([]byte(n.Name))
+		p.mark(n.Pos)
+		_, _ = w.Write([]byte(serializeIdent(n.Name)))
 		_, _ = w.Write([]byte{'('})
-		_ = p.Print(w, n.Values)
+		_ = p.print(w, n.Values)
 		_, err = w.Write([]byte{')'})
 
 	case *Token:
 		if n == nil {
 			return nil
 		}
+		p.mark(n.Pos)
 		switch n.Tok {
 		case IdentToken:
-			_, err = w.Write([]byte(n.Value))
+			_, err = w.Write([]byte(serializeIdent(n.Value)))
 		case FunctionToken:
-			_, err = w.Write([]byte(n.Value + "("))
+			_, err = w.Write([]byte(serializeIdent(n.Value) + "("))
 		case AtKeywordToken:
-			_, err = w.Write([]byte("@" + n.Value))
+			_, err = w.Write([]byte("@" + serializeIdent(n.Value)))
 		case HashToken:
-			_, err = w.Write([]byte("#" + n.Value))
+			_, err = w.Write([]byte("#" + serializeName(n.Value)))
 		case StringToken:
-			_, err = w.Write([]byte(string(n.Ending) + n.Value + string(n.Ending)))
+			_, err = w.Write([]byte(serializeString(n.Value, n.Ending)))
 		case BadStringToken:
 			_, err = w.Write([]byte("''"))
 		case URLToken:
-			_, err = w.Write([]byte("url(" + n.Value + ")"))
+			_, err = w.Write([]byte(serializeURL(n.Value)))
 		case BadURLToken:
 			_, err = w.Write([]byte("url()"))
-		case DelimToken, NumberToken, PercentageToken, DimensionToken, WhitespaceToken:
+		case DimensionToken:
+			_, err = w.Write([]byte(serializeDimension(n)))
+		case DelimToken, NumberToken, PercentageToken, WhitespaceToken:
 			_, err = w.Write([]byte(n.Value))
 		case UnicodeRangeToken:
 			if n.Start == n.End {
@@ -192,6 +234,13 @@ func (p *Printer) Print(w io.Writer, n Node) (err error) {
 	return
 }
 
+// mark maps the current output position to pos, if source maps are enabled.
+func (p *Printer) mark(pos Pos) {
+	if p.SourceMap != nil {
+		p.SourceMap.add(pos)
+	}
+}
+
 // print pretty prints an AST node to a string using the default configuration.
 func print(n Node) string {
 	var p Printer
@@ -199,3 +248,105 @@ func print(n Node) string {
 	_ = p.Print(&buf, n)
 	return buf.String()
 }
+
+// serializeIdent returns an identifier escaped so that it is scanned as the
+// same identifier, following the CSSOM rules for serializing identifiers.
+func serializeIdent(s string) string {
+	var buf strings.Builder
+	for i, ch := range s {
+		switch {
+		case i == 0 && ch >= '0' && ch <= '9',
+			i == 1 && ch >= '0' && ch <= '9' && s[0] == '-':
+			writeHexEscape(&buf, ch)
+		case i == 0 && ch == '-' && len(s) == 1:
+			buf.WriteString(`\-`)
+		default:
+			writeNameChar(&buf, ch)
+		}
+	}
+	return buf.String()
+}
+
+// serializeName returns a name, such as that of a hash token, escaped so
+// that it is scanned as the same name.
+func serializeName(s string) string {
+	var buf strings.Builder
+	for _, ch := range s {
+		writeNameChar(&buf, ch)
+	}
+	return buf.String()
+}
+
+// writeNameChar writes a code point of an identifier or name, escaping it
+// if it isn't a name code point.
+func writeNameChar(buf *strings.Builder, ch rune) {
+	switch {
+	case ch <= 0x1F || ch == 0x7F:
+		writeHexEscape(buf, ch)
+	case ch >= 0x80, ch == '-', ch == '_', ch >= '0' && ch <= '9', ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z':
+		buf.WriteRune(ch)
+	default:
+		buf.WriteByte('\\')
+		buf.WriteRune(ch)
+	}
+}
+
+// serializeString returns a string quoted by quote, or by a double quote if
+// quote is unset, with the quote and backslashes escaped.
+func serializeString(s string, quote rune) string {
+	if quote != '\'' {
+		quote = '"'
+	}
+	var buf strings.Builder
+	buf.WriteRune(quote)
+	for _, ch := range s {
+		switch {
+		case ch <= 0x1F || ch == 0x7F:
+			writeHexEscape(&buf, ch)
+		case ch == quote || ch == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(ch)
+		default:
+			buf.WriteRune(ch)
+		}
+	}
+	buf.WriteRune(quote)
+	return buf.String()
+}
+
+// serializeURL returns a url token. The URL is quoted if it contains
+// characters which can't appear in an unquoted url.
+func serializeURL(s string) string {
+	for _, ch := range s {
+		switch {
+		case ch <= ' ', ch == 0x7F, ch == '"', ch == '\'', ch == '(', ch == ')', ch == '\\':
+			return "url(" + serializeString(s, '"') + ")"
+		}
+	}
+	return "url(" + s + ")"
+}
+
+// serializeDimension returns a dimension token with its unit escaped. An
+// "e" which begins the unit is escaped if it could be read as an exponent.
+func serializeDimension(tok *Token) string {
+	if tok.Unit == "" || !strings.HasSuffix(tok.Value, tok.Unit) {
+		return tok.Value
+	}
+	repr, unit := tok.Value[:len(tok.Value)-len(tok.Unit)], serializeIdent(tok.Unit)
+	if unit[0] == 'e' || unit[0] == 'E' {
+		rest := unit[1:]
+		if len(rest) > 1 && (rest[0] == '+' || rest[0] == '-') {
+			rest = rest[1:]
+		}
+		if len(rest) > 0 && rest[0] >= '0' && rest[0] <= '9' {
+			unit = `\` + strconv.FormatInt(int64(unit[0]), 16) + " " + unit[1:]
+		}
+	}
+	return repr + unit
+}
+
+// writeHexEscape writes a code point as a hexadecimal escape followed by a
+// space so that the escape ends there.
+func writeHexEscape(buf *strings.Builder, ch rune) {
+	buf.WriteString(`\` + strconv.FormatInt(int64(ch), 16) + " ")
+}
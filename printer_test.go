@@ -2,6 +2,8 @@ package css_test
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/benbjohnson/css"
@@ -27,7 +29,7 @@ func TestPrinter_Print(t *testing.T) {
 						Values: []css.ComponentValue{
 							&css.Token{Tok: css.IdentToken, Value: "font-size"},
 							&css.Token{Tok: css.ColonToken},
-							&css.Token{Tok: css.IdentToken, Value: "10px"},
+							&css.Token{Tok: css.DimensionToken, Value: "10px", Unit: "px"},
 						},
 					},
 				},
@@ -106,4 +108,144 @@ func TestPrinter_Print(t *testing.T) {
 	}
 }
 
+// Ensure that the printer omits separators between rules and declarations in compact mode.
+func TestPrinter_Print_Compact(t *testing.T) {
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScanner(strings.NewReader(`a{b:c} @d;`)))
+	decls := p.ParseDeclarations(css.NewScanner(strings.NewReader(`a:b;c:d`)))
+
+	var buf bytes.Buffer
+	printer := css.Printer{Compact: true}
+	if err := printer.Print(&buf, ss); err != nil {
+		t.Fatal(err)
+	} else if s := buf.String(); s != `a{b:c}@d;` {
+		t.Fatalf("unexpected output: %s", s)
+	}
+
+	buf.Reset()
+	if err := printer.Print(&buf, decls); err != nil {
+		t.Fatal(err)
+	} else if s := buf.String(); s != `a:b;c:d;` {
+		t.Fatalf("unexpected output: %s", s)
+	}
+}
+
+// Ensure that the printer formats style sheets with an indent.
+func TestPrinter_Print_Indent(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out string
+	}{
+		{in: ``, out: ``},
+		{in: `a{b:c}`, out: "a {\n  b: c;\n}\n"},
+		{in: `a,b  >c{ b : c  d !important;;e:f }`, out: "a,b >c {\n  b: c d !important;\n  e: f;\n}\n"},
+		{in: "a {}\n\n\nb { }\nc{}", out: "a {}\n\nb {}\nc {}\n"},
+		{in: `@import url(a.css)   screen ;`, out: "@import url(a.css) screen;\n"},
+		{in: `@media print{a{b:c}@media (color){d{}}}`, out: "@media print {\n  a {\n    b: c;\n  }\n  @media (color) {\n    d {}\n  }\n}\n"},
+		{in: `@keyframes x{from{a:b}to{a:c}}`, out: "@keyframes x {\n  from {\n    a: b;\n  }\n  to {\n    a: c;\n  }\n}\n"},
+		{in: `@page{margin:1in;@top-left{content:"x"}}`, out: "@page {\n  margin: 1in;\n  @top-left {\n    content: \"x\";\n  }\n}\n"},
+		{in: `:root{--x:  a  b ;}`, out: ":root {\n  --x: a  b;\n}\n"},
+		{in: `a{width:calc( 1px  +  2em )}`, out: "a {\n  width: calc(1px + 2em);\n}\n"},
+
+		// Blocks which can't be parsed are printed as they are.
+		{in: `a { b:hover { c: d } }`, out: "a { b:hover { c: d } }\n"},
+		{in: `a { &:hover { c: d } }`, out: "a { &:hover { c: d } }\n"},
+		{in: `@foo bar { baz }`, out: "@foo bar { baz }\n"},
+
+		// Comments are kept between rules and declarations.
+		{in: "/* a */\n\nb { c: d; /* e */ }\n/* f */", out: "/* a */\n\nb {\n  c: d; /* e */\n}\n/* f */\n"},
+		{in: "a {\n  /* b */\n  c: d;\n  /* e */\n}", out: "a {\n  /* b */\n  c: d;\n  /* e */\n}\n"},
+		{in: "a { /* b */ }", out: "a {\n  /* b */\n}\n"},
+		{in: "a { b: /* c */ d; e: f }", out: "a {\n  b: d; /* c */\n  e: f;\n}\n"},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		s := css.NewScannerString(tt.in)
+		s.KeepComments = true
+		ss := p.ParseStyleSheet(s)
+
+		var buf bytes.Buffer
+		printer := css.Printer{Indent: "  ", Comments: s.Comments}
+		if err := printer.Print(&buf, ss); err != nil {
+			t.Fatal(err)
+		} else if buf.String() != tt.out {
+			t.Errorf("%d. %q: unexpected output:\n\nexp: %q\n\ngot: %q", i, tt.in, tt.out, buf.String())
+			continue
+		}
+
+		// Formatting the output again doesn't change it.
+		s = css.NewScannerString(tt.out)
+		s.KeepComments = true
+		ss = p.ParseStyleSheet(s)
+		buf.Reset()
+		printer = css.Printer{Indent: "  ", Comments: s.Comments}
+		if err := printer.Print(&buf, ss); err != nil {
+			t.Fatal(err)
+		} else if buf.String() != tt.out {
+			t.Errorf("%d. %q: output changed when formatted again: %q", i, tt.in, buf.String())
+		}
+	}
+}
+
+// Ensure that the printer escapes values so they are scanned as the same tokens.
+func TestPrinter_Print_Escape(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out string
+	}{
+		{in: `.\31 0{a:b}`, out: `.\31 0{a:b}`},
+		{in: `a{b:"a\"b"}`, out: `a{b:"a\"b"}`},
+		{in: `a{b:'a\'b"'}`, out: `a{b:'a\'b"'}`},
+		{in: `a{b:"a\\"}`, out: `a{b:"a\\"}`},
+		{in: `a{b:"a\
+b"}`, out: `a{b:"a\a b"}`},
+		{in: `a{b:"\a"}`, out: `a{b:"\a "}`},
+		{in: `a{b:url( "a b.png" )}`, out: `a{b:url("a b.png")}`},
+		{in: `a{b:url("a\"b")}`, out: `a{b:url("a\"b")}`},
+		{in: `a{b:url("a)")}`, out: `a{b:url("a)")}`},
+		{in: `a{b:url( a.png )}`, out: `a{b:url(a.png)}`},
+		{in: `#x\:y{a:b}`, out: `#x\:y{a:b}`},
+		{in: `#\31 {a:b}`, out: `#1{a:b}`},
+		{in: `\-\31 a{\@b:c}`, out: `-\31 a{\@b:c}`},
+		{in: `\-{a:b}`, out: `\-{a:b}`},
+		{in: `@\31 a;`, out: `@\31 a;`},
+		{in: `a{b:\31 a(c)}`, out: `a{b:\31 a(c)}`},
+		{in: `a{b:1\65 1}`, out: `a{b:1\65 1}`},
+		{in: `a{b:1\65-1}`, out: `a{b:1\65 -1}`},
+		{in: `a{b:1\65m 1\2e x}`, out: `a{b:1em 1\.x}`},
+		{in: `a{b:\0}`, out: `a{b:\0 }`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		ss := p.ParseStyleSheet(css.NewScannerString(tt.in))
+		if len(p.Errors) > 0 {
+			t.Fatalf("%d. %q: unexpected errors: %v", i, tt.in, p.Errors)
+		}
+
+		var buf bytes.Buffer
+		printer := css.Printer{Compact: true}
+		if err := printer.Print(&buf, ss); err != nil {
+			t.Fatal(err)
+		} else if buf.String() != tt.out {
+			t.Errorf("%d. %q: unexpected output:\n\nexp: %s\n\ngot: %s", i, tt.in, tt.out, buf.String())
+		} else if got, exp := tokens(buf.String()), tokens(tt.in); got != exp {
+			t.Errorf("%d. %q: unexpected tokens:\n\nexp: %s\n\ngot: %s", i, tt.in, exp, got)
+		}
+	}
+}
+
+// tokens returns the type and value of each token in s except whitespace.
+func tokens(s string) string {
+	var buf strings.Builder
+	sc := css.NewScannerString(s)
+	for tok := sc.Scan(); tok.Tok != css.EOFToken; tok = sc.Scan() {
+		if tok.Tok != css.WhitespaceToken {
+			fmt.Fprintf(&buf, "%s %q %q\n", tok.Tok, tok.Value, tok.Unit)
+		}
+	}
+	return buf.String()
+}
+
 // TODO(benbjohnson): Example: Printer.Print()
@@ -0,0 +1,482 @@
+package css
+
+import (
+	"sort"
+	"strings"
+)
+
+// Property represents the definition of a standard CSS property.
+type Property struct {
+	Name string
+
+	// Value definition of the property's values.
+	Syntax string
+
+	// Initial value. Empty for shorthands and UA-dependent values.
+	Initial string
+
+	// True if the property is inherited by default.
+	Inherited bool
+
+	// Elements the property applies to.
+	AppliesTo string
+
+	// How the property's values are interpolated.
+	Animation AnimationType
+
+	// Longhands set by a shorthand property, in canonical order.
+	Longhands []string
+
+	grammar *Grammar
+}
+
+// Grammar returns the parsed value definition of the property.
+func (p *Property) Grammar() *Grammar { return p.grammar }
+
+// IsShorthand returns true if the property sets other properties.
+func (p *Property) IsShorthand() bool { return len(p.Longhands) > 0 }
+
+// AnimationType represents the animation type of a property.
+type AnimationType int
+
+const (
+	// The property cannot be animated.
+	NotAnimatable AnimationType = iota
+
+	// Values flip from one to the other at the midpoint.
+	AnimationDiscrete
+
+	// Values are interpolated by their computed value type.
+	AnimationByComputedValue
+
+	// Each item of a list is interpolated, repeating the lists as necessary.
+	AnimationRepeatableList
+
+	// Shorthands are animated through their longhands.
+	AnimationLonghands
+)
+
+// String returns the name of the animation type.
+func (t AnimationType) String() string {
+	switch t {
+	case AnimationDiscrete:
+		return "discrete"
+	case AnimationByComputedValue:
+		return "by computed value type"
+	case AnimationRepeatableList:
+		return "repeatable list"
+	case AnimationLonghands:
+		return "see individual properties"
+	}
+	return "not animatable"
+}
+
+// LookupProperty returns the definition of a property by name. Names are
+// case-insensitive and known vendor-prefixed aliases resolve to their
+// standard property. Returns nil if the property is unknown.
+func LookupProperty(name string) *Property {
+	name = strings.ToLower(name)
+	if alias, ok := PropertyAliases[name]; ok {
+		name = alias
+	}
+	return propertiesByName[name]
+}
+
+// Properties returns the definitions of all standard properties, sorted by name.
+func Properties() []*Property {
+	a := make([]*Property, len(properties))
+	copy(a, properties)
+	sort.Sort(propertiesByNameSlice(a))
+	return a
+}
+
+type propertiesByNameSlice []*Property
+
+func (a propertiesByNameSlice) Len() int           { return len(a) }
+func (a propertiesByNameSlice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a propertiesByNameSlice) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+// Validate checks a declaration against the property database. An *Error
+// is returned for unknown properties and for values which do not match the
+// property's grammar.
+//
+// Custom properties, CSS-wide keywords and values containing var() or env()
+// references are always valid since they can only be checked at computed-value time.
+func Validate(d *Declaration) error {
+	if strings.HasPrefix(d.Name, "--") {
+		return nil
+	}
+
+	p := LookupProperty(d.Name)
+	if p == nil {
+		return newValidatorError("unknown-property", d, "unknown property: %s", d.Name)
+	}
+
+	values := d.Values.nonwhitespace()
+	if len(values) == 0 {
+		return newValidatorError("missing-value", d, "missing value for property: %s", d.Name)
+	} else if len(values) == 1 && isTok(values[0], IdentToken) && IsWideKeyword(values[0].(*Token).Value) {
+		return nil
+	} else if containsFunction(values, "var", "env") {
+		return nil
+	}
+
+	if _, err := propertyMatcher.Match(p.grammar, values); err != nil {
+		if e, ok := err.(*Error); ok {
+			err := newValidatorError("invalid-value", d, "invalid value for %s: %s", d.Name, e.Message)
+			err.Pos, err.End = e.Pos, e.End
+			return err
+		}
+		return err
+	}
+	return nil
+}
+
+// containsFunction returns true if any value or nested value is a function
+// with one of the given names.
+func containsFunction(values ComponentValues, names ...string) bool {
+	for _, v := range values {
+		switch v := v.(type) {
+		case *Function:
+			if isFunction(v, names) || containsFunction(v.Values, names...) {
+				return true
+			}
+		case *SimpleBlock:
+			if containsFunction(v.Values, names...) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PropertyAliases maps known vendor-prefixed property names to their
+// standard equivalent.
+var PropertyAliases = map[string]string{
+	"-webkit-align-content":              "align-content",
+	"-webkit-align-items":                "align-items",
+	"-webkit-align-self":                 "align-self",
+	"-webkit-animation":                  "animation",
+	"-webkit-animation-delay":            "animation-delay",
+	"-webkit-animation-direction":        "animation-direction",
+	"-webkit-animation-duration":         "animation-duration",
+	"-webkit-animation-fill-mode":        "animation-fill-mode",
+	"-webkit-animation-iteration-count":  "animation-iteration-count",
+	"-webkit-animation-name":             "animation-name",
+	"-webkit-animation-play-state":       "animation-play-state",
+	"-webkit-animation-timing-function":  "animation-timing-function",
+	"-webkit-appearance":                 "appearance",
+	"-moz-appearance":                    "appearance",
+	"-webkit-backface-visibility":        "backface-visibility",
+	"-webkit-background-clip":            "background-clip",
+	"-webkit-background-origin":          "background-origin",
+	"-webkit-background-size":            "background-size",
+	"-webkit-border-radius":              "border-radius",
+	"-moz-border-radius":                 "border-radius",
+	"-webkit-box-shadow":                 "box-shadow",
+	"-moz-box-shadow":                    "box-shadow",
+	"-webkit-box-sizing":                 "box-sizing",
+	"-moz-box-sizing":                    "box-sizing",
+	"-webkit-column-count":               "column-count",
+	"-moz-column-count":                  "column-count",
+	"-webkit-column-gap":                 "column-gap",
+	"-moz-column-gap":                    "column-gap",
+	"-webkit-column-width":               "column-width",
+	"-moz-column-width":                  "column-width",
+	"-webkit-columns":                    "columns",
+	"-moz-columns":                       "columns",
+	"-webkit-filter":                     "filter",
+	"-webkit-flex":                       "flex",
+	"-ms-flex":                           "flex",
+	"-webkit-flex-basis":                 "flex-basis",
+	"-webkit-flex-direction":             "flex-direction",
+	"-webkit-flex-flow":                  "flex-flow",
+	"-webkit-flex-grow":                  "flex-grow",
+	"-webkit-flex-shrink":                "flex-shrink",
+	"-webkit-flex-wrap":                  "flex-wrap",
+	"-webkit-hyphens":                    "hyphens",
+	"-ms-hyphens":                        "hyphens",
+	"-webkit-justify-content":            "justify-content",
+	"-webkit-order":                      "order",
+	"-moz-tab-size":                      "tab-size",
+	"-webkit-text-size-adjust":           "text-size-adjust",
+	"-ms-text-size-adjust":               "text-size-adjust",
+	"-webkit-transform":                  "transform",
+	"-moz-transform":                     "transform",
+	"-ms-transform":                      "transform",
+	"-o-transform":                       "transform",
+	"-webkit-transform-origin":           "transform-origin",
+	"-ms-transform-origin":               "transform-origin",
+	"-webkit-transition":                 "transition",
+	"-moz-transition":                    "transition",
+	"-o-transition":                      "transition",
+	"-webkit-transition-delay":           "transition-delay",
+	"-webkit-transition-duration":        "transition-duration",
+	"-webkit-transition-property":        "transition-property",
+	"-webkit-transition-timing-function": "transition-timing-function",
+	"-webkit-user-select":                "user-select",
+	"-moz-user-select":                   "user-select",
+	"-ms-user-select":                    "user-select",
+}
+
+// propertyTypes are the named types referenced by property grammars.
+var propertyTypes = map[string]string{
+	"alpha-value":                      `<number> | <percentage>`,
+	"attachment":                       `scroll | fixed | local`,
+	"absolute-size":                    `xx-small | x-small | small | medium | large | x-large | xx-large | xxx-large`,
+	"relative-size":                    `larger | smaller`,
+	"baseline-position":                `[ first | last ]? baseline`,
+	"bg-clip":                          `<visual-box> | border-area | text`,
+	"bg-image":                         `none | <image>`,
+	"bg-layer":                         `<bg-image> || <bg-position> [ / <bg-size> ]? || <repeat-style> || <attachment> || <visual-box> || <visual-box>`,
+	"bg-position":                      `[ left | center | right | top | bottom | <length-percentage> ] | [ left | center | right | <length-percentage> ] [ top | center | bottom | <length-percentage> ] | [ [ left | right ] <length-percentage> ] && [ [ top | bottom ] <length-percentage> ]`,
+	"bg-size":                          `[ <length-percentage [0,∞]> | auto ]{1,2} | cover | contain`,
+	"blend-mode":                       `normal | multiply | screen | overlay | darken | lighten | color-dodge | color-burn | hard-light | soft-light | difference | exclusion | hue | saturation | color | luminosity`,
+	"content-distribution":             `space-between | space-around | space-evenly | stretch`,
+	"content-item":                     `<string> | <image> | <quote> | attr( <ident> ) | counter( <custom-ident> [ , <custom-ident> ]? ) | counters( <custom-ident> , <string> [ , <custom-ident> ]? )`,
+	"content-position":                 `center | start | end | flex-start | flex-end`,
+	"display-box":                      `contents | none`,
+	"display-inside":                   `flow | flow-root | table | flex | grid | ruby`,
+	"display-internal":                 `table-row-group | table-header-group | table-footer-group | table-row | table-cell | table-column-group | table-column | table-caption | ruby-base | ruby-text | ruby-base-container | ruby-text-container`,
+	"display-legacy":                   `inline-block | inline-table | inline-flex | inline-grid`,
+	"display-listitem":                 `<display-outside>? && [ flow | flow-root ]? && list-item`,
+	"display-outside":                  `block | inline | run-in`,
+	"easing-function":                  `linear | ease | ease-in | ease-out | ease-in-out | step-start | step-end | cubic-bezier( <number [0,1]> , <number> , <number [0,1]> , <number> ) | steps( <integer [1,∞]> [ , <step-position> ]? )`,
+	"final-bg-layer":                   `<'background-color'> || <bg-image> || <bg-position> [ / <bg-size> ]? || <repeat-style> || <attachment> || <visual-box> || <visual-box>`,
+	"family-name":                      `<string> | <custom-ident>+`,
+	"filter-function":                  `blur( <length>? ) | brightness( <number-percentage>? ) | contrast( <number-percentage>? ) | drop-shadow( [ <color>? && <length>{2,3} ] ) | grayscale( <number-percentage>? ) | hue-rotate( <angle>? ) | invert( <number-percentage>? ) | opacity( <number-percentage>? ) | saturate( <number-percentage>? ) | sepia( <number-percentage>? )`,
+	"font-variant-css2":                `normal | small-caps`,
+	"font-width-css3":                  `normal | ultra-condensed | extra-condensed | condensed | semi-condensed | semi-expanded | expanded | extra-expanded | ultra-expanded`,
+	"generic-family":                   `serif | sans-serif | cursive | fantasy | monospace | system-ui | emoji | math | fangsong | ui-serif | ui-sans-serif | ui-monospace | ui-rounded`,
+	"grid-line":                        `auto | [ <integer> && <custom-ident>? ] | [ span && [ <integer [1,∞]> || <custom-ident> ] ] | <custom-ident>`,
+	"inflexible-breadth":               `<length-percentage [0,∞]> | min-content | max-content | auto`,
+	"keyframes-name":                   `<custom-ident> | <string>`,
+	"line-names":                       `'[' <custom-ident>* ']'`,
+	"line-style":                       `none | hidden | dotted | dashed | solid | double | groove | ridge | inset | outset`,
+	"line-width":                       `<length [0,∞]> | thin | medium | thick`,
+	"overflow-position":                `unsafe | safe`,
+	"quote":                            `open-quote | close-quote | no-open-quote | no-close-quote`,
+	"ratio":                            `<number [0,∞]> [ / <number [0,∞]> ]?`,
+	"repeat-style":                     `repeat-x | repeat-y | [ repeat | space | round | no-repeat ]{1,2}`,
+	"self-position":                    `center | start | end | self-start | self-end | flex-start | flex-end`,
+	"shadow":                           `<color>? && [ <length>{2} <length [0,∞]>? <length>? ] && inset?`,
+	"single-animation":                 `<time [0,∞]> || <easing-function> || <time> || <single-animation-iteration-count> || <single-animation-direction> || <single-animation-fill-mode> || <single-animation-play-state> || [ none | <keyframes-name> ]`,
+	"single-animation-direction":       `normal | reverse | alternate | alternate-reverse`,
+	"single-animation-fill-mode":       `none | forwards | backwards | both`,
+	"single-animation-iteration-count": `infinite | <number [0,∞]>`,
+	"single-animation-play-state":      `running | paused`,
+	"single-transition":                `[ none | <single-transition-property> ] || <time> || <easing-function> || <time>`,
+	"single-transition-property":       `all | <custom-ident>`,
+	"step-position":                    `jump-start | jump-end | jump-none | jump-both | start | end`,
+	"track-breadth":                    `<length-percentage [0,∞]> | <flex [0,∞]> | min-content | max-content | auto`,
+	"track-list":                       `[ <line-names>? [ <track-size> | <track-repeat> ] ]+ <line-names>?`,
+	"track-repeat":                     `repeat( [ <integer [1,∞]> | auto-fill | auto-fit ] , [ <line-names>? <track-size> ]+ <line-names>? )`,
+	"track-size":                       `<track-breadth> | minmax( <inflexible-breadth> , <track-breadth> ) | fit-content( <length-percentage [0,∞]> )`,
+	"visual-box":                       `content-box | padding-box | border-box`,
+}
+
+// properties is the database of standard properties.
+var properties = []*Property{
+	{Name: "accent-color", Syntax: `auto | <color>`, Initial: "auto", Inherited: true, AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "align-content", Syntax: `normal | <baseline-position> | <content-distribution> | <overflow-position>? <content-position>`, Initial: "normal", AppliesTo: "block containers, multicol containers, flex containers, and grid containers", Animation: AnimationDiscrete},
+	{Name: "align-items", Syntax: `normal | stretch | <baseline-position> | <overflow-position>? <self-position>`, Initial: "normal", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "align-self", Syntax: `auto | normal | stretch | <baseline-position> | <overflow-position>? <self-position>`, Initial: "auto", AppliesTo: "flex items, grid items, and absolutely-positioned boxes", Animation: AnimationDiscrete},
+	{Name: "animation", Syntax: `<single-animation>#`, AppliesTo: "all elements", Animation: NotAnimatable, Longhands: []string{"animation-duration", "animation-timing-function", "animation-delay", "animation-iteration-count", "animation-direction", "animation-fill-mode", "animation-play-state", "animation-name"}},
+	{Name: "animation-delay", Syntax: `<time>#`, Initial: "0s", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "animation-direction", Syntax: `<single-animation-direction>#`, Initial: "normal", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "animation-duration", Syntax: `[ auto | <time [0,∞]> ]#`, Initial: "0s", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "animation-fill-mode", Syntax: `<single-animation-fill-mode>#`, Initial: "none", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "animation-iteration-count", Syntax: `<single-animation-iteration-count>#`, Initial: "1", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "animation-name", Syntax: `[ none | <keyframes-name> ]#`, Initial: "none", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "animation-play-state", Syntax: `<single-animation-play-state>#`, Initial: "running", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "animation-timing-function", Syntax: `<easing-function>#`, Initial: "ease", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "appearance", Syntax: `none | auto | menulist-button | textfield | button | checkbox | radio | listbox | menulist | meter | progress-bar | searchfield | textarea`, Initial: "none", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "aspect-ratio", Syntax: `auto || <ratio>`, Initial: "auto", AppliesTo: "all elements except inline boxes and internal ruby or table boxes", Animation: AnimationByComputedValue},
+	{Name: "backface-visibility", Syntax: `visible | hidden`, Initial: "visible", AppliesTo: "transformable elements", Animation: AnimationDiscrete},
+	{Name: "background", Syntax: `[ <bg-layer> , ]* <final-bg-layer>`, AppliesTo: "all elements", Animation: AnimationLonghands, Longhands: []string{"background-image", "background-position", "background-size", "background-repeat", "background-attachment", "background-origin", "background-clip", "background-color"}},
+	{Name: "background-attachment", Syntax: `<attachment>#`, Initial: "scroll", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "background-clip", Syntax: `<bg-clip>#`, Initial: "border-box", AppliesTo: "all elements", Animation: AnimationRepeatableList},
+	{Name: "background-color", Syntax: `<color>`, Initial: "transparent", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "background-image", Syntax: `<bg-image>#`, Initial: "none", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "background-origin", Syntax: `<visual-box>#`, Initial: "padding-box", AppliesTo: "all elements", Animation: AnimationRepeatableList},
+	{Name: "background-position", Syntax: `<bg-position>#`, Initial: "0% 0%", AppliesTo: "all elements", Animation: AnimationRepeatableList},
+	{Name: "background-repeat", Syntax: `<repeat-style>#`, Initial: "repeat", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "background-size", Syntax: `<bg-size>#`, Initial: "auto", AppliesTo: "all elements", Animation: AnimationRepeatableList},
+	{Name: "border", Syntax: `<line-width> || <line-style> || <color>`, AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationLonghands, Longhands: []string{"border-width", "border-style", "border-color"}},
+	{Name: "border-bottom", Syntax: `<line-width> || <line-style> || <color>`, AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationLonghands, Longhands: []string{"border-bottom-width", "border-bottom-style", "border-bottom-color"}},
+	{Name: "border-bottom-color", Syntax: `<color>`, Initial: "currentcolor", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationByComputedValue},
+	{Name: "border-bottom-left-radius", Syntax: `<length-percentage [0,∞]>{1,2}`, Initial: "0", AppliesTo: "all elements except internal table elements", Animation: AnimationByComputedValue},
+	{Name: "border-bottom-right-radius", Syntax: `<length-percentage [0,∞]>{1,2}`, Initial: "0", AppliesTo: "all elements except internal table elements", Animation: AnimationByComputedValue},
+	{Name: "border-bottom-style", Syntax: `<line-style>`, Initial: "none", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationDiscrete},
+	{Name: "border-bottom-width", Syntax: `<line-width>`, Initial: "medium", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationByComputedValue},
+	{Name: "border-collapse", Syntax: `separate | collapse`, Initial: "separate", Inherited: true, AppliesTo: "table grid boxes", Animation: AnimationDiscrete},
+	{Name: "border-color", Syntax: `<color>{1,4}`, AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationLonghands, Longhands: []string{"border-top-color", "border-right-color", "border-bottom-color", "border-left-color"}},
+	{Name: "border-left", Syntax: `<line-width> || <line-style> || <color>`, AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationLonghands, Longhands: []string{"border-left-width", "border-left-style", "border-left-color"}},
+	{Name: "border-left-color", Syntax: `<color>`, Initial: "currentcolor", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationByComputedValue},
+	{Name: "border-left-style", Syntax: `<line-style>`, Initial: "none", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationDiscrete},
+	{Name: "border-left-width", Syntax: `<line-width>`, Initial: "medium", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationByComputedValue},
+	{Name: "border-radius", Syntax: `<length-percentage [0,∞]>{1,4} [ / <length-percentage [0,∞]>{1,4} ]?`, AppliesTo: "all elements except internal table elements", Animation: AnimationLonghands, Longhands: []string{"border-top-left-radius", "border-top-right-radius", "border-bottom-right-radius", "border-bottom-left-radius"}},
+	{Name: "border-right", Syntax: `<line-width> || <line-style> || <color>`, AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationLonghands, Longhands: []string{"border-right-width", "border-right-style", "border-right-color"}},
+	{Name: "border-right-color", Syntax: `<color>`, Initial: "currentcolor", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationByComputedValue},
+	{Name: "border-right-style", Syntax: `<line-style>`, Initial: "none", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationDiscrete},
+	{Name: "border-right-width", Syntax: `<line-width>`, Initial: "medium", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationByComputedValue},
+	{Name: "border-spacing", Syntax: `<length [0,∞]>{1,2}`, Initial: "0px 0px", Inherited: true, AppliesTo: "table grid boxes when border-collapse is separate", Animation: AnimationByComputedValue},
+	{Name: "border-style", Syntax: `<line-style>{1,4}`, AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationLonghands, Longhands: []string{"border-top-style", "border-right-style", "border-bottom-style", "border-left-style"}},
+	{Name: "border-top", Syntax: `<line-width> || <line-style> || <color>`, AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationLonghands, Longhands: []string{"border-top-width", "border-top-style", "border-top-color"}},
+	{Name: "border-top-color", Syntax: `<color>`, Initial: "currentcolor", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationByComputedValue},
+	{Name: "border-top-left-radius", Syntax: `<length-percentage [0,∞]>{1,2}`, Initial: "0", AppliesTo: "all elements except internal table elements", Animation: AnimationByComputedValue},
+	{Name: "border-top-right-radius", Syntax: `<length-percentage [0,∞]>{1,2}`, Initial: "0", AppliesTo: "all elements except internal table elements", Animation: AnimationByComputedValue},
+	{Name: "border-top-style", Syntax: `<line-style>`, Initial: "none", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationDiscrete},
+	{Name: "border-top-width", Syntax: `<line-width>`, Initial: "medium", AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationByComputedValue},
+	{Name: "border-width", Syntax: `<line-width>{1,4}`, AppliesTo: "all elements except ruby base containers and ruby annotation containers", Animation: AnimationLonghands, Longhands: []string{"border-top-width", "border-right-width", "border-bottom-width", "border-left-width"}},
+	{Name: "bottom", Syntax: `auto | <length-percentage>`, Initial: "auto", AppliesTo: "positioned elements", Animation: AnimationByComputedValue},
+	{Name: "box-shadow", Syntax: `none | <shadow>#`, Initial: "none", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "box-sizing", Syntax: `content-box | border-box`, Initial: "content-box", AppliesTo: "all elements that accept width or height", Animation: AnimationDiscrete},
+	{Name: "caption-side", Syntax: `top | bottom`, Initial: "top", Inherited: true, AppliesTo: "table-caption boxes", Animation: AnimationDiscrete},
+	{Name: "caret-color", Syntax: `auto | <color>`, Initial: "auto", Inherited: true, AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "clear", Syntax: `inline-start | inline-end | block-start | block-end | left | right | top | bottom | both-inline | both-block | both | none`, Initial: "none", AppliesTo: "block-level boxes, floats, regions, pages", Animation: AnimationDiscrete},
+	{Name: "color", Syntax: `<color>`, Initial: "canvastext", Inherited: true, AppliesTo: "all elements and text", Animation: AnimationByComputedValue},
+	{Name: "column-count", Syntax: `auto | <integer [1,∞]>`, Initial: "auto", AppliesTo: "block containers except table wrapper boxes", Animation: AnimationByComputedValue},
+	{Name: "column-gap", Syntax: `normal | <length-percentage [0,∞]>`, Initial: "normal", AppliesTo: "multi-column containers, flex containers, grid containers", Animation: AnimationByComputedValue},
+	{Name: "column-width", Syntax: `auto | <length [0,∞]>`, Initial: "auto", AppliesTo: "block containers except table wrapper boxes", Animation: AnimationByComputedValue},
+	{Name: "columns", Syntax: `<'column-width'> || <'column-count'>`, AppliesTo: "block containers except table wrapper boxes", Animation: AnimationLonghands, Longhands: []string{"column-width", "column-count"}},
+	{Name: "content", Syntax: `normal | none | <content-item>+ [ / <string>+ ]?`, Initial: "normal", AppliesTo: "all elements, tree-abiding pseudo-elements, and page margin boxes", Animation: AnimationDiscrete},
+	{Name: "counter-increment", Syntax: `[ <custom-ident> <integer>? ]+ | none`, Initial: "none", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "counter-reset", Syntax: `[ <custom-ident> <integer>? ]+ | none`, Initial: "none", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "cursor", Syntax: `[ <url> [ <number> <number> ]? , ]* [ auto | default | none | context-menu | help | pointer | progress | wait | cell | crosshair | text | vertical-text | alias | copy | move | no-drop | not-allowed | grab | grabbing | e-resize | n-resize | ne-resize | nw-resize | s-resize | se-resize | sw-resize | w-resize | ew-resize | ns-resize | nesw-resize | nwse-resize | col-resize | row-resize | all-scroll | zoom-in | zoom-out ]`, Initial: "auto", Inherited: true, AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "direction", Syntax: `ltr | rtl`, Initial: "ltr", Inherited: true, AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "display", Syntax: `[ <display-outside> || <display-inside> ] | <display-listitem> | <display-internal> | <display-box> | <display-legacy>`, Initial: "inline", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "empty-cells", Syntax: `show | hide`, Initial: "show", Inherited: true, AppliesTo: "table-cell boxes", Animation: AnimationDiscrete},
+	{Name: "filter", Syntax: `none | [ <filter-function> | <url> ]+`, Initial: "none", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "flex", Syntax: `none | [ <'flex-grow'> <'flex-shrink'>? || <'flex-basis'> ]`, AppliesTo: "flex items", Animation: AnimationLonghands, Longhands: []string{"flex-grow", "flex-shrink", "flex-basis"}},
+	{Name: "flex-basis", Syntax: `content | <'width'>`, Initial: "auto", AppliesTo: "flex items", Animation: AnimationByComputedValue},
+	{Name: "flex-direction", Syntax: `row | row-reverse | column | column-reverse`, Initial: "row", AppliesTo: "flex containers", Animation: AnimationDiscrete},
+	{Name: "flex-flow", Syntax: `<'flex-direction'> || <'flex-wrap'>`, AppliesTo: "flex containers", Animation: AnimationLonghands, Longhands: []string{"flex-direction", "flex-wrap"}},
+	{Name: "flex-grow", Syntax: `<number [0,∞]>`, Initial: "0", AppliesTo: "flex items", Animation: AnimationByComputedValue},
+	{Name: "flex-shrink", Syntax: `<number [0,∞]>`, Initial: "1", AppliesTo: "flex items", Animation: AnimationByComputedValue},
+	{Name: "flex-wrap", Syntax: `nowrap | wrap | wrap-reverse`, Initial: "nowrap", AppliesTo: "flex containers", Animation: AnimationDiscrete},
+	{Name: "float", Syntax: `left | right | none | inline-start | inline-end`, Initial: "none", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "font", Syntax: `[ [ <'font-style'> || <font-variant-css2> || <'font-weight'> || <font-width-css3> ]? <'font-size'> [ / <'line-height'> ]? <'font-family'> ] | caption | icon | menu | message-box | small-caption | status-bar`, AppliesTo: "all elements and text", Animation: AnimationLonghands, Longhands: []string{"font-style", "font-variant", "font-weight", "font-stretch", "font-size", "line-height", "font-family"}},
+	{Name: "font-family", Syntax: `[ <family-name> | <generic-family> ]#`, Inherited: true, AppliesTo: "all elements and text", Animation: AnimationDiscrete},
+	{Name: "font-size", Syntax: `<absolute-size> | <relative-size> | <length-percentage [0,∞]> | math`, Initial: "medium", Inherited: true, AppliesTo: "all elements and text", Animation: AnimationByComputedValue},
+	{Name: "font-stretch", Syntax: `normal | <percentage [0,∞]> | ultra-condensed | extra-condensed | condensed | semi-condensed | semi-expanded | expanded | extra-expanded | ultra-expanded`, Initial: "normal", Inherited: true, AppliesTo: "all elements and text", Animation: AnimationByComputedValue},
+	{Name: "font-style", Syntax: `normal | italic | oblique <angle>?`, Initial: "normal", Inherited: true, AppliesTo: "all elements and text", Animation: AnimationByComputedValue},
+	{Name: "font-variant", Syntax: `normal | none | small-caps | all-small-caps | petite-caps | all-petite-caps | unicase | titling-caps`, Initial: "normal", Inherited: true, AppliesTo: "all elements and text", Animation: AnimationDiscrete},
+	{Name: "font-weight", Syntax: `normal | bold | bolder | lighter | <number [1,1000]>`, Initial: "normal", Inherited: true, AppliesTo: "all elements and text", Animation: AnimationByComputedValue},
+	{Name: "gap", Syntax: `<'row-gap'> <'column-gap'>?`, AppliesTo: "multi-column containers, flex containers, grid containers", Animation: AnimationLonghands, Longhands: []string{"row-gap", "column-gap"}},
+	{Name: "grid-area", Syntax: `<grid-line> [ / <grid-line> ]{0,3}`, AppliesTo: "grid items and absolutely-positioned boxes whose containing block is a grid container", Animation: AnimationLonghands, Longhands: []string{"grid-row-start", "grid-column-start", "grid-row-end", "grid-column-end"}},
+	{Name: "grid-auto-columns", Syntax: `<track-size>+`, Initial: "auto", AppliesTo: "grid containers", Animation: AnimationByComputedValue},
+	{Name: "grid-auto-flow", Syntax: `[ row | column ] || dense`, Initial: "row", AppliesTo: "grid containers", Animation: AnimationDiscrete},
+	{Name: "grid-auto-rows", Syntax: `<track-size>+`, Initial: "auto", AppliesTo: "grid containers", Animation: AnimationByComputedValue},
+	{Name: "grid-column", Syntax: `<grid-line> [ / <grid-line> ]?`, AppliesTo: "grid items and absolutely-positioned boxes whose containing block is a grid container", Animation: AnimationLonghands, Longhands: []string{"grid-column-start", "grid-column-end"}},
+	{Name: "grid-column-end", Syntax: `<grid-line>`, Initial: "auto", AppliesTo: "grid items and absolutely-positioned boxes whose containing block is a grid container", Animation: AnimationDiscrete},
+	{Name: "grid-column-start", Syntax: `<grid-line>`, Initial: "auto", AppliesTo: "grid items and absolutely-positioned boxes whose containing block is a grid container", Animation: AnimationDiscrete},
+	{Name: "grid-row", Syntax: `<grid-line> [ / <grid-line> ]?`, AppliesTo: "grid items and absolutely-positioned boxes whose containing block is a grid container", Animation: AnimationLonghands, Longhands: []string{"grid-row-start", "grid-row-end"}},
+	{Name: "grid-row-end", Syntax: `<grid-line>`, Initial: "auto", AppliesTo: "grid items and absolutely-positioned boxes whose containing block is a grid container", Animation: AnimationDiscrete},
+	{Name: "grid-row-start", Syntax: `<grid-line>`, Initial: "auto", AppliesTo: "grid items and absolutely-positioned boxes whose containing block is a grid container", Animation: AnimationDiscrete},
+	{Name: "grid-template", Syntax: `none | [ <'grid-template-rows'> / <'grid-template-columns'> ] | [ <line-names>? <string> <track-size>? <line-names>? ]+ [ / <track-list> ]?`, AppliesTo: "grid containers", Animation: AnimationLonghands, Longhands: []string{"grid-template-rows", "grid-template-columns", "grid-template-areas"}},
+	{Name: "grid-template-areas", Syntax: `none | <string>+`, Initial: "none", AppliesTo: "grid containers", Animation: AnimationDiscrete},
+	{Name: "grid-template-columns", Syntax: `none | <track-list>`, Initial: "none", AppliesTo: "grid containers", Animation: AnimationByComputedValue},
+	{Name: "grid-template-rows", Syntax: `none | <track-list>`, Initial: "none", AppliesTo: "grid containers", Animation: AnimationByComputedValue},
+	{Name: "height", Syntax: `auto | <length-percentage [0,∞]> | min-content | max-content | fit-content( <length-percentage [0,∞]> )`, Initial: "auto", AppliesTo: "all elements except non-replaced inlines", Animation: AnimationByComputedValue},
+	{Name: "hyphens", Syntax: `none | manual | auto`, Initial: "manual", Inherited: true, AppliesTo: "text", Animation: AnimationDiscrete},
+	{Name: "inset", Syntax: `<'top'>{1,4}`, AppliesTo: "positioned elements", Animation: AnimationLonghands, Longhands: []string{"top", "right", "bottom", "left"}},
+	{Name: "isolation", Syntax: `auto | isolate`, Initial: "auto", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "justify-content", Syntax: `normal | <content-distribution> | <overflow-position>? [ <content-position> | left | right ]`, Initial: "normal", AppliesTo: "multicol containers, flex containers, and grid containers", Animation: AnimationDiscrete},
+	{Name: "justify-items", Syntax: `normal | stretch | <baseline-position> | <overflow-position>? [ <self-position> | left | right ] | legacy | legacy && [ left | right | center ]`, Initial: "legacy", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "justify-self", Syntax: `auto | normal | stretch | <baseline-position> | <overflow-position>? [ <self-position> | left | right ]`, Initial: "auto", AppliesTo: "block-level boxes, absolutely-positioned boxes, and grid items", Animation: AnimationDiscrete},
+	{Name: "left", Syntax: `auto | <length-percentage>`, Initial: "auto", AppliesTo: "positioned elements", Animation: AnimationByComputedValue},
+	{Name: "letter-spacing", Syntax: `normal | <length-percentage>`, Initial: "normal", Inherited: true, AppliesTo: "inline boxes and text", Animation: AnimationByComputedValue},
+	{Name: "line-height", Syntax: `normal | <number [0,∞]> | <length-percentage [0,∞]>`, Initial: "normal", Inherited: true, AppliesTo: "non-replaced inline boxes and block containers", Animation: AnimationByComputedValue},
+	{Name: "list-style", Syntax: `<'list-style-position'> || <'list-style-image'> || <'list-style-type'>`, Inherited: true, AppliesTo: "list items", Animation: AnimationLonghands, Longhands: []string{"list-style-position", "list-style-image", "list-style-type"}},
+	{Name: "list-style-image", Syntax: `<image> | none`, Initial: "none", Inherited: true, AppliesTo: "list items", Animation: AnimationDiscrete},
+	{Name: "list-style-position", Syntax: `inside | outside`, Initial: "outside", Inherited: true, AppliesTo: "list items", Animation: AnimationDiscrete},
+	{Name: "list-style-type", Syntax: `<custom-ident> | <string> | none`, Initial: "disc", Inherited: true, AppliesTo: "list items", Animation: AnimationDiscrete},
+	{Name: "margin", Syntax: `<'margin-top'>{1,4}`, AppliesTo: "all elements except internal table elements", Animation: AnimationLonghands, Longhands: []string{"margin-top", "margin-right", "margin-bottom", "margin-left"}},
+	{Name: "margin-bottom", Syntax: `<length-percentage> | auto`, Initial: "0", AppliesTo: "all elements except internal table elements", Animation: AnimationByComputedValue},
+	{Name: "margin-left", Syntax: `<length-percentage> | auto`, Initial: "0", AppliesTo: "all elements except internal table elements", Animation: AnimationByComputedValue},
+	{Name: "margin-right", Syntax: `<length-percentage> | auto`, Initial: "0", AppliesTo: "all elements except internal table elements", Animation: AnimationByComputedValue},
+	{Name: "margin-top", Syntax: `<length-percentage> | auto`, Initial: "0", AppliesTo: "all elements except internal table elements", Animation: AnimationByComputedValue},
+	{Name: "max-height", Syntax: `none | <length-percentage [0,∞]> | min-content | max-content | fit-content( <length-percentage [0,∞]> )`, Initial: "none", AppliesTo: "all elements that accept width or height", Animation: AnimationByComputedValue},
+	{Name: "max-width", Syntax: `none | <length-percentage [0,∞]> | min-content | max-content | fit-content( <length-percentage [0,∞]> )`, Initial: "none", AppliesTo: "all elements that accept width or height", Animation: AnimationByComputedValue},
+	{Name: "min-height", Syntax: `auto | <length-percentage [0,∞]> | min-content | max-content | fit-content( <length-percentage [0,∞]> )`, Initial: "auto", AppliesTo: "all elements that accept width or height", Animation: AnimationByComputedValue},
+	{Name: "min-width", Syntax: `auto | <length-percentage [0,∞]> | min-content | max-content | fit-content( <length-percentage [0,∞]> )`, Initial: "auto", AppliesTo: "all elements that accept width or height", Animation: AnimationByComputedValue},
+	{Name: "mix-blend-mode", Syntax: `<blend-mode> | plus-lighter`, Initial: "normal", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "object-fit", Syntax: `fill | contain | cover | none | scale-down`, Initial: "fill", AppliesTo: "replaced elements", Animation: AnimationDiscrete},
+	{Name: "object-position", Syntax: `<bg-position>`, Initial: "50% 50%", AppliesTo: "replaced elements", Animation: AnimationByComputedValue},
+	{Name: "opacity", Syntax: `<alpha-value>`, Initial: "1", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "order", Syntax: `<integer>`, Initial: "0", AppliesTo: "flex items and grid items", Animation: AnimationByComputedValue},
+	{Name: "outline", Syntax: `<'outline-color'> || <'outline-style'> || <'outline-width'>`, AppliesTo: "all elements", Animation: AnimationLonghands, Longhands: []string{"outline-color", "outline-style", "outline-width"}},
+	{Name: "outline-color", Syntax: `auto | <color>`, Initial: "auto", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "outline-offset", Syntax: `<length>`, Initial: "0", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "outline-style", Syntax: `auto | <line-style>`, Initial: "none", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "outline-width", Syntax: `<line-width>`, Initial: "medium", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "overflow", Syntax: `<'overflow-x'>{1,2}`, AppliesTo: "block containers, flex containers, and grid containers", Animation: AnimationLonghands, Longhands: []string{"overflow-x", "overflow-y"}},
+	{Name: "overflow-wrap", Syntax: `normal | break-word | anywhere`, Initial: "normal", Inherited: true, AppliesTo: "text", Animation: AnimationDiscrete},
+	{Name: "overflow-x", Syntax: `visible | hidden | clip | scroll | auto`, Initial: "visible", AppliesTo: "block containers, flex containers, and grid containers", Animation: AnimationDiscrete},
+	{Name: "overflow-y", Syntax: `visible | hidden | clip | scroll | auto`, Initial: "visible", AppliesTo: "block containers, flex containers, and grid containers", Animation: AnimationDiscrete},
+	{Name: "padding", Syntax: `<'padding-top'>{1,4}`, AppliesTo: "all elements except internal table elements other than table cells", Animation: AnimationLonghands, Longhands: []string{"padding-top", "padding-right", "padding-bottom", "padding-left"}},
+	{Name: "padding-bottom", Syntax: `<length-percentage [0,∞]>`, Initial: "0", AppliesTo: "all elements except internal table elements other than table cells", Animation: AnimationByComputedValue},
+	{Name: "padding-left", Syntax: `<length-percentage [0,∞]>`, Initial: "0", AppliesTo: "all elements except internal table elements other than table cells", Animation: AnimationByComputedValue},
+	{Name: "padding-right", Syntax: `<length-percentage [0,∞]>`, Initial: "0", AppliesTo: "all elements except internal table elements other than table cells", Animation: AnimationByComputedValue},
+	{Name: "padding-top", Syntax: `<length-percentage [0,∞]>`, Initial: "0", AppliesTo: "all elements except internal table elements other than table cells", Animation: AnimationByComputedValue},
+	{Name: "pointer-events", Syntax: `auto | none | visiblePainted | visibleFill | visibleStroke | visible | painted | fill | stroke | all`, Initial: "auto", Inherited: true, AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "position", Syntax: `static | relative | absolute | sticky | fixed`, Initial: "static", AppliesTo: "all elements except table-column-group and table-column", Animation: AnimationDiscrete},
+	{Name: "quotes", Syntax: `auto | none | [ <string> <string> ]+`, Initial: "auto", Inherited: true, AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "resize", Syntax: `none | both | horizontal | vertical | block | inline`, Initial: "none", AppliesTo: "elements with overflow other than visible, and optionally replaced elements", Animation: AnimationDiscrete},
+	{Name: "right", Syntax: `auto | <length-percentage>`, Initial: "auto", AppliesTo: "positioned elements", Animation: AnimationByComputedValue},
+	{Name: "row-gap", Syntax: `normal | <length-percentage [0,∞]>`, Initial: "normal", AppliesTo: "multi-column containers, flex containers, grid containers", Animation: AnimationByComputedValue},
+	{Name: "scroll-behavior", Syntax: `auto | smooth`, Initial: "auto", AppliesTo: "scroll containers", Animation: NotAnimatable},
+	{Name: "tab-size", Syntax: `<number [0,∞]> | <length [0,∞]>`, Initial: "8", Inherited: true, AppliesTo: "text", Animation: AnimationByComputedValue},
+	{Name: "table-layout", Syntax: `auto | fixed`, Initial: "auto", AppliesTo: "table grid boxes", Animation: AnimationDiscrete},
+	{Name: "text-align", Syntax: `start | end | left | right | center | justify | match-parent | justify-all`, Initial: "start", Inherited: true, AppliesTo: "block containers", Animation: AnimationDiscrete},
+	{Name: "text-decoration", Syntax: `<'text-decoration-line'> || <'text-decoration-thickness'> || <'text-decoration-style'> || <'text-decoration-color'>`, AppliesTo: "all elements", Animation: AnimationLonghands, Longhands: []string{"text-decoration-line", "text-decoration-thickness", "text-decoration-style", "text-decoration-color"}},
+	{Name: "text-decoration-color", Syntax: `<color>`, Initial: "currentcolor", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "text-decoration-line", Syntax: `none | [ underline || overline || line-through || blink ]`, Initial: "none", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "text-decoration-style", Syntax: `solid | double | dotted | dashed | wavy`, Initial: "solid", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "text-decoration-thickness", Syntax: `auto | from-font | <length-percentage>`, Initial: "auto", AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "text-indent", Syntax: `<length-percentage> && hanging? && each-line?`, Initial: "0", Inherited: true, AppliesTo: "block containers", Animation: AnimationByComputedValue},
+	{Name: "text-overflow", Syntax: `[ clip | ellipsis | <string> ]{1,2}`, Initial: "clip", AppliesTo: "block containers", Animation: AnimationDiscrete},
+	{Name: "text-shadow", Syntax: `none | [ <color>? && <length>{2,3} ]#`, Initial: "none", Inherited: true, AppliesTo: "text", Animation: AnimationByComputedValue},
+	{Name: "text-size-adjust", Syntax: `auto | none | <percentage [0,∞]>`, Initial: "auto", Inherited: true, AppliesTo: "all elements", Animation: AnimationByComputedValue},
+	{Name: "text-transform", Syntax: `none | [ capitalize | uppercase | lowercase ] || full-width || full-size-kana`, Initial: "none", Inherited: true, AppliesTo: "text", Animation: AnimationDiscrete},
+	{Name: "top", Syntax: `auto | <length-percentage>`, Initial: "auto", AppliesTo: "positioned elements", Animation: AnimationByComputedValue},
+	{Name: "transform", Syntax: `none | <transform-function>+`, Initial: "none", AppliesTo: "transformable elements", Animation: AnimationByComputedValue},
+	{Name: "transform-origin", Syntax: `[ left | center | right | top | bottom | <length-percentage> ]{1,2} <length>?`, Initial: "50% 50%", AppliesTo: "transformable elements", Animation: AnimationByComputedValue},
+	{Name: "transition", Syntax: `<single-transition>#`, AppliesTo: "all elements", Animation: NotAnimatable, Longhands: []string{"transition-property", "transition-duration", "transition-timing-function", "transition-delay"}},
+	{Name: "transition-delay", Syntax: `<time>#`, Initial: "0s", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "transition-duration", Syntax: `<time [0,∞]>#`, Initial: "0s", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "transition-property", Syntax: `none | <single-transition-property>#`, Initial: "all", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "transition-timing-function", Syntax: `<easing-function>#`, Initial: "ease", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "unicode-bidi", Syntax: `normal | embed | isolate | bidi-override | isolate-override | plaintext`, Initial: "normal", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "user-select", Syntax: `auto | text | none | contain | all`, Initial: "auto", AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "vertical-align", Syntax: `baseline | sub | super | text-top | text-bottom | middle | top | bottom | <length-percentage>`, Initial: "baseline", AppliesTo: "inline-level boxes and table cells", Animation: AnimationByComputedValue},
+	{Name: "visibility", Syntax: `visible | hidden | collapse`, Initial: "visible", Inherited: true, AppliesTo: "all elements", Animation: AnimationDiscrete},
+	{Name: "white-space", Syntax: `normal | pre | nowrap | pre-wrap | break-spaces | pre-line`, Initial: "normal", Inherited: true, AppliesTo: "text", Animation: AnimationDiscrete},
+	{Name: "width", Syntax: `auto | <length-percentage [0,∞]> | min-content | max-content | fit-content( <length-percentage [0,∞]> )`, Initial: "auto", AppliesTo: "all elements except non-replaced inlines", Animation: AnimationByComputedValue},
+	{Name: "will-change", Syntax: `auto | [ scroll-position | contents | <custom-ident> ]#`, Initial: "auto", AppliesTo: "all elements", Animation: NotAnimatable},
+	{Name: "word-break", Syntax: `normal | keep-all | break-all | break-word`, Initial: "normal", Inherited: true, AppliesTo: "text", Animation: AnimationDiscrete},
+	{Name: "word-spacing", Syntax: `normal | <length-percentage>`, Initial: "normal", Inherited: true, AppliesTo: "text", Animation: AnimationByComputedValue},
+	{Name: "writing-mode", Syntax: `horizontal-tb | vertical-rl | vertical-lr | sideways-rl | sideways-lr`, Initial: "horizontal-tb", Inherited: true, AppliesTo: "all elements except table row groups, table column groups, table rows, table columns, ruby base containers, ruby annotation containers", Animation: NotAnimatable},
+	{Name: "z-index", Syntax: `auto | <integer>`, Initial: "auto", AppliesTo: "positioned elements", Animation: AnimationByComputedValue},
+}
+
+// propertiesByName indexes the property database by name.
+var propertiesByName = make(map[string]*Property)
+
+// propertyMatcher resolves the types and property references in property grammars.
+var propertyMatcher = &Matcher{
+	Types:      make(map[string]*Grammar),
+	Properties: make(map[string]*Grammar),
+}
+
+func init() {
+	for name, def := range propertyTypes {
+		propertyMatcher.Types[name] = MustParseGrammar(def)
+	}
+	for _, p := range properties {
+		p.grammar = MustParseGrammar(p.Syntax)
+		propertiesByName[p.Name] = p
+		propertyMatcher.Properties[p.Name] = p.grammar
+	}
+}
@@ -0,0 +1,126 @@
+package css_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that every property's initial value matches its own grammar.
+func TestProperties_Initial(t *testing.T) {
+	for _, prop := range css.Properties() {
+		if prop.Initial == "" {
+			continue
+		}
+		var p css.Parser
+		values := p.ParseComponentValues(css.NewScanner(strings.NewReader(prop.Initial)))
+		if err := css.Validate(&css.Declaration{Name: prop.Name, Values: values}); err != nil {
+			t.Errorf("%s: initial value %q: %s", prop.Name, prop.Initial, err)
+		}
+	}
+}
+
+// Ensure that the longhands of every shorthand are known properties.
+func TestProperties_Longhands(t *testing.T) {
+	for _, prop := range css.Properties() {
+		for _, name := range prop.Longhands {
+			if css.LookupProperty(name) == nil {
+				t.Errorf("%s: unknown longhand: %s", prop.Name, name)
+			}
+		}
+	}
+}
+
+// Ensure that properties can be looked up by name or vendor-prefixed alias.
+func TestLookupProperty(t *testing.T) {
+	if p := css.LookupProperty("Color"); p == nil || p.Name != "color" || !p.Inherited {
+		t.Fatalf("unexpected property: %#v", p)
+	} else if p.Animation != css.AnimationByComputedValue || p.Animation.String() != "by computed value type" {
+		t.Fatalf("unexpected animation type: %s", p.Animation)
+	}
+	if p := css.LookupProperty("-webkit-transform"); p == nil || p.Name != "transform" {
+		t.Fatalf("unexpected property: %#v", p)
+	}
+	if p := css.LookupProperty("margin"); p == nil || !p.IsShorthand() || p.Initial != "" {
+		t.Fatalf("unexpected property: %#v", p)
+	}
+	if p := css.LookupProperty("no-such-property"); p != nil {
+		t.Fatalf("unexpected property: %#v", p)
+	}
+}
+
+// Ensure that declarations are validated against the property database.
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{in: `margin: 0 auto`},
+		{in: `margin: 1px 2px 3px 4px`},
+		{in: `MARGIN: 1PX`},
+		{in: `padding: 1px 2px`},
+		{in: `border: 1px solid red`},
+		{in: `border: solid`},
+		{in: `background: url(a.png) no-repeat center / cover, #fff`},
+		{in: `font: italic bold 12px/1.5 "Helvetica Neue", Arial, sans-serif`},
+		{in: `font-family: Times New Roman, serif`},
+		{in: `display: inline flex`},
+		{in: `display: list-item`},
+		{in: `flex: 1 0 auto`},
+		{in: `flex: none`},
+		{in: `grid-template-columns: [full-start] minmax(1em, 1fr) repeat(2, [col] 100px) [full-end]`},
+		{in: `grid-area: 1 / span 2 / auto / main`},
+		{in: `transition: opacity 0.3s ease-in-out, transform 1s`},
+		{in: `animation: spin 1s linear infinite`},
+		{in: `box-shadow: inset 0 0 4px rgba(0,0,0,.5), 1px 1px red`},
+		{in: `transform: translate(10px, 20px) rotate(45deg)`},
+		{in: `-webkit-box-shadow: none`},
+		{in: `width: calc(100% - 10px)`},
+		{in: `color: inherit`},
+		{in: `margin: var(--gap) auto`},
+		{in: `--anything: { whatever }`},
+		{in: `color: red !important`},
+
+		{in: `colour: red`, err: `unknown property: colour`},
+		{in: `-webkit-foo: 1`, err: `unknown property: -webkit-foo`},
+		{in: `color: 10px`, err: `invalid value for color: unexpected 10px, expected <color>`},
+		{in: `margin: 1px 2px 3px 4px 5px`, err: `invalid value for margin: unexpected: 5px`},
+		{in: `padding: -1px`, err: `invalid value for padding: unexpected -1px, expected <'padding-top'>`},
+		{in: `display: blocky`, err: `invalid value for display: unexpected blocky, expected <display-outside> or <display-inside> or <display-listitem> or <display-internal> or <display-box> or <display-legacy>`},
+		{in: `border: 1px solid red blue`, err: `invalid value for border: unexpected: blue`},
+		{in: `z-index: 1.5`, err: `invalid value for z-index: unexpected 1.5, expected auto or <integer>`},
+		{in: `color: inherit red`, err: `invalid value for color: unexpected inherit, expected <color>`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		d := p.ParseDeclaration(css.NewScanner(strings.NewReader(tt.in)))
+		err := css.Validate(d)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, err)
+			}
+		} else if err != nil {
+			t.Errorf("%d. <%q> unexpected error: %s", i, tt.in, err)
+		}
+	}
+}
+
+// Ensure that validation errors are positioned at the offending value.
+func TestValidate_Pos(t *testing.T) {
+	var p css.Parser
+	d := p.ParseDeclaration(css.NewScanner(strings.NewReader("margin: 1px\n  foo")))
+	if err, ok := css.Validate(d).(*css.Error); !ok {
+		t.Fatalf("unexpected error: %#v", err)
+	} else if err.Pos != (css.Pos{Char: 3, Line: 1}) {
+		t.Fatalf("unexpected pos: %#v", err.Pos)
+	}
+
+	d = p.ParseDeclaration(css.NewScanner(strings.NewReader("  colour: red")))
+	if err, ok := css.Validate(d).(*css.Error); !ok {
+		t.Fatalf("unexpected error: %#v", err)
+	} else if err.Pos != (css.Pos{Char: 3, Line: 0}) {
+		t.Fatalf("unexpected pos: %#v", err.Pos)
+	}
+}
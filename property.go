@@ -0,0 +1,403 @@
+package css
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PropertyRule represents a custom property registered with an @property
+// rule. (css-properties-values-api-1 §3)
+type PropertyRule struct {
+	Name     string // custom property name, including the leading "--"
+	Syntax   *PropertySyntax
+	Inherits bool
+
+	// The initial value of the property. It is only required when the syntax
+	// is not the universal syntax ("*").
+	InitialValue    ComponentValues
+	HasInitialValue bool
+
+	Pos Pos
+}
+
+// ParsePropertyRule parses and validates an @property at-rule.
+// Returns nil if the at-rule is not an @property rule or if it is invalid.
+func (p *Parser) ParsePropertyRule(r *AtRule) *PropertyRule {
+	if !strings.EqualFold(r.Name, "property") {
+		p.error(newError("expected-at-rule", r, "expected @property, got @%s", r.Name))
+		return nil
+	}
+
+	// The prelude must be a single custom property name.
+	prelude := r.Prelude.nonwhitespace()
+	if len(prelude) != 1 {
+		p.error(newError("expected-custom-property", r, "expected custom property name"))
+		return nil
+	} else if tok, ok := prelude[0].(*Token); !ok || tok.Tok != IdentToken || !strings.HasPrefix(tok.Value, "--") {
+		p.error(newError("expected-custom-property", prelude[0], "expected custom property name, got %s", print(prelude[0])))
+		return nil
+	} else if r.Block == nil {
+		p.error(newError("expected-block", r, "expected block in @property %s", tok.Value))
+		return nil
+	}
+
+	pr := &PropertyRule{Name: prelude[0].(*Token).Value, Pos: r.Pos}
+	var hasSyntax, hasInherits bool
+
+	// Read the descriptors from the block.
+	for _, n := range p.ConsumeDeclarations(NewComponentValueScanner(r.Block.Values)) {
+		d, ok := n.(*Declaration)
+		if !ok {
+			p.error(newError("unexpected-at-rule", n, "unexpected at-rule in @property: @%s", n.(*AtRule).Name))
+			continue
+		}
+		values := d.Values.trimWhitespace()
+
+		switch strings.ToLower(d.Name) {
+		case "syntax":
+			tok, ok := singleToken(values)
+			if !ok || tok.Tok != StringToken {
+				p.error(newError("expected-syntax-string", d, "expected syntax string, got %s", print(values)))
+				return nil
+			}
+			syntax, err := ParsePropertySyntax(tok.Value)
+			if err != nil {
+				p.error(newError("invalid-syntax", tok, "%s", err))
+				return nil
+			}
+			pr.Syntax, hasSyntax = syntax, true
+
+		case "inherits":
+			tok, ok := singleToken(values)
+			if !ok || tok.Tok != IdentToken || (!strings.EqualFold(tok.Value, "true") && !strings.EqualFold(tok.Value, "false")) {
+				p.error(newError("expected-boolean", d, "expected true or false, got %s", print(values)))
+				return nil
+			}
+			pr.Inherits, hasInherits = strings.EqualFold(tok.Value, "true"), true
+
+		case "initial-value":
+			pr.InitialValue, pr.HasInitialValue = values, true
+
+		default:
+			// Unknown descriptors are ignored so they're only a warning.
+			err := newError("unknown-descriptor", d, "unknown @property descriptor: %s", d.Name)
+			err.Severity = Warning
+			p.error(err)
+		}
+	}
+
+	// The syntax and inherits descriptors are required.
+	if !hasSyntax {
+		p.error(newError("missing-descriptor", r, "missing syntax descriptor in @property %s", pr.Name))
+		return nil
+	} else if !hasInherits {
+		p.error(newError("missing-descriptor", r, "missing inherits descriptor in @property %s", pr.Name))
+		return nil
+	}
+
+	// The initial value is optional for the universal syntax. Otherwise it is
+	// required, must match the syntax and must be computationally independent.
+	if !pr.HasInitialValue {
+		if !pr.Syntax.Universal {
+			p.error(newError("missing-descriptor", r, "missing initial-value descriptor in @property %s", pr.Name))
+			return nil
+		}
+	} else if !pr.Syntax.Universal {
+		if !pr.Syntax.Match(pr.InitialValue) {
+			p.error(newError("invalid-initial-value", pr.InitialValue, "initial-value %s does not match syntax %q", print(pr.InitialValue), pr.Syntax))
+			return nil
+		} else if !isComputationallyIndependent(pr.InitialValue) {
+			p.error(newError("invalid-initial-value", pr.InitialValue, "initial-value %s is not computationally independent", print(pr.InitialValue)))
+			return nil
+		}
+	}
+
+	return pr
+}
+
+// PropertyRegistry represents the set of registered custom properties.
+// It can be consulted when substituting var() references to find the
+// initial value, inheritance and syntax of a custom property.
+type PropertyRegistry struct {
+	m map[string]*PropertyRule
+}
+
+// NewPropertyRegistry returns a new, empty registry.
+func NewPropertyRegistry() *PropertyRegistry {
+	return &PropertyRegistry{m: make(map[string]*PropertyRule)}
+}
+
+// Register adds a registration to the registry. A later registration with
+// the same name replaces the previous one.
+func (r *PropertyRegistry) Register(pr *PropertyRule) {
+	r.m[pr.Name] = pr
+}
+
+// Lookup returns the registration for a custom property name.
+// Custom property names are case-sensitive.
+func (r *PropertyRegistry) Lookup(name string) *PropertyRule {
+	if r == nil {
+		return nil
+	}
+	return r.m[name]
+}
+
+// Inherits returns whether the custom property inherits. Unregistered custom
+// properties always inherit.
+func (r *PropertyRegistry) Inherits(name string) bool {
+	if pr := r.Lookup(name); pr != nil {
+		return pr.Inherits
+	}
+	return true
+}
+
+// InitialValue returns the initial value of the custom property. Returns false
+// if the property is unregistered or has no initial value, in which case its
+// initial value is the guaranteed-invalid value.
+func (r *PropertyRegistry) InitialValue(name string) (ComponentValues, bool) {
+	if pr := r.Lookup(name); pr != nil && pr.HasInitialValue {
+		return pr.InitialValue, true
+	}
+	return nil, false
+}
+
+// Valid returns true if values are valid for the custom property's syntax.
+// Unregistered properties accept any value.
+func (r *PropertyRegistry) Valid(name string, values ComponentValues) bool {
+	if pr := r.Lookup(name); pr != nil {
+		return pr.Syntax.Match(values)
+	}
+	return true
+}
+
+// Names returns the sorted names of all registered properties.
+func (r *PropertyRegistry) Names() []string {
+	var a []string
+	for name := range r.m {
+		a = append(a, name)
+	}
+	sort.Strings(a)
+	return a
+}
+
+// ParsePropertyRegistry builds a registry from the top-level @property rules
+// in a style sheet. Invalid rules are reported as errors and ignored.
+func (p *Parser) ParsePropertyRegistry(ss *StyleSheet) *PropertyRegistry {
+	reg := NewPropertyRegistry()
+	for _, r := range ss.Rules {
+		if r, ok := r.(*AtRule); ok && strings.EqualFold(r.Name, "property") {
+			if pr := p.ParsePropertyRule(r); pr != nil {
+				reg.Register(pr)
+			}
+		}
+	}
+	return reg
+}
+
+// PropertySyntax represents a parsed syntax string from the syntax descriptor
+// of an @property rule, such as "<length>" or "<integer> | auto".
+type PropertySyntax struct {
+	Universal  bool
+	Components []*PropertySyntaxComponent
+}
+
+// PropertySyntaxComponent represents a single alternative in a syntax string.
+type PropertySyntaxComponent struct {
+	Name       string // data type name or keyword
+	DataType   bool   // true if the name is a data type, such as <length>.
+	Multiplier rune   // '+' for space-separated or '#' for comma-separated lists.
+}
+
+// String returns the component as it appears in a syntax string.
+func (c *PropertySyntaxComponent) String() string {
+	s := c.Name
+	if c.DataType {
+		s = "<" + s + ">"
+	}
+	if c.Multiplier != 0 {
+		s += string(c.Multiplier)
+	}
+	return s
+}
+
+// propertySyntaxDataTypes is the list of data types allowed in a syntax string.
+var propertySyntaxDataTypes = []string{
+	"length", "number", "percentage", "length-percentage", "color", "image", "url", "integer",
+	"angle", "time", "resolution", "transform-function", "custom-ident", "transform-list", "string",
+}
+
+// ParsePropertySyntax parses a syntax string. (css-properties-values-api-1 §5)
+func ParsePropertySyntax(s string) (*PropertySyntax, error) {
+	s = strings.Trim(s, " \t\n")
+	if s == "" {
+		return nil, fmt.Errorf("empty syntax string")
+	} else if s == "*" {
+		return &PropertySyntax{Universal: true}, nil
+	}
+
+	syntax := &PropertySyntax{}
+	for _, part := range strings.Split(s, "|") {
+		part = strings.Trim(part, " \t\n")
+		if part == "" {
+			return nil, fmt.Errorf("invalid syntax string: %q", s)
+		}
+
+		c := &PropertySyntaxComponent{}
+
+		// Strip the optional multiplier from the end.
+		if strings.HasSuffix(part, "+") || strings.HasSuffix(part, "#") {
+			c.Multiplier, part = rune(part[len(part)-1]), part[:len(part)-1]
+		}
+
+		if strings.HasPrefix(part, "<") && strings.HasSuffix(part, ">") {
+			c.Name, c.DataType = part[1:len(part)-1], true
+			if !containsString(propertySyntaxDataTypes, c.Name) {
+				return nil, fmt.Errorf("unknown syntax data type: <%s>", c.Name)
+			} else if c.Name == "transform-list" && c.Multiplier != 0 {
+				return nil, fmt.Errorf("<transform-list> cannot have a multiplier")
+			}
+		} else {
+			// Keywords must be valid identifiers and not CSS-wide keywords.
+			c.Name = part
+			if !isIdentString(c.Name) || IsWideKeyword(c.Name) || strings.EqualFold(c.Name, "default") {
+				return nil, fmt.Errorf("invalid syntax keyword: %q", c.Name)
+			}
+		}
+		syntax.Components = append(syntax.Components, c)
+	}
+	return syntax, nil
+}
+
+// String returns the syntax string.
+func (s *PropertySyntax) String() string {
+	if s.Universal {
+		return "*"
+	}
+	var a []string
+	for _, c := range s.Components {
+		a = append(a, c.String())
+	}
+	return strings.Join(a, " | ")
+}
+
+// Match returns true if the values match one of the syntax's components.
+// Leading and trailing whitespace is ignored.
+func (s *PropertySyntax) Match(values ComponentValues) bool {
+	if s.Universal {
+		return true
+	}
+	values = values.trimWhitespace()
+	for _, c := range s.Components {
+		if c.match(values) {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns true if the values match the component.
+func (c *PropertySyntaxComponent) match(values ComponentValues) bool {
+	switch {
+	case c.Name == "transform-list" && c.DataType:
+		return c.matchList(values.nonwhitespace(), "transform-function")
+	case c.Multiplier == '+':
+		return c.matchList(values.nonwhitespace(), c.Name)
+	case c.Multiplier == '#':
+		var items ComponentValues
+		for _, part := range values.split(CommaToken) {
+			part = part.nonwhitespace()
+			if len(part) != 1 {
+				return false
+			}
+			items = append(items, part[0])
+		}
+		return c.matchList(items, c.Name)
+	}
+
+	v, ok := singleValue(values)
+	return ok && c.matchOne(v, c.Name)
+}
+
+// matchList returns true if there is at least one value and all values match.
+func (c *PropertySyntaxComponent) matchList(values ComponentValues, name string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if !c.matchOne(v, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchOne returns true if a single value matches a data type or keyword.
+func (c *PropertySyntaxComponent) matchOne(v ComponentValue, name string) bool {
+	if c.DataType {
+		return MatchDataType(name, v)
+	}
+	tok, ok := v.(*Token)
+	return ok && tok.Tok == IdentToken && tok.Value == name
+}
+
+// trimWhitespace returns the values without leading and trailing whitespace.
+func (a ComponentValues) trimWhitespace() ComponentValues {
+	for len(a) > 0 && isTok(a[0], WhitespaceToken) {
+		a = a[1:]
+	}
+	for len(a) > 0 && isTok(a[len(a)-1], WhitespaceToken) {
+		a = a[:len(a)-1]
+	}
+	return a
+}
+
+// split splits the values on tokens of the given type.
+func (a ComponentValues) split(sep Tok) []ComponentValues {
+	parts := []ComponentValues{nil}
+	for _, v := range a {
+		if isTok(v, sep) {
+			parts = append(parts, nil)
+			continue
+		}
+		parts[len(parts)-1] = append(parts[len(parts)-1], v)
+	}
+	return parts
+}
+
+// singleValue returns the only non-whitespace value in a list.
+func singleValue(values ComponentValues) (ComponentValue, bool) {
+	a := values.nonwhitespace()
+	if len(a) != 1 {
+		return nil, false
+	}
+	return a[0], true
+}
+
+// singleToken returns the only non-whitespace value if it is a token.
+func singleToken(values ComponentValues) (*Token, bool) {
+	v, ok := singleValue(values)
+	if !ok {
+		return nil, false
+	}
+	tok, ok := v.(*Token)
+	return tok, ok
+}
+
+// containsString returns true if a contains s.
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdentString returns true if s scans as a single identifier.
+func isIdentString(s string) bool {
+	sc := NewScanner(bytes.NewBufferString(s))
+	tok := sc.Scan()
+	return tok.Tok == IdentToken && tok.Value == s && sc.Scan().Tok == EOFToken
+}
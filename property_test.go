@@ -0,0 +1,168 @@
+package css_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that @property rules are parsed and validated.
+func TestParser_ParsePropertyRule(t *testing.T) {
+	var tests = []struct {
+		in       string
+		syntax   string
+		inherits bool
+		initial  string
+		err      string
+	}{
+		{in: `@property --x { syntax: '<length>'; inherits: false; initial-value: 10px; }`, syntax: `<length>`, initial: `10px`},
+		{in: `@property --x { syntax: "<color>+"; inherits: TRUE; initial-value: red #fff rgb(0 0 0) }`, syntax: `<color>+`, inherits: true, initial: `red #fff rgb(0 0 0)`},
+		{in: `@property --x { syntax: "<integer> | auto"; inherits: false; initial-value: auto }`, syntax: `<integer> | auto`, initial: `auto`},
+		{in: `@property --x { syntax: "<integer> | auto"; inherits: false; initial-value: 3 }`, syntax: `<integer> | auto`, initial: `3`},
+		{in: `@property --x { syntax: "<length-percentage>#"; inherits: false; initial-value: 1px, 50% , 0 }`, syntax: `<length-percentage>#`, initial: `1px, 50% , 0`},
+		{in: `@property --x { syntax: "<transform-list>"; inherits: false; initial-value: rotate(1deg) scale(2) }`, syntax: `<transform-list>`, initial: `rotate(1deg) scale(2)`},
+		{in: `@property --x { syntax: "*"; inherits: false }`, syntax: `*`},
+		{in: `@property --x { syntax: "*"; inherits: false; initial-value: anything 1em }`, syntax: `*`, initial: `anything 1em`},
+
+		{in: `@media x {}`, err: `expected @property, got @media`},
+		{in: `@property {}`, err: `expected custom property name`},
+		{in: `@property x {}`, err: `expected custom property name, got x`},
+		{in: `@property --x;`, err: `expected block in @property --x`},
+		{in: `@property --x { syntax: <length>; }`, err: `expected syntax string, got <length>`},
+		{in: `@property --x { syntax: "<lengthy>"; }`, err: `unknown syntax data type: <lengthy>`},
+		{in: `@property --x { syntax: "<transform-list>+"; }`, err: `<transform-list> cannot have a multiplier`},
+		{in: `@property --x { syntax: "a || b"; }`, err: `invalid syntax string: "a || b"`},
+		{in: `@property --x { syntax: "inherit"; }`, err: `invalid syntax keyword: "inherit"`},
+		{in: `@property --x { syntax: "1px"; }`, err: `invalid syntax keyword: "1px"`},
+		{in: `@property --x { syntax: ""; }`, err: `empty syntax string`},
+		{in: `@property --x { syntax: "*"; inherits: maybe }`, err: `expected true or false, got maybe`},
+		{in: `@property --x { inherits: false }`, err: `missing syntax descriptor in @property --x`},
+		{in: `@property --x { syntax: "*" }`, err: `missing inherits descriptor in @property --x`},
+		{in: `@property --x { syntax: "<length>"; inherits: false }`, err: `missing initial-value descriptor in @property --x`},
+		{in: `@property --x { syntax: "<length>"; inherits: false; initial-value: red }`, err: `initial-value red does not match syntax "<length>"`},
+		{in: `@property --x { syntax: "<length>"; inherits: false; initial-value: 1em }`, err: `initial-value 1em is not computationally independent`},
+		{in: `@property --x { syntax: "<length>"; inherits: false; initial-value: calc(var(--y) + 1px) }`, err: `initial-value calc(var(--y) + 1px) is not computationally independent`},
+		{in: `@property --x { syntax: "<color>+"; inherits: false; initial-value: red, blue }`, err: `initial-value red, blue does not match syntax "<color>+"`},
+		{in: `@property --x { syntax: "<integer> | auto"; inherits: false; initial-value: 1.5 }`, err: `initial-value 1.5 does not match syntax "<integer> | auto"`},
+		{in: `@property --x { syntax: "<integer> | auto"; inherits: false; initial-value: Auto }`, err: `initial-value Auto does not match syntax "<integer> | auto"`},
+		{in: `@property --x { syntax: "*"; inherits: false; foo: bar }`, err: `unknown @property descriptor: foo`},
+		{in: `@property --x { @foo; syntax: "*"; inherits: false }`, err: `unexpected at-rule in @property: @foo`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		r := p.ParsePropertyRule(p.ParseRule(css.NewScanner(strings.NewReader(tt.in))).(*css.AtRule))
+		if tt.err != "" {
+			if len(p.Errors) == 0 || p.Errors[0].Error() != tt.err {
+				t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, p.Errors)
+			}
+			continue
+		} else if len(p.Errors) > 0 {
+			t.Errorf("%d. <%q> unexpected error: %s", i, tt.in, p.Errors)
+			continue
+		}
+
+		if r.Name != "--x" {
+			t.Errorf("%d. <%q> unexpected name: %s", i, tt.in, r.Name)
+		} else if r.Syntax.String() != tt.syntax {
+			t.Errorf("%d. <%q> syntax: exp=%q, got=%q", i, tt.in, tt.syntax, r.Syntax)
+		} else if r.Inherits != tt.inherits {
+			t.Errorf("%d. <%q> inherits: exp=%v, got=%v", i, tt.in, tt.inherits, r.Inherits)
+		} else if s := print(r.InitialValue); s != tt.initial || r.HasInitialValue != (tt.initial != "") {
+			t.Errorf("%d. <%q> initial-value: exp=%q, got=%q", i, tt.in, tt.initial, s)
+		}
+	}
+}
+
+// Ensure that a registry can be built from a style sheet and consulted.
+func TestPropertyRegistry(t *testing.T) {
+	var p css.Parser
+	reg := p.ParsePropertyRegistry(p.ParseStyleSheet(css.NewScanner(strings.NewReader(`
+		@property --gap { syntax: "<length>"; inherits: false; initial-value: 4px; }
+		@property --brand { syntax: "<color>"; inherits: true; initial-value: red; }
+		@property --brand { syntax: "<color>"; inherits: true; initial-value: blue; }
+		@property --bad { syntax: "<length>"; inherits: false; initial-value: blue; }
+		@property --any { syntax: "*"; inherits: false; }
+		.foo { --gap: 1px }
+	`))))
+
+	if len(p.Errors) != 1 {
+		t.Fatalf("unexpected errors: %v", p.Errors)
+	} else if names := reg.Names(); !reflect.DeepEqual(names, []string{"--any", "--brand", "--gap"}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+
+	if v, ok := reg.InitialValue("--brand"); !ok || print(v) != "blue" {
+		t.Errorf("unexpected initial value: %s", print(v))
+	} else if _, ok := reg.InitialValue("--any"); ok {
+		t.Errorf("expected no initial value for universal syntax")
+	} else if _, ok := reg.InitialValue("--unknown"); ok {
+		t.Errorf("expected no initial value for unregistered property")
+	}
+
+	if reg.Inherits("--gap") {
+		t.Errorf("expected --gap to not inherit")
+	} else if !reg.Inherits("--unknown") {
+		t.Errorf("expected unregistered property to inherit")
+	}
+
+	values := p.ParseComponentValues(css.NewScanner(strings.NewReader(` 2em `)))
+	if !reg.Valid("--gap", values) {
+		t.Errorf("expected 2em to be a valid --gap")
+	} else if reg.Valid("--brand", values) {
+		t.Errorf("expected 2em to be an invalid --brand")
+	} else if !reg.Valid("--unknown", values) {
+		t.Errorf("expected unregistered property to accept any value")
+	} else if (*css.PropertyRegistry)(nil).Lookup("--gap") != nil {
+		t.Errorf("expected nil registry lookup to return nil")
+	}
+}
+
+// Ensure that basic data types match the expected component values.
+func TestMatchDataType(t *testing.T) {
+	var tests = []struct {
+		name string
+		in   string
+		ok   bool
+	}{
+		{name: "length", in: `10px`, ok: true},
+		{name: "length", in: `0`, ok: true},
+		{name: "length", in: `1`, ok: false},
+		{name: "length", in: `calc(1px + 2em)`, ok: true},
+		{name: "length", in: `10deg`, ok: false},
+		{name: "percentage", in: `10%`, ok: true},
+		{name: "length-percentage", in: `10%`, ok: true},
+		{name: "integer", in: `10`, ok: true},
+		{name: "integer", in: `1.5`, ok: false},
+		{name: "number", in: `1.5`, ok: true},
+		{name: "angle", in: `1TURN`, ok: true},
+		{name: "time", in: `200ms`, ok: true},
+		{name: "resolution", in: `2x`, ok: true},
+		{name: "color", in: `#abc`, ok: true},
+		{name: "color", in: `#abcde`, ok: false},
+		{name: "color", in: `#ggg`, ok: false},
+		{name: "color", in: `RebeccaPurple`, ok: true},
+		{name: "color", in: `currentColor`, ok: true},
+		{name: "color", in: `hsl(0 0% 0%)`, ok: true},
+		{name: "color", in: `bogus`, ok: false},
+		{name: "image", in: `url(a.png)`, ok: true},
+		{name: "image", in: `linear-gradient(red, blue)`, ok: true},
+		{name: "url", in: `url("a.png")`, ok: true},
+		{name: "string", in: `"a"`, ok: true},
+		{name: "custom-ident", in: `foo`, ok: true},
+		{name: "custom-ident", in: `inherit`, ok: false},
+		{name: "dashed-ident", in: `--foo`, ok: true},
+		{name: "transform-function", in: `translateX(1px)`, ok: true},
+		{name: "unknown", in: `foo`, ok: false},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		v := p.ParseComponentValue(css.NewScanner(strings.NewReader(tt.in)))
+		if ok := css.MatchDataType(tt.name, v); ok != tt.ok {
+			t.Errorf("%d. <%s> %s: exp=%v, got=%v", i, tt.name, tt.in, tt.ok, ok)
+		}
+	}
+}
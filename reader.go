@@ -0,0 +1,36 @@
+package css
+
+import "io"
+
+// RuleReader reads the top-level rules of a style sheet one at a time so
+// that large style sheets can be filtered or printed without holding the
+// whole syntax tree in memory.
+type RuleReader struct {
+	p *Parser
+	s *scanner
+}
+
+// NewRuleReader returns a reader for the rules scanned from s.
+// Parse errors are recorded on p.
+func NewRuleReader(p *Parser, s *Scanner) *RuleReader {
+	return &RuleReader{p: p, s: &scanner{s, p}}
+}
+
+// Next returns the next top-level rule. Returns io.EOF once all rules have
+// been read. If the parser stops because of its error limit then the
+// scanner and parser errors are returned instead.
+func (r *RuleReader) Next() (Rule, error) {
+	rule := r.p.consumeRule(r.s, true)
+	if r.p.stopped() {
+		var errs ErrorList
+		for _, err := range r.s.Errors {
+			errs = append(errs, err)
+		}
+		errs = append(errs, r.p.Errors...)
+		errs.Sort()
+		return nil, errs
+	} else if rule == nil {
+		return nil, io.EOF
+	}
+	return rule, nil
+}
@@ -0,0 +1,56 @@
+package css_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that rules can be read and printed one at a time.
+func TestRuleReader_Next(t *testing.T) {
+	var p css.Parser
+	r := css.NewRuleReader(&p, css.NewScanner(strings.NewReader(`<!-- @import "a.css"; a { color: red } @media print { b {} } c { } -->`)))
+
+	// Filter out the media rule while streaming to the printer.
+	var buf bytes.Buffer
+	var pr css.Printer
+	for {
+		rule, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		} else if at, ok := rule.(*css.AtRule); ok && at.Name == "media" {
+			continue
+		}
+		if err := pr.Print(&buf, rule); err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteString("\n")
+	}
+
+	if s := buf.String(); s != "@import \"a.css\";\na { color: red }\nc { }\n" {
+		t.Fatalf("unexpected output: %q", s)
+	} else if len(p.Errors) != 0 {
+		t.Fatalf("unexpected errors: %s", p.Errors)
+	}
+
+	// Reading past the end continues to return EOF.
+	if rule, err := r.Next(); rule != nil || err != io.EOF {
+		t.Fatalf("unexpected next: %v, %v", rule, err)
+	}
+}
+
+// Ensure that the reader returns the parser's errors once it stops.
+func TestRuleReader_Next_FailFast(t *testing.T) {
+	p := css.Parser{FailFast: true}
+	r := css.NewRuleReader(&p, css.NewScanner(strings.NewReader(`a {} b`)))
+	if rule, err := r.Next(); err != nil || print(rule) != "a {}" {
+		t.Fatalf("unexpected next: %v, %v", rule, err)
+	} else if rule, err := r.Next(); rule != nil || err == nil || err.Error() != "unexpected EOF" {
+		t.Fatalf("unexpected next: %v, %v", rule, err)
+	}
+}
@@ -0,0 +1,210 @@
+package css
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Edit represents a change to the source of a style sheet. The bytes from
+// Start up to End are replaced by Text.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
+// Apply returns src with the edit applied.
+func (e Edit) Apply(src string) string {
+	return src[:e.Start] + e.Text + src[e.End:]
+}
+
+// Reparse returns the style sheet for src after the edit is applied, where
+// ss was parsed from src. Only the top-level rules affected by the edit are
+// reparsed. The rules before them are reused as is and the rules after them
+// are reused with their positions shifted, so ss shouldn't be used
+// afterwards.
+//
+// Scanner and parser errors in the reparsed text are recorded on p. Errors
+// in the reused rules are not reported again.
+func (p *Parser) Reparse(ss *StyleSheet, src string, e Edit) *StyleSheet {
+	old := newLineIndex(src)
+	idx := newLineIndex(e.Apply(src))
+	delta := len(e.Text) - (e.End - e.Start)
+
+	// Find the offset of each rule in the original source.
+	starts := make([]int, len(ss.Rules))
+	c := &lineCursor{idx: old}
+	for i, r := range ss.Rules {
+		starts[i] = c.offset(Position(r))
+	}
+
+	// Reparse from the rule containing the start of the edit. The text
+	// after a rule, such as a comment, is considered part of it.
+	k := sort.SearchInts(starts, e.Start+1) - 1
+	start := idx.lines[0]
+	if k < 0 {
+		k = 0
+	} else {
+		start = starts[k]
+	}
+	rules := append(Rules{}, ss.Rules[:k]...)
+
+	// Reparse rules until one begins where a rule after the edit did.
+	// The rest of the text is unchanged so the remaining rules are reused.
+	m := sort.SearchInts(starts, e.End)
+	s := newScannerAt(idx.src, start, idx.position(start))
+	s.File, s.Arena = Position(ss).File, p.Arena
+	sc := &scanner{s, p}
+	c = &lineCursor{idx: idx}
+	for {
+		// Find the start of the next rule before consuming it so the
+		// rules which are reused aren't scanned again.
+		n := len(s.Errors)
+		tok := sc.Scan()
+		if isTok(tok, WhitespaceToken) || isTok(tok, CDOToken) || isTok(tok, CDCToken) {
+			continue
+		} else if isTok(tok, EOFToken) {
+			break
+		}
+
+		off := c.offset(Position(tok))
+		for m < len(starts) && starts[m]+delta < off {
+			m++
+		}
+		if m < len(starts) && starts[m]+delta == off {
+			s.Errors = s.Errors[:n]
+			pos := Position(ss.Rules[m])
+			newPosShift(pos, idx.position(off)).rules(ss.Rules[m:])
+			rules = append(rules, ss.Rules[m:]...)
+			break
+		}
+
+		sc.Unscan()
+		if r := p.consumeRule(sc, true); r != nil {
+			rules = append(rules, r)
+		}
+	}
+
+	// Scanner errors were passed to the error handler as they were scanned.
+	for _, err := range s.Errors {
+		p.Errors = append(p.Errors, err)
+	}
+	return &StyleSheet{Rules: rules}
+}
+
+// newScannerAt returns a scanner for in-memory input which begins at the
+// offset start, where the code point at start has the position pos.
+func newScannerAt(src string, start int, pos Pos) *Scanner {
+	s := &Scanner{Encoding: "utf-8", src: src, off: start}
+	s.bufpos[s.bufi] = Pos{Char: pos.Char - 1, Line: pos.Line}
+	s.bufend[s.bufi] = start
+	return s
+}
+
+// lineIndex maps between source offsets and positions. Lines are split the
+// same way as the scanner splits them.
+type lineIndex struct {
+	src   string
+	lines []int // offset of the start of each line
+}
+
+func newLineIndex(src string) *lineIndex {
+	idx := &lineIndex{src: src, lines: []int{0}}
+	if strings.HasPrefix(src, "\uFEFF") {
+		idx.lines[0] = 3
+	}
+	for i := idx.lines[0]; i < len(src); i++ {
+		switch src[i] {
+		case '\r':
+			if i+1 < len(src) && src[i+1] == '\n' {
+				i++
+			}
+			idx.lines = append(idx.lines, i+1)
+		case '\n', '\f':
+			idx.lines = append(idx.lines, i+1)
+		}
+	}
+	return idx
+}
+
+// lineCursor finds the offsets of a series of increasing positions. Each
+// position is found from the previous one rather than from the start of
+// its line, so the rules of a style sheet on a single line are found in one
+// pass.
+type lineCursor struct {
+	idx *lineIndex
+	pos Pos // position of the code point at off
+	off int
+}
+
+// offset returns the offset of the code point at pos.
+func (c *lineCursor) offset(pos Pos) int {
+	if pos.Line >= len(c.idx.lines) {
+		return len(c.idx.src)
+	} else if c.pos.Char == 0 || pos.Line != c.pos.Line || pos.Char < c.pos.Char {
+		c.pos, c.off = Pos{Char: 1, Line: pos.Line}, c.idx.lines[pos.Line]
+	}
+	for ; c.pos.Char < pos.Char && c.off < len(c.idx.src); c.pos.Char++ {
+		_, size := utf8.DecodeRuneInString(c.idx.src[c.off:])
+		c.off += size
+	}
+	return c.off
+}
+
+// position returns the position of the code point at off.
+func (idx *lineIndex) position(off int) Pos {
+	line := sort.SearchInts(idx.lines, off+1) - 1
+	return Pos{Char: utf8.RuneCountInString(idx.src[idx.lines[line]:off]) + 1, Line: line}
+}
+
+// posShift moves positions from one line onwards after an edit. Positions
+// on that line are also moved by a number of characters.
+type posShift struct {
+	line         int
+	lines, chars int
+}
+
+// newPosShift returns a shift which moves the position from to the position
+// to, along with every position after it.
+func newPosShift(from, to Pos) posShift {
+	return posShift{line: from.Line, lines: to.Line - from.Line, chars: to.Char - from.Char}
+}
+
+func (sh posShift) pos(p *Pos) {
+	if p.Line == 0 && p.Char == 0 {
+		return // unknown
+	} else if p.Line == sh.line {
+		p.Char += sh.chars
+	}
+	p.Line += sh.lines
+}
+
+// rules shifts the position of every node in a list of rules.
+func (sh posShift) rules(a Rules) {
+	if sh.lines == 0 && sh.chars == 0 {
+		return
+	}
+	Inspect(a, func(n Node) bool {
+		switch n := n.(type) {
+		case *AtRule:
+			sh.pos(&n.Pos)
+			sh.pos(&n.endPos)
+		case *QualifiedRule:
+			sh.pos(&n.Pos)
+		case *SimpleBlock:
+			sh.pos(&n.Pos)
+			sh.pos(&n.End)
+			if n.Token != nil {
+				sh.pos(&n.Token.Pos)
+				sh.pos(&n.Token.endPos)
+			}
+		case *Function:
+			sh.pos(&n.Pos)
+			sh.pos(&n.endPos)
+		case *Token:
+			sh.pos(&n.Pos)
+			sh.pos(&n.endPos)
+		}
+		return true
+	})
+}
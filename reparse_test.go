@@ -0,0 +1,138 @@
+package css_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that reparsing an edit matches parsing the edited source.
+func TestParser_Reparse(t *testing.T) {
+	src := "a { color: red }\n/* c */ b { width: calc(1px + 2em) }\r\n@media print {\n  c { x: y }\n}\nd { }"
+	var tests = []struct {
+		edit   css.Edit
+		reused int // rules reused from the original style sheet
+	}{
+		{edit: css.Edit{Start: 11, End: 14, Text: "blue"}, reused: 3},                // within the first rule
+		{edit: css.Edit{Start: 11, End: 14, Text: "blue;\nx: y"}, reused: 3},         // adds a line
+		{edit: css.Edit{Start: 17, End: 17, Text: "e{}"}, reused: 3},                 // before a comment
+		{edit: css.Edit{Start: 24, End: 24, Text: "/*"}, reused: 0},                  // opens a comment
+		{edit: css.Edit{Start: 0, End: 0, Text: "z "}, reused: 3},                    // joins the first rule
+		{edit: css.Edit{Start: 0, End: 0, Text: "\n\n"}, reused: 4},                  // before every rule
+		{edit: css.Edit{Start: 16, End: 25, Text: ""}, reused: 3},                    // removes a comment
+		{edit: css.Edit{Start: 54, End: 54, Text: "  "}, reused: 3},                  // splits a CRLF
+		{edit: css.Edit{Start: 69, End: 69, Text: "}"}, reused: 2},                   // closes a block early
+		{edit: css.Edit{Start: 84, End: 90, Text: ""}, reused: 2},                    // removes the last rule
+		{edit: css.Edit{Start: 90, End: 90, Text: " e { f: g }"}, reused: 3},         // appends a rule
+		{edit: css.Edit{Start: 0, End: 90, Text: "h { }"}, reused: 0},                // replaces everything
+		{edit: css.Edit{Start: 36, End: 36, Text: "\u00e9\u00e9"}, reused: 3},        // adds multi-byte code points
+		{edit: css.Edit{Start: 52, End: 53, Text: "} @import 'x';\nk {"}, reused: 1}, // adds rules
+	}
+
+	for i, tt := range tests {
+		var p0 css.Parser
+		ss := p0.ParseStyleSheet(css.NewScannerString(src))
+		orig := append(css.Rules{}, ss.Rules...)
+
+		var p css.Parser
+		other := p.Reparse(ss, src, tt.edit)
+
+		var exp css.Parser
+		newsrc := tt.edit.Apply(src)
+		expected := exp.ParseStyleSheet(css.NewScannerString(newsrc))
+
+		if a, b := dump(other), dump(expected); a != b {
+			t.Errorf("%d. %q: unexpected style sheet:\n\nexp: %s\n\ngot: %s", i, newsrc, b, a)
+		} else if n := reused(orig, other.Rules); n != tt.reused {
+			t.Errorf("%d. %q: expected %d rules reused, got %d", i, newsrc, tt.reused, n)
+		}
+	}
+}
+
+// Ensure that errors in reparsed text are reported.
+func TestParser_Reparse_Errors(t *testing.T) {
+	src := "a { } b { }"
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScannerString(src))
+
+	var handled int
+	p.ErrorHandler = func(*css.Error) { handled++ }
+	ss = p.Reparse(ss, src, css.Edit{Start: 10, End: 11, Text: "'x\n}"})
+	if len(p.Errors) != 1 || handled != 1 {
+		t.Fatalf("unexpected errors: %v", p.Errors)
+	} else if err := p.Errors[0].(*css.Error); err.Code != "newline-in-string" || err.Pos != (css.Pos{Char: 11, Line: 0}) {
+		t.Fatalf("unexpected error: %s %s", err.Code, err.Pos)
+	} else if print(ss) != "a { } b { ''\n}" {
+		t.Fatalf("unexpected style sheet: %q", print(ss))
+	}
+}
+
+// Ensure that errors in the rules after an edit are not reported again.
+func TestParser_Reparse_ReusedErrors(t *testing.T) {
+	src := "a { }\nb { 'x\n}"
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScannerString(src))
+
+	var handled int
+	p = css.Parser{ErrorHandler: func(*css.Error) { handled++ }}
+	ss2 := p.Reparse(ss, src, css.Edit{Start: 4, End: 4, Text: "x: y;"})
+	if len(p.Errors) != 0 || handled != 0 {
+		t.Fatalf("unexpected errors: %v", p.Errors)
+	} else if n := reused(ss.Rules, ss2.Rules); n != 1 {
+		t.Fatalf("unexpected reused rules: %d", n)
+	}
+}
+
+// Reparsing a style sheet on a single line should be linear in its size.
+func BenchmarkParser_Reparse_SingleLine(b *testing.B) {
+	src := strings.Replace(string(benchmarkStyleSheet()), "\n", " ", -1)
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScannerString(src))
+	off := len(src)/2 + strings.Index(src[len(src)/2:], "#ff0000")
+
+	// Each edit is applied to the style sheet returned by the last one,
+	// alternating between two colors.
+	edits := []css.Edit{
+		{Start: off, End: off + 7, Text: "#00ff00"},
+		{Start: off, End: off + 7, Text: "#ff0000"},
+	}
+
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e := edits[i%2]
+		ss = p.Reparse(ss, src, e)
+		src = e.Apply(src)
+	}
+}
+
+// dump returns a style sheet and the positions of each of its nodes.
+func dump(ss *css.StyleSheet) string {
+	var buf strings.Builder
+	buf.WriteString(print(ss))
+	css.Inspect(ss, func(n css.Node) bool {
+		if n != nil && n != css.Node(ss) {
+			fmt.Fprintf(&buf, "\n%T %s-%s", n, css.Position(n), css.End(n))
+		}
+		if b, ok := n.(*css.SimpleBlock); ok {
+			fmt.Fprintf(&buf, " %s %s", b.Token.Pos, b.End)
+		}
+		return true
+	})
+	return buf.String()
+}
+
+// reused returns the number of rules in b which are also in a.
+func reused(a, b css.Rules) int {
+	var n int
+	for _, r := range b {
+		for _, r0 := range a {
+			if r == r0 {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -7,33 +7,148 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // eof represents an EOF file byte.
 var eof rune = -1
 
 // Scanner implements a CSS3 standard compliant tokenizer.
-//
-// This implementation only allows UTF-8 encoding.
-// @charset directives will be ignored.
 type Scanner struct {
 	// Errors contains a list of all errors that occur during scanning.
 	Errors []*Error
 
-	rd io.RuneReader
+	// Encoding is the name of the encoding used to decode the input. It is
+	// determined when the first token is scanned for input from a reader.
+	Encoding string
+
+	// File is the file being scanned, if any. It is set on the position
+	// of every token scanned. See FileSet.NewScanner.
+	File *File
+
+	// SourceMap, if set, maps the positions of tokens and errors back to
+	// the original source that generated the input, such as a Sass file.
+	// Positions which aren't mapped are left as is.
+	SourceMap *SourceMap
+
+	// Arena, if set, allocates tokens and interns names. See Arena.
+	Arena *Arena
+
+	// KeepComments records the comments skipped by the scanner in Comments.
+	KeepComments bool
+	Comments     []*Comment
+
+	rd io.RuneScanner
+
+	// The encoding of input from a reader is detected on the first scan so
+	// that creating a scanner doesn't block while the reader fills.
+	detect                bool
+	protocol, environment string
+
+	// In-memory input is read directly from src instead of rd.
+	src string
+	off int // byte offset of the next code point in src
+
+	// Copied token values are built in scratch, which is reused by one
+	// text at a time while scanning a token.
+	scratch     []byte
+	scratchUsed bool
+
+	// Tokens of in-memory input are allocated from chunks when there is
+	// no arena. The chunks double in size so small input stays small.
+	chunk []Token
 
 	tokbuf  *Token // last token read from the scanner.
 	tokbufn bool   // whether the token buffer is in use.
 
+	reported int // number of errors already reported to a parser
+
 	buf    [4]rune // circular buffer for runes
 	bufpos [4]Pos  // circular buffer for position
+	bufend [4]int  // circular buffer for the source offset after each rune
+	bufchg [4]bool // circular buffer for whether preprocessing changed each rune
 	bufi   int     // circular buffer index
 	bufn   int     // number of buffered characters
 }
 
-// New returns a new instance of Scanner.
+// NewScanner returns a new instance of Scanner. The encoding of the input is
+// determined from its byte order mark or @charset rule and defaults to UTF-8.
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{rd: bufio.NewReader(r)}
+	return NewScannerEncoding(r, "", "")
+}
+
+// NewScannerEncoding returns a new instance of Scanner which determines the
+// encoding of the input using the protocol and environment encodings as
+// fallbacks. The protocol encoding is supplied with the input, such as the
+// charset of an HTTP Content-Type header. The environment encoding is the
+// encoding of the referring document. Either label may be blank. (§3.2)
+func NewScannerEncoding(r io.Reader, protocol, environment string) *Scanner {
+	return &Scanner{rd: bufio.NewReader(r), detect: true, protocol: protocol, environment: environment}
+}
+
+// decode determines the encoding of input from a reader from its first
+// bytes and decodes the rest of the input into UTF-8, if necessary.
+func (s *Scanner) decode() {
+	s.detect = false
+	br := s.rd.(*bufio.Reader)
+	b, _ := br.Peek(1024)
+	name, bom := detectEncoding(b, s.protocol, s.environment)
+	_, _ = br.Discard(bom)
+
+	s.Encoding = name
+	if name != "utf-8" {
+		dr := Decoders[name](br)
+		if rd, ok := dr.(io.RuneScanner); ok {
+			s.rd = rd
+		} else {
+			s.rd = bufio.NewReader(dr)
+		}
+	}
+}
+
+// NewScannerString returns a new instance of Scanner which reads from an
+// in-memory string of UTF-8 input. Token values are sliced from the string
+// instead of being copied unless they contain escapes or characters changed
+// by preprocessing, such as CRLF pairs or NULL. Tokens are allocated in
+// chunks unless an Arena is set.
+func NewScannerString(src string) *Scanner {
+	return &Scanner{Encoding: "utf-8", src: strings.TrimPrefix(src, "\uFEFF")}
+}
+
+// NewScannerBytes returns a new instance of Scanner which reads from an
+// in-memory byte slice. The slice is copied once so that token values can be
+// sliced from it. Input in an encoding other than UTF-8 is decoded as it is
+// read, as with NewScanner.
+func NewScannerBytes(b []byte) *Scanner {
+	if name, _ := detectEncoding(b, "", ""); name != "utf-8" {
+		return NewScanner(bytes.NewReader(b))
+	}
+	return NewScannerString(string(b))
+}
+
+// minTokenChunk and maxTokenChunk bound the number of tokens in each chunk
+// allocated for in-memory input.
+const (
+	minTokenChunk = 16
+	maxTokenChunk = 1024
+)
+
+// token returns a copy of tok allocated from the arena, if set, or from the
+// current chunk for in-memory input.
+func (s *Scanner) token(tok Token) *Token {
+	if s.Arena != nil || s.rd != nil {
+		return s.Arena.token(tok)
+	} else if len(s.chunk) == cap(s.chunk) {
+		n := 2 * cap(s.chunk)
+		if n < minTokenChunk {
+			n = minTokenChunk
+		} else if n > maxTokenChunk {
+			n = maxTokenChunk
+		}
+		s.chunk = make([]Token, 0, n)
+	}
+	s.chunk = append(s.chunk, tok)
+	return &s.chunk[len(s.chunk)-1]
 }
 
 // Scan returns the next token from the reader.
@@ -45,11 +160,53 @@ func (s *Scanner) Scan() *Token {
 	}
 
 	// Otherwise read from the reader and save the token.
+	if s.detect {
+		s.decode()
+	}
+	n, m := len(s.Errors), len(s.Comments)
+	s.scratchUsed = false
 	tok := s.scan()
 	s.tokbuf = tok
+
+	// The token ends just after the last code point that was read.
+	if tok.Tok != EOFToken {
+		tok.endPos = s.pos()
+		tok.endPos.Char++
+	}
+
+	// Remap positions to the original source, if available.
+	if s.SourceMap != nil {
+		pos := s.remap(tok.Pos)
+		if tok.endPos != (Pos{}) && tok.endPos.Line == tok.Pos.Line {
+			tok.endPos = Pos{Char: pos.Char + tok.endPos.Char - tok.Pos.Char, Line: pos.Line, File: pos.File}
+		} else if tok.endPos != (Pos{}) {
+			tok.endPos = s.remap(tok.endPos)
+		}
+		tok.Pos = pos
+		for _, c := range s.Comments[m:] {
+			c.Pos = s.remap(c.Pos)
+		}
+		for _, err := range s.Errors[n:] {
+			pos := s.remap(err.Pos)
+			if err.End.Line == err.Pos.Line {
+				err.End = Pos{Char: pos.Char + err.End.Char - err.Pos.Char, Line: pos.Line, File: pos.File}
+			} else {
+				err.End = s.remap(err.End)
+			}
+			err.Pos = pos
+		}
+	}
 	return tok
 }
 
+// remap returns the position in the original source, if it is mapped.
+func (s *Scanner) remap(pos Pos) Pos {
+	if other, ok := s.SourceMap.Lookup(pos); ok {
+		return other
+	}
+	return pos
+}
+
 func (s *Scanner) scan() *Token {
 	for {
 		// Read next code point.
@@ -86,7 +243,7 @@ func (s *Scanner) scan() *Token {
 		// Check against individual code points next.
 		switch ch {
 		case eof:
-			return &Token{Tok: EOFToken, Pos: pos}
+			return s.token(Token{Tok: EOFToken, Pos: pos})
 		case '"', '\'':
 			return s.scanString()
 		case '#':
@@ -94,68 +251,72 @@ func (s *Scanner) scan() *Token {
 
 		case '$':
 			if next := s.read(); next == '=' {
-				return &Token{Tok: SuffixMatchToken, Pos: pos}
+				return s.token(Token{Tok: SuffixMatchToken, Pos: pos})
 			}
 			s.unread(1)
-			return &Token{Tok: DelimToken, Value: string(ch), Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: s.char(ch), Pos: pos})
 
 		case '*':
 			if next := s.read(); next == '=' {
-				return &Token{Tok: SubstringMatchToken, Pos: pos}
+				return s.token(Token{Tok: SubstringMatchToken, Pos: pos})
 			}
 			s.unread(1)
-			return &Token{Tok: DelimToken, Value: string(ch), Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: s.char(ch), Pos: pos})
 
 		case '^':
 			if next := s.read(); next == '=' {
-				return &Token{Tok: PrefixMatchToken, Pos: pos}
+				return s.token(Token{Tok: PrefixMatchToken, Pos: pos})
 			}
 			s.unread(1)
-			return &Token{Tok: DelimToken, Value: string(ch), Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: s.char(ch), Pos: pos})
 
 		case '~':
 			if next := s.read(); next == '=' {
-				return &Token{Tok: IncludeMatchToken, Pos: pos}
+				return s.token(Token{Tok: IncludeMatchToken, Pos: pos})
 			}
 			s.unread(1)
-			return &Token{Tok: DelimToken, Value: string(ch), Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: s.char(ch), Pos: pos})
 
 		case ',':
-			return &Token{Tok: CommaToken, Pos: pos}
+			return s.token(Token{Tok: CommaToken, Pos: pos})
 
 		case '-':
-			// Check for a number or identifier.
+			// Check for a number first.
 			if s.peekNumber() {
 				s.unread(1)
 				return s.scanNumeric(pos)
-			} else if s.peekIdent() {
-				return s.scanIdent()
 			}
 
 			// Scan next two code points to see if we have a CDC (-->).
+			// This must be checked before identifiers since "--" starts one.
 			ch1, ch2 := s.read(), s.read()
 			if ch1 == '-' && ch2 == '>' {
-				return &Token{Tok: CDCToken, Pos: pos}
+				return s.token(Token{Tok: CDCToken, Pos: pos})
 			}
 			s.unread(2)
 
+			// Check for an identifier.
+			if s.peekIdent() {
+				return s.scanIdent()
+			}
+
 			// Otherwise return the hyphen by itself.
-			return &Token{Tok: DelimToken, Value: "-", Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: "-", Pos: pos})
 
 		case '/':
 			// Comments are ignored by the scanner so restart the loop from
 			// the end of the comment and get the next token.
 			if ch1 := s.read(); ch1 == '*' {
-				s.scanComment()
+				s.scanComment(pos)
 				continue
 			}
 			s.unread(1)
-			return &Token{Tok: DelimToken, Value: "/", Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: "/", Pos: pos})
 
 		case ':':
-			return &Token{Tok: ColonToken, Pos: pos}
+			return s.token(Token{Tok: ColonToken, Pos: pos})
 		case ';':
-			return &Token{Tok: SemicolonToken, Pos: pos}
+			return s.token(Token{Tok: SemicolonToken, Pos: pos})
 
 		case '<':
 			// Attempt to read a comment open ("<!--").
@@ -163,35 +324,35 @@ func (s *Scanner) scan() *Token {
 			if ch0 := s.read(); ch0 == '!' {
 				if ch1 := s.read(); ch1 == '-' {
 					if ch2 := s.read(); ch2 == '-' {
-						return &Token{Tok: CDOToken, Pos: pos}
+						return s.token(Token{Tok: CDOToken, Pos: pos})
 					}
 					s.unread(1)
 				}
 				s.unread(1)
 			}
 			s.unread(1)
-			return &Token{Tok: DelimToken, Value: "<", Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: "<", Pos: pos})
 
 		case '@':
 			// This is an at-keyword token if an identifier follows.
 			// Otherwise it's just a DELIM.
 			if s.read(); s.peekIdent() {
-				return &Token{Tok: AtKeywordToken, Value: s.scanName(), Pos: pos}
+				return s.token(Token{Tok: AtKeywordToken, Value: s.scanName(), Pos: pos})
 			}
-			return &Token{Tok: DelimToken, Value: "@", Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: "@", Pos: pos})
 
 		case '(':
-			return &Token{Tok: LParenToken, Pos: pos}
+			return s.token(Token{Tok: LParenToken, Pos: pos})
 		case ')':
-			return &Token{Tok: RParenToken, Pos: pos}
+			return s.token(Token{Tok: RParenToken, Pos: pos})
 		case '[':
-			return &Token{Tok: LBrackToken, Pos: pos}
+			return s.token(Token{Tok: LBrackToken, Pos: pos})
 		case ']':
-			return &Token{Tok: RBrackToken, Pos: pos}
+			return s.token(Token{Tok: RBrackToken, Pos: pos})
 		case '{':
-			return &Token{Tok: LBraceToken, Pos: pos}
+			return s.token(Token{Tok: LBraceToken, Pos: pos})
 		case '}':
-			return &Token{Tok: RBraceToken, Pos: pos}
+			return s.token(Token{Tok: RBraceToken, Pos: pos})
 
 		case '\\':
 			// Return a valid escape, if possible.
@@ -199,30 +360,30 @@ func (s *Scanner) scan() *Token {
 				return s.scanIdent()
 			}
 			// Otherwise this is a parse error but continue on as a DELIM.
-			s.Errors = append(s.Errors, &Error{Message: "unescaped \\", Pos: s.pos()})
-			return &Token{Tok: DelimToken, Value: "\\", Pos: pos}
+			s.error("unescaped-backslash", s.pos(), "unescaped \\")
+			return s.token(Token{Tok: DelimToken, Value: "\\", Pos: pos})
 
 		case '+', '.':
 			if s.peekNumber() {
 				s.unread(1)
 				return s.scanNumeric(pos)
 			}
-			return &Token{Tok: DelimToken, Value: string(ch), Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: s.char(ch), Pos: pos})
 
 		case '|':
 			// If the next token is an equals sign, it's a dash token.
 			// If the next token is a pipe, it's a column token.
 			// Otherwise, just treat this pipe as a delim token.
 			if ch1 := s.read(); ch1 == '=' {
-				return &Token{Tok: DashMatchToken, Pos: pos}
+				return s.token(Token{Tok: DashMatchToken, Pos: pos})
 			} else if ch1 == '|' {
-				return &Token{Tok: ColumnToken, Pos: pos}
+				return s.token(Token{Tok: ColumnToken, Pos: pos})
 			}
 			s.unread(1)
-			return &Token{Tok: DelimToken, Value: string(ch), Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: s.char(ch), Pos: pos})
 
 		default:
-			return &Token{Tok: DelimToken, Value: string(ch), Pos: pos}
+			return s.token(Token{Tok: DelimToken, Value: s.char(ch), Pos: pos})
 		}
 	}
 }
@@ -240,8 +401,8 @@ func (s *Scanner) current() *Token {
 // scanWhitespace consumes the current code point and all subsequent whitespace.
 func (s *Scanner) scanWhitespace() *Token {
 	pos := s.pos()
-	var buf bytes.Buffer
-	_, _ = buf.WriteRune(s.curr())
+	buf := s.text()
+	buf.add(s.curr())
 	for {
 		ch := s.read()
 		if ch == eof {
@@ -250,9 +411,9 @@ func (s *Scanner) scanWhitespace() *Token {
 			s.unread(1)
 			break
 		}
-		_, _ = buf.WriteRune(ch)
+		buf.add(ch)
 	}
-	return &Token{Tok: WhitespaceToken, Value: buf.String(), Pos: pos}
+	return s.token(Token{Tok: WhitespaceToken, Value: buf.String(), Pos: pos})
 }
 
 // scanString consumes a quoted string. (§4.3.4)
@@ -260,38 +421,42 @@ func (s *Scanner) scanWhitespace() *Token {
 // This assumes that the current token is a single or double quote.
 // This function consumes all code points and escaped code points up until
 // a matching, unescaped ending quote.
-// An EOF closes out a string but does not return an error.
+// An EOF closes out a string but is a parse error.
 // A newline will close a string and returns a bad-string token.
 func (s *Scanner) scanString() *Token {
 	pos, ending := s.pos(), s.curr()
-	var buf bytes.Buffer
+	buf := s.text()
 	for {
 		ch := s.read()
 		if ch == eof || ch == ending {
-			return &Token{Tok: StringToken, Value: buf.String(), Ending: ending, Pos: pos}
+			if ch == eof {
+				s.error("eof-in-string", pos, "unexpected EOF in string")
+			}
+			return s.token(Token{Tok: StringToken, Value: buf.String(), Ending: ending, Pos: pos})
 		} else if ch == '\n' {
 			s.unread(1)
-			return &Token{Tok: BadStringToken, Pos: pos}
+			s.error("newline-in-string", pos, "unexpected newline in string")
+			return s.token(Token{Tok: BadStringToken, Pos: pos})
 		} else if ch == '\\' {
 			// If the next code point is EOF then do nothing.
 			// If it is a newline then consume it.
 			if next := s.read(); next == eof {
 				continue
 			} else if next == '\n' {
-				_, _ = buf.WriteRune(next)
+				buf.add(next)
 				continue
 			}
 			s.unread(1)
 
 			// If it is an escape then consume the escaped code point.
 			if s.peekEscape() {
-				_, _ = buf.WriteRune(s.scanEscape())
+				buf.write(s.scanEscape())
 				continue
 			}
 		}
 
 		// Append anything else to the buffer.
-		_, _ = buf.WriteRune(ch)
+		buf.add(ch)
 	}
 }
 
@@ -299,49 +464,50 @@ func (s *Scanner) scanString() *Token {
 //
 // This assumes that the current token is a +, -, . or digit.
 func (s *Scanner) scanNumeric(pos Pos) *Token {
+	_, start := s.span() // the number follows the current code point
 	num, typ, repr := s.scanNumber()
 
 	// If the number is immediately followed by an identifier then scan dimension.
 	if s.read(); s.peekIdent() {
 		unit := s.scanName()
-		return &Token{Tok: DimensionToken, Type: typ, Value: repr + unit, Number: num, Unit: unit, Pos: pos}
+		return s.token(Token{Tok: DimensionToken, Type: typ, Value: s.concat(start, repr, unit), Number: num, Unit: unit, Pos: pos})
 	} else {
 		s.unread(1)
 	}
 
 	// If the number is followed by a percent sign then return a percentage.
 	if ch := s.read(); ch == '%' {
-		return &Token{Tok: PercentageToken, Type: typ, Value: repr + "%", Number: num, Pos: pos}
+		return s.token(Token{Tok: PercentageToken, Type: typ, Value: s.concat(start, repr, "%"), Number: num, Pos: pos})
 	} else {
 		s.unread(1)
 	}
 
 	// Otherwise return a number token.
-	return &Token{Tok: NumberToken, Type: typ, Value: repr, Number: num, Pos: pos}
+	return s.token(Token{Tok: NumberToken, Type: typ, Value: repr, Number: num, Pos: pos})
 }
 
 // scanNumber consumes a number.
 func (s *Scanner) scanNumber() (num float64, typ, repr string) {
-	var buf bytes.Buffer
+	buf := s.text()
 	typ = "integer"
 
 	// If initial code point is + or - then store it.
 	if ch := s.read(); ch == '+' || ch == '-' {
-		_, _ = buf.WriteRune(ch)
+		buf.add(ch)
 	} else {
 		s.unread(1)
 	}
 
 	// Read as many digits as possible.
-	_, _ = buf.WriteString(s.scanDigits())
+	s.scanDigits(&buf)
 
 	// If next code points are a full stop and digit then consume them.
 	if ch0 := s.read(); ch0 == '.' {
 		if ch1 := s.read(); isDigit(ch1) {
 			typ = "number"
-			_, _ = buf.WriteRune(ch0)
-			_, _ = buf.WriteRune(ch1)
-			_, _ = buf.WriteString(s.scanDigits())
+			s.unread(1)
+			buf.add(ch0)
+			s.scanDigits(&buf)
 		} else {
 			s.unread(2)
 		}
@@ -354,16 +520,18 @@ func (s *Scanner) scanNumber() (num float64, typ, repr string) {
 		if ch1 := s.read(); ch1 == '+' || ch1 == '-' {
 			if ch2 := s.read(); isDigit(ch2) {
 				typ = "number"
-				_, _ = buf.WriteRune(ch0)
-				_, _ = buf.WriteRune(ch1)
-				_, _ = buf.WriteRune(ch2)
+				s.unread(2)
+				buf.add(ch0)
+				buf.add(s.read())
+				buf.add(s.read())
 			} else {
 				s.unread(3)
 			}
 		} else if isDigit(ch1) {
 			typ = "number"
-			_, _ = buf.WriteRune(ch0)
-			_, _ = buf.WriteRune(ch1)
+			s.unread(1)
+			buf.add(ch0)
+			buf.add(s.read())
 		} else {
 			s.unread(2)
 		}
@@ -372,40 +540,53 @@ func (s *Scanner) scanNumber() (num float64, typ, repr string) {
 	}
 
 	// Parse number.
-	num, _ = strconv.ParseFloat(buf.String(), 64)
 	repr = buf.String()
+	num, _ = strconv.ParseFloat(repr, 64)
 	return
 }
 
-// scanDigits consume a contiguous series of digits.
-func (s *Scanner) scanDigits() string {
-	var buf bytes.Buffer
+// scanDigits consume a contiguous series of digits into buf.
+func (s *Scanner) scanDigits(buf *text) {
 	for {
 		if ch := s.read(); isDigit(ch) {
-			_, _ = buf.WriteRune(ch)
+			buf.add(ch)
 		} else {
 			s.unread(1)
 			break
 		}
 	}
-	return buf.String()
 }
 
 // scanComment consumes all characters up to "*/", inclusive.
 // This function assumes that the initial "/*" have just been consumed.
-func (s *Scanner) scanComment() {
+// An EOF closes out a comment but is a parse error.
+func (s *Scanner) scanComment(pos Pos) {
+	// Reread the opening "/*" so that it's part of the comment's text.
+	buf := s.text()
+	s.unread(2)
+	buf.add(s.read())
+	buf.add(s.read())
+
 	for {
 		ch0 := s.read()
 		if ch0 == eof {
+			s.error("eof-in-comment", pos, "unexpected EOF in comment")
 			break
-		} else if ch0 == '*' {
+		}
+		buf.add(ch0)
+		if ch0 == '*' {
 			if ch1 := s.read(); ch1 == '/' {
+				buf.add(ch1)
 				break
 			} else {
 				s.unread(1)
 			}
 		}
 	}
+
+	if s.KeepComments {
+		s.Comments = append(s.Comments, &Comment{Text: buf.String(), Pos: pos})
+	}
 }
 
 // scanHash consumes a hash token.
@@ -425,27 +606,27 @@ func (s *Scanner) scanHash() *Token {
 		if s.peekIdent() {
 			typ = "id"
 		}
-		return &Token{Tok: HashToken, Value: s.scanName(), Type: typ, Pos: pos}
+		return s.token(Token{Tok: HashToken, Value: s.scanName(), Type: typ, Pos: pos})
 	}
 	s.unread(1)
 
 	// If there is no name following the hash symbol then return delim-token.
-	return &Token{Tok: DelimToken, Value: "#", Pos: pos}
+	return s.token(Token{Tok: DelimToken, Value: "#", Pos: pos})
 }
 
 // scanName consumes a name.
 // Consumes contiguous name code points and escaped code points.
 func (s *Scanner) scanName() string {
-	var buf bytes.Buffer
+	buf := s.text()
 	s.unread(1)
 	for {
 		if ch := s.read(); isName(ch) {
-			_, _ = buf.WriteRune(ch)
+			buf.add(ch)
 		} else if s.peekEscape() {
-			_, _ = buf.WriteRune(s.scanEscape())
+			buf.write(s.scanEscape())
 		} else {
 			s.unread(1)
-			return buf.String()
+			return buf.name()
 		}
 	}
 }
@@ -457,17 +638,17 @@ func (s *Scanner) scanIdent() *Token {
 	v := s.scanName()
 
 	// Check if this is the start of a url token.
-	if strings.ToLower(v) == "url" {
+	if strings.EqualFold(v, "url") {
 		if ch := s.read(); ch == '(' {
 			return s.scanURL(pos)
 		}
 		s.unread(1)
 	} else if ch := s.read(); ch == '(' {
-		return &Token{Tok: FunctionToken, Value: v, Pos: pos}
+		return s.token(Token{Tok: FunctionToken, Value: v, Pos: pos})
 	}
 	s.unread(1)
 
-	return &Token{Tok: IdentToken, Value: v, Pos: pos}
+	return s.token(Token{Tok: IdentToken, Value: v, Pos: pos})
 }
 
 // scanURL consumes the contents of a URL function.
@@ -485,9 +666,11 @@ func (s *Scanner) scanURL(pos Pos) *Token {
 	// If it starts with a single or double quote then consume a string and
 	// use the string's value as the URL.
 	if ch := s.read(); ch == eof {
-		return &Token{Tok: URLToken, Pos: pos}
+		s.error("eof-in-url", pos, "unexpected EOF in url")
+		return s.token(Token{Tok: URLToken, Pos: pos})
 	} else if ch == '"' || ch == '\'' {
 		// Scan the string as the value.
+		n := len(s.Errors)
 		tok := s.scanString()
 
 		// Scanning a bad-string causes a bad-url token.
@@ -496,53 +679,65 @@ func (s *Scanner) scanURL(pos Pos) *Token {
 			value = tok.Value
 		} else if tok.Tok == BadStringToken {
 			s.scanBadURL()
-			return &Token{Tok: BadURLToken, Pos: pos}
+			return s.token(Token{Tok: BadURLToken, Pos: pos})
 		}
 
 		// Scan whitespace after the string.
 		if ch := s.read(); isWhitespace(ch) {
 			s.scanWhitespace()
+		} else {
+			s.unread(1)
 		}
-		s.unread(1)
 
-		// Scan right parenthesis.
-		if ch := s.read(); ch != ')' && ch != eof {
+		// Scan right parenthesis. An EOF after an unterminated string has
+		// already been reported.
+		if ch := s.read(); ch == eof && len(s.Errors) == n {
+			s.error("eof-in-url", pos, "unexpected EOF in url")
+		} else if ch != ')' && ch != eof {
+			s.error("bad-url", pos, "unexpected %c (%U) after url string", ch, ch)
 			s.scanBadURL()
-			return &Token{Tok: BadURLToken, Pos: pos}
+			return s.token(Token{Tok: BadURLToken, Pos: pos})
 		}
-		return &Token{Tok: URLToken, Value: value, Pos: pos}
+		return s.token(Token{Tok: URLToken, Value: value, Pos: pos})
 	}
 	s.unread(1)
 
 	// If we have a non-quote character then scan all non-whitespace, non-quote
 	// and non-lparen code points to form the URL value.
-	var buf bytes.Buffer
+	buf := s.text()
 	for {
 		ch := s.read()
 		if ch == ')' || ch == eof {
-			return &Token{Tok: URLToken, Value: buf.String(), Pos: pos}
+			if ch == eof {
+				s.error("eof-in-url", pos, "unexpected EOF in url")
+			}
+			return s.token(Token{Tok: URLToken, Value: buf.String(), Pos: pos})
 		} else if isWhitespace(ch) {
 			s.scanWhitespace()
 			if ch0 := s.read(); ch0 == ')' || ch0 == eof {
-				return &Token{Tok: URLToken, Value: buf.String(), Pos: pos}
+				if ch0 == eof {
+					s.error("eof-in-url", pos, "unexpected EOF in url")
+				}
+				return s.token(Token{Tok: URLToken, Value: buf.String(), Pos: pos})
 			} else {
+				s.error("bad-url", pos, "unexpected whitespace in url")
 				s.scanBadURL()
-				return &Token{Tok: BadURLToken, Pos: pos}
+				return s.token(Token{Tok: BadURLToken, Pos: pos})
 			}
 		} else if ch == '"' || ch == '\'' || ch == '(' || isNonPrintable(ch) {
-			s.Errors = append(s.Errors, &Error{Message: fmt.Sprintf("invalid url code point: %c (%U)", ch, ch), Pos: pos})
+			s.error("bad-url", pos, "invalid url code point: %c (%U)", ch, ch)
 			s.scanBadURL()
-			return &Token{Tok: BadURLToken, Pos: pos}
+			return s.token(Token{Tok: BadURLToken, Pos: pos})
 		} else if ch == '\\' {
 			if s.peekEscape() {
-				_, _ = buf.WriteRune(s.scanEscape())
+				buf.write(s.scanEscape())
 			} else {
-				s.Errors = append(s.Errors, &Error{Message: "unescaped \\ in url", Pos: s.pos()})
+				s.error("bad-url", s.pos(), "unescaped \\ in url")
 				s.scanBadURL()
-				return &Token{Tok: BadURLToken, Pos: pos}
+				return s.token(Token{Tok: BadURLToken, Pos: pos})
 			}
 		} else {
-			_, _ = buf.WriteRune(ch)
+			buf.add(ch)
 		}
 	}
 }
@@ -596,7 +791,7 @@ func (s *Scanner) scanUnicodeRange() *Token {
 	if buf.Len() > n {
 		start64, _ := strconv.ParseInt(strings.Replace(buf.String(), "?", "0", -1), 16, 0)
 		end64, _ := strconv.ParseInt(strings.Replace(buf.String(), "?", "F", -1), 16, 0)
-		return &Token{Tok: UnicodeRangeToken, Start: int(start64), End: int(end64), Pos: pos}
+		return s.token(Token{Tok: UnicodeRangeToken, Start: int(start64), End: int(end64), Pos: pos})
 	}
 
 	// Otherwise calculate this token is the start of the range.
@@ -618,20 +813,21 @@ func (s *Scanner) scanUnicodeRange() *Token {
 			}
 		}
 		end64, _ := strconv.ParseInt(buf.String(), 16, 0)
-		return &Token{Tok: UnicodeRangeToken, Start: int(start64), End: int(end64), Pos: pos}
+		return s.token(Token{Tok: UnicodeRangeToken, Start: int(start64), End: int(end64), Pos: pos})
 	}
 	s.unread(2)
 
 	// Otherwise set the end value to the start value.
-	return &Token{Tok: UnicodeRangeToken, Start: int(start64), End: int(start64), Pos: pos}
+	return s.token(Token{Tok: UnicodeRangeToken, Start: int(start64), End: int(start64), Pos: pos})
 }
 
 // scanEscape consumes an escaped code point.
+// An EOF after the backslash is a parse error.
 func (s *Scanner) scanEscape() rune {
-	var buf bytes.Buffer
+	pos := s.pos()
 	ch := s.read()
 	if isHexDigit(ch) {
-		_, _ = buf.WriteRune(ch)
+		v := hexValue(ch)
 		for i := 0; i < 5; i++ {
 			if next := s.read(); next == eof || isWhitespace(next) {
 				break
@@ -639,12 +835,12 @@ func (s *Scanner) scanEscape() rune {
 				s.unread(1)
 				break
 			} else {
-				_, _ = buf.WriteRune(next)
+				v = v*16 + hexValue(next)
 			}
 		}
-		v, _ := strconv.ParseInt(buf.String(), 16, 0)
-		return rune(v)
+		return v
 	} else if ch == eof {
+		s.error("eof-in-escape", pos, "unexpected EOF in escape")
 		return '\uFFFD'
 	} else {
 		return ch
@@ -667,9 +863,12 @@ func (s *Scanner) peekEscape() bool {
 // peekIdent checks if the next code points are a valid identifier.
 func (s *Scanner) peekIdent() bool {
 	if s.curr() == '-' {
+		// A hyphen must be followed by a name start, a second hyphen or an
+		// escape. Two hyphens start a custom property name such as "--foo".
 		ch := s.read()
+		ok := isNameStart(ch) || ch == '-' || s.peekEscape()
 		s.unread(1)
-		return isNameStart(ch) || s.peekEscape()
+		return ok
 	} else if isNameStart(s.curr()) {
 		return true
 	} else if s.curr() == '\\' && s.peekEscape() {
@@ -716,30 +915,30 @@ func (s *Scanner) read() rune {
 		return s.buf[s.bufi]
 	}
 
-	// Otherwise read from the reader.
-	ch, _, err := s.rd.ReadRune()
+	// Otherwise read from the input.
+	ch, size, err := s.readRune()
 	pos := s.pos()
+	pos.File = s.File
+	changed := ch == utf8.RuneError && size == 1
 	if err != nil {
 		ch = eof
 	} else {
 		// Preprocess the input stream by replacing FF with LF. (§3.3)
 		if ch == '\f' {
-			ch = '\n'
+			ch, changed = '\n', true
 		}
 
 		// Preprocess the input stream by replacing CR and CRLF with LF. (§3.3)
 		if ch == '\r' {
-			if ch, _, err := s.rd.ReadRune(); err != nil {
-				// nop
-			} else if ch != '\n' {
-				s.unread(1)
+			if ch, _, err := s.readRune(); err == nil && ch != '\n' {
+				s.unreadRune()
 			}
-			ch = '\n'
+			ch, changed = '\n', true
 		}
 
 		// Replace NULL with Unicode replacement character. (§3.3)
 		if ch == '\000' {
-			ch = '\uFFFD'
+			ch, changed = '\uFFFD', true
 		}
 
 		// Track scanner position.
@@ -755,9 +954,33 @@ func (s *Scanner) read() rune {
 	s.bufi = ((s.bufi + 1) % len(s.buf))
 	s.buf[s.bufi] = ch
 	s.bufpos[s.bufi] = pos
+	s.bufend[s.bufi] = s.off
+	s.bufchg[s.bufi] = changed
 	return ch
 }
 
+// readRune reads the next code point from the input.
+func (s *Scanner) readRune() (ch rune, size int, err error) {
+	if s.rd != nil {
+		return s.rd.ReadRune()
+	} else if s.off >= len(s.src) {
+		return 0, 0, io.EOF
+	}
+	ch, size = utf8.DecodeRuneInString(s.src[s.off:])
+	s.off += size
+	return ch, size, nil
+}
+
+// unreadRune pushes the last code point read back onto the input.
+func (s *Scanner) unreadRune() {
+	if s.rd != nil {
+		_ = s.rd.UnreadRune()
+		return
+	}
+	_, size := utf8.DecodeLastRuneInString(s.src[:s.off])
+	s.off -= size
+}
+
 // unread adds the previous n code points back onto the buffer.
 func (s *Scanner) unread(n int) {
 	for i := 0; i < n; i++ {
@@ -776,6 +999,150 @@ func (s *Scanner) pos() Pos {
 	return s.bufpos[s.bufi]
 }
 
+// span returns the source offsets of the current code point.
+// Offsets are only tracked for in-memory input.
+func (s *Scanner) span() (start, end int) {
+	return s.bufend[(s.bufi+len(s.buf)-1)%len(s.buf)], s.bufend[s.bufi]
+}
+
+// char returns the current code point as a string. In-memory input is
+// sliced from the source and other input is interned by the arena, if any,
+// to avoid an allocation.
+func (s *Scanner) char(ch rune) string {
+	if s.rd == nil && !s.bufchg[s.bufi] {
+		start, end := s.span()
+		return s.src[start:end]
+	} else if s.Arena != nil {
+		var b [utf8.UTFMax]byte
+		return s.Arena.intern(b[:utf8.EncodeRune(b[:], ch)])
+	}
+	return string(ch)
+}
+
+// concat returns a + b where a begins at the source offset start. In-memory
+// input is sliced from the source if a and b appear together in it.
+func (s *Scanner) concat(start int, a, b string) string {
+	if s.rd == nil && start+len(a)+len(b) <= len(s.src) {
+		if v := s.src[start : start+len(a)+len(b)]; v[:len(a)] == a && v[len(a):] == b {
+			return v
+		}
+	}
+	return a + b
+}
+
+// text returns a new builder for a token value.
+func (s *Scanner) text() text {
+	return text{s: s, start: -1, end: -1, copied: s.rd != nil}
+}
+
+// text builds the value of a token. Values from in-memory input are sliced
+// from the source until they differ from it, such as by an escape, and are
+// only then copied.
+type text struct {
+	s          *Scanner
+	start, end int // source range of the value, if not copied
+	buf        []byte
+	copied     bool
+	scratch    bool // buf is the scanner's scratch buffer
+}
+
+// add appends the current code point of the scanner.
+func (t *text) add(ch rune) {
+	if !t.copied {
+		start, end := t.s.span()
+		if t.end == -1 {
+			t.start, t.end = start, start
+		}
+
+		// Extend the range if the code point follows it unchanged.
+		if start == t.end && !t.s.bufchg[t.s.bufi] {
+			t.end = end
+			return
+		}
+		t.copy()
+	}
+	t.write(ch)
+}
+
+// write appends a code point which doesn't appear in the source, such as
+// an escaped code point.
+func (t *text) write(ch rune) {
+	if !t.copied {
+		t.copy()
+	}
+	var b [utf8.UTFMax]byte
+	n := utf8.EncodeRune(b[:], ch)
+	t.grow()
+	t.buf = append(t.buf, b[:n]...)
+}
+
+// copy copies the source range into the buffer.
+func (t *text) copy() {
+	if t.end != -1 {
+		t.grow()
+		t.buf = append(t.buf, t.s.src[t.start:t.end]...)
+	}
+	t.copied = true
+}
+
+// grow borrows the scanner's scratch buffer, if no other text is using it,
+// before the first code point is copied.
+func (t *text) grow() {
+	if t.buf == nil && !t.scratch && !t.s.scratchUsed {
+		t.buf, t.scratch = t.s.scratch[:0], true
+		t.s.scratchUsed = true
+	}
+}
+
+// release returns the scratch buffer to the scanner.
+func (t *text) release() {
+	if t.scratch {
+		t.s.scratch, t.s.scratchUsed = t.buf[:0], false
+		t.buf, t.scratch = nil, false
+	}
+}
+
+// name returns the value. Copied names are interned by the scanner's arena.
+func (t *text) name() string {
+	if t.copied {
+		v := t.s.Arena.intern(t.buf)
+		t.release()
+		return v
+	}
+	return t.String()
+}
+
+// String returns the value. Short copied values, such as whitespace and
+// numbers, are interned by the scanner's arena, if it has one.
+func (t *text) String() string {
+	if t.copied {
+		var v string
+		if t.s.Arena != nil && len(t.buf) <= arenaMaxValueLen {
+			v = t.s.Arena.intern(t.buf)
+		} else {
+			v = string(t.buf)
+		}
+		t.release()
+		return v
+	} else if t.end == -1 {
+		return ""
+	}
+	return t.s.src[t.start:t.end]
+}
+
+// error appends an error for the text from pos through the current character.
+func (s *Scanner) error(code string, pos Pos, format string, args ...interface{}) {
+	end := s.pos()
+	end.Char++
+	s.Errors = append(s.Errors, &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Origin:  ScannerOrigin,
+		Pos:     pos,
+		End:     end,
+	})
+}
+
 // isWhitespace returns true if the rune is a space, tab, or newline.
 func isWhitespace(ch rune) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n'
@@ -796,6 +1163,17 @@ func isHexDigit(ch rune) bool {
 	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
 }
 
+// hexValue returns the value of a hex digit.
+func hexValue(ch rune) rune {
+	switch {
+	case ch >= 'a':
+		return ch - 'a' + 10
+	case ch >= 'A':
+		return ch - 'A' + 10
+	}
+	return ch - '0'
+}
+
 // isNonASCII returns true if the rune is greater than U+0080.
 func isNonASCII(ch rune) bool {
 	return ch >= '\u0080'
@@ -817,10 +1195,31 @@ func isNonPrintable(ch rune) bool {
 }
 
 // scanner wraps the Scanner to provide a componentValueScanner interface.
+// It reports scanner errors to its parser and returns EOF once the parser
+// has stopped because of errors.
 type scanner struct {
 	*Scanner
+	p *Parser
+}
+
+func (s *scanner) Current() ComponentValue {
+	if s.p.stopped() {
+		return &Token{Tok: EOFToken}
+	}
+	return s.Scanner.current()
+}
+
+func (s *scanner) Scan() ComponentValue {
+	if s.p.stopped() {
+		return &Token{Tok: EOFToken}
+	}
+	tok := s.Scanner.Scan()
+	s.p.scanned(s.Scanner)
+	return tok
 }
 
-func (s *scanner) Current() ComponentValue { return s.Scanner.current() }
-func (s *scanner) Scan() ComponentValue    { return s.Scanner.Scan() }
-func (s *scanner) Unscan()                 { s.Scanner.unscan() }
+func (s *scanner) Unscan() {
+	if !s.p.stopped() {
+		s.Scanner.unscan()
+	}
+}
@@ -3,7 +3,9 @@ package css_test
 import (
 	"bytes"
 	"flag"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/benbjohnson/css"
@@ -12,10 +14,6 @@ import (
 // testiter sets the table test iteration to run in isolation.
 var testiter = flag.Int("test.iter", -1, "table test number")
 
-func init() {
-	flag.Parse()
-}
-
 // Ensure than the scanner returns appropriate tokens and literals.
 func TestScanner_Scan(t *testing.T) {
 	var tests = []struct {
@@ -28,19 +26,19 @@ func TestScanner_Scan(t *testing.T) {
 		{s: " \n", tok: &css.Token{Tok: css.WhitespaceToken, Value: " \n", Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: " \f", tok: &css.Token{Tok: css.WhitespaceToken, Value: " \n", Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: " \r", tok: &css.Token{Tok: css.WhitespaceToken, Value: " \n", Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: " \r ", tok: &css.Token{Tok: css.WhitespaceToken, Value: " \n", Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: " \r ", tok: &css.Token{Tok: css.WhitespaceToken, Value: " \n ", Pos: css.Pos{Char: 1, Line: 0}}},
 
 		{s: `""`, tok: &css.Token{Tok: css.StringToken, Value: ``, Ending: '"', Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `"`, tok: &css.Token{Tok: css.StringToken, Value: ``, Ending: '"', Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `"foo`, tok: &css.Token{Tok: css.StringToken, Value: `foo`, Ending: '"', Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `"`, tok: &css.Token{Tok: css.StringToken, Value: ``, Ending: '"', Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in string"},
+		{s: `"foo`, tok: &css.Token{Tok: css.StringToken, Value: `foo`, Ending: '"', Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in string"},
 		{s: `"hello world"`, tok: &css.Token{Tok: css.StringToken, Value: `hello world`, Ending: '"', Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `'hello world'`, tok: &css.Token{Tok: css.StringToken, Value: `hello world`, Ending: '\'', Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: "'foo\\\nbar'", tok: &css.Token{Tok: css.StringToken, Value: "foo\nbar", Ending: '\'', Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `'foo\ bar'`, tok: &css.Token{Tok: css.StringToken, Value: `foo bar`, Ending: '\'', Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `'foo\\bar'`, tok: &css.Token{Tok: css.StringToken, Value: `foo\bar`, Ending: '\'', Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `'foo\`, tok: &css.Token{Tok: css.StringToken, Value: `foo`, Ending: '\'', Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `'foo\`, tok: &css.Token{Tok: css.StringToken, Value: `foo`, Ending: '\'', Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in string"},
 		{s: `'frosty the \2603'`, tok: &css.Token{Tok: css.StringToken, Value: `frosty the ☃`, Ending: '\'', Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: "'foo bar\n", tok: &css.Token{Tok: css.BadStringToken, Value: ``, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: "'foo bar\n", tok: &css.Token{Tok: css.BadStringToken, Value: ``, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected newline in string"},
 
 		{s: `0`, tok: &css.Token{Tok: css.NumberToken, Type: "integer", Value: `0`, Number: 0.0, Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `1.0`, tok: &css.Token{Tok: css.NumberToken, Type: "number", Value: `1.0`, Number: 1.0, Pos: css.Pos{Char: 1, Line: 0}}},
@@ -66,27 +64,31 @@ func TestScanner_Scan(t *testing.T) {
 
 		{s: `url`, tok: &css.Token{Tok: css.IdentToken, Value: `url`, Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `-url`, tok: &css.Token{Tok: css.IdentToken, Value: `-url`, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `--foo`, tok: &css.Token{Tok: css.IdentToken, Value: `--foo`, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `--`, tok: &css.Token{Tok: css.IdentToken, Value: `--`, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `-\2603`, tok: &css.Token{Tok: css.IdentToken, Value: `-☃`, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `-->`, tok: &css.Token{Tok: css.CDCToken, Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `myIdent`, tok: &css.Token{Tok: css.IdentToken, Value: `myIdent`, Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `my\2603`, tok: &css.Token{Tok: css.IdentToken, Value: `my☃`, Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `\2603`, tok: &css.Token{Tok: css.IdentToken, Value: `☃`, Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: "\000", tok: &css.Token{Tok: css.IdentToken, Value: "\uFFFD", Pos: css.Pos{Char: 1, Line: 0}}},
 
-		{s: `url(`, tok: &css.Token{Tok: css.URLToken, Value: ``, Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `url(foo`, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `url(`, tok: &css.Token{Tok: css.URLToken, Value: ``, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in url"},
+		{s: `url(foo`, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in url"},
 		{s: `url(http://foo.com#bar?baz=bat)`, tok: &css.Token{Tok: css.URLToken, Value: `http://foo.com#bar?baz=bat`, Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `url(  foo`, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `url(  foo  `, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `url(  \2603  `, tok: &css.Token{Tok: css.URLToken, Value: `☃`, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `url(  foo`, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in url"},
+		{s: `url(  foo  `, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in url"},
+		{s: `url(  \2603  `, tok: &css.Token{Tok: css.URLToken, Value: `☃`, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in url"},
 		{s: `url(foo)`, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `url("http://foo.com#bar?baz=bat")`, tok: &css.Token{Tok: css.URLToken, Value: `http://foo.com#bar?baz=bat`, Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `url(  "foo"  `, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `url("foo"  `, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `url(  "foo"  `, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in url"},
+		{s: `url("foo"  `, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in url"},
 		{s: `url("foo")`, tok: &css.Token{Tok: css.URLToken, Value: `foo`, Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `url("foo"x`, tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `url("foo" x`, tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: "url('foo\n", tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `url("foo"x`, tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected x (U+0078) after url string"},
+		{s: `url("foo" x`, tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected x (U+0078) after url string"},
+		{s: "url('foo\n", tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected newline in string"},
 		{s: `url(foo"`, tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}, err: `invalid url code point: " (U+0022)`},
-		{s: `url(foo bar)`, tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `url(foo bar)`, tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected whitespace in url"},
 		{s: `url(foo'`, tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}, err: `invalid url code point: ' (U+0027)`},
 		{s: `url(foo(`, tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}, err: `invalid url code point: ( (U+0028)`},
 		{s: "url(foo\001 \\2603", tok: &css.Token{Tok: css.BadURLToken, Pos: css.Pos{Char: 1, Line: 0}}, err: "invalid url code point: \001 (U+0001)"},
@@ -119,7 +121,7 @@ func TestScanner_Scan(t *testing.T) {
 
 		{s: `/`, tok: &css.Token{Tok: css.DelimToken, Value: `/`, Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `/* this is * a comment */#`, tok: &css.Token{Tok: css.DelimToken, Value: "#", Pos: css.Pos{Char: 26, Line: 0}}},
-		{s: `/* this is a comment`, tok: &css.Token{Tok: css.EOFToken, Pos: css.Pos{Char: 20, Line: 0}}},
+		{s: `/* this is a comment`, tok: &css.Token{Tok: css.EOFToken, Pos: css.Pos{Char: 20, Line: 0}}, err: "unexpected EOF in comment"},
 
 		{s: `<`, tok: &css.Token{Tok: css.DelimToken, Value: "<", Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: `<!`, tok: &css.Token{Tok: css.DelimToken, Value: "<", Pos: css.Pos{Char: 1, Line: 0}}},
@@ -131,7 +133,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `@\2603`, tok: &css.Token{Tok: css.AtKeywordToken, Value: "☃", Pos: css.Pos{Char: 1, Line: 0}}},
 
 		{s: `\2603`, tok: &css.Token{Tok: css.IdentToken, Value: "☃", Pos: css.Pos{Char: 1, Line: 0}}},
-		{s: `\`, tok: &css.Token{Tok: css.IdentToken, Value: "\uFFFD", Pos: css.Pos{Char: 1, Line: 0}}},
+		{s: `\`, tok: &css.Token{Tok: css.IdentToken, Value: "\uFFFD", Pos: css.Pos{Char: 1, Line: 0}}, err: "unexpected EOF in escape"},
 		{s: `\ `, tok: &css.Token{Tok: css.IdentToken, Value: " ", Pos: css.Pos{Char: 1, Line: 0}}},
 		{s: "\\\n", tok: &css.Token{Tok: css.DelimToken, Value: `\`, Pos: css.Pos{Char: 1, Line: 0}}, err: "unescaped \\"},
 
@@ -173,23 +175,230 @@ func TestScanner_Scan(t *testing.T) {
 			continue
 		}
 
-		// Scan token.
+		// Scan token from each kind of input.
+		for _, input := range inputs {
+			s := input.new(tt.s)
+			tok := exported(s.Scan())
+
+			// Verify properties.
+			if !reflect.DeepEqual(tok, tt.tok) {
+				t.Errorf("%d. <%q> %s tok: =>\n\ngot %#v\n\nwant %#v\n\n", i, tt.s, input.name, tok, tt.tok)
+			} else if tt.err != "" {
+				if len(s.Errors) == 0 {
+					t.Errorf("%d. <%q> %s error expected", i, tt.s, input.name)
+				} else if len(s.Errors) > 1 {
+					t.Errorf("%d. <%q> %s too many errors occurred", i, tt.s, input.name)
+				} else if s.Errors[0].Message != tt.err {
+					t.Errorf("%d. <%q> %s error: got %q, want %q", i, tt.s, input.name, s.Errors[0].Message, tt.err)
+				}
+			} else if tt.err == "" && len(s.Errors) > 0 {
+				t.Errorf("%d. <%q> %s unexpected error: %q", i, tt.s, input.name, s.Errors[0].Message)
+			}
+		}
+	}
+}
+
+// inputs creates scanners for each kind of input.
+var inputs = []struct {
+	name string
+	new  func(string) *css.Scanner
+}{
+	{"reader", func(s string) *css.Scanner { return css.NewScanner(bytes.NewBufferString(s)) }},
+	{"string", css.NewScannerString},
+	{"bytes", func(s string) *css.Scanner { return css.NewScannerBytes([]byte(s)) }},
+}
+
+// Ensure that the scanner reports parse errors with a code and position.
+func TestScanner_Errors(t *testing.T) {
+	var tests = []struct {
+		s    string
+		code string
+		pos  css.Pos
+	}{
+		{s: "a /* foo", code: "eof-in-comment", pos: css.Pos{Char: 3}},
+		{s: "a 'foo", code: "eof-in-string", pos: css.Pos{Char: 3}},
+		{s: "a\n 'foo\nbar", code: "newline-in-string", pos: css.Pos{Char: 2, Line: 1}},
+		{s: "a url(foo", code: "eof-in-url", pos: css.Pos{Char: 3}},
+		{s: "a url(foo bar)", code: "bad-url", pos: css.Pos{Char: 3}},
+		{s: "a url('foo' bar)", code: "bad-url", pos: css.Pos{Char: 3}},
+		{s: "a url(fo'o)", code: "bad-url", pos: css.Pos{Char: 3}},
+		{s: "a url(fo\\\n)", code: "bad-url", pos: css.Pos{Char: 9}},
+		{s: "a b\\", code: "eof-in-escape", pos: css.Pos{Char: 4}},
+		{s: "a \\\n", code: "unescaped-backslash", pos: css.Pos{Char: 3}},
+	}
+
+	for i, tt := range tests {
 		s := css.NewScanner(bytes.NewBufferString(tt.s))
-		tok := s.Scan()
-
-		// Verify properties.
-		if !reflect.DeepEqual(tok, tt.tok) {
-			t.Errorf("%d. <%q> tok: =>\n\ngot %#v\n\nwant %#v\n\n", i, tt.s, tok, tt.tok)
-		} else if tt.err != "" {
-			if len(s.Errors) == 0 {
-				t.Errorf("%d. <%q> error expected", i, tt.s)
-			} else if len(s.Errors) > 1 {
-				t.Errorf("%d. <%q> too many errors occurred", i, tt.s)
-			} else if s.Errors[0].Message != tt.err {
-				t.Errorf("%d. <%q> error: got %q, want %q", i, tt.s, s.Errors[0].Message, tt.err)
+		for tok := s.Scan(); tok.Tok != css.EOFToken; tok = s.Scan() {
+		}
+
+		if len(s.Errors) != 1 {
+			t.Errorf("%d. <%q> unexpected errors: %v", i, tt.s, s.Errors)
+		} else if e := s.Errors[0]; e.Code != tt.code || e.Origin != css.ScannerOrigin {
+			t.Errorf("%d. <%q> unexpected error: %s (%s, %s)", i, tt.s, e, e.Code, e.Origin)
+		} else if e.Pos != tt.pos {
+			t.Errorf("%d. <%q> pos: expected %s, got %s", i, tt.s, tt.pos, e.Pos)
+		}
+	}
+}
+
+// Ensure that the scanner records comments if requested.
+func TestScanner_KeepComments(t *testing.T) {
+	src := "/* a */ b /**/c\r\n  /* d\r\n * é */ /* f"
+	for _, in := range inputs {
+		s := in.new(src)
+		s.KeepComments = true
+		var toks []string
+		for tok := s.Scan(); tok.Tok != css.EOFToken; tok = s.Scan() {
+			if tok.Tok == css.IdentToken {
+				toks = append(toks, tok.Value)
 			}
-		} else if tt.err == "" && len(s.Errors) > 0 {
-			t.Errorf("%d. <%q> unexpected error: %q", i, tt.s, s.Errors[0].Message)
 		}
+
+		var a []string
+		for _, c := range s.Comments {
+			a = append(a, fmt.Sprintf("%s %q", c.Pos, c.Text))
+		}
+		if exp := `1:1 "/* a */",1:11 "/**/",2:3 "/* d\n * é */",3:9 "/* f"`; strings.Join(a, ",") != exp {
+			t.Errorf("%s: unexpected comments: %s", in.name, strings.Join(a, ","))
+		} else if strings.Join(toks, ",") != "b,c" {
+			t.Errorf("%s: unexpected tokens: %v", in.name, toks)
+		}
+	}
+
+	// Comments aren't recorded by default.
+	s := css.NewScannerString(src)
+	for tok := s.Scan(); tok.Tok != css.EOFToken; tok = s.Scan() {
+	}
+	if len(s.Comments) != 0 {
+		t.Fatalf("unexpected comments: %v", s.Comments)
 	}
 }
+
+// Ensure that in-memory input scans the same tokens as the reader.
+func TestNewScannerString(t *testing.T) {
+	src := "\uFEFF@media print {\r\n  a.b\\2603 > #c[d=\"e\\\"f\"] { width: 10.5px; x: url( g\\68 ); }\r\n}\n" +
+		"/* h */ i { j: \"k\\\nl\" 1e+3% -.5E2em U+00?? \x00 \xff <!-- --> 'm\n}"
+
+	scan := func(s *css.Scanner) (tokens []*css.Token) {
+		for {
+			tok := s.Scan()
+			tokens = append(tokens, tok)
+			if tok.Tok == css.EOFToken {
+				return tokens
+			}
+		}
+	}
+
+	r := css.NewScanner(bytes.NewBufferString(src))
+	exp := scan(r)
+	for _, input := range inputs[1:] {
+		s := input.new(src)
+		if tokens := scan(s); !reflect.DeepEqual(tokens, exp) {
+			for i := range tokens {
+				if i >= len(exp) || !reflect.DeepEqual(tokens[i], exp[i]) {
+					t.Fatalf("%s: token %d: got %#v", input.name, i, tokens[i])
+				}
+			}
+			t.Fatalf("%s: unexpected tokens", input.name)
+		} else if !reflect.DeepEqual(s.Errors, r.Errors) {
+			t.Fatalf("%s: unexpected errors: %v", input.name, s.Errors)
+		}
+	}
+}
+
+// Ensure that a CR which isn't followed by LF doesn't drop the next code point.
+func TestScanner_CR(t *testing.T) {
+	for _, input := range inputs {
+		s := input.new("a\rb\r\nc")
+		var a []string
+		for tok := s.Scan(); tok.Tok != css.EOFToken; tok = s.Scan() {
+			a = append(a, fmt.Sprintf("%q@%s", tok.Value, tok.Pos))
+		}
+		if got, exp := strings.Join(a, " "), `"a"@1:1 "\n"@2:0 "b"@2:1 "\n"@3:0 "c"@3:1`; got != exp {
+			t.Errorf("%s: unexpected tokens: %s", input.name, got)
+		}
+	}
+}
+
+// Ensure that non-UTF-8 byte input is decoded.
+func TestNewScannerBytes_Encoding(t *testing.T) {
+	s := css.NewScannerBytes([]byte("\xFF\xFEa\x00"))
+	if tok := s.Scan(); tok.Tok != css.IdentToken || tok.Value != "a" {
+		t.Fatalf("unexpected token: %#v", tok)
+	} else if s.Encoding != "utf-16le" {
+		t.Fatalf("unexpected encoding: %s", s.Encoding)
+	}
+}
+
+// Ensure that in-memory input only allocates the scanner and chunks of tokens.
+func TestNewScannerString_Allocs(t *testing.T) {
+	src := `a-b { c: "d e" 10.5px 50% url(f.png) #abc; }`
+
+	var n int
+	allocs := testing.AllocsPerRun(100, func() {
+		s := css.NewScannerString(src)
+		for n = 0; s.Scan().Tok != css.EOFToken; n++ {
+		}
+	})
+	// One allocation for the scanner and one for each of the two chunks.
+	if allocs > 3 {
+		t.Fatalf("expected at most 3 allocations for %d tokens, got %v", n, allocs)
+	}
+}
+
+// Ensure that tokens of in-memory input remain valid after later tokens are scanned.
+func TestNewScannerString_Chunks(t *testing.T) {
+	src := strings.Repeat("a ", 1000)
+	s := css.NewScannerString(src)
+	var tokens []*css.Token
+	for tok := s.Scan(); tok.Tok != css.EOFToken; tok = s.Scan() {
+		tokens = append(tokens, tok)
+	}
+	if len(tokens) != 2000 {
+		t.Fatalf("unexpected token count: %d", len(tokens))
+	}
+	for i, tok := range tokens {
+		if exp := (css.Pos{Char: i + 1}); tok.Pos != exp || (i%2 == 0) != (tok.Tok == css.IdentToken) {
+			t.Fatalf("%d. unexpected token: %#v", i, tok)
+		}
+	}
+}
+
+func BenchmarkScanner_Reader(b *testing.B) {
+	src := benchmarkStyleSheet()
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := css.NewScanner(bytes.NewReader(src))
+		for s.Scan().Tok != css.EOFToken {
+		}
+	}
+}
+
+func BenchmarkScanner_String(b *testing.B) {
+	src := string(benchmarkStyleSheet())
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := css.NewScannerString(src)
+		for s.Scan().Tok != css.EOFToken {
+		}
+	}
+}
+
+// benchmarkStyleSheet returns a style sheet of about 100KB.
+func benchmarkStyleSheet() []byte {
+	var buf bytes.Buffer
+	for buf.Len() < 100000 {
+		buf.WriteString(".nav > li a:hover, #main .item-1 { color: #ff0000; margin: 0 auto 10.5px; ")
+		buf.WriteString("background: url(images/bg.png) no-repeat 50% 50%; font: 12px/1.5 \"Helvetica Neue\", sans-serif; }\n")
+		buf.WriteString("@media (max-width: 600px) { .nav { display: none !important; } }\n")
+	}
+	return buf.Bytes()
+}
+
+// exported returns a copy of a token with only its exported fields set.
+func exported(tok *css.Token) *css.Token {
+	return &css.Token{Tok: tok.Tok, Type: tok.Type, Value: tok.Value, Ending: tok.Ending, Number: tok.Number, Unit: tok.Unit, Start: tok.Start, End: tok.End, Pos: tok.Pos}
+}
@@ -0,0 +1,683 @@
+package css
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Selector represents a complex selector: a sequence of compound selectors
+// separated by combinators. (selectors-4 §3.1)
+type Selector struct {
+	Compounds []*CompoundSelector
+	Pos       Pos
+}
+
+// Specificity returns the specificity of the selector. (selectors-4 §17)
+func (s *Selector) Specificity() Specificity {
+	var spec Specificity
+	for _, c := range s.Compounds {
+		spec = spec.add(c.Specificity())
+	}
+	return spec
+}
+
+// String returns the selector as CSS text.
+func (s *Selector) String() string {
+	var buf bytes.Buffer
+	for i, c := range s.Compounds {
+		switch c.Combinator {
+		case DescendantCombinator:
+			if i > 0 {
+				_, _ = buf.WriteString(" ")
+			}
+		case NoCombinator:
+		default:
+			if i > 0 {
+				_, _ = buf.WriteString(" ")
+			}
+			_, _ = buf.WriteString(c.Combinator.String() + " ")
+		}
+		_, _ = buf.WriteString(c.String())
+	}
+	return buf.String()
+}
+
+// SelectorList represents a comma-separated list of selectors.
+type SelectorList []*Selector
+
+// String returns the selector list as CSS text.
+func (a SelectorList) String() string {
+	var s []string
+	for _, sel := range a {
+		s = append(s, sel.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+// specificity returns the highest specificity in the list.
+func (a SelectorList) specificity() Specificity {
+	var max Specificity
+	for _, sel := range a {
+		if spec := sel.Specificity(); max.Less(spec) {
+			max = spec
+		}
+	}
+	return max
+}
+
+// Combinator represents the relationship between two compound selectors.
+type Combinator int
+
+const (
+	NoCombinator                Combinator = iota // first compound in a selector
+	DescendantCombinator                          // whitespace
+	ChildCombinator                               // >
+	NextSiblingCombinator                         // +
+	SubsequentSiblingCombinator                   // ~
+	ColumnCombinator                              // ||
+)
+
+// String returns the CSS representation of the combinator.
+func (c Combinator) String() string {
+	switch c {
+	case DescendantCombinator:
+		return " "
+	case ChildCombinator:
+		return ">"
+	case NextSiblingCombinator:
+		return "+"
+	case SubsequentSiblingCombinator:
+		return "~"
+	case ColumnCombinator:
+		return "||"
+	}
+	return ""
+}
+
+// CompoundSelector represents a sequence of simple selectors that are not
+// separated by a combinator. The combinator is the one that precedes the
+// compound. It is NoCombinator for the first compound of a selector unless
+// the selector is relative, such as the arguments to :has().
+type CompoundSelector struct {
+	Combinator Combinator
+	Type       *TypeSelector
+	Selectors  []SimpleSelector
+	Pos        Pos
+}
+
+// Specificity returns the specificity of the compound selector.
+func (c *CompoundSelector) Specificity() Specificity {
+	var spec Specificity
+	if c.Type != nil && c.Type.Name != "*" {
+		spec[2]++
+	}
+	for _, s := range c.Selectors {
+		switch s := s.(type) {
+		case *IDSelector:
+			spec[0]++
+		case *ClassSelector, *AttributeSelector:
+			spec[1]++
+		case *PseudoClassSelector:
+			spec = spec.add(s.specificity())
+		case *PseudoElementSelector:
+			spec[2]++
+		}
+	}
+	return spec
+}
+
+// String returns the compound selector as CSS text.
+func (c *CompoundSelector) String() string {
+	var buf bytes.Buffer
+	if c.Type != nil {
+		_, _ = buf.WriteString(c.Type.String())
+	}
+	for _, s := range c.Selectors {
+		_, _ = buf.WriteString(s.String())
+	}
+	return buf.String()
+}
+
+// SimpleSelector represents a single id, class, attribute, pseudo-class
+// or pseudo-element selector within a compound selector.
+type SimpleSelector interface {
+	String() string
+	simpleSelector()
+}
+
+func (_ *IDSelector) simpleSelector()            {}
+func (_ *ClassSelector) simpleSelector()         {}
+func (_ *AttributeSelector) simpleSelector()     {}
+func (_ *PseudoClassSelector) simpleSelector()   {}
+func (_ *PseudoElementSelector) simpleSelector() {}
+
+// NamespacePrefix represents the optional namespace component of type and
+// attribute selectors, such as the "svg" in "svg|circle".
+type NamespacePrefix struct {
+	// The prefix as written. "*" is used for any namespace.
+	// This is ignored unless HasPrefix is set.
+	Prefix    string
+	HasPrefix bool
+
+	// The namespace the prefix resolves to. If AnyNamespace is set then the
+	// selector matches elements in all namespaces. An empty URI without the
+	// AnyNamespace flag matches elements without a namespace.
+	URI          string
+	AnyNamespace bool
+}
+
+// String returns the prefix as CSS text, including the trailing "|".
+func (ns NamespacePrefix) String() string {
+	if !ns.HasPrefix {
+		return ""
+	}
+	return ns.Prefix + "|"
+}
+
+// TypeSelector represents an element name or the universal selector.
+type TypeSelector struct {
+	Namespace NamespacePrefix
+	Name      string // element name or "*"
+	Pos       Pos
+}
+
+// String returns the type selector as CSS text.
+func (s *TypeSelector) String() string { return s.Namespace.String() + s.Name }
+
+// IDSelector represents an id selector such as "#foo".
+type IDSelector struct {
+	Name string
+	Pos  Pos
+}
+
+// String returns the id selector as CSS text.
+func (s *IDSelector) String() string { return "#" + s.Name }
+
+// ClassSelector represents a class selector such as ".foo".
+type ClassSelector struct {
+	Name string
+	Pos  Pos
+}
+
+// String returns the class selector as CSS text.
+func (s *ClassSelector) String() string { return "." + s.Name }
+
+// AttributeSelector represents an attribute selector such as "[href^='http']".
+type AttributeSelector struct {
+	Namespace NamespacePrefix
+	Name      string
+	Matcher   Tok    // zero for presence, DelimToken for "=", or a *MatchToken.
+	Value     string // the value to compare against, if there is a matcher.
+	Modifier  string // "i" or "s", if specified.
+	Pos       Pos
+}
+
+// String returns the attribute selector as CSS text.
+func (s *AttributeSelector) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("[" + s.Namespace.String() + s.Name)
+	if s.Matcher != 0 {
+		if s.Matcher == DelimToken {
+			_, _ = buf.WriteString("=")
+		} else {
+			_, _ = buf.WriteString(print(&Token{Tok: s.Matcher}))
+		}
+		_, _ = buf.WriteString(print(&Token{Tok: StringToken, Value: s.Value, Ending: '"'}))
+	}
+	if s.Modifier != "" {
+		_, _ = buf.WriteString(" " + s.Modifier)
+	}
+	_, _ = buf.WriteString("]")
+	return buf.String()
+}
+
+// PseudoClassSelector represents a pseudo-class such as ":hover" or
+// ":nth-child(2n+1)". The arguments of :is(), :where(), :not() and :has()
+// are parsed into Selectors. All other arguments are left in Args.
+type PseudoClassSelector struct {
+	Name      string
+	Args      ComponentValues
+	Selectors SelectorList
+	Function  bool
+	Pos       Pos
+}
+
+// String returns the pseudo-class as CSS text.
+func (s *PseudoClassSelector) String() string {
+	if !s.Function {
+		return ":" + s.Name
+	} else if s.Selectors != nil {
+		return ":" + s.Name + "(" + s.Selectors.String() + ")"
+	}
+	return ":" + s.Name + "(" + print(s.Args) + ")"
+}
+
+// specificity returns the specificity contributed by the pseudo-class.
+func (s *PseudoClassSelector) specificity() Specificity {
+	switch strings.ToLower(s.Name) {
+	case "where":
+		return Specificity{}
+	case "is", "matches", "not", "has":
+		return s.Selectors.specificity()
+	case "before", "after", "first-line", "first-letter":
+		// Legacy single-colon pseudo-elements.
+		return Specificity{0, 0, 1}
+	}
+	return Specificity{0, 1, 0}
+}
+
+// PseudoElementSelector represents a pseudo-element such as "::before".
+type PseudoElementSelector struct {
+	Name     string
+	Args     ComponentValues
+	Function bool
+	Pos      Pos
+}
+
+// String returns the pseudo-element as CSS text.
+func (s *PseudoElementSelector) String() string {
+	if s.Function {
+		return "::" + s.Name + "(" + print(s.Args) + ")"
+	}
+	return "::" + s.Name
+}
+
+// Specificity represents the (a, b, c) specificity of a selector.
+// The first element counts id selectors, the second counts class, attribute
+// and pseudo-class selectors, and the third counts type and pseudo-element
+// selectors.
+type Specificity [3]int
+
+// Less returns true if the specificity is lower than other.
+func (a Specificity) Less(other Specificity) bool {
+	for i := range a {
+		if a[i] != other[i] {
+			return a[i] < other[i]
+		}
+	}
+	return false
+}
+
+// add returns the sum of two specificities.
+func (a Specificity) add(other Specificity) Specificity {
+	return Specificity{a[0] + other[0], a[1] + other[1], a[2] + other[2]}
+}
+
+// ParseSelectors parses a selector list, such as the prelude of a qualified
+// rule. Namespace prefixes are resolved against ns, which may be nil if the
+// style sheet does not declare any namespaces. Undeclared prefixes are
+// reported as errors. Returns nil if the selector list is invalid.
+func (p *Parser) ParseSelectors(values ComponentValues, ns *Namespaces) SelectorList {
+	sp := &selectorParser{p: p, ns: ns, s: NewComponentValueScanner(values)}
+	return sp.parseSelectorList(false)
+}
+
+// selectorParser holds the state for parsing a single selector list.
+type selectorParser struct {
+	p  *Parser
+	ns *Namespaces
+	s  ComponentValueScanner
+}
+
+// error appends a new error to the parser.
+func (sp *selectorParser) error(code string, v ComponentValue, format string, args ...interface{}) {
+	sp.p.error(newError(code, v, format, args...))
+}
+
+// parseSelectorList parses comma-separated selectors until EOF.
+// If relative is true then each selector may start with a combinator.
+func (sp *selectorParser) parseSelectorList(relative bool) SelectorList {
+	var a SelectorList
+	for {
+		sel := sp.parseSelector(relative)
+		if sel == nil {
+			return nil
+		}
+		a = append(a, sel)
+
+		// The selector stops at a comma or at EOF.
+		if tok := sp.s.Current().(*Token); tok.Tok == EOFToken {
+			return a
+		}
+	}
+}
+
+// parseSelector parses a complex selector up to a comma or EOF.
+func (sp *selectorParser) parseSelector(relative bool) *Selector {
+	sp.skipWhitespace()
+	sel := &Selector{Pos: Position(sp.s.Scan())}
+	sp.s.Unscan()
+
+	// Relative selectors can begin with a combinator.
+	var comb Combinator
+	if relative {
+		comb = sp.parseCombinator()
+	}
+
+	for {
+		c := sp.parseCompound()
+		if c == nil {
+			return nil
+		}
+		c.Combinator = comb
+		sel.Compounds = append(sel.Compounds, c)
+
+		// Read the following combinator, if any. Whitespace between compounds
+		// is a descendant combinator unless it surrounds another combinator.
+		ws := sp.skipWhitespace()
+		if tok, ok := sp.s.Scan().(*Token); ok && (tok.Tok == EOFToken || tok.Tok == CommaToken) {
+			return sel
+		}
+		sp.s.Unscan()
+
+		if comb = sp.parseCombinator(); comb == NoCombinator {
+			if !ws {
+				sp.s.Scan()
+				sp.error("unexpected-token", sp.s.Current(), "unexpected: %s", print(sp.s.Current()))
+				return nil
+			}
+			comb = DescendantCombinator
+		}
+	}
+}
+
+// parseCombinator reads an explicit combinator and trailing whitespace.
+// Returns NoCombinator if the next value is not a combinator.
+func (sp *selectorParser) parseCombinator() Combinator {
+	var comb Combinator
+	if tok, ok := sp.s.Scan().(*Token); ok {
+		switch {
+		case tok.Tok == DelimToken && tok.Value == ">":
+			comb = ChildCombinator
+		case tok.Tok == DelimToken && tok.Value == "+":
+			comb = NextSiblingCombinator
+		case tok.Tok == DelimToken && tok.Value == "~":
+			comb = SubsequentSiblingCombinator
+		case tok.Tok == ColumnToken:
+			comb = ColumnCombinator
+		}
+	}
+	if comb == NoCombinator {
+		sp.s.Unscan()
+		return comb
+	}
+	sp.skipWhitespace()
+	return comb
+}
+
+// parseCompound parses a type selector followed by zero or more subclass
+// selectors and pseudo-elements.
+func (sp *selectorParser) parseCompound() *CompoundSelector {
+	c := &CompoundSelector{Pos: Position(sp.s.Scan())}
+	sp.s.Unscan()
+
+	// Parse the optional type selector first.
+	name, prefix, first, ok, err := sp.parseQualifiedName(true)
+	if err != nil {
+		sp.p.error(err)
+		return nil
+	} else if ok {
+		if !sp.resolve(&prefix, first, false) {
+			return nil
+		}
+		c.Type = &TypeSelector{Namespace: prefix, Name: name, Pos: Position(first)}
+	}
+
+	for {
+		v := sp.s.Scan()
+		switch v := v.(type) {
+		case *Token:
+			switch {
+			case v.Tok == HashToken:
+				if v.Type != "id" {
+					sp.error("invalid-id-selector", v, "invalid id selector: #%s", v.Value)
+					return nil
+				}
+				c.Selectors = append(c.Selectors, &IDSelector{Name: v.Value, Pos: v.Pos})
+				continue
+
+			case v.Tok == DelimToken && v.Value == ".":
+				tok, ok := sp.s.Scan().(*Token)
+				if !ok || tok.Tok != IdentToken {
+					sp.error("expected-class-name", sp.s.Current(), "expected class name, got %s", print(sp.s.Current()))
+					return nil
+				}
+				c.Selectors = append(c.Selectors, &ClassSelector{Name: tok.Value, Pos: v.Pos})
+				continue
+
+			case v.Tok == ColonToken:
+				sel := sp.parsePseudo(v)
+				if sel == nil {
+					return nil
+				}
+				c.Selectors = append(c.Selectors, sel)
+				continue
+			}
+
+		case *SimpleBlock:
+			if v.Token.Tok == LBrackToken {
+				sel := sp.parseAttribute(v)
+				if sel == nil {
+					return nil
+				}
+				c.Selectors = append(c.Selectors, sel)
+				continue
+			}
+		}
+
+		// Any other value ends the compound selector.
+		sp.s.Unscan()
+		break
+	}
+
+	// A compound selector cannot be empty.
+	if c.Type == nil && len(c.Selectors) == 0 {
+		sp.s.Scan()
+		sp.error("expected-selector", sp.s.Current(), "expected selector, got %s", print(sp.s.Current()))
+		return nil
+	}
+	return c
+}
+
+// parseQualifiedName parses an optionally namespace-prefixed name such as
+// "foo", "ns|foo", "|foo" or "*|*". The universal selector is only allowed
+// if wildcard is true. The first value of the name, which holds the prefix
+// if there is one, is also returned. Returns ok as false if there is no name.
+// Returns an error if a prefix is not followed by a name.
+func (sp *selectorParser) parseQualifiedName(wildcard bool) (name string, prefix NamespacePrefix, first ComponentValue, ok bool, err *Error) {
+	isName := func(v ComponentValue, wildcard bool) (string, bool) {
+		if tok, ok := v.(*Token); ok {
+			if tok.Tok == IdentToken {
+				return tok.Value, true
+			} else if wildcard && tok.Tok == DelimToken && tok.Value == "*" {
+				return "*", true
+			}
+		}
+		return "", false
+	}
+	isBar := func(v ComponentValue) bool {
+		tok, ok := v.(*Token)
+		return ok && tok.Tok == DelimToken && tok.Value == "|"
+	}
+
+	first = sp.s.Scan()
+
+	// Check for a name or prefix first. The "*" prefix is always allowed.
+	if s, ok := isName(first, true); ok {
+		if isBar(sp.s.Scan()) {
+			if local, ok := isName(sp.s.Scan(), wildcard); ok {
+				return local, NamespacePrefix{Prefix: s, HasPrefix: true}, first, true, nil
+			}
+			return "", NamespacePrefix{}, first, false, newError("expected-name", sp.s.Current(), "expected name after namespace prefix, got %s", print(sp.s.Current()))
+		}
+		sp.s.Unscan()
+		if s != "*" || wildcard {
+			return s, NamespacePrefix{}, first, true, nil
+		}
+	}
+
+	// Otherwise check for an empty prefix ("|foo").
+	if isBar(first) {
+		if local, ok := isName(sp.s.Scan(), wildcard); ok {
+			return local, NamespacePrefix{HasPrefix: true}, first, true, nil
+		}
+		return "", NamespacePrefix{}, first, false, newError("expected-name", sp.s.Current(), "expected name after namespace prefix, got %s", print(sp.s.Current()))
+	}
+
+	sp.s.Unscan()
+	return "", NamespacePrefix{}, first, false, nil
+}
+
+// resolve sets the namespace URI for a prefix. Unprefixed type selectors use
+// the default namespace while unprefixed attributes have no namespace.
+// The prefix was read from v. Returns false if the prefix has not been declared.
+func (sp *selectorParser) resolve(prefix *NamespacePrefix, v ComponentValue, attr bool) bool {
+	switch {
+	case !prefix.HasPrefix:
+		if attr {
+			return true
+		} else if sp.ns != nil && sp.ns.HasDefault {
+			prefix.URI = sp.ns.Default
+		} else {
+			prefix.AnyNamespace = true
+		}
+	case prefix.Prefix == "*":
+		prefix.AnyNamespace = true
+	case prefix.Prefix == "":
+		// Explicitly no namespace.
+	default:
+		uri, ok := sp.ns.Lookup(prefix.Prefix)
+		if !ok {
+			sp.error("undeclared-namespace", v, "undeclared namespace prefix: %s", prefix.Prefix)
+			return false
+		}
+		prefix.URI = uri
+	}
+	return true
+}
+
+// parseAttribute parses the contents of an attribute selector's [-block.
+func (sp *selectorParser) parseAttribute(b *SimpleBlock) *AttributeSelector {
+	sel := &AttributeSelector{Pos: b.Pos}
+
+	// Parse the attribute values with a separate scanner.
+	parent := sp.s
+	sp.s = NewComponentValueScanner(b.Values)
+	defer func() { sp.s = parent }()
+
+	// Read the attribute name.
+	sp.skipWhitespace()
+	name, prefix, first, ok, err := sp.parseQualifiedName(false)
+	if err != nil {
+		sp.p.error(err)
+		return nil
+	} else if !ok {
+		sp.s.Scan()
+		sp.error("expected-attribute-name", sp.s.Current(), "expected attribute name, got %s", print(sp.s.Current()))
+		return nil
+	} else if !sp.resolve(&prefix, first, true) {
+		return nil
+	}
+	sel.Name, sel.Namespace = name, prefix
+	sp.skipWhitespace()
+
+	// Read the optional matcher and value.
+	tok, ok := sp.s.Scan().(*Token)
+	if !ok {
+		sp.error("unexpected-token", sp.s.Current(), "unexpected: %s", print(sp.s.Current()))
+		return nil
+	}
+	switch tok.Tok {
+	case EOFToken:
+		return sel
+	case IncludeMatchToken, DashMatchToken, PrefixMatchToken, SuffixMatchToken, SubstringMatchToken:
+		sel.Matcher = tok.Tok
+	case DelimToken:
+		if tok.Value != "=" {
+			sp.error("unexpected-token", tok, "unexpected: %s", print(tok))
+			return nil
+		}
+		sel.Matcher = DelimToken
+	default:
+		sp.error("unexpected-token", tok, "unexpected: %s", print(tok))
+		return nil
+	}
+
+	sp.skipWhitespace()
+	if tok, ok := sp.s.Scan().(*Token); !ok || (tok.Tok != IdentToken && tok.Tok != StringToken) {
+		sp.error("expected-attribute-value", sp.s.Current(), "expected attribute value, got %s", print(sp.s.Current()))
+		return nil
+	} else {
+		sel.Value = tok.Value
+	}
+
+	// Read the optional case-sensitivity modifier.
+	sp.skipWhitespace()
+	if tok, ok := sp.s.Scan().(*Token); ok && tok.Tok == IdentToken && (strings.EqualFold(tok.Value, "i") || strings.EqualFold(tok.Value, "s")) {
+		sel.Modifier = tok.Value
+		sp.skipWhitespace()
+	} else {
+		sp.s.Unscan()
+	}
+
+	if tok, ok := sp.s.Scan().(*Token); !ok || tok.Tok != EOFToken {
+		sp.error("unexpected-token", sp.s.Current(), "unexpected: %s", print(sp.s.Current()))
+		return nil
+	}
+	return sel
+}
+
+// parsePseudo parses a pseudo-class or pseudo-element after its first colon.
+func (sp *selectorParser) parsePseudo(colon *Token) SimpleSelector {
+	element := false
+	if tok, ok := sp.s.Scan().(*Token); ok && tok.Tok == ColonToken {
+		element = true
+	} else {
+		sp.s.Unscan()
+	}
+
+	var name string
+	var args ComponentValues
+	var function bool
+	switch v := sp.s.Scan().(type) {
+	case *Token:
+		if v.Tok != IdentToken {
+			sp.error("expected-pseudo-class", v, "expected pseudo-class name, got %s", print(v))
+			return nil
+		}
+		name = v.Value
+	case *Function:
+		name, args, function = v.Name, v.Values, true
+	default:
+		sp.error("expected-pseudo-class", v, "expected pseudo-class name, got %s", print(v))
+		return nil
+	}
+
+	if element {
+		return &PseudoElementSelector{Name: name, Args: args, Function: function, Pos: colon.Pos}
+	}
+
+	sel := &PseudoClassSelector{Name: name, Args: args, Function: function, Pos: colon.Pos}
+	if function {
+		switch strings.ToLower(name) {
+		case "is", "matches", "where", "not", "has":
+			nested := &selectorParser{p: sp.p, ns: sp.ns, s: NewComponentValueScanner(args)}
+			if sel.Selectors = nested.parseSelectorList(strings.EqualFold(name, "has")); sel.Selectors == nil {
+				return nil
+			}
+		}
+	}
+	return sel
+}
+
+// skipWhitespace skips whitespace and returns true if any was skipped.
+func (sp *selectorParser) skipWhitespace() bool {
+	var ws bool
+	for {
+		if tok, ok := sp.s.Scan().(*Token); !ok || tok.Tok != WhitespaceToken {
+			sp.s.Unscan()
+			return ws
+		}
+		ws = true
+	}
+}
@@ -0,0 +1,123 @@
+package css_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that selectors can be parsed and printed.
+func TestParser_ParseSelectors(t *testing.T) {
+	var tests = []struct {
+		in   string
+		out  string
+		spec css.Specificity
+		err  string
+	}{
+		{in: `foo`, out: `foo`, spec: css.Specificity{0, 0, 1}},
+		{in: `*`, out: `*`, spec: css.Specificity{0, 0, 0}},
+		{in: `a.b#c`, out: `a.b#c`, spec: css.Specificity{1, 1, 1}},
+		{in: `  ul   li  `, out: `ul li`, spec: css.Specificity{0, 0, 2}},
+		{in: `ul>li+p ~ a || td`, out: `ul > li + p ~ a || td`, spec: css.Specificity{0, 0, 5}},
+		{in: `a, b:hover , .c::before`, out: `a, b:hover, .c::before`},
+		{in: `[href]`, out: `[href]`, spec: css.Specificity{0, 1, 0}},
+		{in: `a[ href ^= 'http' i ]`, out: `a[href^="http" i]`, spec: css.Specificity{0, 1, 1}},
+		{in: `[lang|=en][a~=b][a$=b][a*=b][a=b]`, out: `[lang|="en"][a~="b"][a$="b"][a*="b"][a="b"]`, spec: css.Specificity{0, 5, 0}},
+		{in: `li:nth-child(2n+1)`, out: `li:nth-child(2n+1)`, spec: css.Specificity{0, 1, 1}},
+		{in: `a:not(#x, .y)`, out: `a:not(#x, .y)`, spec: css.Specificity{1, 0, 1}},
+		{in: `a:where(#x)`, out: `a:where(#x)`, spec: css.Specificity{0, 0, 1}},
+		{in: `a:has(> img)`, out: `a:has(> img)`, spec: css.Specificity{0, 0, 2}},
+		{in: `p:first-line`, out: `p:first-line`, spec: css.Specificity{0, 0, 2}},
+		{in: `input::-webkit-input-placeholder`, out: `input::-webkit-input-placeholder`, spec: css.Specificity{0, 0, 2}},
+		{in: `|foo, *|*, [*|att], [|att]`, out: `|foo, *|*, [*|att], [|att]`},
+
+		{in: ``, err: `expected selector, got EOF`},
+		{in: `a,`, err: `expected selector, got EOF`},
+		{in: `a >`, err: `expected selector, got EOF`},
+		{in: `a{`, err: `unexpected: {}`},
+		{in: `a:has(> img) ) `, err: `expected selector, got )`},
+		{in: `#123`, err: `invalid id selector: #123`},
+		{in: `.1`, err: `expected selector, got .1`},
+		{in: `a. b`, err: `expected class name, got  `},
+		{in: `a:1`, err: `expected pseudo-class name, got 1`},
+		{in: `a:(b)`, err: `expected pseudo-class name, got (b)`},
+		{in: `a:not(,)`, err: `expected selector, got ,`},
+		{in: `[]`, err: `expected attribute name, got EOF`},
+		{in: `[a b]`, err: `unexpected: b`},
+		{in: `[a ^ b]`, err: `unexpected: ^`},
+		{in: `[a = (b)]`, err: `expected attribute value, got (b)`},
+		{in: `[a = 1]`, err: `expected attribute value, got 1`},
+		{in: `[a = b c]`, err: `unexpected: c`},
+		{in: `foo|`, err: `expected name after namespace prefix, got EOF`},
+		{in: `|1`, err: `expected name after namespace prefix, got 1`},
+		{in: `svg|circle`, err: `undeclared namespace prefix: svg`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		a := p.ParseSelectors(p.ParseComponentValues(css.NewScanner(strings.NewReader(tt.in))), nil)
+		if tt.err != "" {
+			if len(p.Errors) == 0 || p.Errors[0].Error() != tt.err {
+				t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, p.Errors)
+			} else if a != nil {
+				t.Errorf("%d. <%q> unexpected selectors: %s", i, tt.in, a)
+			}
+			continue
+		} else if len(p.Errors) > 0 {
+			t.Errorf("%d. <%q> unexpected error: %s", i, tt.in, p.Errors)
+			continue
+		}
+
+		if s := a.String(); s != tt.out {
+			t.Errorf("%d. <%q>\n\nexp: %s\n\ngot: %s", i, tt.in, tt.out, s)
+		} else if spec := a[0].Specificity(); len(a) == 1 && spec != tt.spec {
+			t.Errorf("%d. <%q> specificity: exp=%v, got=%v", i, tt.in, tt.spec, spec)
+		}
+	}
+}
+
+// Ensure that the position of a selector error is reported.
+func TestParser_ParseSelectors_ErrorPos(t *testing.T) {
+	var p css.Parser
+	p.ParseSelectors(p.ParseComponentValues(css.NewScanner(strings.NewReader("a,\n  svg|rect"))), nil)
+	if len(p.Errors) != 1 {
+		t.Fatalf("unexpected errors: %v", p.Errors)
+	} else if pos := p.Errors[0].(*css.Error).Pos; pos != (css.Pos{Char: 3, Line: 1}) {
+		t.Errorf("unexpected position: %#v", pos)
+	}
+}
+
+// Ensure that an undeclared namespace error covers the prefix as written.
+func TestParser_ParseSelectors_UndeclaredNamespaceRange(t *testing.T) {
+	var tests = []struct {
+		in  string
+		end css.Pos
+	}{
+		{in: `svg|rect`, end: css.Pos{Char: 4}},
+		{in: `s\76g|rect`, end: css.Pos{Char: 6}},
+		{in: `émoji|rect`, end: css.Pos{Char: 6}},
+		{in: `[svg|href]`, end: css.Pos{Char: 5}},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		p.ParseSelectors(p.ParseComponentValues(css.NewScanner(strings.NewReader(tt.in))), nil)
+		if len(p.Errors) != 1 {
+			t.Errorf("%d. <%q> unexpected errors: %v", i, tt.in, p.Errors)
+		} else if end := p.Errors[0].(*css.Error).End; end != tt.end {
+			t.Errorf("%d. <%q> end: exp=%#v, got=%#v", i, tt.in, tt.end, end)
+		}
+	}
+}
+
+// Ensure that the specificity comparison orders ids before classes and types.
+func TestSpecificity_Less(t *testing.T) {
+	if !(css.Specificity{0, 9, 9}).Less(css.Specificity{1, 0, 0}) {
+		t.Errorf("expected (0,9,9) < (1,0,0)")
+	} else if (css.Specificity{0, 1, 0}).Less(css.Specificity{0, 0, 5}) {
+		t.Errorf("expected (0,1,0) >= (0,0,5)")
+	} else if (css.Specificity{1, 1, 1}).Less(css.Specificity{1, 1, 1}) {
+		t.Errorf("expected specificity to not be less than itself")
+	}
+}
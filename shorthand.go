@@ -0,0 +1,971 @@
+package css
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExpandShorthand expands a shorthand declaration into the declarations of
+// its longhand properties, in canonical order. Longhands which are omitted
+// from the shorthand's value are set to their initial values. Longhands
+// which are themselves shorthands, such as "border-width" for "border", are
+// expanded recursively.
+//
+// An error is returned if the property is not a supported shorthand, if
+// its value is invalid or if its value contains var() references, which
+// can only be expanded after substitution.
+func ExpandShorthand(d *Declaration) ([]*Declaration, error) {
+	p := LookupProperty(d.Name)
+	if p == nil || !p.IsShorthand() {
+		return nil, newValidatorError("not-shorthand", d, "not a shorthand property: %s", d.Name)
+	}
+	expand := shorthandExpanders[p.Name]
+	if expand == nil {
+		return nil, newValidatorError("unsupported-shorthand", d, "unsupported shorthand property: %s", d.Name)
+	}
+
+	values := d.Values.nonwhitespace()
+	if containsFunction(values, "var", "env") {
+		return nil, newValidatorError("cannot-expand", d, "cannot expand %s with var() references", d.Name)
+	}
+
+	// CSS-wide keywords apply to every longhand.
+	var a []ComponentValues
+	if len(values) == 1 && isTok(values[0], IdentToken) && IsWideKeyword(values[0].(*Token).Value) {
+		a = make([]ComponentValues, len(p.Longhands))
+		for i := range a {
+			a[i] = values
+		}
+	} else {
+		if err := Validate(d); err != nil {
+			return nil, err
+		}
+		m, _ := propertyMatcher.Match(p.grammar, values)
+
+		var err error
+		if a, err = expand(p, m, d.Values); err != nil {
+			return nil, newValidatorError("cannot-expand", d, "%s", err)
+		}
+	}
+
+	// Create a declaration for each longhand, using the initial value if omitted.
+	var decls []*Declaration
+	for i, name := range p.Longhands {
+		longhand := LookupProperty(name)
+		if a[i] == nil {
+			// Omitted shorthand longhands set each of their leaves to their initial values.
+			for _, leaf := range leafLonghands(longhand) {
+				decls = append(decls, &Declaration{Name: leaf, Values: parseValueString(LookupProperty(leaf).Initial), Important: d.Important, Pos: d.Pos})
+			}
+			continue
+		}
+
+		child := &Declaration{Name: name, Values: a[i], Important: d.Important, Pos: d.Pos}
+		if !longhand.IsShorthand() {
+			decls = append(decls, child)
+			continue
+		}
+		children, err := ExpandShorthand(child)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, children...)
+	}
+	return decls, nil
+}
+
+// CombineShorthand combines the declarations of a shorthand's longhands into
+// a single shorthand declaration using the shortest value possible. Later
+// declarations of the same longhand override earlier ones. Declarations of
+// other properties are ignored.
+//
+// An error is returned if a longhand is missing, if the longhands differ in
+// importance or if the values cannot be represented by the shorthand.
+func CombineShorthand(name string, decls []*Declaration) (*Declaration, error) {
+	p := LookupProperty(name)
+	if p == nil || !p.IsShorthand() {
+		return nil, fmt.Errorf("not a shorthand property: %s", name)
+	}
+	combine := shorthandCombiners[p.Name]
+	if combine == nil {
+		return nil, fmt.Errorf("unsupported shorthand property: %s", name)
+	}
+
+	// Find the last declaration of each longhand.
+	leaves := leafLonghands(p)
+	set := make(map[string]*Declaration)
+	for _, d := range decls {
+		if n := strings.ToLower(d.Name); containsString(leaves, n) {
+			set[n] = d
+		}
+	}
+
+	// Verify that every longhand is set with the same importance.
+	var first *Declaration
+	var keyword string
+	for _, leaf := range leaves {
+		d := set[leaf]
+		if d == nil {
+			return nil, fmt.Errorf("cannot combine %s: missing %s", p.Name, leaf)
+		} else if first == nil {
+			first = d
+		} else if d.Important != first.Important {
+			return nil, fmt.Errorf("cannot combine %s: mixed !important", p.Name)
+		}
+
+		values := d.Values.nonwhitespace()
+		if containsFunction(values, "var", "env") {
+			return nil, fmt.Errorf("cannot combine %s: %s has var() references", p.Name, leaf)
+		}
+
+		// CSS-wide keywords can only be combined if every longhand uses the same one.
+		var kw string
+		if len(values) == 1 && isTok(values[0], IdentToken) && IsWideKeyword(values[0].(*Token).Value) {
+			kw = strings.ToLower(values[0].(*Token).Value)
+		}
+		if leaf == leaves[0] {
+			keyword = kw
+		} else if kw != keyword {
+			return nil, fmt.Errorf("cannot combine %s: mixed CSS-wide keywords", p.Name)
+		}
+	}
+
+	var value string
+	if keyword != "" {
+		value = keyword
+	} else {
+		get := func(name string) string { return valueString(set[name].Values) }
+		var err error
+		if value, err = combine(p, get); err != nil {
+			return nil, fmt.Errorf("cannot combine %s: %s", p.Name, err)
+		}
+	}
+
+	d := &Declaration{Name: p.Name, Values: parseValueString(value), Important: first.Important, Pos: first.Pos}
+	if keyword == "" {
+		if err := Validate(d); err != nil {
+			return nil, fmt.Errorf("cannot combine %s: %s", p.Name, err)
+		}
+	}
+	return d, nil
+}
+
+// leafLonghands returns the longhands of a property, expanding any
+// longhands which are shorthands themselves.
+func leafLonghands(p *Property) []string {
+	if !p.IsShorthand() {
+		return []string{p.Name}
+	}
+	var a []string
+	for _, name := range p.Longhands {
+		a = append(a, leafLonghands(LookupProperty(name))...)
+	}
+	return a
+}
+
+// shorthandExpanders return the values of each longhand of a shorthand from
+// its match tree, in the order of the property's Longhands. A nil value
+// indicates that the longhand takes its initial value.
+var shorthandExpanders = map[string]func(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error){
+	"animation":       expandAnimation,
+	"background":      expandBackground,
+	"border":          expandUnordered,
+	"border-bottom":   expandUnordered,
+	"border-color":    expandBox,
+	"border-left":     expandUnordered,
+	"border-radius":   expandBorderRadius,
+	"border-right":    expandUnordered,
+	"border-style":    expandBox,
+	"border-top":      expandUnordered,
+	"border-width":    expandBox,
+	"columns":         expandUnordered,
+	"flex":            expandFlex,
+	"flex-flow":       expandUnordered,
+	"font":            expandFont,
+	"gap":             expandPair,
+	"grid-area":       expandGridLines,
+	"grid-column":     expandGridLines,
+	"grid-row":        expandGridLines,
+	"grid-template":   expandGridTemplate,
+	"inset":           expandBox,
+	"list-style":      expandListStyle,
+	"margin":          expandBox,
+	"outline":         expandUnordered,
+	"overflow":        expandPair,
+	"padding":         expandBox,
+	"text-decoration": expandUnordered,
+	"transition":      expandTransition,
+}
+
+// shorthandCombiners return the shortest value of a shorthand given a
+// function which returns the printed value of each leaf longhand.
+var shorthandCombiners = map[string]func(p *Property, get func(string) string) (string, error){
+	"animation":       combineAnimation,
+	"background":      combineBackground,
+	"border":          combineBorder,
+	"border-bottom":   combineUnordered,
+	"border-color":    combineBox,
+	"border-left":     combineUnordered,
+	"border-radius":   combineBorderRadius,
+	"border-right":    combineUnordered,
+	"border-style":    combineBox,
+	"border-top":      combineUnordered,
+	"border-width":    combineBox,
+	"columns":         combineUnordered,
+	"flex":            combineFlex,
+	"flex-flow":       combineUnordered,
+	"font":            combineFont,
+	"gap":             combinePair,
+	"grid-area":       combineGridLines,
+	"grid-column":     combineGridLines,
+	"grid-row":        combineGridLines,
+	"grid-template":   combineGridTemplate,
+	"inset":           combineBox,
+	"list-style":      combineUnordered,
+	"margin":          combineBox,
+	"outline":         combineUnordered,
+	"overflow":        combinePair,
+	"padding":         combineBox,
+	"text-decoration": combineUnordered,
+	"transition":      combineTransition,
+}
+
+// expandBox expands a 1-4 value box shorthand, such as margin, into its
+// top, right, bottom and left longhands.
+func expandBox(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	var a []ComponentValues
+	for _, c := range m.Children {
+		a = append(a, span(orig, c.Values))
+	}
+	return boxSides(a), nil
+}
+
+// boxSides fills in the omitted sides of a 1-4 value box.
+func boxSides(a []ComponentValues) []ComponentValues {
+	switch len(a) {
+	case 1:
+		return []ComponentValues{a[0], a[0], a[0], a[0]}
+	case 2:
+		return []ComponentValues{a[0], a[1], a[0], a[1]}
+	case 3:
+		return []ComponentValues{a[0], a[1], a[2], a[1]}
+	}
+	return a
+}
+
+// combineBox returns the shortest 1-4 value box for the longhands.
+func combineBox(p *Property, get func(string) string) (string, error) {
+	var a []string
+	for _, name := range p.Longhands {
+		a = append(a, get(name))
+	}
+	return boxString(a), nil
+}
+
+// boxString returns the shortest form of top, right, bottom and left values.
+func boxString(a []string) string {
+	top, right, bottom, left := a[0], a[1], a[2], a[3]
+	if !strings.EqualFold(left, right) {
+		return strings.Join(a, " ")
+	} else if !strings.EqualFold(bottom, top) {
+		return top + " " + right + " " + bottom
+	} else if !strings.EqualFold(right, top) {
+		return top + " " + right
+	}
+	return top
+}
+
+// expandBorderRadius expands border-radius into its four corners.
+func expandBorderRadius(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	var h, v []ComponentValues
+	for _, c := range m.Children[0].Children {
+		h = append(h, span(orig, c.Values))
+	}
+	for _, c := range findMatches(m.Children[1], "<length-percentage [0,∞]>") {
+		v = append(v, span(orig, c.Values))
+	}
+	if v == nil {
+		v = h
+	}
+	h, v = boxSides(h), boxSides(v)
+
+	a := make([]ComponentValues, 4)
+	for i := range a {
+		if valueString(h[i]) == valueString(v[i]) {
+			a[i] = h[i]
+		} else {
+			a[i] = parseValueString(valueString(h[i]) + " " + valueString(v[i]))
+		}
+	}
+	return a, nil
+}
+
+// combineBorderRadius returns the shortest border-radius for its corners.
+func combineBorderRadius(p *Property, get func(string) string) (string, error) {
+	var h, v []string
+	for _, name := range p.Longhands {
+		a := strings.Fields(get(name))
+		h = append(h, a[0])
+		v = append(v, a[len(a)-1])
+	}
+	if s, t := boxString(h), boxString(v); s != t {
+		return s + " / " + t, nil
+	}
+	return boxString(h), nil
+}
+
+// expandPair expands a shorthand with one or two values, where the second
+// value defaults to the first.
+func expandPair(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	var a []ComponentValues
+	m.Walk(func(c *Match) bool {
+		if n, ok := c.Node.(*TypeNode); ok && n.Property && len(c.Values) > 0 {
+			a = append(a, span(orig, c.Values))
+			return false
+		}
+		return true
+	})
+	if len(a) == 1 {
+		a = append(a, a[0])
+	}
+	return a, nil
+}
+
+// combinePair returns the shortest value for a one or two value shorthand.
+func combinePair(p *Property, get func(string) string) (string, error) {
+	first, second := get(p.Longhands[0]), get(p.Longhands[1])
+	if strings.EqualFold(first, second) {
+		return first, nil
+	}
+	return first + " " + second, nil
+}
+
+// expandUnordered expands a shorthand whose grammar combines one component
+// per longhand with "||", in the same order as the longhands.
+func expandUnordered(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	g := m.Node.(*GroupNode)
+	a := make([]ComponentValues, len(p.Longhands))
+	for _, c := range m.Children {
+		for i, n := range g.Children {
+			if c.Node == n {
+				a[i] = span(orig, c.Values)
+			}
+		}
+	}
+	return a, nil
+}
+
+// combineUnordered returns the longhand values separated by spaces,
+// omitting the ones which are set to their initial value.
+func combineUnordered(p *Property, get func(string) string) (string, error) {
+	var a []string
+	for _, name := range p.Longhands {
+		if v := get(name); !isInitial(name, v) {
+			a = append(a, v)
+		}
+	}
+	if len(a) == 0 {
+		return get(p.Longhands[0]), nil
+	}
+	return strings.Join(a, " "), nil
+}
+
+// expandListStyle expands list-style. A "none" value sets both the image
+// and the type unless one of them is otherwise specified.
+func expandListStyle(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	a, _ := expandUnordered(p, m, orig)
+	if a[2] == nil && strings.EqualFold(valueString(a[1]), "none") {
+		a[2] = a[1]
+	}
+	return a, nil
+}
+
+// combineBorder returns the shortest border value. The border shorthand can
+// only be used if all four sides are the same.
+func combineBorder(p *Property, get func(string) string) (string, error) {
+	var a []string
+	for _, part := range []string{"width", "style", "color"} {
+		var sides []string
+		for _, side := range []string{"top", "right", "bottom", "left"} {
+			sides = append(sides, get("border-"+side+"-"+part))
+		}
+		if boxString(sides) != sides[0] {
+			return "", fmt.Errorf("border %ss differ", part)
+		} else if !isInitial("border-top-"+part, sides[0]) {
+			a = append(a, sides[0])
+		}
+	}
+	if len(a) == 0 {
+		return get("border-top-style"), nil
+	}
+	return strings.Join(a, " "), nil
+}
+
+// expandFlex expands flex. An omitted grow or shrink factor is 1 and an
+// omitted basis is 0% rather than the initial values.
+func expandFlex(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	if _, ok := m.Children[0].Node.(*KeywordNode); ok {
+		return []ComponentValues{parseValueString("0"), parseValueString("0"), parseValueString("auto")}, nil
+	}
+
+	a := []ComponentValues{parseValueString("1"), parseValueString("1"), parseValueString("0%")}
+	for i, name := range p.Longhands {
+		if c := findMatch(m, "<'"+name+"'>"); c != nil {
+			a[i] = span(orig, c.Values)
+		}
+	}
+	return a, nil
+}
+
+// combineFlex returns the shortest flex value.
+func combineFlex(p *Property, get func(string) string) (string, error) {
+	grow, shrink, basis := get("flex-grow"), get("flex-shrink"), get("flex-basis")
+	switch {
+	case grow == "0" && shrink == "0" && strings.EqualFold(basis, "auto"):
+		return "none", nil
+	case grow == "1" && shrink == "1" && strings.EqualFold(basis, "auto"):
+		return "auto", nil
+	case basis == "0%" && shrink == "1":
+		return grow, nil
+	case basis == "0%":
+		return grow + " " + shrink, nil
+	case shrink == "1":
+		return grow + " " + basis, nil
+	}
+	return grow + " " + shrink + " " + basis, nil
+}
+
+// expandFont expands font. System font keywords cannot be expanded since
+// their longhand values depend on the user agent.
+func expandFont(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	if n, ok := m.Children[0].Node.(*KeywordNode); ok {
+		return nil, fmt.Errorf("cannot expand system font: %s", n.Name)
+	}
+
+	types := []string{"<'font-style'>", "<font-variant-css2>", "<'font-weight'>", "<font-width-css3>", "<'font-size'>", "<'line-height'>", "<'font-family'>"}
+	a := make([]ComponentValues, len(types))
+	for i, typ := range types {
+		if c := findMatch(m, typ); c != nil {
+			a[i] = span(orig, c.Values)
+		}
+	}
+	return a, nil
+}
+
+// combineFont returns the shortest font value. The font shorthand can only
+// represent CSS 2 variants and keyword widths.
+func combineFont(p *Property, get func(string) string) (string, error) {
+	var a []string
+	for _, name := range []string{"font-style", "font-variant", "font-weight", "font-stretch"} {
+		if v := get(name); !strings.EqualFold(v, "normal") {
+			if name == "font-variant" && !strings.EqualFold(v, "small-caps") {
+				return "", fmt.Errorf("font-variant cannot be represented: %s", v)
+			} else if name == "font-stretch" && !matchesType("font-width-css3", v) {
+				return "", fmt.Errorf("font-stretch cannot be represented: %s", v)
+			}
+			a = append(a, v)
+		}
+	}
+
+	size := get("font-size")
+	if v := get("line-height"); !strings.EqualFold(v, "normal") {
+		size += "/" + v
+	}
+	return strings.Join(append(a, size, get("font-family")), " "), nil
+}
+
+// expandBackground expands background into its comma-separated layers.
+// The color can only be set in the final layer.
+func expandBackground(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	layers := append(findMatches(m, "<bg-layer>"), findMatches(m, "<final-bg-layer>")...)
+
+	items := make([][]ComponentValues, len(p.Longhands)-1)
+	for _, layer := range layers {
+		var origin, clip ComponentValues
+		if boxes := findMatches(layer, "<visual-box>"); len(boxes) == 1 {
+			origin, clip = boxes[0].Values, boxes[0].Values
+		} else if len(boxes) == 2 {
+			origin, clip = boxes[0].Values, boxes[1].Values
+		}
+
+		for i, v := range []ComponentValues{
+			matchValues(layer, "<bg-image>"),
+			matchValues(layer, "<bg-position>"),
+			matchValues(layer, "<bg-size>"),
+			matchValues(layer, "<repeat-style>"),
+			matchValues(layer, "<attachment>"),
+			origin,
+			clip,
+		} {
+			if v == nil {
+				v = parseValueString(LookupProperty(p.Longhands[i]).Initial)
+			}
+			items[i] = append(items[i], span(orig, v))
+		}
+	}
+
+	var a []ComponentValues
+	for _, item := range items {
+		a = append(a, joinValues(item))
+	}
+	return append(a, span(orig, matchValues(m, "<'background-color'>"))), nil
+}
+
+// combineBackground returns the shortest background value. Every layered
+// longhand must have the same number of layers.
+func combineBackground(p *Property, get func(string) string) (string, error) {
+	names := p.Longhands[:len(p.Longhands)-1]
+	lists := make([][]string, len(names))
+	for i, name := range names {
+		lists[i] = splitList(get(name))
+		if len(lists[i]) != len(lists[0]) {
+			return "", fmt.Errorf("%s has a different number of layers", name)
+		}
+	}
+
+	var layers []string
+	for i := range lists[0] {
+		image, position, size, repeat, attachment, origin, clip :=
+			lists[0][i], lists[1][i], lists[2][i], lists[3][i], lists[4][i], lists[5][i], lists[6][i]
+
+		var a []string
+		if !isInitial("background-image", image) {
+			a = append(a, image)
+		}
+		if !isInitial("background-size", size) {
+			a = append(a, position+" / "+size)
+		} else if !isInitial("background-position", position) {
+			a = append(a, position)
+		}
+		if !isInitial("background-repeat", repeat) {
+			a = append(a, repeat)
+		}
+		if !isInitial("background-attachment", attachment) {
+			a = append(a, attachment)
+		}
+		if strings.EqualFold(origin, clip) {
+			a = append(a, origin)
+		} else if !isInitial("background-origin", origin) || !isInitial("background-clip", clip) {
+			a = append(a, origin, clip)
+		}
+
+		if i == len(lists[0])-1 {
+			if color := get("background-color"); !isInitial("background-color", color) || len(a) == 0 {
+				a = append(a, color)
+			}
+		} else if len(a) == 0 {
+			a = append(a, image)
+		}
+		layers = append(layers, strings.Join(a, " "))
+	}
+	return strings.Join(layers, ", "), nil
+}
+
+// expandTransition expands transition into its comma-separated longhands.
+// The first time is the duration and the second is the delay.
+func expandTransition(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	items := make([][]ComponentValues, len(p.Longhands))
+	for _, t := range findMatches(m, "<single-transition>") {
+		var property, easing ComponentValues
+		var times []ComponentValues
+		for _, c := range groupChildren(t) {
+			switch c.Node.String() {
+			case "<time>":
+				times = append(times, c.Values)
+			case "<easing-function>":
+				easing = c.Values
+			default:
+				property = c.Values
+			}
+		}
+		times = append(times, nil, nil)
+		items = appendListItems(p, items, orig, property, times[0], easing, times[1])
+	}
+	return joinListItems(items), nil
+}
+
+// combineTransition returns the shortest transition value.
+func combineTransition(p *Property, get func(string) string) (string, error) {
+	items, err := listItems(p, get)
+	if err != nil {
+		return "", err
+	}
+
+	var a []string
+	for _, item := range items {
+		a = append(a, timedItem(p, item, 1, 3))
+	}
+	return strings.Join(a, ", "), nil
+}
+
+// expandAnimation expands animation into its comma-separated longhands.
+func expandAnimation(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	items := make([][]ComponentValues, len(p.Longhands))
+	for _, anim := range findMatches(m, "<single-animation>") {
+		values := make([]ComponentValues, len(p.Longhands))
+		for _, c := range groupChildren(anim) {
+			switch c.Node.String() {
+			case "<time [0,∞]>":
+				values[0] = c.Values
+			case "<easing-function>":
+				values[1] = c.Values
+			case "<time>":
+				values[2] = c.Values
+			case "<single-animation-iteration-count>":
+				values[3] = c.Values
+			case "<single-animation-direction>":
+				values[4] = c.Values
+			case "<single-animation-fill-mode>":
+				values[5] = c.Values
+			case "<single-animation-play-state>":
+				values[6] = c.Values
+			default:
+				values[7] = c.Values
+			}
+		}
+		items = appendListItems(p, items, orig, values...)
+	}
+	return joinListItems(items), nil
+}
+
+// combineAnimation returns the shortest animation value.
+func combineAnimation(p *Property, get func(string) string) (string, error) {
+	items, err := listItems(p, get)
+	if err != nil {
+		return "", err
+	}
+
+	var a []string
+	for _, item := range items {
+		a = append(a, timedItem(p, item, 0, 2))
+	}
+	return strings.Join(a, ", "), nil
+}
+
+// listItems splits each comma-separated longhand into its items and returns
+// the values of each item in the order of the longhands. Every longhand must
+// have the same number of items.
+func listItems(p *Property, get func(string) string) ([][]string, error) {
+	var items [][]string
+	for i, name := range p.Longhands {
+		list := splitList(get(name))
+		if i == 0 {
+			items = make([][]string, len(list))
+		} else if len(list) != len(items) {
+			return nil, fmt.Errorf("%s has a different number of items", name)
+		}
+		for j, v := range list {
+			items[j] = append(items[j], v)
+		}
+	}
+	return items, nil
+}
+
+// timedItem returns the values of a transition or animation item separated
+// by spaces, omitting initial values. The duration at index dur is kept if
+// the delay at index delay is set since the first time is always the duration.
+// If every value is initial then the last value is used.
+func timedItem(p *Property, item []string, dur, delay int) string {
+	var a []string
+	for i, v := range item {
+		if !isInitial(p.Longhands[i], v) || (i == dur && !isInitial(p.Longhands[delay], item[delay])) {
+			a = append(a, v)
+		}
+	}
+	if len(a) == 0 {
+		return item[len(item)-1]
+	}
+	return strings.Join(a, " ")
+}
+
+// expandGridLines expands grid-row, grid-column and grid-area. An omitted
+// end line defaults to the start line if it is a custom identifier and to
+// auto otherwise.
+func expandGridLines(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	var lines []ComponentValues
+	for _, c := range findMatches(m, "<grid-line>") {
+		lines = append(lines, span(orig, c.Values))
+	}
+
+	a := make([]ComponentValues, len(p.Longhands))
+	copy(a, lines)
+	for i := len(lines); i < len(a); i++ {
+		if start := a[gridStart(i, len(a))]; isGridIdent(valueString(start)) {
+			a[i] = start
+		} else {
+			a[i] = parseValueString("auto")
+		}
+	}
+	return a, nil
+}
+
+// combineGridLines returns the shortest grid-row, grid-column or grid-area
+// value by omitting end lines that match their default.
+func combineGridLines(p *Property, get func(string) string) (string, error) {
+	var a []string
+	for _, name := range p.Longhands {
+		a = append(a, get(name))
+	}
+	for n := len(a); n > 1; n-- {
+		start, end := a[gridStart(n-1, len(a))], a[n-1]
+		if !(isGridIdent(start) && end == start) && !(!isGridIdent(start) && strings.EqualFold(end, "auto")) {
+			break
+		}
+		a = a[:n-1]
+	}
+	return strings.Join(a, " / "), nil
+}
+
+// gridStart returns the index of the line that the omitted line at index i
+// defaults to, given n lines in total. In grid-area, an omitted
+// grid-column-start defaults to grid-row-start.
+func gridStart(i, n int) int {
+	if i < n/2 {
+		return 0
+	}
+	return i - n/2
+}
+
+// isGridIdent returns true if a grid line is a single custom identifier.
+func isGridIdent(s string) bool {
+	values := parseValueString(s).nonwhitespace()
+	if len(values) != 1 || !MatchDataType("custom-ident", values[0]) {
+		return false
+	}
+	switch strings.ToLower(values[0].(*Token).Value) {
+	case "auto", "span":
+		return false
+	}
+	return true
+}
+
+// expandGridTemplate expands grid-template into its rows, columns and areas.
+func expandGridTemplate(p *Property, m *Match, orig ComponentValues) ([]ComponentValues, error) {
+	alt := m.Children[0]
+	if _, ok := alt.Node.(*KeywordNode); ok {
+		return []ComponentValues{alt.Values, alt.Values, alt.Values}, nil
+	} else if rows := findMatch(alt, "<'grid-template-rows'>"); rows != nil {
+		return []ComponentValues{span(orig, rows.Values), span(orig, matchValues(alt, "<'grid-template-columns'>")), nil}, nil
+	}
+
+	// Otherwise each row has a string of areas with optional line names and
+	// a track size. Adjacent line names are merged into a single list.
+	var rows, areas, names []string
+	flush := func() {
+		if len(names) > 0 {
+			rows = append(rows, "["+strings.Join(names, " ")+"]")
+			names = nil
+		}
+	}
+	for _, row := range alt.Children[0].Children {
+		names = append(names, lineNames(row.Children[0])...)
+		flush()
+
+		areas = append(areas, valueString(row.Children[1].Values))
+		if track := valueString(row.Children[2].Values); track != "" {
+			rows = append(rows, track)
+		} else {
+			rows = append(rows, "auto")
+		}
+		names = append(names, lineNames(row.Children[3])...)
+	}
+	flush()
+
+	var columns ComponentValues
+	if c := findMatch(alt.Children[1], "<track-list>"); c != nil {
+		columns = span(orig, c.Values)
+	}
+	return []ComponentValues{parseValueString(strings.Join(rows, " ")), columns, parseValueString(strings.Join(areas, " "))}, nil
+}
+
+// lineNames returns the names in a matched line name list.
+func lineNames(m *Match) []string {
+	var a []string
+	for _, v := range m.Values {
+		if b, ok := v.(*SimpleBlock); ok {
+			a = append(a, strings.Fields(valueString(b.Values))...)
+		}
+	}
+	return a
+}
+
+// combineGridTemplate returns the shortest grid-template value. Rows can
+// only be combined with areas if there is one explicit track per area string.
+func combineGridTemplate(p *Property, get func(string) string) (string, error) {
+	rows, columns, areas := get("grid-template-rows"), get("grid-template-columns"), get("grid-template-areas")
+	if strings.EqualFold(areas, "none") {
+		if strings.EqualFold(rows, "none") && strings.EqualFold(columns, "none") {
+			return "none", nil
+		}
+		return rows + " / " + columns, nil
+	}
+
+	// Interleave the area strings with the row tracks and line names.
+	var a []string
+	strs := parseValueString(areas).nonwhitespace()
+	tracks := parseValueString(rows).nonwhitespace()
+	for _, str := range strs {
+		if len(tracks) > 0 {
+			if b, ok := tracks[0].(*SimpleBlock); ok && b.Token.Tok == LBrackToken {
+				a, tracks = append(a, print(b)), tracks[1:]
+			}
+		}
+		a = append(a, print(str))
+		if len(tracks) == 0 {
+			return "", fmt.Errorf("grid-template-rows does not match areas")
+		} else if _, ok := tracks[0].(*SimpleBlock); ok || isFunction(tracks[0], []string{"repeat"}) {
+			return "", fmt.Errorf("grid-template-rows cannot be represented: %s", rows)
+		} else if s := print(tracks[0]); !strings.EqualFold(s, "auto") {
+			a = append(a, s)
+		}
+		tracks = tracks[1:]
+
+		// Line names after the last row are emitted with it.
+		if len(tracks) == 1 {
+			if b, ok := tracks[0].(*SimpleBlock); ok && b.Token.Tok == LBrackToken {
+				a, tracks = append(a, print(b)), nil
+			}
+		}
+	}
+	if len(tracks) > 0 {
+		return "", fmt.Errorf("grid-template-rows does not match areas")
+	}
+
+	if !strings.EqualFold(columns, "none") {
+		a = append(a, "/", columns)
+	}
+	return strings.Join(a, " "), nil
+}
+
+// appendListItems appends one item to each comma-separated longhand list,
+// using the longhand's initial value for nil values.
+func appendListItems(p *Property, items [][]ComponentValues, orig ComponentValues, values ...ComponentValues) [][]ComponentValues {
+	for i, v := range values {
+		if v == nil {
+			v = parseValueString(LookupProperty(p.Longhands[i]).Initial)
+		}
+		items[i] = append(items[i], span(orig, v))
+	}
+	return items
+}
+
+// joinListItems joins each list of items with commas.
+func joinListItems(items [][]ComponentValues) []ComponentValues {
+	a := make([]ComponentValues, len(items))
+	for i, item := range items {
+		a[i] = joinValues(item)
+	}
+	return a
+}
+
+// joinValues joins lists of values into a single comma-separated list.
+func joinValues(items []ComponentValues) ComponentValues {
+	var a ComponentValues
+	for i, item := range items {
+		if i > 0 {
+			a = append(a, &Token{Tok: CommaToken, Value: ","}, &Token{Tok: WhitespaceToken, Value: " "})
+		}
+		a = append(a, item...)
+	}
+	return a
+}
+
+// splitList splits a printed comma-separated value into its items.
+func splitList(s string) []string {
+	var a []string
+	for _, item := range parseValueString(s).split(CommaToken) {
+		a = append(a, valueString(item))
+	}
+	return a
+}
+
+// groupChildren returns the matches of the components of the group that a
+// match resolves to, skipping through named types.
+func groupChildren(m *Match) []*Match {
+	for {
+		switch m.Node.(type) {
+		case *TypeNode:
+			if len(m.Children) == 1 {
+				m = m.Children[0]
+				continue
+			}
+		case *GroupNode:
+			return m.Children
+		}
+		return []*Match{m}
+	}
+}
+
+// findMatch returns the first match of a node with the given string form.
+func findMatch(m *Match, s string) *Match {
+	if a := findMatches(m, s); len(a) > 0 {
+		return a[0]
+	}
+	return nil
+}
+
+// findMatches returns all matches of nodes with the given string form.
+// Matching nodes are not searched for nested matches.
+func findMatches(m *Match, s string) []*Match {
+	var a []*Match
+	m.Walk(func(m *Match) bool {
+		if m.Node.String() == s && len(m.Values) > 0 {
+			a = append(a, m)
+			return false
+		}
+		return true
+	})
+	return a
+}
+
+// matchValues returns the values of the first match of a node with the
+// given string form or nil if there is no match.
+func matchValues(m *Match, s string) ComponentValues {
+	if c := findMatch(m, s); c != nil {
+		return c.Values
+	}
+	return nil
+}
+
+// span returns the values from orig between the first and last of values,
+// including whitespace. Values which are not found in orig are returned as is.
+func span(orig, values ComponentValues) ComponentValues {
+	if len(values) == 0 {
+		return values
+	}
+	start, end := -1, -1
+	for i, v := range orig {
+		if v == values[0] && start == -1 {
+			start = i
+		}
+		if v == values[len(values)-1] {
+			end = i
+		}
+	}
+	if start == -1 || end < start {
+		return values
+	}
+	return orig[start : end+1]
+}
+
+// isInitial returns true if a printed value is the initial value of a property.
+func isInitial(name, v string) bool {
+	return strings.EqualFold(LookupProperty(name).Initial, v)
+}
+
+// matchesType returns true if a printed value matches a named property type.
+func matchesType(name, v string) bool {
+	_, err := propertyMatcher.Match(propertyMatcher.Types[name], parseValueString(v))
+	return err == nil
+}
+
+// valueString returns the printed values without surrounding whitespace.
+func valueString(values ComponentValues) string {
+	return strings.TrimSpace(print(values))
+}
+
+// parseValueString parses a string into a list of component values.
+func parseValueString(s string) ComponentValues {
+	var p Parser
+	return p.ParseComponentValues(NewScanner(strings.NewReader(s)))
+}
@@ -0,0 +1,140 @@
+package css_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that shorthand declarations can be expanded into their longhands.
+func TestExpandShorthand(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out string
+		err string
+	}{
+		{in: `margin: 1px`, out: `margin-top:1px; margin-right:1px; margin-bottom:1px; margin-left:1px;`},
+		{in: `margin: 1px auto`, out: `margin-top:1px; margin-right:auto; margin-bottom:1px; margin-left:auto;`},
+		{in: `padding: 1px 2px 3px`, out: `padding-top:1px; padding-right:2px; padding-bottom:3px; padding-left:2px;`},
+		{in: `inset: 1px 2px 3px 4px !important`, out: `top:1px!important; right:2px!important; bottom:3px!important; left:4px!important;`},
+		{in: `border: 1px solid`, out: `border-top-width:1px; border-right-width:1px; border-bottom-width:1px; border-left-width:1px; border-top-style:solid; border-right-style:solid; border-bottom-style:solid; border-left-style:solid; border-top-color:currentcolor; border-right-color:currentcolor; border-bottom-color:currentcolor; border-left-color:currentcolor;`},
+		{in: `border-top: red dashed`, out: `border-top-width:medium; border-top-style:dashed; border-top-color:red;`},
+		{in: `border-radius: 1px 2px / 3px`, out: `border-top-left-radius:1px 3px; border-top-right-radius:2px 3px; border-bottom-right-radius:1px 3px; border-bottom-left-radius:2px 3px;`},
+		{in: `flex: none`, out: `flex-grow:0; flex-shrink:0; flex-basis:auto;`},
+		{in: `flex: 2`, out: `flex-grow:2; flex-shrink:1; flex-basis:0%;`},
+		{in: `flex: 2 3 10px`, out: `flex-grow:2; flex-shrink:3; flex-basis:10px;`},
+		{in: `flex: auto`, out: `flex-grow:1; flex-shrink:1; flex-basis:auto;`},
+		{in: `gap: 1px`, out: `row-gap:1px; column-gap:1px;`},
+		{in: `overflow: hidden auto`, out: `overflow-x:hidden; overflow-y:auto;`},
+		{in: `list-style: none`, out: `list-style-position:outside; list-style-image:none; list-style-type:none;`},
+		{in: `font: italic bold 12px/1.5 "Helvetica Neue", Arial, sans-serif`, out: `font-style:italic; font-variant:normal; font-weight:bold; font-stretch:normal; font-size:12px; line-height:1.5; font-family:"Helvetica Neue", Arial, sans-serif;`},
+		{in: `font: 12px Times New Roman`, out: `font-style:normal; font-variant:normal; font-weight:normal; font-stretch:normal; font-size:12px; line-height:normal; font-family:Times New Roman;`},
+		{in: `background: url(a.png) no-repeat, center / cover #fff`, out: `background-image:url(a.png), none; background-position:0% 0%, center; background-size:auto, cover; background-repeat:no-repeat, repeat; background-attachment:scroll, scroll; background-origin:padding-box, padding-box; background-clip:border-box, border-box; background-color:#fff;`},
+		{in: `background: content-box red`, out: `background-image:none; background-position:0% 0%; background-size:auto; background-repeat:repeat; background-attachment:scroll; background-origin:content-box; background-clip:content-box; background-color:red;`},
+		{in: `transition: opacity 1s, transform 2s ease-in 3s`, out: `transition-property:opacity, transform; transition-duration:1s, 2s; transition-timing-function:ease, ease-in; transition-delay:0s, 3s;`},
+		{in: `animation: spin 1s linear infinite`, out: `animation-duration:1s; animation-timing-function:linear; animation-delay:0s; animation-iteration-count:infinite; animation-direction:normal; animation-fill-mode:none; animation-play-state:running; animation-name:spin;`},
+		{in: `grid-template: auto 1fr / 100px 1fr`, out: `grid-template-rows:auto 1fr; grid-template-columns:100px 1fr; grid-template-areas:none;`},
+		{in: `grid-template: [a] "x x" 10px [b] [c] "y z" [d] / 1fr 2fr`, out: `grid-template-rows:[a] 10px [b c] auto [d]; grid-template-columns:1fr 2fr; grid-template-areas:"x x" "y z";`},
+		{in: `grid-template: none`, out: `grid-template-rows:none; grid-template-columns:none; grid-template-areas:none;`},
+		{in: `grid-area: main`, out: `grid-row-start:main; grid-column-start:main; grid-row-end:main; grid-column-end:main;`},
+		{in: `grid-row: 1 / span 2`, out: `grid-row-start:1; grid-row-end:span 2;`},
+		{in: `grid-column: 2`, out: `grid-column-start:2; grid-column-end:auto;`},
+		{in: `margin: inherit`, out: `margin-top:inherit; margin-right:inherit; margin-bottom:inherit; margin-left:inherit;`},
+
+		{in: `color: red`, err: `not a shorthand property: color`},
+		{in: `margin: red`, err: `invalid value for margin: unexpected red, expected <'margin-top'>`},
+		{in: `margin: var(--x)`, err: `cannot expand margin with var() references`},
+		{in: `font: caption`, err: `cannot expand system font: caption`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		d := p.ParseDeclaration(css.NewScanner(strings.NewReader(tt.in)))
+		a, err := css.ExpandShorthand(d)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%d. <%q> unexpected error: %s", i, tt.in, err)
+			continue
+		}
+
+		var decls css.Declarations
+		for _, d := range a {
+			decls = append(decls, d)
+		}
+		if s := print(decls); s != tt.out {
+			t.Errorf("%d. <%q>\n\nexp: %s\n\ngot: %s", i, tt.in, tt.out, s)
+		}
+	}
+}
+
+// Ensure that longhands can be combined into the shortest shorthand.
+func TestCombineShorthand(t *testing.T) {
+	var tests = []struct {
+		name string
+		in   string
+		out  string
+		err  string
+	}{
+		{name: `margin`, in: `margin-top: 1px; margin-right: 1px; margin-bottom: 1px; margin-left: 1px`, out: `margin:1px`},
+		{name: `margin`, in: `margin-top: 1px; margin-right: 2px; margin-bottom: 1px; margin-left: 2px`, out: `margin:1px 2px`},
+		{name: `margin`, in: `margin-top: 1px; margin-right: 2px; margin-bottom: 3px; margin-left: 2px`, out: `margin:1px 2px 3px`},
+		{name: `margin`, in: `margin-top: 1px; margin-right: 2px; margin-bottom: 3px; margin-left: 4px`, out: `margin:1px 2px 3px 4px`},
+		{name: `margin`, in: `margin-top: 9px; margin-top: 1px; margin-right: 1px; margin-bottom: 1px; margin-left: 1px; color: red`, out: `margin:1px`},
+		{name: `padding`, in: `padding-top: 0 !important; padding-right: 0 !important; padding-bottom: 0 !important; padding-left: 0 !important`, out: `padding:0!important`},
+		{name: `border`, in: `border-top-width: 1px; border-right-width: 1px; border-bottom-width: 1px; border-left-width: 1px; border-top-style: solid; border-right-style: solid; border-bottom-style: solid; border-left-style: solid; border-top-color: currentcolor; border-right-color: currentcolor; border-bottom-color: currentcolor; border-left-color: currentcolor`, out: `border:1px solid`},
+		{name: `border-radius`, in: `border-top-left-radius: 1px 3px; border-top-right-radius: 2px 3px; border-bottom-right-radius: 1px 3px; border-bottom-left-radius: 2px 3px`, out: `border-radius:1px 2px / 3px`},
+		{name: `flex`, in: `flex-grow: 2; flex-shrink: 1; flex-basis: 0%`, out: `flex:2`},
+		{name: `flex`, in: `flex-grow: 0; flex-shrink: 0; flex-basis: auto`, out: `flex:none`},
+		{name: `flex`, in: `flex-grow: 2; flex-shrink: 1; flex-basis: 10px`, out: `flex:2 10px`},
+		{name: `gap`, in: `row-gap: 1px; column-gap: 2px`, out: `gap:1px 2px`},
+		{name: `list-style`, in: `list-style-position: outside; list-style-image: none; list-style-type: none`, out: `list-style:none`},
+		{name: `font`, in: `font-style: italic; font-variant: normal; font-weight: bold; font-stretch: normal; font-size: 12px; line-height: 1.5; font-family: Arial, sans-serif`, out: `font:italic bold 12px/1.5 Arial, sans-serif`},
+		{name: `background`, in: `background-image: url(a.png), none; background-position: 0% 0%, center; background-size: auto, cover; background-repeat: no-repeat, repeat; background-attachment: scroll, scroll; background-origin: padding-box, padding-box; background-clip: border-box, border-box; background-color: #fff`, out: `background:url(a.png) no-repeat, center / cover #fff`},
+		{name: `background`, in: `background-image: none; background-position: 0% 0%; background-size: auto; background-repeat: repeat; background-attachment: scroll; background-origin: content-box; background-clip: content-box; background-color: transparent`, out: `background:content-box`},
+		{name: `transition`, in: `transition-property: opacity, all; transition-duration: 1s, 0s; transition-timing-function: ease, ease; transition-delay: 0s, 2s`, out: `transition:opacity 1s, 0s 2s`},
+		{name: `animation`, in: `animation-duration: 1s; animation-timing-function: linear; animation-delay: 0s; animation-iteration-count: infinite; animation-direction: normal; animation-fill-mode: none; animation-play-state: running; animation-name: spin`, out: `animation:1s linear infinite spin`},
+		{name: `grid-template`, in: `grid-template-rows: auto 1fr; grid-template-columns: 100px 1fr; grid-template-areas: none`, out: `grid-template:auto 1fr / 100px 1fr`},
+		{name: `grid-template`, in: `grid-template-rows: [a] 10px [b c] auto [d]; grid-template-columns: 1fr 2fr; grid-template-areas: "x x" "y z"`, out: `grid-template:[a] "x x" 10px [b c] "y z" [d] / 1fr 2fr`},
+		{name: `grid-area`, in: `grid-row-start: main; grid-column-start: main; grid-row-end: main; grid-column-end: main`, out: `grid-area:main`},
+		{name: `grid-area`, in: `grid-row-start: 1; grid-column-start: 2; grid-row-end: auto; grid-column-end: auto`, out: `grid-area:1 / 2`},
+		{name: `grid-row`, in: `grid-row-start: 1; grid-row-end: span 2`, out: `grid-row:1 / span 2`},
+		{name: `margin`, in: `margin-top: inherit; margin-right: inherit; margin-bottom: inherit; margin-left: inherit`, out: `margin:inherit`},
+
+		{name: `color`, err: `not a shorthand property: color`},
+		{name: `margin`, in: `margin-top: 1px`, err: `cannot combine margin: missing margin-right`},
+		{name: `margin`, in: `margin-top: 1px !important; margin-right: 1px; margin-bottom: 1px; margin-left: 1px`, err: `cannot combine margin: mixed !important`},
+		{name: `margin`, in: `margin-top: inherit; margin-right: 1px; margin-bottom: 1px; margin-left: 1px`, err: `cannot combine margin: mixed CSS-wide keywords`},
+		{name: `margin`, in: `margin-top: var(--x); margin-right: 1px; margin-bottom: 1px; margin-left: 1px`, err: `cannot combine margin: margin-top has var() references`},
+		{name: `border`, in: `border-top-width: 1px; border-right-width: 2px; border-bottom-width: 1px; border-left-width: 1px; border-top-style: solid; border-right-style: solid; border-bottom-style: solid; border-left-style: solid; border-top-color: red; border-right-color: red; border-bottom-color: red; border-left-color: red`, err: `cannot combine border: border widths differ`},
+		{name: `font`, in: `font-style: normal; font-variant: all-small-caps; font-weight: normal; font-stretch: normal; font-size: 12px; line-height: normal; font-family: serif`, err: `cannot combine font: font-variant cannot be represented: all-small-caps`},
+		{name: `grid-template`, in: `grid-template-rows: repeat(2, 1fr); grid-template-columns: none; grid-template-areas: "a" "b"`, err: `cannot combine grid-template: grid-template-rows cannot be represented: repeat(2, 1fr)`},
+	}
+
+	for i, tt := range tests {
+		var p css.Parser
+		var decls []*css.Declaration
+		for _, n := range p.ParseDeclarations(css.NewScanner(strings.NewReader(tt.in))) {
+			decls = append(decls, n.(*css.Declaration))
+		}
+
+		d, err := css.CombineShorthand(tt.name, decls)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, err)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%d. <%q> unexpected error: %s", i, tt.in, err)
+			continue
+		}
+
+		if s := print(d); s != tt.out {
+			t.Errorf("%d. <%q> exp=%q, got=%q", i, tt.in, tt.out, s)
+		}
+	}
+}
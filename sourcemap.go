@@ -0,0 +1,305 @@
+package css
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SourceMap represents a Source Map v3 document.
+type SourceMap struct {
+	Version        int      `json:"version"`
+	File           string   `json:"file,omitempty"`
+	SourceRoot     string   `json:"sourceRoot,omitempty"`
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent,omitempty"`
+	Names          []string `json:"names"`
+	Mappings       string   `json:"mappings"`
+
+	lines [][]segment // decoded mappings, by output line
+	files *FileSet    // sources of positions without a file
+}
+
+// ParseSourceMap parses a Source Map v3 document, such as one generated by
+// a preprocessor, so that positions in its output can be mapped back.
+func ParseSourceMap(data []byte) (*SourceMap, error) {
+	var m SourceMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	} else if m.Version != 3 {
+		return nil, errors.New("unsupported source map version")
+	}
+	if err := m.decode(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Lookup returns the original position of a position in the generated
+// output. Returns false if the position is not mapped. The original file is
+// added to the file set of the position, if it has one.
+func (m *SourceMap) Lookup(pos Pos) (Pos, bool) {
+	if m.lines == nil && m.decode() != nil {
+		return Pos{}, false
+	}
+	if pos.Line < 0 || pos.Line >= len(m.lines) {
+		return Pos{}, false
+	}
+
+	// Find the last segment starting at or before the column.
+	// Character positions are one-based, except for newlines.
+	col := pos.Char - 1
+	if col < 0 {
+		col = 0
+	}
+	line := m.lines[pos.Line]
+	i := sort.Search(len(line), func(i int) bool { return line[i].col > col }) - 1
+	if i < 0 || line[i].source < 0 {
+		return Pos{}, false
+	}
+
+	// Resolve the source to a file in the same set as the position.
+	seg := line[i]
+	set := m.files
+	if pos.File != nil {
+		set = pos.File.set
+	} else if set == nil {
+		set = NewFileSet()
+		m.files = set
+	}
+	return Pos{Char: seg.srcCol + 1, Line: seg.srcLine, File: set.AddFile(m.source(seg.source))}, true
+}
+
+// source returns the name of the source at index i, including the source root.
+func (m *SourceMap) source(i int) string {
+	if m.SourceRoot == "" || strings.HasSuffix(m.SourceRoot, "/") {
+		return m.SourceRoot + m.Sources[i]
+	}
+	return m.SourceRoot + "/" + m.Sources[i]
+}
+
+// decode decodes the mappings into segments.
+func (m *SourceMap) decode() error {
+	var last segment
+	var lines [][]segment
+	for _, line := range strings.Split(m.Mappings, ";") {
+		// Output columns are relative to the start of each line.
+		var segs []segment
+		last.col = 0
+		for _, s := range strings.Split(line, ",") {
+			if s == "" {
+				continue
+			}
+			fields, err := readVLQs(s)
+			if err != nil {
+				return err
+			}
+
+			last.col += fields[0]
+			switch len(fields) {
+			case 1:
+				segs = append(segs, segment{col: last.col, source: -1})
+				continue
+			case 4, 5:
+				last.source += fields[1]
+				last.srcLine += fields[2]
+				last.srcCol += fields[3]
+			default:
+				return errors.New("invalid source map segment: " + s)
+			}
+			if last.source < 0 || last.source >= len(m.Sources) {
+				return errors.New("invalid source map source index")
+			}
+			segs = append(segs, last)
+		}
+
+		// Segments are usually in order but the spec doesn't require it.
+		sort.Stable(segments(segs))
+		lines = append(lines, segs)
+	}
+	m.lines = lines
+	return nil
+}
+
+// SourceMapGenerator records the source position of each token and rule
+// printed by a Printer and generates a source map from them.
+//
+// Positions without a line or character, such as tokens created after
+// parsing, are not mapped and inherit the previous mapping. Inputs from
+// several scanners are mapped to their files when scanned from a FileSet.
+type SourceMapGenerator struct {
+	// The name of the generated file and the root of the source names.
+	File       string
+	SourceRoot string
+
+	line, col int // current output position
+
+	sources []string
+	indexes map[*File]int
+
+	mappings bytes.Buffer
+	mapped   bool // true if the current line has a segment
+	lastLine int  // output line of the last segment
+	last     segment
+}
+
+// segment represents a single decoded mapping.
+type segment struct {
+	col     int // output column
+	source  int // source index
+	srcLine int
+	srcCol  int
+}
+
+// SourceMap returns the source map for everything printed so far.
+func (g *SourceMapGenerator) SourceMap() *SourceMap {
+	m := &SourceMap{
+		Version:    3,
+		File:       g.File,
+		SourceRoot: g.SourceRoot,
+		Sources:    append([]string{}, g.sources...),
+		Names:      []string{},
+		Mappings:   g.mappings.String(),
+	}
+	return m
+}
+
+// add maps the current output position to pos.
+func (g *SourceMapGenerator) add(pos Pos) {
+	if pos.Line == 0 && pos.Char == 0 {
+		return
+	}
+
+	// Find or register the source.
+	if g.indexes == nil {
+		g.indexes = make(map[*File]int)
+	}
+	index, ok := g.indexes[pos.File]
+	if !ok {
+		index = len(g.sources)
+		g.indexes[pos.File], g.sources = index, append(g.sources, pos.File.Name())
+	}
+
+	// Character positions are one-based for everything except newlines.
+	srcCol := pos.Char - 1
+	if srcCol < 0 {
+		srcCol = 0
+	}
+	seg := segment{col: g.col, source: index, srcLine: pos.Line, srcCol: srcCol}
+
+	// Skip segments which don't change the mapping.
+	if g.mapped && g.lastLine == g.line && (seg.col == g.last.col || (seg.source == g.last.source && seg.srcLine == g.last.srcLine && seg.srcCol == g.last.srcCol)) {
+		return
+	}
+
+	// Separate lines with semicolons and segments with commas.
+	// The output column is relative to the start of each line.
+	prevCol := g.last.col
+	if !g.mapped || g.lastLine != g.line {
+		for i := g.lastLine; i < g.line; i++ {
+			g.mappings.WriteByte(';')
+		}
+		prevCol = 0
+	} else {
+		g.mappings.WriteByte(',')
+	}
+
+	writeVLQ(&g.mappings, seg.col-prevCol)
+	writeVLQ(&g.mappings, seg.source-g.last.source)
+	writeVLQ(&g.mappings, seg.srcLine-g.last.srcLine)
+	writeVLQ(&g.mappings, seg.srcCol-g.last.srcCol)
+
+	g.last, g.lastLine, g.mapped = seg, g.line, true
+}
+
+// advance moves the output position past the printed bytes.
+func (g *SourceMapGenerator) advance(b []byte) {
+	for len(b) > 0 {
+		ch, size := utf8.DecodeRune(b)
+		if ch == '\n' {
+			g.line, g.col = g.line+1, 0
+		} else {
+			g.col++
+		}
+		b = b[size:]
+	}
+}
+
+// segments sorts segments by output column.
+type segments []segment
+
+func (a segments) Len() int           { return len(a) }
+func (a segments) Less(i, j int) bool { return a[i].col < a[j].col }
+func (a segments) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// sourceMapWriter tracks the output position of a source map generator.
+type sourceMapWriter struct {
+	w io.Writer
+	g *SourceMapGenerator
+}
+
+func (w *sourceMapWriter) Write(b []byte) (n int, err error) {
+	n, err = w.w.Write(b)
+	w.g.advance(b[:n])
+	return
+}
+
+// base64 is the alphabet used to encode VLQ digits.
+const base64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// writeVLQ writes v as a base64 variable-length quantity.
+func writeVLQ(buf *bytes.Buffer, v int) {
+	// The sign is stored in the least significant bit.
+	if v < 0 {
+		v = (-v << 1) | 1
+	} else {
+		v <<= 1
+	}
+
+	for {
+		digit := v & 0x1f
+		v >>= 5
+		if v > 0 {
+			digit |= 0x20
+		}
+		buf.WriteByte(base64[digit])
+		if v == 0 {
+			return
+		}
+	}
+}
+
+// readVLQs reads a list of base64 variable-length quantities.
+func readVLQs(s string) ([]int, error) {
+	var a []int
+	var v, shift uint
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base64, s[i])
+		if digit == -1 {
+			return nil, errors.New("invalid source map mapping: " + s)
+		}
+		v |= uint(digit&0x1f) << shift
+		if digit&0x20 != 0 {
+			if shift += 5; shift > 30 {
+				return nil, errors.New("invalid source map mapping: " + s)
+			}
+			continue
+		}
+
+		// The sign is stored in the least significant bit.
+		if v&1 == 1 {
+			a = append(a, -int(v>>1))
+		} else {
+			a = append(a, int(v>>1))
+		}
+		v, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, errors.New("invalid source map mapping: " + s)
+	}
+	return a, nil
+}
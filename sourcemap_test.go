@@ -0,0 +1,154 @@
+package css_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that the printer generates source maps for bundled style sheets.
+func TestSourceMapGenerator(t *testing.T) {
+	var p css.Parser
+	fset := css.NewFileSet()
+	s0 := fset.NewScanner("a.css", strings.NewReader("a {\n  color: red;\n}"))
+	s1 := fset.NewScanner("b.css", strings.NewReader("b{x:y}"))
+
+	ss := p.ParseStyleSheet(s0)
+	ss.Rules = append(ss.Rules, p.ParseStyleSheet(s1).Rules...)
+
+	var buf bytes.Buffer
+	g := &css.SourceMapGenerator{File: "out.css"}
+	printer := css.Printer{Compact: true, SourceMap: g}
+	if err := printer.Print(&buf, ss); err != nil {
+		t.Fatal(err)
+	} else if s := buf.String(); s != "a {\n  color: red;\n}b{x:y}" {
+		t.Fatalf("unexpected output: %q", s)
+	}
+
+	b, err := json.Marshal(g.SourceMap())
+	if err != nil {
+		t.Fatal(err)
+	} else if s := string(b); s != `{"version":3,"file":"out.css","sources":["a.css","b.css"],"names":[],"mappings":"AAAA,CAAC,CAAC,CACF;EAAE,KAAK,CAAC,CAAC,GAAG,CACZ;CCFA,CAAC,CAAC,CAAC,CAAC"}` {
+		t.Fatalf("unexpected source map: %s", s)
+	}
+}
+
+// Ensure that nodes without a position are not mapped.
+func TestSourceMapGenerator_NoPos(t *testing.T) {
+	var p css.Parser
+	s := css.NewFileSet().NewScanner("a.css", strings.NewReader("a{b:c}"))
+	ss := p.ParseStyleSheet(s)
+	ss.Rules = append(css.Rules{&css.AtRule{Name: "import", Prelude: css.ComponentValues{&css.Token{Tok: css.StringToken, Value: "x.css", Ending: '"'}}}}, ss.Rules...)
+
+	var buf bytes.Buffer
+	g := &css.SourceMapGenerator{}
+	printer := css.Printer{Compact: true, SourceMap: g}
+	if err := printer.Print(&buf, ss); err != nil {
+		t.Fatal(err)
+	} else if m := g.SourceMap(); m.Mappings != "eAAA,CAAC,CAAC,CAAC,CAAC" {
+		t.Fatalf("unexpected mappings: %s", m.Mappings)
+	}
+}
+
+// Ensure that source maps can be parsed and looked up.
+func TestParseSourceMap(t *testing.T) {
+	m, err := css.ParseSourceMap([]byte(`{"version":3,"sourceRoot":"src","sources":["main.scss"],"names":[],"mappings":"AAAA;;AAEA,EACE,C"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		in  css.Pos
+		out string
+	}{
+		{in: css.Pos{Char: 1, Line: 0}, out: "src/main.scss:1:1"},
+		{in: css.Pos{Char: 10, Line: 0}, out: "src/main.scss:1:1"},
+		{in: css.Pos{Char: 1, Line: 1}},
+		{in: css.Pos{Char: 2, Line: 2}, out: "src/main.scss:3:1"},
+		{in: css.Pos{Char: 3, Line: 2}, out: "src/main.scss:4:3"},
+		{in: css.Pos{Char: 4, Line: 2}},
+		{in: css.Pos{Char: 1, Line: 3}},
+	}
+	for i, tt := range tests {
+		if pos, ok := m.Lookup(tt.in); ok != (tt.out != "") || (ok && pos.String() != tt.out) {
+			t.Errorf("%d. %#v: unexpected lookup: %s (%v)", i, tt.in, pos, ok)
+		}
+	}
+
+	// Original files are added to the file set of the position.
+	fset := css.NewFileSet()
+	if pos, ok := m.Lookup(css.Pos{Char: 1, Line: 0, File: fset.AddFile("out.css")}); !ok || pos.File != fset.File("src/main.scss") {
+		t.Fatalf("unexpected lookup: %#v", pos)
+	}
+}
+
+// Ensure that invalid source maps return an error.
+func TestParseSourceMap_Err(t *testing.T) {
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{in: `{"version":2,"sources":[],"mappings":""}`, err: `unsupported source map version`},
+		{in: `{"version":3,"sources":["a"],"mappings":"AA!A"}`, err: `invalid source map mapping: AA!A`},
+		{in: `{"version":3,"sources":["a"],"mappings":"AAA"}`, err: `invalid source map segment: AAA`},
+		{in: `{"version":3,"sources":["a"],"mappings":"ACAA"}`, err: `invalid source map source index`},
+		{in: `{"version":3,"sources":["a"],"mappings":"g"}`, err: `invalid source map mapping: g`},
+	}
+	for i, tt := range tests {
+		if _, err := css.ParseSourceMap([]byte(tt.in)); err == nil || err.Error() != tt.err {
+			t.Errorf("%d. <%q> error: exp=%q, got=%v", i, tt.in, tt.err, err)
+		}
+	}
+}
+
+// Ensure that a generated source map can be looked up.
+func TestSourceMap_Lookup_Generated(t *testing.T) {
+	s := css.NewFileSet().NewScanner("a.css", strings.NewReader("a {\n  b: c;\n}"))
+	var p css.Parser
+	ss := p.ParseStyleSheet(s)
+
+	var buf bytes.Buffer
+	g := &css.SourceMapGenerator{}
+	printer := css.Printer{Compact: true, SourceMap: g}
+	if err := printer.Print(&buf, ss); err != nil {
+		t.Fatal(err)
+	}
+	if pos, ok := g.SourceMap().Lookup(css.Pos{Char: 3, Line: 1}); !ok || pos.String() != "a.css:2:3" {
+		t.Fatalf("unexpected lookup: %#v (%v)", pos, ok)
+	}
+}
+
+// Ensure that the scanner remaps token and error positions with a source map.
+func TestScanner_SourceMap(t *testing.T) {
+	m, err := css.ParseSourceMap([]byte(`{"version":3,"sources":["main.scss"],"names":[],"mappings":"AAEA,EACE"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tokens within a segment map to the start of the segment.
+	fset := css.NewFileSet()
+	s := fset.NewScanner("main.css", strings.NewReader("b c"))
+	s.SourceMap = m
+	var p css.Parser
+	if d := p.ParseDeclaration(s); d != nil {
+		t.Fatalf("unexpected declaration: %#v", d)
+	} else if len(p.Errors) != 1 {
+		t.Fatalf("unexpected errors: %#v", p.Errors)
+	} else if err := p.Errors[0]; err.Error() != "main.scss:4:3: expected colon, got c" {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Scanner errors are also remapped.
+	s = fset.NewScanner("main2.css", strings.NewReader("ab\\\n"))
+	s.SourceMap = m
+	if tok := s.Scan(); tok.Pos != (css.Pos{Char: 1, Line: 2, File: fset.File("main.scss")}) {
+		t.Fatalf("unexpected pos: %#v", tok.Pos)
+	} else if tok := s.Scan(); tok.Pos != (css.Pos{Char: 3, Line: 3, File: fset.File("main.scss")}) {
+		t.Fatalf("unexpected pos: %#v", tok.Pos)
+	} else if len(s.Errors) != 1 || s.Errors[0].Pos != (css.Pos{Char: 3, Line: 3, File: fset.File("main.scss")}) {
+		t.Fatalf("unexpected errors: %#v", s.Errors)
+	}
+}
@@ -1,3 +1,74 @@
 package css
 
-// TODO(benbjohnson): Walk(Visitor, Node) Visitor
+// Visitor visits each node in a syntax tree. If the visitor returned by
+// Visit is not nil then Walk visits each of the node's children with it,
+// followed by a call to Visit(nil).
+type Visitor interface {
+	Visit(n Node) (w Visitor)
+}
+
+// Walk traverses a syntax tree in depth-first order, starting with n.
+// The opening token of a simple block is not visited.
+func Walk(v Visitor, n Node) {
+	if v = v.Visit(n); v == nil {
+		return
+	}
+
+	switch n := n.(type) {
+	case *StyleSheet:
+		for _, r := range n.Rules {
+			Walk(v, r)
+		}
+	case Rules:
+		for _, r := range n {
+			Walk(v, r)
+		}
+	case *AtRule:
+		walkComponentValues(v, n.Prelude)
+		if n.Block != nil {
+			Walk(v, n.Block)
+		}
+	case *QualifiedRule:
+		walkComponentValues(v, n.Prelude)
+		if n.Block != nil {
+			Walk(v, n.Block)
+		}
+	case Declarations:
+		for _, d := range n {
+			Walk(v, d)
+		}
+	case *Declaration:
+		walkComponentValues(v, n.Values)
+	case ComponentValues:
+		walkComponentValues(v, n)
+	case *SimpleBlock:
+		walkComponentValues(v, n.Values)
+	case *Function:
+		walkComponentValues(v, n.Values)
+	}
+
+	v.Visit(nil)
+}
+
+func walkComponentValues(v Visitor, a ComponentValues) {
+	for _, cv := range a {
+		Walk(v, cv)
+	}
+}
+
+// inspector adapts a function to the Visitor interface.
+type inspector func(Node) bool
+
+func (f inspector) Visit(n Node) Visitor {
+	if f(n) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a syntax tree in depth-first order, calling f for each
+// node. The children of a node are skipped if f returns false. After the
+// children are visited, f is called with nil.
+func Inspect(n Node, f func(Node) bool) {
+	Walk(inspector(f), n)
+}
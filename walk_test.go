@@ -1,3 +1,66 @@
 package css_test
 
-// TODO(benbjohnson): Example: Walk()
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/benbjohnson/css"
+)
+
+// Ensure that every node in a style sheet is visited in order.
+func TestInspect(t *testing.T) {
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScanner(strings.NewReader(`@import "a.css"; a { b: c(d) }`)))
+
+	var a []string
+	css.Inspect(ss, func(n css.Node) bool {
+		switch n := n.(type) {
+		case nil:
+			a = append(a, ")")
+		case *css.Token:
+			if n.Tok != css.WhitespaceToken {
+				a = append(a, n.Value)
+			}
+			return false
+		default:
+			a = append(a, fmt.Sprintf("%T(", n))
+		}
+		return true
+	})
+	if s := strings.Join(a, ""); s != `*css.StyleSheet(*css.AtRule(a.css)*css.QualifiedRule(a*css.SimpleBlock(b*css.Function(d))))` {
+		t.Fatalf("unexpected walk: %s", s)
+	}
+}
+
+// Ensure that returning false skips the children of a node.
+func TestInspect_Skip(t *testing.T) {
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScanner(strings.NewReader(`a { b: c } @media print { d { } }`)))
+
+	var n int
+	css.Inspect(ss, func(node css.Node) bool {
+		if _, ok := node.(*css.Token); ok {
+			n++
+		}
+		_, ok := node.(*css.AtRule)
+		return !ok
+	})
+	if n != 8 {
+		t.Fatalf("unexpected token count: %d", n)
+	}
+}
+
+func ExampleInspect() {
+	var p css.Parser
+	ss := p.ParseStyleSheet(css.NewScanner(strings.NewReader(`a { color: red } b { width: calc(1px + 2em) }`)))
+
+	// Print the name of every function in the style sheet.
+	css.Inspect(ss, func(n css.Node) bool {
+		if fn, ok := n.(*css.Function); ok {
+			fmt.Println(fn.Name, fn.Pos)
+		}
+		return true
+	})
+	// Output: calc 1:29
+}